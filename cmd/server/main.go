@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/labstack/echo/v4"
@@ -17,31 +21,96 @@ import (
 	"drone/internal/service"
 )
 
+// shutdownTimeout is how long requests in progress are given to finish when
+// the server stops, well within the 10 seconds docker stop waits
+const shutdownTimeout = 5 * time.Second
+
 func main() {
 	// Load configuration
 	cfg := config.Load()
 
-	// Connect to database
-	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName, cfg.DBSSLMode)
-	
-	dbPool, err := pgxpool.New(context.Background(), dbURL)
-	if err != nil {
-		log.Fatalf("Unable to connect to database: %v", err)
+	// Initialize repository
+	var (
+		repo     repository.Repository
+		migrator *repository.Migrator
+	)
+	switch cfg.Storage {
+	case config.StorageMemory:
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			log.Fatalf("Migrations do not apply to %s storage", config.StorageMemory)
+		}
+		log.Printf("Keeping data in memory, it is lost when the server stops")
+		repo = repository.NewMemoryRepository()
+	case config.StorageSQLite:
+		db, err := repository.OpenSQLite(cfg.SQLitePath)
+		if err != nil {
+			log.Fatalf("Unable to open database: %v", err)
+		}
+		defer db.Close()
+
+		migrator, err = repository.NewSQLiteMigrator(db)
+		if err != nil {
+			log.Fatalf("Loading migrations failed: %v", err)
+		}
+		repo = repository.NewSQLiteRepository(db)
+	case config.StoragePostgres:
+		// Connect to database
+		dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
+			cfg.DBUser, cfg.DBPassword, cfg.DBHost, cfg.DBPort, cfg.DBName, cfg.DBSSLMode)
+
+		dbPool, err := pgxpool.New(context.Background(), dbURL)
+		if err != nil {
+			log.Fatalf("Unable to connect to database: %v", err)
+		}
+		defer dbPool.Close()
+
+		migrator, err = repository.NewMigrator(dbPool)
+		if err != nil {
+			log.Fatalf("Loading migrations failed: %v", err)
+		}
+		repo = repository.NewRepository(dbPool)
+	default:
+		log.Fatalf("Unknown storage %q", cfg.Storage)
 	}
-	defer dbPool.Close()
 
-	// Initialize repository
-	repo := repository.NewRepository(dbPool)
+	if migrator != nil {
+		// "server migrate up|down|status" manages the schema instead of serving
+		if len(os.Args) > 1 && os.Args[1] == "migrate" {
+			if err := runMigrate(context.Background(), migrator, os.Args[2:]); err != nil {
+				log.Fatalf("Migration failed: %v", err)
+			}
+			return
+		}
+
+		// Bring the schema up to date; replicas starting together wait for the
+		// first one to finish
+		if cfg.AutoMigrate {
+			if err := migrateUp(context.Background(), migrator); err != nil {
+				log.Fatalf("Migration failed: %v", err)
+			}
+		}
+	}
 
 	// Initialize service with repository
 	svc := service.NewService(repo)
 
+	// Run until interrupted, such as by docker stop
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Calculate queued drone plan jobs in the background until the server stops
+	workersDone := make(chan struct{})
+	go func() {
+		defer close(workersDone)
+		svc.RunJobWorkers(ctx, cfg.JobWorkers)
+	}()
+
 	// Initialize API handler with service
 	handler := api.NewHandler(svc)
 
 	// Set up Echo server
 	e := echo.New()
+	e.HTTPErrorHandler = api.ErrorHandler
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 
@@ -51,7 +120,20 @@ func main() {
 	// Start server
 	serverAddr := fmt.Sprintf(":%s", cfg.ServerPort)
 	log.Printf("Starting server on %s", serverAddr)
-	if err := e.Start(serverAddr); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Error starting server: %v", err)
+	go func() {
+		if err := e.Start(serverAddr); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Error starting server: %v", err)
+		}
+	}()
+
+	// Finish the requests in progress, and wait for the workers to put the
+	// jobs they were calculating back into the queue
+	<-ctx.Done()
+	log.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
 	}
+	<-workersDone
 } 
\ No newline at end of file
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"drone/internal/repository"
+)
+
+// errMigrateUsage is returned when the migrate subcommand is not given one of
+// its actions
+var errMigrateUsage = errors.New("usage: server migrate up|down|status")
+
+// runMigrate runs the migrate subcommand: up applies the pending migrations,
+// down reverts the latest applied one and status lists them all
+func runMigrate(ctx context.Context, migrator *repository.Migrator, args []string) error {
+	if len(args) != 1 {
+		return errMigrateUsage
+	}
+
+	switch args[0] {
+	case "up":
+		return migrateUp(ctx, migrator)
+	case "down":
+		reverted, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			log.Printf("No migration to revert")
+			return nil
+		}
+		log.Printf("Reverted migration %04d_%s", reverted.Version, reverted.Name)
+		return nil
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	}
+	return errMigrateUsage
+}
+
+// migrateUp applies the pending migrations, logging each one
+func migrateUp(ctx context.Context, migrator *repository.Migrator) error {
+	applied, err := migrator.Up(ctx)
+	for _, migration := range applied {
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"drone/generated"
+	"drone/internal/service"
+)
+
+// ErrorHandler is the Echo HTTP error handler of the API. Errors of the
+// service are answered with the status of their kind and their code, errors
+// raised by Echo or the handlers with their status, and anything else as an
+// internal server error without revealing its cause.
+func ErrorHandler(err error, ctx echo.Context) {
+	// Part of the response has gone out already, so it cannot be replaced
+	if ctx.Response().Committed {
+		ctx.Logger().Error(err)
+		return
+	}
+
+	status, response := errorResponse(err)
+	if status == http.StatusInternalServerError {
+		ctx.Logger().Error(err)
+	}
+
+	if ctx.Request().Method == http.MethodHead {
+		err = ctx.NoContent(status)
+	} else {
+		err = ctx.JSON(status, response)
+	}
+	if err != nil {
+		ctx.Logger().Error(err)
+	}
+}
+
+// errorResponse returns the status and body of the response to an error
+func errorResponse(err error) (int, generated.ErrorResponse) {
+	var (
+		serviceErr *service.Error
+		httpErr    *echo.HTTPError
+	)
+	switch {
+	case errors.As(err, &serviceErr):
+		response := generated.ErrorResponse{
+			Code:    serviceErr.Code,
+			Message: strPtr(serviceErr.Message),
+		}
+		if len(serviceErr.Fields) > 0 {
+			fields := make([]generated.FieldError, len(serviceErr.Fields))
+			for i, field := range serviceErr.Fields {
+				fields[i] = generated.FieldError{Field: field.Field, Message: field.Message}
+			}
+			response.Fields = &fields
+		}
+		return kindStatus(serviceErr), response
+	case errors.As(err, &httpErr):
+		return httpErr.Code, generated.ErrorResponse{
+			Code:    statusCode(httpErr.Code),
+			Message: strPtr(fmt.Sprint(httpErr.Message)),
+		}
+	}
+
+	return http.StatusInternalServerError, generated.ErrorResponse{
+		Code:    "internal_error",
+		Message: strPtr("internal error"),
+	}
+}
+
+// kindStatus returns the HTTP status matching the kind of a service error
+func kindStatus(err *service.Error) int {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrValidation):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// statusCode returns the error code of an HTTP status, such as "bad_request"
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
+
+// badRequest returns the error of a request that is malformed or whose
+// parameters do not fit together, before it reaches the service
+func badRequest(message string) error {
+	return echo.NewHTTPError(http.StatusBadRequest, message)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"drone/generated"
+	"drone/internal/service"
+)
+
+func TestErrorHandler(t *testing.T) {
+	testCases := []struct {
+		name             string
+		err              error
+		expectedStatus   int
+		expectedResponse generated.ErrorResponse
+	}{
+		{
+			name:           "Not Found",
+			err:            service.ErrEstateNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedResponse: generated.ErrorResponse{
+				Code:    "estate_not_found",
+				Message: strPtr("estate not found"),
+			},
+		},
+		{
+			name:           "Wrapped Conflict",
+			err:            fmt.Errorf("planting: %w", service.ErrPlotOccupied),
+			expectedStatus: http.StatusConflict,
+			expectedResponse: generated.ErrorResponse{
+				Code:    "plot_occupied",
+				Message: strPtr("plot already has a tree"),
+			},
+		},
+		{
+			name:           "Validation",
+			err:            service.NewValidationError("tree coordinates outside estate boundaries", "x", "y"),
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedResponse: generated.ErrorResponse{
+				Code:    "validation_failed",
+				Message: strPtr("tree coordinates outside estate boundaries"),
+				Fields: &[]generated.FieldError{
+					{Field: "x", Message: "tree coordinates outside estate boundaries"},
+					{Field: "y", Message: "tree coordinates outside estate boundaries"},
+				},
+			},
+		},
+		{
+			name:           "Internal",
+			err:            service.NewInternalError(errors.New("invalid character")),
+			expectedStatus: http.StatusInternalServerError,
+			expectedResponse: generated.ErrorResponse{
+				Code:    "internal_error",
+				Message: strPtr("internal error"),
+			},
+		},
+		{
+			name:           "Bad Request",
+			err:            badRequest("Invalid order value"),
+			expectedStatus: http.StatusBadRequest,
+			expectedResponse: generated.ErrorResponse{
+				Code:    "bad_request",
+				Message: strPtr("Invalid order value"),
+			},
+		},
+		{
+			name:           "Unknown Route",
+			err:            echo.ErrNotFound,
+			expectedStatus: http.StatusNotFound,
+			expectedResponse: generated.ErrorResponse{
+				Code:    "not_found",
+				Message: strPtr("Not Found"),
+			},
+		},
+		{
+			name:           "Database Error Is Not Revealed",
+			err:            errors.New(`duplicate key value violates unique constraint "trees_estate_id_x_y_key"`),
+			expectedStatus: http.StatusInternalServerError,
+			expectedResponse: generated.ErrorResponse{
+				Code:    "internal_error",
+				Message: strPtr("internal error"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/estate", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			ErrorHandler(tc.err, c)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			var response generated.ErrorResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, tc.expectedResponse, response)
+		})
+	}
+}
+
+func TestErrorHandlerCommittedResponse(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/estate/export", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	// A download that fails halfway through is left truncated
+	assert.NoError(t, c.String(http.StatusOK, "x,y,height\n"))
+	ErrorHandler(errors.New("connection reset"), c)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "x,y,height\n", rec.Body.String())
+}
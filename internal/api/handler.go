@@ -1,13 +1,18 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"strconv"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	openapi_types "github.com/oapi-codegen/runtime/types"
 
 	"drone/generated"
+	"drone/internal/repository"
 	"drone/internal/service"
 )
 
@@ -27,16 +32,12 @@ func NewHandler(svc service.Service) *Handler {
 func (h *Handler) CreateEstate(ctx echo.Context) error {
 	var req generated.EstateRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Message: strPtr("Invalid request format"),
-		})
+		return badRequest("Invalid request format")
 	}
 
 	estateID, err := h.service.CreateEstate(ctx.Request().Context(), int(req.Width), int(req.Length))
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Message: strPtr(err.Error()),
-		})
+		return err
 	}
 
 	id := openapi_types.UUID(estateID)
@@ -45,28 +46,223 @@ func (h *Handler) CreateEstate(ctx echo.Context) error {
 	})
 }
 
+// GetEstate gets an estate with the number of trees planted on it
+func (h *Handler) GetEstate(ctx echo.Context, id openapi_types.UUID) error {
+	estate, err := h.service.GetEstateDetail(ctx.Request().Context(), uuid.UUID(id))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, toEstateDetail(estate))
+}
+
+// ResizeEstate changes the width and length of an estate
+func (h *Handler) ResizeEstate(ctx echo.Context, id openapi_types.UUID, params generated.ResizeEstateParams) error {
+	var req generated.EstateRequest
+	if err := ctx.Bind(&req); err != nil {
+		return badRequest("Invalid request format")
+	}
+
+	prune := params.Prune != nil && *params.Prune
+
+	estate, pruned, err := h.service.ResizeEstate(ctx.Request().Context(), uuid.UUID(id), int(req.Width), int(req.Length), prune)
+	if err != nil {
+		return err
+	}
+
+	response := toEstateDetail(estate)
+	if prune {
+		pruned32 := int32(pruned)
+		response.PrunedTrees = &pruned32
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// DeleteEstate deletes an estate along with its trees
+func (h *Handler) DeleteEstate(ctx echo.Context, id openapi_types.UUID) error {
+	if err := h.service.DeleteEstate(ctx.Request().Context(), uuid.UUID(id)); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// ExportEstate streams an estate with all of its trees
+func (h *Handler) ExportEstate(ctx echo.Context, id openapi_types.UUID, params generated.ExportEstateParams) error {
+	estateID := uuid.UUID(id)
+
+	format := service.ExportJSON
+	if params.Format != nil {
+		format = string(*params.Format)
+	}
+
+	var geo service.GeoReference
+	if params.OriginLat != nil {
+		geo.OriginLat = *params.OriginLat
+	}
+	if params.OriginLon != nil {
+		geo.OriginLon = *params.OriginLon
+	}
+	if params.PlotSize != nil {
+		if *params.PlotSize <= 0 {
+			return badRequest("Plot size must be positive")
+		}
+		geo.PlotSize = *params.PlotSize
+	}
+
+	// The headers go out with the first row, so they are set up front and
+	// only replaced by an error response if nothing has been written yet
+	header := ctx.Response().Header()
+	switch format {
+	case service.ExportCSV:
+		header.Set(echo.HeaderContentType, "text/csv")
+	case service.ExportGeoJSON:
+		header.Set(echo.HeaderContentType, "application/geo+json")
+	default:
+		header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=estate-%s.%s", estateID, format))
+	if format == service.ExportCSV {
+		if width, length, err := h.service.GetEstate(ctx.Request().Context(), estateID); err == nil {
+			header.Set("X-Estate-Width", strconv.Itoa(width))
+			header.Set("X-Estate-Length", strconv.Itoa(length))
+		}
+	}
+
+	attributes := params.Attributes != nil && *params.Attributes
+	err := h.service.ExportEstate(ctx.Request().Context(), estateID, format, geo, attributes, ctx.Response())
+	if err == nil {
+		return nil
+	}
+	// The export failed halfway through; the client sees a truncated download
+	if ctx.Response().Committed {
+		return err
+	}
+
+	for _, key := range []string{echo.HeaderContentType, echo.HeaderContentDisposition, "X-Estate-Width", "X-Estate-Length"} {
+		header.Del(key)
+	}
+	return err
+}
+
+// GetEstateHeatmapPng renders the trees of an estate as a PNG heatmap
+func (h *Handler) GetEstateHeatmapPng(ctx echo.Context, id openapi_types.UUID, params generated.GetEstateHeatmapPngParams) error {
+	opts, msg := heatmapOptions(params.Size, (*string)(params.Overlay), params.MaxDistance, (*string)(params.Pattern))
+	if msg != "" {
+		return badRequest(msg)
+	}
+	return h.renderHeatmap(ctx, uuid.UUID(id), service.HeatmapPNG, "image/png", opts)
+}
+
+// GetEstateHeatmapSvg renders the trees of an estate as an SVG heatmap
+func (h *Handler) GetEstateHeatmapSvg(ctx echo.Context, id openapi_types.UUID, params generated.GetEstateHeatmapSvgParams) error {
+	opts, msg := heatmapOptions(params.Size, (*string)(params.Overlay), params.MaxDistance, (*string)(params.Pattern))
+	if msg != "" {
+		return badRequest(msg)
+	}
+	return h.renderHeatmap(ctx, uuid.UUID(id), service.HeatmapSVG, "image/svg+xml", opts)
+}
+
+// heatmapOptions validates the heatmap parameters and converts them to
+// heatmap options. It returns a message describing the first invalid parameter.
+func heatmapOptions(size *int32, overlay *string, maxDistance *int32, pattern *string) (service.HeatmapOptions, string) {
+	var opts service.HeatmapOptions
+
+	if size != nil {
+		if *size <= 0 {
+			return opts, "Size must be positive"
+		}
+		opts.Size = int(*size)
+	}
+
+	if overlay != nil {
+		if *overlay != "route" {
+			return opts, "Invalid overlay value"
+		}
+		opts.Route = true
+	}
+
+	if maxDistance != nil {
+		if !opts.Route {
+			return opts, "Max distance requires the route overlay"
+		}
+		if *maxDistance <= 0 {
+			return opts, "Max distance must be positive"
+		}
+		opts.Drone.MaxDistance = int(*maxDistance)
+	}
+
+	if pattern != nil {
+		if !opts.Route {
+			return opts, "Pattern requires the route overlay"
+		}
+		opts.Drone.Pattern = *pattern
+	}
+
+	return opts, ""
+}
+
+// renderHeatmap writes the heatmap of an estate in the given format
+func (h *Handler) renderHeatmap(ctx echo.Context, estateID uuid.UUID, format, contentType string, opts service.HeatmapOptions) error {
+	// The image is only written once it is complete, but the content type
+	// has to be set before the first byte goes out
+	header := ctx.Response().Header()
+	header.Set(echo.HeaderContentType, contentType)
+
+	err := h.service.RenderHeatmap(ctx.Request().Context(), estateID, format, opts, ctx.Response())
+	if err == nil {
+		return nil
+	}
+	if ctx.Response().Committed {
+		return err
+	}
+
+	header.Del(echo.HeaderContentType)
+	return err
+}
+
+// toEstateDetail converts an estate to its detailed API representation
+func toEstateDetail(estate repository.Estate) generated.EstateDetailResponse {
+	id := openapi_types.UUID(estate.ID)
+	width := int32(estate.Width)
+	length := int32(estate.Length)
+	treeCount := int32(estate.TreeCount)
+	return generated.EstateDetailResponse{
+		Id:        &id,
+		Width:     &width,
+		Length:    &length,
+		TreeCount: &treeCount,
+	}
+}
+
 // CreateTree adds a tree to an estate
 func (h *Handler) CreateTree(ctx echo.Context, id openapi_types.UUID) error {
 	var req generated.TreeRequest
 	if err := ctx.Bind(&req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Message: strPtr("Invalid request format"),
-		})
+		return badRequest("Invalid request format")
 	}
 
 	// Since openapi_types.UUID is an alias for uuid.UUID, we can use it directly
 	estateID := uuid.UUID(id)
 
-	treeID, err := h.service.CreateTree(ctx.Request().Context(), estateID, int(req.X), int(req.Y), int(req.Height))
+	input := service.TreeInput{X: int(req.X), Y: int(req.Y), Height: int(req.Height)}
+	if req.Species != nil {
+		input.Species = *req.Species
+	}
+	if req.PlantedOn != nil {
+		input.PlantedOn = &req.PlantedOn.Time
+	}
+	if req.Health != nil {
+		input.Health = string(*req.Health)
+	}
+	if req.Tags != nil {
+		input.Tags = *req.Tags
+	}
+
+	treeID, err := h.service.CreateTree(ctx.Request().Context(), estateID, input)
 	if err != nil {
-		if err.Error() == "estate not found" {
-			return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
-				Message: strPtr("Estate not found"),
-			})
-		}
-		return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-			Message: strPtr(err.Error()),
-		})
+		return err
 	}
 
 	// Convert uuid.UUID to openapi_types.UUID (just a type conversion since they're the same underlying type)
@@ -76,41 +272,617 @@ func (h *Handler) CreateTree(ctx echo.Context, id openapi_types.UUID) error {
 	})
 }
 
+// ListTrees lists the trees of an estate a page at a time
+func (h *Handler) ListTrees(ctx echo.Context, id openapi_types.UUID, params generated.ListTreesParams) error {
+	opts := service.TreeListOptions{
+		MinHeight: intValue(params.HeightMin),
+		MaxHeight: intValue(params.HeightMax),
+		XMin:      intValue(params.XMin),
+		XMax:      intValue(params.XMax),
+		YMin:      intValue(params.YMin),
+		YMax:      intValue(params.YMax),
+		Filter:    treeFilter(params.Species, params.Health, params.Tag, params.PlantedFrom, params.PlantedTo),
+		Limit:     intValue(params.Limit),
+	}
+	if params.Cursor != nil {
+		opts.Cursor = *params.Cursor
+	}
+	if params.Sort != nil {
+		opts.Sort = string(*params.Sort)
+	}
+	if params.Order != nil {
+		switch string(*params.Order) {
+		case "asc":
+		case "desc":
+			opts.Descending = true
+		default:
+			return badRequest("Invalid order value")
+		}
+	}
+
+	page, err := h.service.ListTrees(ctx.Request().Context(), uuid.UUID(id), opts)
+	if err != nil {
+		return err
+	}
+
+	response := generated.TreeListResponse{
+		Trees: make([]generated.Tree, len(page.Trees)),
+	}
+	for i, tree := range page.Trees {
+		response.Trees[i] = toTree(tree)
+	}
+	if page.NextCursor != "" {
+		response.NextCursor = strPtr(page.NextCursor)
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// maxTreeImportSize is the largest bulk tree import accepted, in bytes
+const maxTreeImportSize = 64 << 20
+
+// ImportTrees adds many trees to an estate at once
+func (h *Handler) ImportTrees(ctx echo.Context, id openapi_types.UUID, params generated.ImportTreesParams) error {
+	var format string
+	mediaType, _, _ := mime.ParseMediaType(ctx.Request().Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case "text/csv":
+		format = service.TreeImportCSV
+	case echo.MIMEApplicationJSON:
+		format = service.TreeImportJSON
+	case "application/geo+json":
+		format = service.TreeImportGeoJSON
+	default:
+		return echo.NewHTTPError(http.StatusUnsupportedMediaType, "Content type must be text/csv, application/json or application/geo+json")
+	}
+
+	bestEffort := false
+	if params.Mode != nil {
+		switch string(*params.Mode) {
+		case "atomic":
+		case "best_effort":
+			bestEffort = true
+		default:
+			return badRequest("Invalid mode value")
+		}
+	}
+
+	body := http.MaxBytesReader(ctx.Response(), ctx.Request().Body, maxTreeImportSize)
+	report, err := h.service.ImportTrees(ctx.Request().Context(), uuid.UUID(id), format, body, bestEffort)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Import too large")
+		}
+		return err
+	}
+
+	imported32 := int32(report.Imported)
+	errs := make([]generated.TreeImportError, len(report.Errors))
+	for i, rowErr := range report.Errors {
+		errs[i] = generated.TreeImportError{
+			Row:     int32(rowErr.Row),
+			Message: rowErr.Message,
+		}
+	}
+	response := generated.TreeImportResponse{
+		Imported: &imported32,
+		Errors:   &errs,
+	}
+
+	// Nothing is imported in atomic mode when a row is rejected
+	if len(report.Errors) > 0 && !bestEffort {
+		return ctx.JSON(http.StatusUnprocessableEntity, response)
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// GetTree gets a tree of an estate
+func (h *Handler) GetTree(ctx echo.Context, id openapi_types.UUID, treeId openapi_types.UUID) error {
+	tree, err := h.service.GetTree(ctx.Request().Context(), uuid.UUID(id), uuid.UUID(treeId))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, toTree(tree))
+}
+
+// UpdateTree changes the height of a tree or moves it to another plot
+func (h *Handler) UpdateTree(ctx echo.Context, id openapi_types.UUID, treeId openapi_types.UUID) error {
+	var req generated.TreeUpdateRequest
+	if err := ctx.Bind(&req); err != nil {
+		return badRequest("Invalid request format")
+	}
+
+	if req.X == nil && req.Y == nil && req.Height == nil &&
+		req.Species == nil && req.PlantedOn == nil && req.ClearPlantedOn == nil && req.Health == nil && req.Tags == nil {
+		return badRequest("Nothing to update")
+	}
+
+	var update service.TreeUpdate
+	if req.X != nil {
+		x := int(*req.X)
+		update.X = &x
+	}
+	if req.Y != nil {
+		y := int(*req.Y)
+		update.Y = &y
+	}
+	if req.Height != nil {
+		height := int(*req.Height)
+		update.Height = &height
+	}
+	update.Species = req.Species
+	if req.PlantedOn != nil {
+		update.PlantedOn = &req.PlantedOn.Time
+	}
+	update.ClearPlantedOn = req.ClearPlantedOn != nil && *req.ClearPlantedOn
+	if req.Health != nil {
+		health := string(*req.Health)
+		update.Health = &health
+	}
+	if req.Tags != nil {
+		update.Tags = *req.Tags
+	}
+
+	tree, err := h.service.UpdateTree(ctx.Request().Context(), uuid.UUID(id), uuid.UUID(treeId), update)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, toTree(tree))
+}
+
+// DeleteTree removes a tree from an estate
+func (h *Handler) DeleteTree(ctx echo.Context, id openapi_types.UUID, treeId openapi_types.UUID) error {
+	if err := h.service.DeleteTree(ctx.Request().Context(), uuid.UUID(id), uuid.UUID(treeId)); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// GetTreeHistory lists the heights a tree has been measured at
+func (h *Handler) GetTreeHistory(ctx echo.Context, id openapi_types.UUID, treeId openapi_types.UUID) error {
+	measurements, err := h.service.GetTreeHistory(ctx.Request().Context(), uuid.UUID(id), uuid.UUID(treeId))
+	if err != nil {
+		return err
+	}
+
+	response := generated.TreeHistoryResponse{
+		Measurements: make([]generated.TreeMeasurement, len(measurements)),
+	}
+	for i, measurement := range measurements {
+		response.Measurements[i] = generated.TreeMeasurement{
+			Height:     int32(measurement.Height),
+			MeasuredAt: measurement.MeasuredAt,
+		}
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// toTree converts a tree to its API representation
+func toTree(tree repository.Tree) generated.Tree {
+	id := openapi_types.UUID(tree.ID)
+	x := int32(tree.X)
+	y := int32(tree.Y)
+	height := int32(tree.Height)
+	response := generated.Tree{
+		Id:     &id,
+		X:      &x,
+		Y:      &y,
+		Height: &height,
+	}
+	if tree.Species != "" {
+		species := tree.Species
+		response.Species = &species
+	}
+	if tree.PlantedOn != nil {
+		response.PlantedOn = &openapi_types.Date{Time: *tree.PlantedOn}
+	}
+	if tree.Health != "" {
+		health := generated.TreeHealth(tree.Health)
+		response.Health = &health
+	}
+	if len(tree.Tags) > 0 {
+		tags := tree.Tags
+		response.Tags = &tags
+	}
+	if tree.Zones != nil {
+		zones := tree.Zones
+		response.Zones = &zones
+	}
+	return response
+}
+
+// treeFilter converts the tree attribute query parameters to a filter
+func treeFilter(species *string, health *generated.TreeHealth, tags *[]string, plantedFrom, plantedTo *openapi_types.Date) repository.TreeFilter {
+	var filter repository.TreeFilter
+	if species != nil {
+		filter.Species = *species
+	}
+	if health != nil {
+		filter.Health = string(*health)
+	}
+	if tags != nil {
+		filter.Tags = *tags
+	}
+	if plantedFrom != nil {
+		filter.PlantedFrom = plantedFrom.Time
+	}
+	if plantedTo != nil {
+		filter.PlantedTo = plantedTo.Time
+	}
+	return filter
+}
+
+// ListZones lists the zones of an estate
+func (h *Handler) ListZones(ctx echo.Context, id openapi_types.UUID) error {
+	zones, err := h.service.ListZones(ctx.Request().Context(), uuid.UUID(id))
+	if err != nil {
+		return err
+	}
+
+	items := make([]generated.Zone, len(zones))
+	for i, zone := range zones {
+		items[i] = toZone(zone)
+	}
+	return ctx.JSON(http.StatusOK, generated.ZoneListResponse{Zones: &items})
+}
+
+// CreateZone adds a named zone to an estate
+func (h *Handler) CreateZone(ctx echo.Context, id openapi_types.UUID) error {
+	var req generated.ZoneRequest
+	if err := ctx.Bind(&req); err != nil {
+		return badRequest("Invalid request format")
+	}
+
+	if (req.Rect == nil) == (req.Polygon == nil) {
+		return badRequest("Either rect or polygon is required")
+	}
+
+	input := service.ZoneInput{Name: req.Name}
+	input.Rect, input.Polygon = zoneShape(req.Rect, req.Polygon)
+
+	zone, err := h.service.CreateZone(ctx.Request().Context(), uuid.UUID(id), input)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, toZone(zone))
+}
+
+// GetZone gets a zone of an estate
+func (h *Handler) GetZone(ctx echo.Context, id openapi_types.UUID, zoneId openapi_types.UUID) error {
+	zone, err := h.service.GetZone(ctx.Request().Context(), uuid.UUID(id), uuid.UUID(zoneId))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, toZone(zone))
+}
+
+// UpdateZone renames or reshapes a zone
+func (h *Handler) UpdateZone(ctx echo.Context, id openapi_types.UUID, zoneId openapi_types.UUID) error {
+	var req generated.ZoneUpdateRequest
+	if err := ctx.Bind(&req); err != nil {
+		return badRequest("Invalid request format")
+	}
+
+	if req.Name == nil && req.Rect == nil && req.Polygon == nil {
+		return badRequest("Nothing to update")
+	}
+	if req.Rect != nil && req.Polygon != nil {
+		return badRequest("Only one of rect and polygon can be given")
+	}
+
+	update := service.ZoneUpdate{Name: req.Name}
+	update.Rect, update.Polygon = zoneShape(req.Rect, req.Polygon)
+
+	zone, err := h.service.UpdateZone(ctx.Request().Context(), uuid.UUID(id), uuid.UUID(zoneId), update)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, toZone(zone))
+}
+
+// DeleteZone removes a zone from an estate
+func (h *Handler) DeleteZone(ctx echo.Context, id openapi_types.UUID, zoneId openapi_types.UUID) error {
+	if err := h.service.DeleteZone(ctx.Request().Context(), uuid.UUID(id), uuid.UUID(zoneId)); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// zoneShape converts the shape of a zone request to a rectangle or polygon
+func zoneShape(rect *generated.ZoneRect, polygon *[]generated.ZonePoint) (*service.ZoneRect, []repository.Point) {
+	if rect != nil {
+		return &service.ZoneRect{
+			XMin: int(rect.XMin),
+			XMax: int(rect.XMax),
+			YMin: int(rect.YMin),
+			YMax: int(rect.YMax),
+		}, nil
+	}
+	if polygon == nil {
+		return nil, nil
+	}
+
+	points := make([]repository.Point, len(*polygon))
+	for i, p := range *polygon {
+		points[i] = repository.Point{X: int(p.X), Y: int(p.Y)}
+	}
+	return nil, points
+}
+
+// toZone converts a zone to its API representation
+func toZone(zone repository.Zone) generated.Zone {
+	id := openapi_types.UUID(zone.ID)
+	name := zone.Name
+	shape := generated.ZoneShape(zone.Shape)
+	polygon := make([]generated.ZonePoint, len(zone.Vertices))
+	for i, v := range zone.Vertices {
+		polygon[i] = generated.ZonePoint{X: int32(v.X), Y: int32(v.Y)}
+	}
+
+	response := generated.Zone{
+		Id:      &id,
+		Name:    &name,
+		Shape:   &shape,
+		Polygon: &polygon,
+	}
+	// Rectangles are stored as their corners from the south-west one
+	// counter-clockwise
+	if zone.Shape == repository.ZoneShapeRect && len(zone.Vertices) == 4 {
+		response.Rect = &generated.ZoneRect{
+			XMin: int32(zone.Vertices[0].X),
+			XMax: int32(zone.Vertices[2].X),
+			YMin: int32(zone.Vertices[0].Y),
+			YMax: int32(zone.Vertices[2].Y),
+		}
+	}
+	return response
+}
+
+// ListObstacles lists the obstacles of an estate
+func (h *Handler) ListObstacles(ctx echo.Context, id openapi_types.UUID) error {
+	obstacles, err := h.service.ListObstacles(ctx.Request().Context(), uuid.UUID(id))
+	if err != nil {
+		return err
+	}
+
+	items := make([]generated.Obstacle, len(obstacles))
+	for i, obstacle := range obstacles {
+		items[i] = toObstacle(obstacle)
+	}
+	return ctx.JSON(http.StatusOK, generated.ObstacleListResponse{Obstacles: &items})
+}
+
+// CreateObstacle adds an obstacle to an estate
+func (h *Handler) CreateObstacle(ctx echo.Context, id openapi_types.UUID) error {
+	var req generated.ObstacleRequest
+	if err := ctx.Bind(&req); err != nil {
+		return badRequest("Invalid request format")
+	}
+
+	if (req.Cell == nil) == (req.Rect == nil) {
+		return badRequest("Either cell or rect is required")
+	}
+	impassable := req.Impassable != nil && *req.Impassable
+	if (req.MinAltitude == nil) == !impassable {
+		return badRequest("Either min_altitude or impassable is required")
+	}
+
+	input := service.ObstacleInput{Impassable: impassable}
+	if req.Cell != nil {
+		x, y := int(req.Cell.X), int(req.Cell.Y)
+		input.Rect = service.PlotRect{XMin: x, XMax: x, YMin: y, YMax: y}
+	} else {
+		input.Rect = service.PlotRect{
+			XMin: int(req.Rect.XMin),
+			XMax: int(req.Rect.XMax),
+			YMin: int(req.Rect.YMin),
+			YMax: int(req.Rect.YMax),
+		}
+	}
+	if req.MinAltitude != nil {
+		input.MinAltitude = int(*req.MinAltitude)
+		if input.MinAltitude < 1 {
+			return badRequest("Min altitude must be positive")
+		}
+	}
+
+	obstacle, err := h.service.CreateObstacle(ctx.Request().Context(), uuid.UUID(id), input)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, toObstacle(obstacle))
+}
+
+// DeleteObstacle removes an obstacle from an estate
+func (h *Handler) DeleteObstacle(ctx echo.Context, id openapi_types.UUID, obstacleId openapi_types.UUID) error {
+	if err := h.service.DeleteObstacle(ctx.Request().Context(), uuid.UUID(id), uuid.UUID(obstacleId)); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// toObstacle converts an obstacle to its API representation
+func toObstacle(obstacle repository.Obstacle) generated.Obstacle {
+	id := openapi_types.UUID(obstacle.ID)
+	rect := toPlotRect(service.PlotRect{XMin: obstacle.XMin, XMax: obstacle.XMax, YMin: obstacle.YMin, YMax: obstacle.YMax})
+	impassable := obstacle.Impassable
+
+	response := generated.Obstacle{
+		Id:         &id,
+		Rect:       &rect,
+		Impassable: &impassable,
+	}
+	if !obstacle.Impassable {
+		minAltitude32 := int32(obstacle.MinAltitude)
+		response.MinAltitude = &minAltitude32
+	}
+	return response
+}
+
+// toPlotRect converts a rectangle of plots to its API representation
+func toPlotRect(rect service.PlotRect) generated.PlotRect {
+	return generated.PlotRect{
+		XMin: int32(rect.XMin),
+		XMax: int32(rect.XMax),
+		YMin: int32(rect.YMin),
+		YMax: int32(rect.YMax),
+	}
+}
+
 // GetEstateStats gets stats about trees in an estate
-func (h *Handler) GetEstateStats(ctx echo.Context, id openapi_types.UUID) error {
+func (h *Handler) GetEstateStats(ctx echo.Context, id openapi_types.UUID, params generated.GetEstateStatsParams) error {
 	// Since openapi_types.UUID is an alias for uuid.UUID, we can use it directly
 	estateID := uuid.UUID(id)
 
-	count, maxHeight, minHeight, medianHeight, err := h.service.GetTreeStats(ctx.Request().Context(), estateID)
+	opts := service.TreeStatsOptions{
+		XMin:   intValue(params.XMin),
+		XMax:   intValue(params.XMax),
+		YMin:   intValue(params.YMin),
+		YMax:   intValue(params.YMax),
+		Grid:   intValue(params.Grid),
+		Filter: treeFilter(params.Species, params.Health, params.Tag, params.PlantedFrom, params.PlantedTo),
+	}
+	if params.Zone != nil {
+		opts.Zone = *params.Zone
+	}
+	if params.Percentiles != nil {
+		opts.Percentiles = *params.Percentiles
+	}
+
+	stats, err := h.service.GetTreeStats(ctx.Request().Context(), estateID, opts)
 	if err != nil {
-		if err.Error() == "estate not found" {
-			return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
-				Message: strPtr("Estate not found"),
-			})
-		}
-		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
-			Message: strPtr(err.Error()),
-		})
+		return err
 	}
 
-	count32 := int32(count)
-	maxHeight32 := int32(maxHeight)
-	minHeight32 := int32(minHeight)
-	medianHeight32 := int32(medianHeight)
+	count32 := int32(stats.Count)
+	maxHeight32 := int32(stats.MaxHeight)
+	minHeight32 := int32(stats.MinHeight)
+
+	percentiles := make([]generated.HeightPercentile, len(stats.Percentiles))
+	for i, p := range stats.Percentiles {
+		percentile, height := p.Percentile, p.Height
+		percentiles[i] = generated.HeightPercentile{Percentile: &percentile, Height: &height}
+	}
+
+	histogram := make([]generated.HeightBucket, len(stats.Histogram))
+	for i, n := range stats.Histogram {
+		height, count := int32(i+1), int32(n)
+		histogram[i] = generated.HeightBucket{Height: &height, Count: &count}
+	}
 
 	return ctx.JSON(http.StatusOK, generated.StatsResponse{
 		Count:        &count32,
 		MaxHeight:    &maxHeight32,
 		MinHeight:    &minHeight32,
-		MedianHeight: &medianHeight32,
+		MedianHeight: &stats.MedianHeight,
+		MeanHeight:   &stats.MeanHeight,
+		StddevHeight: &stats.StdDevHeight,
+		Percentiles:  &percentiles,
+		Histogram:    &histogram,
+		Density:      &stats.Density,
+		Grid:         toStatsGrid(stats.Grid),
 	})
 }
 
+// toStatsGrid converts a grid of tree statistics to its API representation
+func toStatsGrid(grid *service.StatsGrid) *generated.StatsGrid {
+	if grid == nil {
+		return nil
+	}
+
+	cellSize := int32(grid.CellSize)
+	columns := int32(grid.Columns)
+	rows := int32(grid.Rows)
+	cells := make([][]generated.StatsGridCell, len(grid.Cells))
+	for i, row := range grid.Cells {
+		cells[i] = make([]generated.StatsGridCell, len(row))
+		for j, cell := range row {
+			xMin, xMax := int32(cell.XMin), int32(cell.XMax)
+			yMin, yMax := int32(cell.YMin), int32(cell.YMax)
+			count := int32(cell.Count)
+			maxHeight, minHeight := int32(cell.MaxHeight), int32(cell.MinHeight)
+			medianHeight, meanHeight, emptyRatio := cell.MedianHeight, cell.MeanHeight, cell.EmptyRatio
+			cells[i][j] = generated.StatsGridCell{
+				XMin:         &xMin,
+				XMax:         &xMax,
+				YMin:         &yMin,
+				YMax:         &yMax,
+				Count:        &count,
+				MaxHeight:    &maxHeight,
+				MinHeight:    &minHeight,
+				MedianHeight: &medianHeight,
+				MeanHeight:   &meanHeight,
+				EmptyRatio:   &emptyRatio,
+			}
+		}
+	}
+
+	return &generated.StatsGrid{
+		CellSize: &cellSize,
+		Columns:  &columns,
+		Rows:     &rows,
+		Cells:    &cells,
+	}
+}
+
+// GetEstateGrowth reports how fast the trees of an estate grow
+func (h *Handler) GetEstateGrowth(ctx echo.Context, id openapi_types.UUID, params generated.GetEstateGrowthParams) error {
+	opts := service.GrowthReportOptions{Limit: intValue(params.Limit)}
+
+	report, err := h.service.GetGrowthReport(ctx.Request().Context(), uuid.UUID(id), opts)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, generated.GrowthResponse{
+		Trees:                int32(report.Trees),
+		AverageMonthlyGrowth: report.AverageMonthlyGrowth,
+		Fastest:              toTreeGrowths(report.Fastest),
+		Slowest:              toTreeGrowths(report.Slowest),
+	})
+}
+
+// toTreeGrowths converts the growth of trees to their API representation
+func toTreeGrowths(growths []repository.TreeGrowth) []generated.TreeGrowth {
+	response := make([]generated.TreeGrowth, len(growths))
+	for i, growth := range growths {
+		response[i] = generated.TreeGrowth{
+			TreeId:          openapi_types.UUID(growth.TreeID),
+			X:               int32(growth.X),
+			Y:               int32(growth.Y),
+			FirstHeight:     int32(growth.FirstHeight),
+			LastHeight:      int32(growth.LastHeight),
+			FirstMeasuredAt: growth.FirstMeasuredAt,
+			LastMeasuredAt:  growth.LastMeasuredAt,
+			MonthlyGrowth:   growth.MonthlyGrowth,
+		}
+	}
+	return response
+}
+
 // GetDronePlan gets the drone monitoring travel plan
 func (h *Handler) GetDronePlan(ctx echo.Context, id openapi_types.UUID, params generated.GetDronePlanParams) error {
 	// Since openapi_types.UUID is an alias for uuid.UUID, we can use it directly
 	estateID := uuid.UUID(id)
 
+	// Flight paths, multi-leg plans and other patterns are only produced by the full plan calculation
+	if params.Include != nil || params.Mode != nil || params.Pattern != nil || params.Optimize != nil ||
+		params.Clearance != nil || params.Altitude != nil || params.Lookahead != nil || params.Zone != nil ||
+		params.ExcludeDead != nil {
+		return h.getFullDronePlan(ctx, estateID, params)
+	}
+
 	var response generated.DronePlanResponse
 
 	// If max_distance is provided, calculate with rest
@@ -118,21 +890,12 @@ func (h *Handler) GetDronePlan(ctx echo.Context, id openapi_types.UUID, params g
 		maxDistance := int(*params.MaxDistance)
 		
 		if maxDistance <= 0 {
-			return ctx.JSON(http.StatusBadRequest, generated.ErrorResponse{
-				Message: strPtr("Max distance must be positive"),
-			})
+			return badRequest("Max distance must be positive")
 		}
 
 		distance, restX, restY, err := h.service.CalculateDronePathWithRest(ctx.Request().Context(), estateID, maxDistance)
 		if err != nil {
-			if err.Error() == "estate not found" {
-				return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
-					Message: strPtr("Estate not found"),
-				})
-			}
-			return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
-				Message: strPtr(err.Error()),
-			})
+			return err
 		}
 
 		// Convert to int32 for the response
@@ -154,14 +917,7 @@ func (h *Handler) GetDronePlan(ctx echo.Context, id openapi_types.UUID, params g
 		// Calculate without rest
 		distance, err := h.service.CalculateDronePath(ctx.Request().Context(), estateID)
 		if err != nil {
-			if err.Error() == "estate not found" {
-				return ctx.JSON(http.StatusNotFound, generated.ErrorResponse{
-					Message: strPtr("Estate not found"),
-				})
-			}
-			return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
-				Message: strPtr(err.Error()),
-			})
+			return err
 		}
 
 		// Convert to int32 for the response
@@ -174,27 +930,337 @@ func (h *Handler) GetDronePlan(ctx echo.Context, id openapi_types.UUID, params g
 	return ctx.JSON(http.StatusOK, response)
 }
 
-// ListEstates lists all estates
-func (h *Handler) ListEstates(ctx echo.Context) error {
-	estates, err := h.service.ListEstates(ctx.Request().Context())
+// getFullDronePlan calculates the drone plan with all requested details
+func (h *Handler) getFullDronePlan(ctx echo.Context, estateID uuid.UUID, params generated.GetDronePlanParams) error {
+	opts, msg := dronePlanOptions(params)
+	if msg != "" {
+		return badRequest(msg)
+	}
+
+	plan, err := h.service.PlanDronePath(ctx.Request().Context(), estateID, opts)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, toDronePlanResponse(plan, opts))
+}
+
+// dronePlanOptions validates the drone plan parameters and converts them to
+// plan options. It returns a message describing the first invalid parameter.
+func dronePlanOptions(params generated.GetDronePlanParams) (service.DronePlanOptions, string) {
+	var opts service.DronePlanOptions
+
+	if params.Include != nil {
+		if string(*params.Include) != "path" {
+			return opts, "Invalid include value"
+		}
+		opts.IncludePath = true
+	}
+
+	if params.Mode != nil {
+		switch string(*params.Mode) {
+		case "rest":
+		case "legs":
+			if params.MaxDistance == nil {
+				return opts, "Max distance is required for multi-leg plans"
+			}
+			opts.Legs = true
+		default:
+			return opts, "Invalid mode value"
+		}
+	}
+
+	if params.Pattern != nil {
+		opts.Pattern = string(*params.Pattern)
+	}
+
+	if params.Optimize != nil && *params.Optimize {
+		if params.Pattern != nil {
+			return opts, "Pattern cannot be combined with optimize"
+		}
+		opts.Optimize = true
+	}
+
+	if params.MaxDistance != nil {
+		if *params.MaxDistance <= 0 {
+			return opts, "Max distance must be positive"
+		}
+		opts.MaxDistance = int(*params.MaxDistance)
+	}
+
+	if params.Clearance != nil {
+		if *params.Clearance <= 0 {
+			return opts, "Clearance must be positive"
+		}
+		opts.Clearance = int(*params.Clearance)
+	}
+
+	if params.Altitude != nil {
+		switch string(*params.Altitude) {
+		case "terrain":
+		case "smoothed":
+			opts.SmoothAltitude = true
+		default:
+			return opts, "Invalid altitude value"
+		}
+	}
+
+	if params.Lookahead != nil {
+		if !opts.SmoothAltitude {
+			return opts, "Lookahead requires smoothed altitude"
+		}
+		if *params.Lookahead <= 0 {
+			return opts, "Lookahead must be positive"
+		}
+		opts.Lookahead = int(*params.Lookahead)
+	}
+
+	if params.Zone != nil {
+		opts.Zone = *params.Zone
+	}
+
+	opts.ExcludeDead = params.ExcludeDead != nil && *params.ExcludeDead
+
+	return opts, ""
+}
+
+// toDronePlanResponse converts a drone plan calculated with the given options
+// to its API representation
+func toDronePlanResponse(plan *service.DronePlan, opts service.DronePlanOptions) generated.DronePlanResponse {
+	distance32 := int32(plan.Distance)
+	response := generated.DronePlanResponse{
+		Distance: &distance32,
+	}
+
+	if plan.Pattern != "" {
+		response.Pattern = strPtr(plan.Pattern)
+		response.StartCorner = strPtr(plan.StartCorner)
+	}
+
+	if opts.SmoothAltitude {
+		verticalSaved32 := int32(plan.VerticalSaved)
+		response.VerticalSaved = &verticalSaved32
+	}
+
+	if plan.Alternatives != nil {
+		alternatives := make([]generated.DronePlanVariant, len(plan.Alternatives))
+		for i, variant := range plan.Alternatives {
+			alternatives[i] = generated.DronePlanVariant{
+				Pattern:     variant.Pattern,
+				StartCorner: variant.StartCorner,
+				Distance:    int32(variant.Distance),
+			}
+		}
+		response.Alternatives = &alternatives
+	}
+
+	if plan.Rest != nil {
+		restX32 := int32(plan.Rest.X)
+		restY32 := int32(plan.Rest.Y)
+		response.Rest = &struct {
+			X *int32 `json:"x,omitempty"`
+			Y *int32 `json:"y,omitempty"`
+		}{
+			X: &restX32,
+			Y: &restY32,
+		}
+	}
+
+	if plan.Legs != nil {
+		legs := make([]generated.DroneLeg, len(plan.Legs))
+		for i, leg := range plan.Legs {
+			legs[i] = generated.DroneLeg{
+				Start:    toPlot(leg.Start),
+				Landing:  toPlot(leg.Landing),
+				Distance: int32(leg.Distance),
+			}
+		}
+		response.Legs = &legs
+	}
+
+	if plan.UnreachablePlots > 0 {
+		unreachable := make([]generated.PlotRect, len(plan.Unreachable))
+		for i, rect := range plan.Unreachable {
+			unreachable[i] = toPlotRect(rect)
+		}
+		unreachablePlots32 := int32(plan.UnreachablePlots)
+		response.Unreachable = &unreachable
+		response.UnreachablePlots = &unreachablePlots32
+	}
+
+	if plan.Path != nil {
+		path := make([]generated.Waypoint, len(plan.Path))
+		for i, waypoint := range plan.Path {
+			path[i] = generated.Waypoint{
+				X: int32(waypoint.X),
+				Y: int32(waypoint.Y),
+				Z: int32(waypoint.Z),
+			}
+		}
+		response.Path = &path
+	}
+
+	return response
+}
+
+// CreateDronePlanJob submits a drone plan to be calculated in the background
+func (h *Handler) CreateDronePlanJob(ctx echo.Context, id openapi_types.UUID) error {
+	var req generated.DronePlanJobRequest
+	if err := ctx.Bind(&req); err != nil {
+		return badRequest("Invalid request format")
+	}
+
+	// Since openapi_types.UUID is an alias for uuid.UUID, we can use it directly
+	estateID := uuid.UUID(id)
+
+	// The request body takes the same options as the drone plan query parameters
+	opts, msg := dronePlanOptions(generated.GetDronePlanParams{
+		MaxDistance: req.MaxDistance,
+		Include:     (*generated.GetDronePlanParamsInclude)(req.Include),
+		Mode:        (*generated.GetDronePlanParamsMode)(req.Mode),
+		Pattern:     (*generated.GetDronePlanParamsPattern)(req.Pattern),
+		Optimize:    req.Optimize,
+		Clearance:   req.Clearance,
+		Altitude:    (*generated.GetDronePlanParamsAltitude)(req.Altitude),
+		Lookahead:   req.Lookahead,
+		Zone:        req.Zone,
+		ExcludeDead: req.ExcludeDead,
+	})
+	if msg != "" {
+		return badRequest(msg)
+	}
+
+	jobID, err := h.service.SubmitDronePlanJob(ctx.Request().Context(), estateID, opts)
+	if err != nil {
+		return err
+	}
+
+	jobUUID := openapi_types.UUID(jobID)
+	status := "queued"
+	return ctx.JSON(http.StatusAccepted, generated.JobResponse{
+		Id:     &jobUUID,
+		Status: &status,
+	})
+}
+
+// GetJob gets the status, progress and result of a drone plan job
+func (h *Handler) GetJob(ctx echo.Context, jobId openapi_types.UUID) error {
+	job, err := h.service.GetJob(ctx.Request().Context(), uuid.UUID(jobId))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, toJobResponse(job))
+}
+
+// CancelJob cancels a queued or running drone plan job
+func (h *Handler) CancelJob(ctx echo.Context, jobId openapi_types.UUID) error {
+	id := uuid.UUID(jobId)
+
+	if err := h.service.CancelJob(ctx.Request().Context(), id); err != nil {
+		return err
+	}
+
+	job, err := h.service.GetJob(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, toJobResponse(job))
+}
+
+// toJobResponse converts a drone plan job to its API representation
+func toJobResponse(job *service.Job) generated.JobResponse {
+	id := openapi_types.UUID(job.ID)
+	estateID := openapi_types.UUID(job.EstateID)
+	progress32 := int32(job.Progress)
+
+	response := generated.JobResponse{
+		Id:        &id,
+		EstateId:  &estateID,
+		Status:    strPtr(job.Status),
+		Progress:  &progress32,
+		CreatedAt: &job.CreatedAt,
+		UpdatedAt: &job.UpdatedAt,
+	}
+
+	if job.Error != "" {
+		response.Error = strPtr(job.Error)
+	}
+
+	if job.Result != nil {
+		result := toDronePlanResponse(job.Result, job.Options)
+		response.Result = &result
+	}
+
+	return response
+}
+
+// ListEstates lists the estates a page at a time. The cursor of the next page
+// is returned in the X-Next-Cursor header so that the body stays a plain array.
+func (h *Handler) ListEstates(ctx echo.Context, params generated.ListEstatesParams) error {
+	opts := service.EstateListOptions{
+		MinWidth:  intValue(params.WidthMin),
+		MaxWidth:  intValue(params.WidthMax),
+		MinLength: intValue(params.LengthMin),
+		MaxLength: intValue(params.LengthMax),
+		Limit:     intValue(params.Limit),
+	}
+	if params.AreaMin != nil {
+		opts.MinArea = *params.AreaMin
+	}
+	if params.AreaMax != nil {
+		opts.MaxArea = *params.AreaMax
+	}
+	if params.Cursor != nil {
+		opts.Cursor = *params.Cursor
+	}
+	if params.Sort != nil {
+		opts.Sort = string(*params.Sort)
+	}
+	if params.Order != nil {
+		switch string(*params.Order) {
+		case "asc":
+		case "desc":
+			opts.Descending = true
+		default:
+			return badRequest("Invalid order value")
+		}
+	}
+	if params.Include != nil {
+		if string(*params.Include) != "tree_count" {
+			return badRequest("Invalid include value")
+		}
+		opts.WithTreeCount = true
+	}
+
+	page, err := h.service.ListEstates(ctx.Request().Context(), opts)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, generated.ErrorResponse{
-			Message: strPtr(err.Error()),
-		})
+		return err
 	}
 
 	// Convert from repository.Estate to generated.EstateListItem
-	response := make([]generated.EstateListItem, len(estates))
-	for i, estate := range estates {
+	response := make([]generated.EstateListItem, len(page.Estates))
+	for i, estate := range page.Estates {
 		id := openapi_types.UUID(estate.ID)
 		width := int32(estate.Width)
 		length := int32(estate.Length)
-		
+		createdAt := estate.CreatedAt
+
 		response[i] = generated.EstateListItem{
-			Id:     &id,
-			Width:  &width,
-			Length: &length,
+			Id:        &id,
+			Width:     &width,
+			Length:    &length,
+			CreatedAt: &createdAt,
 		}
+		if opts.WithTreeCount {
+			treeCount := int32(estate.TreeCount)
+			response[i].TreeCount = &treeCount
+		}
+	}
+
+	if page.NextCursor != "" {
+		ctx.Response().Header().Set("X-Next-Cursor", page.NextCursor)
 	}
 
 	return ctx.JSON(http.StatusOK, response)
@@ -208,6 +1274,22 @@ func (h *Handler) Ping(ctx echo.Context) error {
 	})
 }
 
+// toPlot converts a service plot to its API representation
+func toPlot(plot service.Plot) generated.Plot {
+	return generated.Plot{
+		X: int32(plot.X),
+		Y: int32(plot.Y),
+	}
+}
+
+// intValue returns the value of an optional integer parameter, 0 if not set
+func intValue(v *int32) int {
+	if v == nil {
+		return 0
+	}
+	return int(*v)
+}
+
 // Helper function to convert a string to a pointer
 func strPtr(s string) *string {
 	return &s
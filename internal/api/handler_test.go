@@ -1,12 +1,15 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
@@ -15,6 +18,9 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"drone/generated"
+	"drone/internal/repository"
+	repomocks "drone/internal/repository/mocks"
+	"drone/internal/service"
 	"drone/internal/service/mocks"
 )
 
@@ -48,40 +54,2993 @@ func TestCreateEstate(t *testing.T) {
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
 					CreateEstate(gomock.Any(), 1000, 0).
-					Return(uuid.UUID{}, errors.New("invalid estate dimensions"))
+					Return(uuid.UUID{}, service.NewValidationError("invalid estate dimensions"))
 			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Invalid Request - Invalid Width",
+			requestBody: `{"width": 0, "length": 2000}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateEstate(gomock.Any(), 0, 2000).
+					Return(uuid.UUID{}, service.NewValidationError("invalid estate dimensions"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Invalid Request - Invalid Length",
+			requestBody: `{"width": 1000, "length": 60000}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateEstate(gomock.Any(), 1000, 60000).
+					Return(uuid.UUID{}, service.NewValidationError("invalid estate dimensions"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Repository Error",
+			requestBody: `{"width": 1000, "length": 2000}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateEstate(gomock.Any(), 1000, 2000).
+					Return(uuid.UUID{}, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+			
+			// Setup test request
+			req := httptest.NewRequest(http.MethodPost, "/estate", strings.NewReader(tc.requestBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+			
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+			
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+			
+			// Perform the test
+			respond(c, h.CreateEstate(c))
+			
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestListEstates(t *testing.T) {
+	sortArea := generated.ListEstatesParamsSort("area")
+	includeTreeCount := generated.ListEstatesParamsInclude("tree_count")
+	invalidInclude := generated.ListEstatesParamsInclude("trees")
+	areaMin := int64(2500000000)
+	widthMax := int32(50000)
+
+	testCases := []struct {
+		name           string
+		params         generated.ListEstatesParams
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "With Tree Count And Next Page",
+			params: generated.ListEstatesParams{Sort: &sortArea, AreaMin: &areaMin, WidthMax: &widthMax, Include: &includeTreeCount},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ListEstates(gomock.Any(), service.EstateListOptions{Sort: "area", MinArea: 2500000000, MaxWidth: 50000, WithTreeCount: true}).
+					Return(&service.EstatePage{
+						Estates:    []repository.Estate{{ID: uuid.New(), Width: 50000, Length: 50000, TreeCount: 7}},
+						NextCursor: "next",
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response []generated.EstateListItem
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Len(t, response, 1)
+				assert.Equal(t, int32(7), *response[0].TreeCount)
+				assert.Equal(t, "next", rec.Header().Get("X-Next-Cursor"))
+			},
+		},
+		{
+			name: "Last Page Without Tree Count",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ListEstates(gomock.Any(), service.EstateListOptions{}).
+					Return(&service.EstatePage{Estates: []repository.Estate{{ID: uuid.New(), Width: 10, Length: 20}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response []generated.EstateListItem
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Nil(t, response[0].TreeCount)
+				assert.Empty(t, rec.Header().Get("X-Next-Cursor"))
+			},
+		},
+		{
+			name:           "Invalid Include",
+			params:         generated.ListEstatesParams{Include: &invalidInclude},
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid Size Range",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ListEstates(gomock.Any(), gomock.Any()).
+					Return(nil, service.NewValidationError("invalid size range"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Repository Error",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ListEstates(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodGet, "/estate", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, h.ListEstates(c, tc.params))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+// TestListEstatesUnpaged pins down that clients passing neither limit nor
+// cursor get every estate, as before the listing was paginated, however many
+// there are
+func TestListEstatesUnpaged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	estates := make([]repository.Estate, 150)
+	for i := range estates {
+		estates[i] = repository.Estate{ID: uuid.New(), Width: 10, Length: 10}
+	}
+	repo := repomocks.NewMockRepository(ctrl)
+	// Without a limit the repository is asked for every estate
+	repo.EXPECT().QueryEstates(gomock.Any(), repository.EstateQuery{}).Return(estates, nil)
+	// A page is fetched with one more estate than asked for
+	repo.EXPECT().QueryEstates(gomock.Any(), repository.EstateQuery{Limit: 101}).Return(estates[:101], nil)
+	h := NewHandler(service.NewService(repo))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/estate", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	assert.NoError(t, h.ListEstates(c, generated.ListEstatesParams{}))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response []generated.EstateListItem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Len(t, response, 150)
+	assert.Empty(t, rec.Header().Get("X-Next-Cursor"))
+
+	// Asking for a page still pages
+	limit := int32(100)
+	req = httptest.NewRequest(http.MethodGet, "/estate?limit=100", nil)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+
+	assert.NoError(t, h.ListEstates(c, generated.ListEstatesParams{Limit: &limit}))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Len(t, response, 100)
+	assert.NotEmpty(t, rec.Header().Get("X-Next-Cursor"))
+}
+
+func TestGetEstate(t *testing.T) {
+	// Generate estate ID
+	estateID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetEstateDetail(gomock.Any(), estateID).
+					Return(repository.Estate{ID: estateID, Width: 10, Length: 20, TreeCount: 3}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.EstateDetailResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, estateID, uuid.UUID(*response.Id))
+				assert.Equal(t, int32(10), *response.Width)
+				assert.Equal(t, int32(20), *response.Length)
+				assert.Equal(t, int32(3), *response.TreeCount)
+				assert.Nil(t, response.PrunedTrees)
+			},
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetEstateDetail(gomock.Any(), estateID).
+					Return(repository.Estate{}, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Repository Error",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetEstateDetail(gomock.Any(), estateID).
+					Return(repository.Estate{}, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodGet, "/estate/"+estateID.String(), nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, h.GetEstate(c, openapi_types.UUID(estateID)))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestResizeEstate(t *testing.T) {
+	// Generate estate ID
+	estateID := uuid.New()
+	prune := true
+
+	testCases := []struct {
+		name           string
+		requestBody    string
+		prune          *bool
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Success",
+			requestBody: `{"width": 5, "length": 8}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ResizeEstate(gomock.Any(), estateID, 5, 8, false).
+					Return(repository.Estate{ID: estateID, Width: 5, Length: 8, TreeCount: 3}, 0, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.EstateDetailResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(5), *response.Width)
+				assert.Equal(t, int32(8), *response.Length)
+				assert.Nil(t, response.PrunedTrees)
+			},
+		},
+		{
+			name:        "Success - Prune",
+			requestBody: `{"width": 5, "length": 8}`,
+			prune:       &prune,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ResizeEstate(gomock.Any(), estateID, 5, 8, true).
+					Return(repository.Estate{ID: estateID, Width: 5, Length: 8, TreeCount: 1}, 2, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.EstateDetailResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(1), *response.TreeCount)
+				assert.Equal(t, int32(2), *response.PrunedTrees)
+			},
+		},
+		{
+			name:        "Trees Outside New Boundaries",
+			requestBody: `{"width": 5, "length": 8}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ResizeEstate(gomock.Any(), estateID, 5, 8, false).
+					Return(repository.Estate{}, 0, service.ErrTreesOutOfBounds)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:        "Invalid Dimensions",
+			requestBody: `{"width": 0, "length": 8}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ResizeEstate(gomock.Any(), estateID, 0, 8, false).
+					Return(repository.Estate{}, 0, service.NewValidationError("invalid estate dimensions"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Invalid Request Format",
+			requestBody:    `{"width": "wide"}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Estate Not Found",
+			requestBody: `{"width": 5, "length": 8}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ResizeEstate(gomock.Any(), estateID, 5, 8, false).
+					Return(repository.Estate{}, 0, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodPatch, "/estate/"+estateID.String(), strings.NewReader(tc.requestBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, h.ResizeEstate(c, openapi_types.UUID(estateID), generated.ResizeEstateParams{Prune: tc.prune}))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestDeleteEstate(t *testing.T) {
+	// Generate estate ID
+	estateID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					DeleteEstate(gomock.Any(), estateID).
+					Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					DeleteEstate(gomock.Any(), estateID).
+					Return(service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodDelete, "/estate/"+estateID.String(), nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, h.DeleteEstate(c, openapi_types.UUID(estateID)))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestExportEstate(t *testing.T) {
+	// Generate estate ID
+	estateID := uuid.New()
+	estateUUID := openapi_types.UUID(estateID)
+	csvFormat := generated.ExportEstateParamsFormat("csv")
+	geoFormat := generated.ExportEstateParamsFormat("geojson")
+	xmlFormat := generated.ExportEstateParamsFormat("xml")
+	lat, lon, plotSize := 1.5, 101.25, 5.0
+	invalidPlotSize := 0.0
+	attributes := true
+
+	testCases := []struct {
+		name           string
+		params         generated.ExportEstateParams
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "JSON",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ExportEstate(gomock.Any(), estateID, service.ExportJSON, service.GeoReference{}, false, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ uuid.UUID, _ string, _ service.GeoReference, _ bool, w io.Writer) error {
+						_, err := io.WriteString(w, `{"trees":[]}`)
+						return err
+					})
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, echo.MIMEApplicationJSON, rec.Header().Get(echo.HeaderContentType))
+				assert.Equal(t, `{"trees":[]}`, rec.Body.String())
+			},
+		},
+		{
+			name:   "CSV",
+			params: generated.ExportEstateParams{Format: &csvFormat},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetEstate(gomock.Any(), estateID).
+					Return(4, 3, nil)
+				mockSvc.EXPECT().
+					ExportEstate(gomock.Any(), estateID, service.ExportCSV, service.GeoReference{}, false, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ uuid.UUID, _ string, _ service.GeoReference, _ bool, w io.Writer) error {
+						_, err := io.WriteString(w, "x,y,height\n")
+						return err
+					})
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, "text/csv", rec.Header().Get(echo.HeaderContentType))
+				assert.Equal(t, "4", rec.Header().Get("X-Estate-Width"))
+				assert.Equal(t, "3", rec.Header().Get("X-Estate-Length"))
+				assert.Equal(t, "x,y,height\n", rec.Body.String())
+			},
+		},
+		{
+			name:   "CSV With Attributes",
+			params: generated.ExportEstateParams{Format: &csvFormat, Attributes: &attributes},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetEstate(gomock.Any(), estateID).
+					Return(4, 3, nil)
+				mockSvc.EXPECT().
+					ExportEstate(gomock.Any(), estateID, service.ExportCSV, service.GeoReference{}, true, gomock.Any()).
+					Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "GeoJSON",
+			params: generated.ExportEstateParams{Format: &geoFormat, OriginLat: &lat, OriginLon: &lon, PlotSize: &plotSize},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ExportEstate(gomock.Any(), estateID, service.ExportGeoJSON, service.GeoReference{OriginLat: 1.5, OriginLon: 101.25, PlotSize: 5}, false, gomock.Any()).
+					Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, "application/geo+json", rec.Header().Get(echo.HeaderContentType))
+			},
+		},
+		{
+			name:           "Invalid Plot Size",
+			params:         generated.ExportEstateParams{Format: &geoFormat, PlotSize: &invalidPlotSize},
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Unsupported Format",
+			params: generated.ExportEstateParams{Format: &xmlFormat},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ExportEstate(gomock.Any(), estateID, "xml", service.GeoReference{}, false, gomock.Any()).
+					Return(service.NewValidationError("unsupported export format"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, echo.MIMEApplicationJSONCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+				assert.Empty(t, rec.Header().Get(echo.HeaderContentDisposition))
+			},
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ExportEstate(gomock.Any(), estateID, service.ExportJSON, service.GeoReference{}, false, gomock.Any()).
+					Return(service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodGet, "/estate/"+estateID.String()+"/export", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, h.ExportEstate(c, estateUUID, tc.params))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestGetEstateHeatmap(t *testing.T) {
+	estateID := uuid.New()
+	estateUUID := openapi_types.UUID(estateID)
+	route := generated.GetEstateHeatmapPngParamsOverlay("route")
+	spiral := generated.GetEstateHeatmapSvgParamsPattern("spiral")
+	size, maxDistance, zero := int32(256), int32(50), int32(0)
+	writeImage := func(_ context.Context, _ uuid.UUID, _ string, _ service.HeatmapOptions, w io.Writer) error {
+		_, err := io.WriteString(w, "image")
+		return err
+	}
+
+	testCases := []struct {
+		name           string
+		render         func(h *Handler, c echo.Context) error
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "PNG With Route",
+			render: func(h *Handler, c echo.Context) error {
+				return h.GetEstateHeatmapPng(c, estateUUID, generated.GetEstateHeatmapPngParams{Size: &size, Overlay: &route, MaxDistance: &maxDistance})
+			},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					RenderHeatmap(gomock.Any(), estateID, service.HeatmapPNG, service.HeatmapOptions{
+						Size:  256,
+						Route: true,
+						Drone: service.DronePlanOptions{MaxDistance: 50},
+					}, gomock.Any()).
+					DoAndReturn(writeImage)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+				assert.Equal(t, "image", rec.Body.String())
+			},
+		},
+		{
+			name: "SVG",
+			render: func(h *Handler, c echo.Context) error {
+				return h.GetEstateHeatmapSvg(c, estateUUID, generated.GetEstateHeatmapSvgParams{})
+			},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					RenderHeatmap(gomock.Any(), estateID, service.HeatmapSVG, service.HeatmapOptions{}, gomock.Any()).
+					DoAndReturn(writeImage)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, "image/svg+xml", rec.Header().Get(echo.HeaderContentType))
+				assert.Equal(t, "image", rec.Body.String())
+			},
+		},
+		{
+			name: "Invalid Size",
+			render: func(h *Handler, c echo.Context) error {
+				return h.GetEstateHeatmapPng(c, estateUUID, generated.GetEstateHeatmapPngParams{Size: &zero})
+			},
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Pattern Without Route",
+			render: func(h *Handler, c echo.Context) error {
+				return h.GetEstateHeatmapSvg(c, estateUUID, generated.GetEstateHeatmapSvgParams{Pattern: &spiral})
+			},
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Estate Not Found",
+			render: func(h *Handler, c echo.Context) error {
+				return h.GetEstateHeatmapPng(c, estateUUID, generated.GetEstateHeatmapPngParams{})
+			},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					RenderHeatmap(gomock.Any(), estateID, service.HeatmapPNG, gomock.Any(), gomock.Any()).
+					Return(service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.Equal(t, echo.MIMEApplicationJSONCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+			},
+		},
+		{
+			name: "Repository Error",
+			render: func(h *Handler, c echo.Context) error {
+				return h.GetEstateHeatmapPng(c, estateUUID, generated.GetEstateHeatmapPngParams{})
+			},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					RenderHeatmap(gomock.Any(), estateID, service.HeatmapPNG, gomock.Any(), gomock.Any()).
+					Return(errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodGet, "/estate/"+estateID.String()+"/heatmap.png", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, tc.render(h, c))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestCreateTree(t *testing.T) {
+	// Generate estate ID
+	estateID := uuid.New()
+	estateUUID := openapi_types.UUID(estateID)
+
+	testCases := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Success",
+			requestBody: `{"x": 5, "y": 10, "height": 15}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateTree(gomock.Any(), estateID, service.TreeInput{X: 5, Y: 10, Height: 15}).
+					Return(uuid.New(), nil)
+			},
+			expectedStatus: http.StatusCreated,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.TreeResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.NotNil(t, response.Id)
+			},
+		},
+		{
+			name:        "Success - Attributes",
+			requestBody: `{"x": 5, "y": 10, "height": 15, "species": "Oil palm", "planted_on": "2020-03-01", "health": "healthy", "tags": ["north"]}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				plantedOn := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+				mockSvc.EXPECT().
+					CreateTree(gomock.Any(), estateID, service.TreeInput{
+						X: 5, Y: 10, Height: 15,
+						Species: "Oil palm", PlantedOn: &plantedOn, Health: "healthy", Tags: []string{"north"},
+					}).
+					Return(uuid.New(), nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:        "Invalid Health",
+			requestBody: `{"x": 5, "y": 10, "height": 15, "health": "sick"}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateTree(gomock.Any(), estateID, service.TreeInput{X: 5, Y: 10, Height: 15, Health: "sick"}).
+					Return(uuid.UUID{}, service.NewValidationError("invalid health status"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Invalid Request - Missing Fields",
+			requestBody: `{"x": 5, "y": 10}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateTree(gomock.Any(), gomock.Any(), service.TreeInput{X: 5, Y: 10, Height: 0}).
+					Return(uuid.UUID{}, service.NewValidationError("invalid tree height"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Invalid Request - Invalid X",
+			requestBody: `{"x": 0, "y": 10, "height": 15}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateTree(gomock.Any(), estateID, service.TreeInput{X: 0, Y: 10, Height: 15}).
+					Return(uuid.UUID{}, service.NewValidationError("tree coordinates outside estate boundaries"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Invalid Request - Invalid Y",
+			requestBody: `{"x": 5, "y": 0, "height": 15}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateTree(gomock.Any(), estateID, service.TreeInput{X: 5, Y: 0, Height: 15}).
+					Return(uuid.UUID{}, service.NewValidationError("tree coordinates outside estate boundaries"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Invalid Request - Invalid Height",
+			requestBody: `{"x": 5, "y": 10, "height": 40}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateTree(gomock.Any(), estateID, service.TreeInput{X: 5, Y: 10, Height: 40}).
+					Return(uuid.UUID{}, service.NewValidationError("invalid tree height"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Estate Not Found",
+			requestBody: `{"x": 5, "y": 10, "height": 15}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateTree(gomock.Any(), estateID, service.TreeInput{X: 5, Y: 10, Height: 15}).
+					Return(uuid.UUID{}, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:        "Tree Out of Bounds",
+			requestBody: `{"x": 200, "y": 10, "height": 15}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateTree(gomock.Any(), estateID, service.TreeInput{X: 200, Y: 10, Height: 15}).
+					Return(uuid.UUID{}, service.NewValidationError("tree coordinates outside estate boundaries"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Plot Occupied",
+			requestBody: `{"x": 5, "y": 10, "height": 15}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateTree(gomock.Any(), estateID, service.TreeInput{X: 5, Y: 10, Height: 15}).
+					Return(uuid.UUID{}, service.ErrPlotOccupied)
+			},
+			expectedStatus: http.StatusConflict,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.ErrorResponse
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				assert.Equal(t, "plot_occupied", response.Code)
+			},
+		},
+		{
+			name:        "Repository Error",
+			requestBody: `{"x": 5, "y": 10, "height": 15}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateTree(gomock.Any(), estateID, service.TreeInput{X: 5, Y: 10, Height: 15}).
+					Return(uuid.UUID{}, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+			
+			// Setup test request
+			req := httptest.NewRequest(http.MethodPost, "/estate/"+estateID.String()+"/tree", strings.NewReader(tc.requestBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+			
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+			
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+			
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+			
+			// Perform the test
+			respond(c, h.CreateTree(c, estateUUID))
+			
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestListTrees(t *testing.T) {
+	// Generate estate ID
+	estateID := uuid.New()
+	estateUUID := openapi_types.UUID(estateID)
+	sortHeight := generated.ListTreesParamsSort("height")
+	orderDesc := generated.ListTreesParamsOrder("desc")
+	invalidOrder := generated.ListTreesParamsOrder("up")
+	cursor := "next"
+	limit, heightMin, xMax := int32(2), int32(10), int32(50)
+	species, dead, sick := "Oil palm", generated.TreeHealthDead, generated.TreeHealth("sick")
+	tags := []string{"north", "fenced"}
+	plantedFrom := openapi_types.Date{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	plantedOn := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		params         generated.ListTreesParams
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "First Page",
+			params: generated.ListTreesParams{
+				Limit:     &limit,
+				Sort:      &sortHeight,
+				Order:     &orderDesc,
+				HeightMin: &heightMin,
+				XMax:      &xMax,
+			},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ListTrees(gomock.Any(), estateID, service.TreeListOptions{Sort: "height", Descending: true, MinHeight: 10, XMax: 50, Limit: 2}).
+					Return(&service.TreePage{
+						Trees:      []repository.Tree{{ID: uuid.New(), X: 1, Y: 1, Height: 30}, {ID: uuid.New(), X: 4, Y: 2, Height: 20}},
+						NextCursor: "next",
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.TreeListResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Len(t, response.Trees, 2)
+				assert.Equal(t, int32(30), *response.Trees[0].Height)
+				assert.Equal(t, "next", *response.NextCursor)
+			},
+		},
+		{
+			name:   "Last Page",
+			params: generated.ListTreesParams{Cursor: &cursor},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ListTrees(gomock.Any(), estateID, service.TreeListOptions{Cursor: "next"}).
+					Return(&service.TreePage{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.JSONEq(t, `{"trees": []}`, rec.Body.String())
+			},
+		},
+		{
+			name: "Filtered By Attributes",
+			params: generated.ListTreesParams{
+				Species:     &species,
+				Health:      &dead,
+				Tag:         &tags,
+				PlantedFrom: &plantedFrom,
+			},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ListTrees(gomock.Any(), estateID, service.TreeListOptions{Filter: repository.TreeFilter{
+						Species:     "Oil palm",
+						Health:      "dead",
+						Tags:        []string{"north", "fenced"},
+						PlantedFrom: plantedFrom.Time,
+					}}).
+					Return(&service.TreePage{
+						Trees: []repository.Tree{{
+							X: 1, Y: 1, Height: 3,
+							Species: "Oil palm", PlantedOn: &plantedOn, Health: "dead", Tags: []string{"north", "fenced"},
+						}},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.JSONEq(t, `{"trees": [{
+					"id": "00000000-0000-0000-0000-000000000000", "x": 1, "y": 1, "height": 3,
+					"species": "Oil palm", "planted_on": "2020-03-01", "health": "dead", "tags": ["north", "fenced"]
+				}]}`, rec.Body.String())
+			},
+		},
+		{
+			name:   "Invalid Health",
+			params: generated.ListTreesParams{Health: &sick},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ListTrees(gomock.Any(), estateID, gomock.Any()).
+					Return(nil, service.NewValidationError("invalid health status"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Invalid Order",
+			params:         generated.ListTreesParams{Order: &invalidOrder},
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Invalid Cursor",
+			params: generated.ListTreesParams{Cursor: &cursor},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ListTrees(gomock.Any(), estateID, gomock.Any()).
+					Return(nil, service.NewValidationError("invalid cursor"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ListTrees(gomock.Any(), estateID, gomock.Any()).
+					Return(nil, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodGet, "/estate/"+estateID.String()+"/tree", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, h.ListTrees(c, estateUUID, tc.params))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestImportTrees(t *testing.T) {
+	// Generate estate ID
+	estateID := uuid.New()
+	estateUUID := openapi_types.UUID(estateID)
+	bestEffort := generated.ImportTreesParamsMode("best_effort")
+	invalidMode := generated.ImportTreesParamsMode("some")
+
+	testCases := []struct {
+		name           string
+		contentType    string
+		requestBody    string
+		mode           *generated.ImportTreesParamsMode
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "CSV",
+			contentType: "text/csv; charset=utf-8",
+			requestBody: "1,1,5\n2,1,6\n",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ImportTrees(gomock.Any(), estateID, service.TreeImportCSV, gomock.Any(), false).
+					Return(&service.TreeImportReport{Imported: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.TreeImportResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(2), *response.Imported)
+				assert.Empty(t, *response.Errors)
+			},
+		},
+		{
+			name:        "JSON Best Effort",
+			contentType: echo.MIMEApplicationJSON,
+			requestBody: `[{"x": 1, "y": 1, "height": 5}, {"x": 1, "y": 1, "height": 6}]`,
+			mode:        &bestEffort,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ImportTrees(gomock.Any(), estateID, service.TreeImportJSON, gomock.Any(), true).
+					Return(&service.TreeImportReport{Imported: 1, Errors: []service.TreeImportError{{Row: 2, Message: "plot already has a tree"}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.TreeImportResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(1), *response.Imported)
+				assert.Equal(t, []generated.TreeImportError{{Row: 2, Message: "plot already has a tree"}}, *response.Errors)
+			},
+		},
+		{
+			name:        "Atomic With Rejected Rows",
+			contentType: "application/geo+json",
+			requestBody: `{"type": "FeatureCollection", "features": []}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ImportTrees(gomock.Any(), estateID, service.TreeImportGeoJSON, gomock.Any(), false).
+					Return(&service.TreeImportReport{Errors: []service.TreeImportError{{Row: 1, Message: "invalid tree height"}}}, nil)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Unsupported Content Type",
+			contentType:    "application/xml",
+			requestBody:    `<trees/>`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "Invalid Mode",
+			contentType:    "text/csv",
+			requestBody:    "1,1,5\n",
+			mode:           &invalidMode,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Malformed File",
+			contentType: echo.MIMEApplicationJSON,
+			requestBody: `{}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ImportTrees(gomock.Any(), estateID, service.TreeImportJSON, gomock.Any(), false).
+					Return(nil, service.NewValidationError("invalid JSON: expected an array of trees"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Estate Not Found",
+			contentType: "text/csv",
+			requestBody: "1,1,5\n",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ImportTrees(gomock.Any(), estateID, service.TreeImportCSV, gomock.Any(), false).
+					Return(nil, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:        "Plot Taken During Import",
+			contentType: "text/csv",
+			requestBody: "1,1,5\n",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ImportTrees(gomock.Any(), estateID, service.TreeImportCSV, gomock.Any(), false).
+					Return(nil, service.ErrPlotOccupied)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodPost, "/estate/"+estateID.String()+"/tree/import", strings.NewReader(tc.requestBody))
+			req.Header.Set(echo.HeaderContentType, tc.contentType)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, h.ImportTrees(c, estateUUID, generated.ImportTreesParams{Mode: tc.mode}))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestGetTree(t *testing.T) {
+	// Generate estate and tree IDs
+	estateID := uuid.New()
+	treeID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTree(gomock.Any(), estateID, treeID).
+					Return(repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 15}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.Tree
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, treeID, uuid.UUID(*response.Id))
+				assert.Equal(t, int32(5), *response.X)
+				assert.Equal(t, int32(10), *response.Y)
+				assert.Equal(t, int32(15), *response.Height)
+			},
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTree(gomock.Any(), estateID, treeID).
+					Return(repository.Tree{}, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Tree Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTree(gomock.Any(), estateID, treeID).
+					Return(repository.Tree{}, service.ErrTreeNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Repository Error",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTree(gomock.Any(), estateID, treeID).
+					Return(repository.Tree{}, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodGet, "/estate/"+estateID.String()+"/tree/"+treeID.String(), nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id", "treeId")
+			c.SetParamValues(estateID.String(), treeID.String())
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, h.GetTree(c, openapi_types.UUID(estateID), openapi_types.UUID(treeID)))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestUpdateTree(t *testing.T) {
+	// Generate estate and tree IDs
+	estateID := uuid.New()
+	treeID := uuid.New()
+	height := 20
+	x, y := 7, 8
+
+	testCases := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Change Height",
+			requestBody: `{"height": 20}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					UpdateTree(gomock.Any(), estateID, treeID, service.TreeUpdate{Height: &height}).
+					Return(repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 20}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.Tree
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(20), *response.Height)
+			},
+		},
+		{
+			name:        "Move",
+			requestBody: `{"x": 7, "y": 8}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					UpdateTree(gomock.Any(), estateID, treeID, service.TreeUpdate{X: &x, Y: &y}).
+					Return(repository.Tree{ID: treeID, EstateID: estateID, X: 7, Y: 8, Height: 15}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.Tree
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(7), *response.X)
+				assert.Equal(t, int32(8), *response.Y)
+			},
+		},
+		{
+			name:        "Attributes",
+			requestBody: `{"species": "", "health": "diseased", "tags": []}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				species, health := "", "diseased"
+				mockSvc.EXPECT().
+					UpdateTree(gomock.Any(), estateID, treeID, service.TreeUpdate{Species: &species, Health: &health, Tags: []string{}}).
+					Return(repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 15, Health: "diseased", Tags: []string{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.Tree
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, generated.TreeHealthDiseased, *response.Health)
+				assert.Nil(t, response.Species)
+				assert.Nil(t, response.Tags)
+			},
+		},
+		{
+			name:        "Clear Planting Date",
+			requestBody: `{"clear_planted_on": true}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					UpdateTree(gomock.Any(), estateID, treeID, service.TreeUpdate{ClearPlantedOn: true}).
+					Return(repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 15}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.Tree
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Nil(t, response.PlantedOn)
+			},
+		},
+		{
+			name:           "Nothing To Update",
+			requestBody:    `{}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Request Format",
+			requestBody:    `{"height": "tall"}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Tree Out of Bounds",
+			requestBody: `{"x": 7, "y": 8}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					UpdateTree(gomock.Any(), estateID, treeID, gomock.Any()).
+					Return(repository.Tree{}, service.NewValidationError("tree coordinates outside estate boundaries"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Invalid Height",
+			requestBody: `{"height": 20}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					UpdateTree(gomock.Any(), estateID, treeID, gomock.Any()).
+					Return(repository.Tree{}, service.NewValidationError("invalid tree height"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Plot Occupied",
+			requestBody: `{"x": 7, "y": 8}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					UpdateTree(gomock.Any(), estateID, treeID, gomock.Any()).
+					Return(repository.Tree{}, service.ErrPlotOccupied)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:        "Tree Not Found",
+			requestBody: `{"height": 20}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					UpdateTree(gomock.Any(), estateID, treeID, gomock.Any()).
+					Return(repository.Tree{}, service.ErrTreeNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodPatch, "/estate/"+estateID.String()+"/tree/"+treeID.String(), strings.NewReader(tc.requestBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id", "treeId")
+			c.SetParamValues(estateID.String(), treeID.String())
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, h.UpdateTree(c, openapi_types.UUID(estateID), openapi_types.UUID(treeID)))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestDeleteTree(t *testing.T) {
+	// Generate estate and tree IDs
+	estateID := uuid.New()
+	treeID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					DeleteTree(gomock.Any(), estateID, treeID).
+					Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					DeleteTree(gomock.Any(), estateID, treeID).
+					Return(service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Tree Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					DeleteTree(gomock.Any(), estateID, treeID).
+					Return(service.ErrTreeNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodDelete, "/estate/"+estateID.String()+"/tree/"+treeID.String(), nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id", "treeId")
+			c.SetParamValues(estateID.String(), treeID.String())
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, h.DeleteTree(c, openapi_types.UUID(estateID), openapi_types.UUID(treeID)))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestGetTreeHistory(t *testing.T) {
+	estateID := uuid.New()
+	treeID := uuid.New()
+	planted := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTreeHistory(gomock.Any(), estateID, treeID).
+					Return([]repository.TreeMeasurement{
+						{Height: 3, MeasuredAt: planted},
+						{Height: 5, MeasuredAt: planted.AddDate(0, 6, 0)},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.JSONEq(t, `{"measurements": [
+					{"height": 3, "measured_at": "2024-01-01T00:00:00Z"},
+					{"height": 5, "measured_at": "2024-07-01T00:00:00Z"}
+				]}`, rec.Body.String())
+			},
+		},
+		{
+			name: "Tree Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTreeHistory(gomock.Any(), estateID, treeID).
+					Return(nil, service.ErrTreeNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/estate/"+estateID.String()+"/tree/"+treeID.String()+"/history", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id", "treeId")
+			c.SetParamValues(estateID.String(), treeID.String())
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSvc := mocks.NewMockService(ctrl)
+			tc.mockSetup(mockSvc)
+
+			h := NewHandler(mockSvc)
+			respond(c, h.GetTreeHistory(c, openapi_types.UUID(estateID), openapi_types.UUID(treeID)))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestCreateZone(t *testing.T) {
+	// Generate estate and zone IDs
+	estateID := uuid.New()
+	zoneID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Rectangle",
+			requestBody: `{"name": "Block A", "rect": {"x_min": 2, "x_max": 4, "y_min": 3, "y_max": 5}}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateZone(gomock.Any(), estateID, service.ZoneInput{
+						Name: "Block A",
+						Rect: &service.ZoneRect{XMin: 2, XMax: 4, YMin: 3, YMax: 5},
+					}).
+					Return(repository.Zone{
+						ID:       zoneID,
+						EstateID: estateID,
+						Name:     "Block A",
+						Shape:    repository.ZoneShapeRect,
+						Vertices: []repository.Point{{X: 2, Y: 3}, {X: 4, Y: 3}, {X: 4, Y: 5}, {X: 2, Y: 5}},
+					}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.Zone
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, zoneID, uuid.UUID(*response.Id))
+				assert.Equal(t, generated.ZoneShape("rect"), *response.Shape)
+				assert.Equal(t, generated.ZoneRect{XMin: 2, XMax: 4, YMin: 3, YMax: 5}, *response.Rect)
+				assert.Len(t, *response.Polygon, 4)
+			},
+		},
+		{
+			name:        "Polygon",
+			requestBody: `{"name": "nursery", "polygon": [{"x": 1, "y": 1}, {"x": 5, "y": 1}, {"x": 1, "y": 5}]}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				polygon := []repository.Point{{X: 1, Y: 1}, {X: 5, Y: 1}, {X: 1, Y: 5}}
+				mockSvc.EXPECT().
+					CreateZone(gomock.Any(), estateID, service.ZoneInput{Name: "nursery", Polygon: polygon}).
+					Return(repository.Zone{
+						ID:       zoneID,
+						EstateID: estateID,
+						Name:     "nursery",
+						Shape:    repository.ZoneShapePolygon,
+						Vertices: polygon,
+					}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.Zone
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Nil(t, response.Rect)
+				assert.Len(t, *response.Polygon, 3)
+			},
+		},
+		{
+			name:           "Missing Shape",
+			requestBody:    `{"name": "Block A"}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Both Shapes",
+			requestBody:    `{"name": "Block A", "rect": {"x_min": 1, "x_max": 1, "y_min": 1, "y_max": 1}, "polygon": []}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Invalid Shape",
+			requestBody: `{"name": "bow tie", "polygon": [{"x": 1, "y": 1}, {"x": 5, "y": 5}, {"x": 5, "y": 1}, {"x": 1, "y": 5}]}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateZone(gomock.Any(), estateID, gomock.Any()).
+					Return(repository.Zone{}, service.NewValidationError("invalid zone shape"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Name Taken",
+			requestBody: `{"name": "Block A", "rect": {"x_min": 1, "x_max": 1, "y_min": 1, "y_max": 1}}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateZone(gomock.Any(), estateID, gomock.Any()).
+					Return(repository.Zone{}, service.ErrZoneNameTaken)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:        "Estate Not Found",
+			requestBody: `{"name": "Block A", "rect": {"x_min": 1, "x_max": 1, "y_min": 1, "y_max": 1}}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateZone(gomock.Any(), estateID, gomock.Any()).
+					Return(repository.Zone{}, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodPost, "/estate/"+estateID.String()+"/zone", strings.NewReader(tc.requestBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, h.CreateZone(c, openapi_types.UUID(estateID)))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestListZones(t *testing.T) {
+	estateID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ListZones(gomock.Any(), estateID).
+					Return([]repository.Zone{
+						{ID: uuid.New(), Name: "Block A", Shape: repository.ZoneShapeRect, Vertices: []repository.Point{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 2, Y: 2}, {X: 1, Y: 2}}},
+						{ID: uuid.New(), Name: "nursery", Shape: repository.ZoneShapePolygon, Vertices: []repository.Point{{X: 1, Y: 1}, {X: 5, Y: 1}, {X: 1, Y: 5}}},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.ZoneListResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Len(t, *response.Zones, 2)
+				assert.Equal(t, "Block A", *(*response.Zones)[0].Name)
+			},
+		},
+		{
+			name: "No Zones",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().ListZones(gomock.Any(), estateID).Return(nil, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.JSONEq(t, `{"zones": []}`, rec.Body.String())
+			},
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().ListZones(gomock.Any(), estateID).Return(nil, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/estate/"+estateID.String()+"/zone", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSvc := mocks.NewMockService(ctrl)
+			tc.mockSetup(mockSvc)
+
+			h := NewHandler(mockSvc)
+			respond(c, h.ListZones(c, openapi_types.UUID(estateID)))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestUpdateZone(t *testing.T) {
+	estateID := uuid.New()
+	zoneID := uuid.New()
+	name := "Block B"
+
+	testCases := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+	}{
+		{
+			name:        "Rename",
+			requestBody: `{"name": "Block B"}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					UpdateZone(gomock.Any(), estateID, zoneID, service.ZoneUpdate{Name: &name}).
+					Return(repository.Zone{ID: zoneID, Name: name, Shape: repository.ZoneShapeRect}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Nothing To Update",
+			requestBody:    `{}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Both Shapes",
+			requestBody:    `{"rect": {"x_min": 1, "x_max": 1, "y_min": 1, "y_max": 1}, "polygon": []}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Zone Not Found",
+			requestBody: `{"name": "Block B"}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					UpdateZone(gomock.Any(), estateID, zoneID, gomock.Any()).
+					Return(repository.Zone{}, service.ErrZoneNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:        "Name Taken",
+			requestBody: `{"name": "Block B"}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					UpdateZone(gomock.Any(), estateID, zoneID, gomock.Any()).
+					Return(repository.Zone{}, service.ErrZoneNameTaken)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPatch, "/estate/"+estateID.String()+"/zone/"+zoneID.String(), strings.NewReader(tc.requestBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id", "zoneId")
+			c.SetParamValues(estateID.String(), zoneID.String())
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSvc := mocks.NewMockService(ctrl)
+			tc.mockSetup(mockSvc)
+
+			h := NewHandler(mockSvc)
+			respond(c, h.UpdateZone(c, openapi_types.UUID(estateID), openapi_types.UUID(zoneID)))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestDeleteZone(t *testing.T) {
+	estateID := uuid.New()
+	zoneID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().DeleteZone(gomock.Any(), estateID, zoneID).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "Zone Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().DeleteZone(gomock.Any(), estateID, zoneID).Return(service.ErrZoneNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/estate/"+estateID.String()+"/zone/"+zoneID.String(), nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id", "zoneId")
+			c.SetParamValues(estateID.String(), zoneID.String())
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSvc := mocks.NewMockService(ctrl)
+			tc.mockSetup(mockSvc)
+
+			h := NewHandler(mockSvc)
+			respond(c, h.DeleteZone(c, openapi_types.UUID(estateID), openapi_types.UUID(zoneID)))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestCreateObstacle(t *testing.T) {
+	// Generate estate and obstacle IDs
+	estateID := uuid.New()
+	obstacleID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		requestBody    string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Power Line",
+			requestBody: `{"rect": {"x_min": 1, "x_max": 10, "y_min": 4, "y_max": 4}, "min_altitude": 40}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateObstacle(gomock.Any(), estateID, service.ObstacleInput{
+						Rect:        service.PlotRect{XMin: 1, XMax: 10, YMin: 4, YMax: 4},
+						MinAltitude: 40,
+					}).
+					Return(repository.Obstacle{ID: obstacleID, EstateID: estateID, XMin: 1, XMax: 10, YMin: 4, YMax: 4, MinAltitude: 40}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.Obstacle
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, obstacleID, uuid.UUID(*response.Id))
+				assert.Equal(t, generated.PlotRect{XMin: 1, XMax: 10, YMin: 4, YMax: 4}, *response.Rect)
+				assert.Equal(t, int32(40), *response.MinAltitude)
+				assert.False(t, *response.Impassable)
+			},
+		},
+		{
+			name:        "Impassable Cell",
+			requestBody: `{"cell": {"x": 3, "y": 5}, "impassable": true}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateObstacle(gomock.Any(), estateID, service.ObstacleInput{
+						Rect:       service.PlotRect{XMin: 3, XMax: 3, YMin: 5, YMax: 5},
+						Impassable: true,
+					}).
+					Return(repository.Obstacle{ID: obstacleID, EstateID: estateID, XMin: 3, XMax: 3, YMin: 5, YMax: 5, Impassable: true}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.Obstacle
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Nil(t, response.MinAltitude)
+				assert.True(t, *response.Impassable)
+			},
+		},
+		{
+			name:           "Missing Plots",
+			requestBody:    `{"impassable": true}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Cell And Rect",
+			requestBody:    `{"cell": {"x": 1, "y": 1}, "rect": {"x_min": 1, "x_max": 2, "y_min": 1, "y_max": 2}, "impassable": true}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Altitude And Impassable",
+			requestBody:    `{"cell": {"x": 1, "y": 1}, "min_altitude": 5, "impassable": true}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Neither Altitude Nor Impassable",
+			requestBody:    `{"cell": {"x": 1, "y": 1}, "impassable": false}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Altitude",
+			requestBody:    `{"cell": {"x": 1, "y": 1}, "min_altitude": 0}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Outside Estate",
+			requestBody: `{"cell": {"x": 20, "y": 1}, "impassable": true}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateObstacle(gomock.Any(), estateID, gomock.Any()).
+					Return(repository.Obstacle{}, service.NewValidationError("invalid obstacle bounds"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Estate Not Found",
+			requestBody: `{"cell": {"x": 1, "y": 1}, "impassable": true}`,
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CreateObstacle(gomock.Any(), estateID, gomock.Any()).
+					Return(repository.Obstacle{}, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+
+			// Setup test request
+			req := httptest.NewRequest(http.MethodPost, "/estate/"+estateID.String()+"/obstacle", strings.NewReader(tc.requestBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+
+			// Perform the test
+			respond(c, h.CreateObstacle(c, openapi_types.UUID(estateID)))
+
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestListObstacles(t *testing.T) {
+	estateID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					ListObstacles(gomock.Any(), estateID).
+					Return([]repository.Obstacle{
+						{ID: uuid.New(), XMin: 1, XMax: 10, YMin: 4, YMax: 4, MinAltitude: 40},
+						{ID: uuid.New(), XMin: 3, XMax: 3, YMin: 5, YMax: 5, Impassable: true},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.ObstacleListResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Len(t, *response.Obstacles, 2)
+				assert.True(t, *(*response.Obstacles)[1].Impassable)
+			},
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().ListObstacles(gomock.Any(), estateID).Return(nil, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/estate/"+estateID.String()+"/obstacle", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSvc := mocks.NewMockService(ctrl)
+			tc.mockSetup(mockSvc)
+
+			h := NewHandler(mockSvc)
+			respond(c, h.ListObstacles(c, openapi_types.UUID(estateID)))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestDeleteObstacle(t *testing.T) {
+	estateID := uuid.New()
+	obstacleID := uuid.New()
+
+	testCases := []struct {
+		name           string
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().DeleteObstacle(gomock.Any(), estateID, obstacleID).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "Obstacle Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().DeleteObstacle(gomock.Any(), estateID, obstacleID).Return(service.ErrObstacleNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/estate/"+estateID.String()+"/obstacle/"+obstacleID.String(), nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id", "obstacleId")
+			c.SetParamValues(estateID.String(), obstacleID.String())
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSvc := mocks.NewMockService(ctrl)
+			tc.mockSetup(mockSvc)
+
+			h := NewHandler(mockSvc)
+			respond(c, h.DeleteObstacle(c, openapi_types.UUID(estateID), openapi_types.UUID(obstacleID)))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestGetEstateStats(t *testing.T) {
+	estateID := uuid.New()
+	estateUUID := openapi_types.UUID(estateID)
+	xMin := int32(6)
+	grid := int32(5)
+	diseased := generated.TreeHealthDiseased
+	plantedFrom := openapi_types.Date{Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	plantedTo := openapi_types.Date{Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	testCases := []struct {
+		name           string
+		params         generated.GetEstateStatsParams
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				stats := &service.TreeStats{
+					Count:        2,
+					MaxHeight:    6,
+					MinHeight:    5,
+					MedianHeight: 5.5,
+					MeanHeight:   5.5,
+					StdDevHeight: 0.5,
+					Percentiles:  []service.HeightPercentile{{Percentile: 90, Height: 5.9}},
+					Density:      0.02,
+				}
+				stats.Histogram[4] = 1
+				stats.Histogram[5] = 1
+				mockSvc.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, service.TreeStatsOptions{}).
+					Return(stats, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.StatsResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Nil(t, response.Grid)
+				assert.Equal(t, int32(2), *response.Count)
+				assert.Equal(t, int32(6), *response.MaxHeight)
+				assert.Equal(t, int32(5), *response.MinHeight)
+				assert.Equal(t, 5.5, *response.MedianHeight)
+				assert.Equal(t, 5.5, *response.MeanHeight)
+				assert.Equal(t, 0.5, *response.StddevHeight)
+				assert.Equal(t, 0.02, *response.Density)
+				assert.Len(t, *response.Percentiles, 1)
+				assert.Equal(t, 90.0, *(*response.Percentiles)[0].Percentile)
+				assert.Equal(t, 5.9, *(*response.Percentiles)[0].Height)
+				assert.Len(t, *response.Histogram, 30)
+				assert.Equal(t, int32(5), *(*response.Histogram)[4].Height)
+				assert.Equal(t, int32(1), *(*response.Histogram)[4].Count)
+				assert.Equal(t, int32(0), *(*response.Histogram)[0].Count)
+			},
+		},
+		{
+			name:        "Success - Custom Percentiles",
+			params:      generated.GetEstateStatsParams{Percentiles: &[]float64{10, 99.5}},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, service.TreeStatsOptions{Percentiles: []float64{10, 99.5}}).
+					Return(&service.TreeStats{Percentiles: []service.HeightPercentile{{Percentile: 10}, {Percentile: 99.5}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.StatsResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(0), *response.Count)
+				assert.Equal(t, 0.0, *response.MedianHeight)
+				assert.Len(t, *response.Percentiles, 2)
+				assert.Equal(t, 99.5, *(*response.Percentiles)[1].Percentile)
+			},
+		},
+		{
+			name:   "Success - Grid",
+			params: generated.GetEstateStatsParams{XMin: &xMin, Grid: &grid},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, service.TreeStatsOptions{XMin: 6, Grid: 5}).
+					Return(&service.TreeStats{
+						Count: 1,
+						Grid: &service.StatsGrid{
+							CellSize: 5,
+							Columns:  1,
+							Rows:     2,
+							Cells: [][]service.CellStats{
+								{{XMin: 6, XMax: 10, YMin: 1, YMax: 5, Count: 1, MaxHeight: 4, MinHeight: 4, MedianHeight: 4, MeanHeight: 4, EmptyRatio: 0.96}},
+								{{XMin: 6, XMax: 10, YMin: 6, YMax: 8, EmptyRatio: 1}},
+							},
+						},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.StatsResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(5), *response.Grid.CellSize)
+				assert.Equal(t, int32(1), *response.Grid.Columns)
+				assert.Equal(t, int32(2), *response.Grid.Rows)
+				cells := *response.Grid.Cells
+				assert.Len(t, cells, 2)
+				assert.Equal(t, int32(1), *cells[0][0].Count)
+				assert.Equal(t, 0.96, *cells[0][0].EmptyRatio)
+				assert.Equal(t, int32(6), *cells[1][0].YMin)
+				assert.Equal(t, int32(8), *cells[1][0].YMax)
+				assert.Equal(t, 1.0, *cells[1][0].EmptyRatio)
+			},
+		},
+		{
+			name:   "Filtered By Health",
+			params: generated.GetEstateStatsParams{Health: &diseased},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, service.TreeStatsOptions{Filter: repository.TreeFilter{Health: "diseased"}}).
+					Return(&service.TreeStats{Count: 3}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.StatsResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(3), *response.Count)
+			},
+		},
+		{
+			name:   "Invalid Planting Date Range",
+			params: generated.GetEstateStatsParams{PlantedFrom: &plantedFrom, PlantedTo: &plantedTo},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, gomock.Any()).
+					Return(nil, service.NewValidationError("invalid planting date range"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "Invalid Bounding Box",
+			params: generated.GetEstateStatsParams{XMin: &xMin},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, gomock.Any()).
+					Return(nil, service.NewValidationError("invalid bounding box"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "Invalid Grid",
+			params: generated.GetEstateStatsParams{Grid: &grid},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, gomock.Any()).
+					Return(nil, service.NewValidationError("invalid grid"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "Invalid Percentile",
+			params:      generated.GetEstateStatsParams{Percentiles: &[]float64{101}},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, gomock.Any()).
+					Return(nil, service.NewValidationError("invalid percentile"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, gomock.Any()).
+					Return(nil, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Repository Error",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, gomock.Any()).
+					Return(nil, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Initialize Echo
+			e := echo.New()
+			
+			// Setup test request
+			req := httptest.NewRequest(http.MethodGet, "/estate/"+estateID.String()+"/stats", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+			
+			// Setup mock controller
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			
+			// Create mock service
+			mockSvc := mocks.NewMockService(ctrl)
+			
+			// Setup mock expectations
+			tc.mockSetup(mockSvc)
+			
+			// Create handler with mock service
+			h := NewHandler(mockSvc)
+			
+			// Perform the test
+			respond(c, h.GetEstateStats(c, estateUUID, tc.params))
+			
+			// Assert the results
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			
+			// Additional response checks if provided
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestGetEstateGrowth(t *testing.T) {
+	estateID := uuid.New()
+	treeID := uuid.New()
+	planted := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := int32(1)
+
+	testCases := []struct {
+		name           string
+		params         generated.GetEstateGrowthParams
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Success",
+			params: generated.GetEstateGrowthParams{Limit: &limit},
+			mockSetup: func(mockSvc *mocks.MockService) {
+				growth := repository.TreeGrowth{
+					TreeID: treeID, X: 2, Y: 3, FirstHeight: 3, LastHeight: 6,
+					FirstMeasuredAt: planted, LastMeasuredAt: planted.AddDate(0, 6, 0), MonthlyGrowth: 0.5,
+				}
+				mockSvc.EXPECT().
+					GetGrowthReport(gomock.Any(), estateID, service.GrowthReportOptions{Limit: 1}).
+					Return(&service.GrowthReport{
+						Trees:                1,
+						AverageMonthlyGrowth: 0.5,
+						Fastest:              []repository.TreeGrowth{growth},
+						Slowest:              []repository.TreeGrowth{growth},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.GrowthResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(1), response.Trees)
+				assert.Equal(t, 0.5, response.AverageMonthlyGrowth)
+				assert.Len(t, response.Fastest, 1)
+				assert.Equal(t, treeID, uuid.UUID(response.Fastest[0].TreeId))
+				assert.Equal(t, int32(6), response.Fastest[0].LastHeight)
+				assert.Len(t, response.Slowest, 1)
+			},
+		},
+		{
+			name: "No Trees Measured Twice",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetGrowthReport(gomock.Any(), estateID, service.GrowthReportOptions{}).
+					Return(&service.GrowthReport{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				assert.JSONEq(t, `{"trees": 0, "average_monthly_growth": 0, "fastest": [], "slowest": []}`, rec.Body.String())
+			},
+		},
+		{
+			name: "Invalid Limit",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetGrowthReport(gomock.Any(), estateID, gomock.Any()).
+					Return(nil, service.NewValidationError("invalid limit"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					GetGrowthReport(gomock.Any(), estateID, gomock.Any()).
+					Return(nil, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/estate/"+estateID.String()+"/growth", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockSvc := mocks.NewMockService(ctrl)
+			tc.mockSetup(mockSvc)
+
+			h := NewHandler(mockSvc)
+			respond(c, h.GetEstateGrowth(c, openapi_types.UUID(estateID), tc.params))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.checkResponse != nil {
+				tc.checkResponse(t, rec)
+			}
+		})
+	}
+}
+
+func TestGetDronePlan(t *testing.T) {
+	estateID := uuid.New()
+	estateUUID := openapi_types.UUID(estateID)
+
+	testCases := []struct {
+		name           string
+		maxDistance    *int32
+		include        *generated.GetDronePlanParamsInclude
+		mode           *generated.GetDronePlanParamsMode
+		pattern        *generated.GetDronePlanParamsPattern
+		optimize       *bool
+		clearance      *int32
+		altitude       *generated.GetDronePlanParamsAltitude
+		lookahead      *int32
+		excludeDead    *bool
+		mockSetup      func(*mocks.MockService)
+		expectedStatus int
+		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Success - No Max Distance",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CalculateDronePath(gomock.Any(), estateID).
+					Return(120, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.DronePlanResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.NotNil(t, response.Distance)
+				assert.Nil(t, response.Rest)
+			},
+		},
+		{
+			name: "Success - With Max Distance",
+			maxDistance: func() *int32 { val := int32(50); return &val }(),
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CalculateDronePathWithRest(gomock.Any(), estateID, 50).
+					Return(50, 25, 30, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.DronePlanResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.NotNil(t, response.Distance)
+				assert.NotNil(t, response.Rest)
+				assert.NotNil(t, response.Rest.X)
+				assert.NotNil(t, response.Rest.Y)
+			},
+		},
+		{
+			name:    "Success - With Path",
+			include: func() *generated.GetDronePlanParamsInclude { val := generated.GetDronePlanParamsInclude("path"); return &val }(),
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					PlanDronePath(gomock.Any(), estateID, service.DronePlanOptions{IncludePath: true}).
+					Return(&service.DronePlan{
+						Distance: 4,
+						Path: []service.Waypoint{
+							{X: 1, Y: 1, Z: 0},
+							{X: 1, Y: 1, Z: 1},
+							{X: 2, Y: 1, Z: 1},
+							{X: 2, Y: 1, Z: 0},
+						},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.DronePlanResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(4), *response.Distance)
+				assert.Nil(t, response.Rest)
+				assert.NotNil(t, response.Path)
+				assert.Len(t, *response.Path, 4)
+				assert.Equal(t, generated.Waypoint{X: 2, Y: 1, Z: 1}, (*response.Path)[2])
+			},
+		},
+		{
+			name:        "Success - Exclude Dead Trees",
+			excludeDead: func() *bool { val := true; return &val }(),
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					PlanDronePath(gomock.Any(), estateID, service.DronePlanOptions{ExcludeDead: true}).
+					Return(&service.DronePlan{Distance: 11}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.DronePlanResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(11), *response.Distance)
+			},
+		},
+		{
+			name:    "Success - Unreachable Plots",
+			include: func() *generated.GetDronePlanParamsInclude { val := generated.GetDronePlanParamsInclude("path"); return &val }(),
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					PlanDronePath(gomock.Any(), estateID, service.DronePlanOptions{IncludePath: true}).
+					Return(&service.DronePlan{
+						Distance:         7,
+						Path:             []service.Waypoint{{X: 1, Y: 1, Z: 0}, {X: 1, Y: 1, Z: 1}, {X: 2, Y: 3, Z: 1}, {X: 2, Y: 3, Z: 0}},
+						Unreachable:      []service.PlotRect{{XMin: 4, XMax: 5, YMin: 1, YMax: 3}},
+						UnreachablePlots: 6,
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.DronePlanResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, []generated.PlotRect{{XMin: 4, XMax: 5, YMin: 1, YMax: 3}}, *response.Unreachable)
+				assert.Equal(t, int32(6), *response.UnreachablePlots)
+			},
+		},
+		{
+			name:    "Every Plot Impassable",
+			include: func() *generated.GetDronePlanParamsInclude { val := generated.GetDronePlanParamsInclude("path"); return &val }(),
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					PlanDronePath(gomock.Any(), estateID, gomock.Any()).
+					Return(nil, service.ErrImpassable)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:        "Success - With Path And Max Distance",
+			maxDistance: func() *int32 { val := int32(3); return &val }(),
+			include:     func() *generated.GetDronePlanParamsInclude { val := generated.GetDronePlanParamsInclude("path"); return &val }(),
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					PlanDronePath(gomock.Any(), estateID, service.DronePlanOptions{MaxDistance: 3, IncludePath: true}).
+					Return(&service.DronePlan{
+						Distance: 3,
+						Rest:     &service.Plot{X: 2, Y: 1},
+						Path: []service.Waypoint{
+							{X: 1, Y: 1, Z: 0},
+							{X: 1, Y: 1, Z: 1},
+							{X: 2, Y: 1, Z: 1},
+							{X: 2, Y: 1, Z: 0},
+						},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.DronePlanResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.NotNil(t, response.Rest)
+				assert.Equal(t, int32(2), *response.Rest.X)
+				assert.NotNil(t, response.Path)
+			},
+		},
+		{
+			name:           "Invalid Include",
+			include:        func() *generated.GetDronePlanParamsInclude { val := generated.GetDronePlanParamsInclude("legs"); return &val }(),
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "Success - Legs",
+			maxDistance: func() *int32 { val := int32(14); return &val }(),
+			mode:        func() *generated.GetDronePlanParamsMode { val := generated.GetDronePlanParamsMode("legs"); return &val }(),
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					PlanDronePath(gomock.Any(), estateID, service.DronePlanOptions{MaxDistance: 14, Legs: true}).
+					Return(&service.DronePlan{
+						Distance: 19,
+						Legs: []service.DroneLeg{
+							{Start: service.Plot{X: 1, Y: 1}, Landing: service.Plot{X: 3, Y: 1}, Distance: 14},
+							{Start: service.Plot{X: 3, Y: 1}, Landing: service.Plot{X: 1, Y: 2}, Distance: 5},
+						},
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.DronePlanResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(19), *response.Distance)
+				assert.Nil(t, response.Rest)
+				assert.NotNil(t, response.Legs)
+				assert.Len(t, *response.Legs, 2)
+				assert.Equal(t, generated.Plot{X: 3, Y: 1}, (*response.Legs)[1].Start)
+				assert.Equal(t, int32(5), (*response.Legs)[1].Distance)
+			},
+		},
+		{
+			name:           "Legs Without Max Distance",
+			mode:           func() *generated.GetDronePlanParamsMode { val := generated.GetDronePlanParamsMode("legs"); return &val }(),
+			mockSetup:      func(mockSvc *mocks.MockService) {},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name:        "Invalid Request - Invalid Width",
-			requestBody: `{"width": 0, "length": 2000}`,
+			name:        "Legs With Too Short Max Distance",
+			maxDistance: func() *int32 { val := int32(1); return &val }(),
+			mode:        func() *generated.GetDronePlanParamsMode { val := generated.GetDronePlanParamsMode("legs"); return &val }(),
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					CreateEstate(gomock.Any(), 0, 2000).
-					Return(uuid.UUID{}, errors.New("invalid estate dimensions"))
+					PlanDronePath(gomock.Any(), estateID, service.DronePlanOptions{MaxDistance: 1, Legs: true}).
+					Return(nil, service.NewValidationError("max distance too short to complete a leg"))
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusUnprocessableEntity,
 		},
 		{
-			name:        "Invalid Request - Invalid Length",
-			requestBody: `{"width": 1000, "length": 60000}`,
+			name:    "Success - Spiral Pattern",
+			pattern: func() *generated.GetDronePlanParamsPattern { val := generated.GetDronePlanParamsPattern("spiral"); return &val }(),
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					CreateEstate(gomock.Any(), 1000, 60000).
-					Return(uuid.UUID{}, errors.New("invalid estate dimensions"))
+					PlanDronePath(gomock.Any(), estateID, service.DronePlanOptions{Pattern: "spiral"}).
+					Return(&service.DronePlan{Distance: 42}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.DronePlanResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(42), *response.Distance)
+				assert.Nil(t, response.Path)
+			},
+		},
+		{
+			name:    "Unknown Pattern",
+			pattern: func() *generated.GetDronePlanParamsPattern { val := generated.GetDronePlanParamsPattern("diagonal"); return &val }(),
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					PlanDronePath(gomock.Any(), estateID, service.DronePlanOptions{Pattern: "diagonal"}).
+					Return(nil, service.NewValidationError("unknown patrol pattern"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Success - Optimize",
+			optimize: func() *bool { val := true; return &val }(),
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					PlanDronePath(gomock.Any(), estateID, service.DronePlanOptions{Optimize: true}).
+					Return(&service.DronePlan{
+						Pattern:     "column",
+						StartCorner: "northeast",
+						Alternatives: []service.DronePlanVariant{
+							{Pattern: "row", StartCorner: "southwest", Distance: 60},
+							{Pattern: "column", StartCorner: "northeast", Distance: 48},
+						},
+						Distance: 48,
+					}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.DronePlanResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(48), *response.Distance)
+				assert.Equal(t, "column", *response.Pattern)
+				assert.Equal(t, "northeast", *response.StartCorner)
+				assert.NotNil(t, response.Alternatives)
+				assert.Len(t, *response.Alternatives, 2)
+				assert.Equal(t, int32(60), (*response.Alternatives)[0].Distance)
 			},
+		},
+		{
+			name:           "Optimize With Pattern",
+			optimize:       func() *bool { val := true; return &val }(),
+			pattern:        func() *generated.GetDronePlanParamsPattern { val := generated.GetDronePlanParamsPattern("row"); return &val }(),
+			mockSetup:      func(mockSvc *mocks.MockService) {},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name:        "Repository Error",
-			requestBody: `{"width": 1000, "length": 2000}`,
+			name:      "Success - Smoothed Altitude",
+			clearance: func() *int32 { val := int32(2); return &val }(),
+			altitude:  func() *generated.GetDronePlanParamsAltitude { val := generated.GetDronePlanParamsAltitude("smoothed"); return &val }(),
+			lookahead: func() *int32 { val := int32(5); return &val }(),
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					CreateEstate(gomock.Any(), 1000, 2000).
-					Return(uuid.UUID{}, errors.New("database error"))
+					PlanDronePath(gomock.Any(), estateID, service.DronePlanOptions{Clearance: 2, SmoothAltitude: true, Lookahead: 5}).
+					Return(&service.DronePlan{Distance: 80, VerticalSaved: 24}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.DronePlanResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, int32(80), *response.Distance)
+				assert.NotNil(t, response.VerticalSaved)
+				assert.Equal(t, int32(24), *response.VerticalSaved)
 			},
+		},
+		{
+			name:           "Invalid Clearance",
+			clearance:      func() *int32 { val := int32(0); return &val }(),
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Lookahead Without Smoothed Altitude",
+			lookahead:      func() *int32 { val := int32(5); return &val }(),
+			mockSetup:      func(mockSvc *mocks.MockService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Invalid Max Distance",
+			maxDistance: func() *int32 { val := int32(0); return &val }(),
+			mockSetup: func(mockSvc *mocks.MockService) {},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CalculateDronePath(gomock.Any(), estateID).
+					Return(0, service.ErrEstateNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Repository Error",
+			mockSetup: func(mockSvc *mocks.MockService) {
+				mockSvc.EXPECT().
+					CalculateDronePath(gomock.Any(), estateID).
+					Return(0, errors.New("database error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -90,10 +3049,16 @@ func TestCreateEstate(t *testing.T) {
 			e := echo.New()
 			
 			// Setup test request
-			req := httptest.NewRequest(http.MethodPost, "/estate", strings.NewReader(tc.requestBody))
-			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			path := "/estate/" + estateID.String() + "/drone-plan"
+			if tc.maxDistance != nil {
+				path += "?max_distance=" + string(rune(*tc.maxDistance+'0'))
+			}
+			
+			req := httptest.NewRequest(http.MethodGet, path, nil)
 			rec := httptest.NewRecorder()
 			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(estateID.String())
 			
 			// Setup mock controller
 			ctrl := gomock.NewController(t)
@@ -108,8 +3073,21 @@ func TestCreateEstate(t *testing.T) {
 			// Create handler with mock service
 			h := NewHandler(mockSvc)
 			
+			// Prepare params
+			params := generated.GetDronePlanParams{
+				MaxDistance: tc.maxDistance,
+				Include:     tc.include,
+				Mode:        tc.mode,
+				Pattern:     tc.pattern,
+				Optimize:    tc.optimize,
+				Clearance:   tc.clearance,
+				Altitude:    tc.altitude,
+				Lookahead:   tc.lookahead,
+				ExcludeDead: tc.excludeDead,
+			}
+			
 			// Perform the test
-			_ = h.CreateEstate(c)
+			respond(c, h.GetDronePlan(c, estateUUID, params))
 			
 			// Assert the results
 			assert.Equal(t, tc.expectedStatus, rec.Code)
@@ -122,10 +3100,11 @@ func TestCreateEstate(t *testing.T) {
 	}
 }
 
-func TestCreateTree(t *testing.T) {
+func TestCreateDronePlanJob(t *testing.T) {
 	// Generate estate ID
 	estateID := uuid.New()
 	estateUUID := openapi_types.UUID(estateID)
+	jobID := uuid.New()
 
 	testCases := []struct {
 		name           string
@@ -136,89 +3115,62 @@ func TestCreateTree(t *testing.T) {
 	}{
 		{
 			name:        "Success",
-			requestBody: `{"x": 5, "y": 10, "height": 15}`,
+			requestBody: `{"max_distance": 500, "mode": "legs", "optimize": true}`,
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					CreateTree(gomock.Any(), estateID, 5, 10, 15).
-					Return(uuid.New(), nil)
+					SubmitDronePlanJob(gomock.Any(), estateID, service.DronePlanOptions{MaxDistance: 500, Legs: true, Optimize: true}).
+					Return(jobID, nil)
 			},
-			expectedStatus: http.StatusCreated,
+			expectedStatus: http.StatusAccepted,
 			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
-				var response generated.TreeResponse
+				var response generated.JobResponse
 				err := json.Unmarshal(rec.Body.Bytes(), &response)
 				assert.NoError(t, err)
-				assert.NotNil(t, response.Id)
+				assert.Equal(t, jobID, uuid.UUID(*response.Id))
+				assert.Equal(t, "queued", *response.Status)
 			},
 		},
 		{
-			name:        "Invalid Request - Missing Fields",
-			requestBody: `{"x": 5, "y": 10}`,
-			mockSetup: func(mockSvc *mocks.MockService) {
-				mockSvc.EXPECT().
-					CreateTree(gomock.Any(), gomock.Any(), 5, 10, 0).
-					Return(uuid.UUID{}, errors.New("invalid tree height"))
-			},
-			expectedStatus: http.StatusBadRequest,
-		},
-		{
-			name:        "Invalid Request - Invalid X",
-			requestBody: `{"x": 0, "y": 10, "height": 15}`,
+			name:        "Success - Default Options",
+			requestBody: `{}`,
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					CreateTree(gomock.Any(), estateID, 0, 10, 15).
-					Return(uuid.UUID{}, errors.New("tree coordinates outside estate boundaries"))
+					SubmitDronePlanJob(gomock.Any(), estateID, service.DronePlanOptions{}).
+					Return(jobID, nil)
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusAccepted,
 		},
 		{
-			name:        "Invalid Request - Invalid Y",
-			requestBody: `{"x": 5, "y": 0, "height": 15}`,
-			mockSetup: func(mockSvc *mocks.MockService) {
-				mockSvc.EXPECT().
-					CreateTree(gomock.Any(), estateID, 5, 0, 15).
-					Return(uuid.UUID{}, errors.New("tree coordinates outside estate boundaries"))
-			},
+			name:           "Invalid Request Format",
+			requestBody:    `{"max_distance": "far"}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name:        "Invalid Request - Invalid Height",
-			requestBody: `{"x": 5, "y": 10, "height": 40}`,
-			mockSetup: func(mockSvc *mocks.MockService) {
-				mockSvc.EXPECT().
-					CreateTree(gomock.Any(), estateID, 5, 10, 40).
-					Return(uuid.UUID{}, errors.New("invalid tree height"))
-			},
+			name:           "Invalid Options",
+			requestBody:    `{"mode": "legs"}`,
+			mockSetup:      func(mockSvc *mocks.MockService) {},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
 			name:        "Estate Not Found",
-			requestBody: `{"x": 5, "y": 10, "height": 15}`,
+			requestBody: `{}`,
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					CreateTree(gomock.Any(), estateID, 5, 10, 15).
-					Return(uuid.UUID{}, errors.New("estate not found"))
+					SubmitDronePlanJob(gomock.Any(), estateID, service.DronePlanOptions{}).
+					Return(uuid.UUID{}, service.ErrEstateNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 		},
-		{
-			name:        "Tree Out of Bounds",
-			requestBody: `{"x": 200, "y": 10, "height": 15}`,
-			mockSetup: func(mockSvc *mocks.MockService) {
-				mockSvc.EXPECT().
-					CreateTree(gomock.Any(), estateID, 200, 10, 15).
-					Return(uuid.UUID{}, errors.New("tree coordinates outside estate boundaries"))
-			},
-			expectedStatus: http.StatusBadRequest,
-		},
 		{
 			name:        "Repository Error",
-			requestBody: `{"x": 5, "y": 10, "height": 15}`,
+			requestBody: `{}`,
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					CreateTree(gomock.Any(), estateID, 5, 10, 15).
+					SubmitDronePlanJob(gomock.Any(), estateID, service.DronePlanOptions{}).
 					Return(uuid.UUID{}, errors.New("database error"))
 			},
-			expectedStatus: http.StatusBadRequest,
+			expectedStatus: http.StatusInternalServerError,
 		},
 	}
 
@@ -226,34 +3178,34 @@ func TestCreateTree(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			// Initialize Echo
 			e := echo.New()
-			
+
 			// Setup test request
-			req := httptest.NewRequest(http.MethodPost, "/estate/"+estateID.String()+"/tree", strings.NewReader(tc.requestBody))
+			req := httptest.NewRequest(http.MethodPost, "/estate/"+estateID.String()+"/drone-plan/jobs", strings.NewReader(tc.requestBody))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 			c := e.NewContext(req, rec)
 			c.SetParamNames("id")
 			c.SetParamValues(estateID.String())
-			
+
 			// Setup mock controller
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
-			
+
 			// Create mock service
 			mockSvc := mocks.NewMockService(ctrl)
-			
+
 			// Setup mock expectations
 			tc.mockSetup(mockSvc)
-			
+
 			// Create handler with mock service
 			h := NewHandler(mockSvc)
-			
+
 			// Perform the test
-			_ = h.CreateTree(c, estateUUID)
-			
+			respond(c, h.CreateDronePlanJob(c, estateUUID))
+
 			// Assert the results
 			assert.Equal(t, tc.expectedStatus, rec.Code)
-			
+
 			// Additional response checks if provided
 			if tc.checkResponse != nil {
 				tc.checkResponse(t, rec)
@@ -262,9 +3214,10 @@ func TestCreateTree(t *testing.T) {
 	}
 }
 
-func TestGetEstateStats(t *testing.T) {
-	estateID := uuid.New()
-	estateUUID := openapi_types.UUID(estateID)
+func TestGetJob(t *testing.T) {
+	// Generate job ID
+	jobID := uuid.New()
+	jobUUID := openapi_types.UUID(jobID)
 
 	testCases := []struct {
 		name           string
@@ -273,58 +3226,68 @@ func TestGetEstateStats(t *testing.T) {
 		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
 	}{
 		{
-			name: "Success",
+			name: "Running",
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					GetTreeStats(gomock.Any(), estateID).
-					Return(3, 20, 10, 15, nil)
+					GetJob(gomock.Any(), jobID).
+					Return(&service.Job{ID: jobID, Status: "running", Progress: 42}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
-				var response generated.StatsResponse
+				var response generated.JobResponse
 				err := json.Unmarshal(rec.Body.Bytes(), &response)
 				assert.NoError(t, err)
-				assert.Equal(t, int32(3), *response.Count)
-				assert.Equal(t, int32(20), *response.MaxHeight)
-				assert.Equal(t, int32(10), *response.MinHeight)
-				assert.Equal(t, int32(15), *response.MedianHeight)
+				assert.Equal(t, "running", *response.Status)
+				assert.Equal(t, int32(42), *response.Progress)
+				assert.Nil(t, response.Result)
 			},
 		},
 		{
-			name: "Success - No Trees",
+			name: "Succeeded",
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					GetTreeStats(gomock.Any(), estateID).
-					Return(0, 0, 0, 0, nil)
+					GetJob(gomock.Any(), jobID).
+					Return(&service.Job{
+						ID:       jobID,
+						Status:   "succeeded",
+						Progress: 100,
+						Options:  service.DronePlanOptions{SmoothAltitude: true},
+						Result:   &service.DronePlan{Pattern: "row", StartCorner: "southwest", Distance: 100, VerticalSaved: 8},
+					}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
-				var response generated.StatsResponse
+				var response generated.JobResponse
 				err := json.Unmarshal(rec.Body.Bytes(), &response)
 				assert.NoError(t, err)
-				assert.Equal(t, int32(0), *response.Count)
-				assert.Equal(t, int32(0), *response.MaxHeight)
-				assert.Equal(t, int32(0), *response.MinHeight)
-				assert.Equal(t, int32(0), *response.MedianHeight)
+				assert.Equal(t, "succeeded", *response.Status)
+				assert.Equal(t, int32(100), *response.Result.Distance)
+				assert.Equal(t, int32(8), *response.Result.VerticalSaved)
 			},
 		},
 		{
-			name: "Estate Not Found",
+			name: "Failed",
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					GetTreeStats(gomock.Any(), estateID).
-					Return(0, 0, 0, 0, errors.New("estate not found"))
+					GetJob(gomock.Any(), jobID).
+					Return(&service.Job{ID: jobID, Status: "failed", Error: "max distance too short to complete a leg"}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				var response generated.JobResponse
+				err := json.Unmarshal(rec.Body.Bytes(), &response)
+				assert.NoError(t, err)
+				assert.Equal(t, "max distance too short to complete a leg", *response.Error)
 			},
-			expectedStatus: http.StatusNotFound,
 		},
 		{
-			name: "Repository Error",
+			name: "Job Not Found",
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					GetTreeStats(gomock.Any(), estateID).
-					Return(0, 0, 0, 0, errors.New("database error"))
+					GetJob(gomock.Any(), jobID).
+					Return(nil, service.ErrJobNotFound)
 			},
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusNotFound,
 		},
 	}
 
@@ -332,33 +3295,33 @@ func TestGetEstateStats(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			// Initialize Echo
 			e := echo.New()
-			
+
 			// Setup test request
-			req := httptest.NewRequest(http.MethodGet, "/estate/"+estateID.String()+"/stats", nil)
+			req := httptest.NewRequest(http.MethodGet, "/jobs/"+jobID.String(), nil)
 			rec := httptest.NewRecorder()
 			c := e.NewContext(req, rec)
-			c.SetParamNames("id")
-			c.SetParamValues(estateID.String())
-			
+			c.SetParamNames("jobId")
+			c.SetParamValues(jobID.String())
+
 			// Setup mock controller
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
-			
+
 			// Create mock service
 			mockSvc := mocks.NewMockService(ctrl)
-			
+
 			// Setup mock expectations
 			tc.mockSetup(mockSvc)
-			
+
 			// Create handler with mock service
 			h := NewHandler(mockSvc)
-			
+
 			// Perform the test
-			_ = h.GetEstateStats(c, estateUUID)
-			
+			respond(c, h.GetJob(c, jobUUID))
+
 			// Assert the results
 			assert.Equal(t, tc.expectedStatus, rec.Code)
-			
+
 			// Additional response checks if provided
 			if tc.checkResponse != nil {
 				tc.checkResponse(t, rec)
@@ -367,75 +3330,52 @@ func TestGetEstateStats(t *testing.T) {
 	}
 }
 
-func TestGetDronePlan(t *testing.T) {
-	estateID := uuid.New()
-	estateUUID := openapi_types.UUID(estateID)
+func TestCancelJob(t *testing.T) {
+	// Generate job ID
+	jobID := uuid.New()
+	jobUUID := openapi_types.UUID(jobID)
 
 	testCases := []struct {
 		name           string
-		maxDistance    *int32
 		mockSetup      func(*mocks.MockService)
 		expectedStatus int
 		checkResponse  func(t *testing.T, rec *httptest.ResponseRecorder)
 	}{
 		{
-			name: "Success - No Max Distance",
+			name: "Success",
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					CalculateDronePath(gomock.Any(), estateID).
-					Return(120, nil)
-			},
-			expectedStatus: http.StatusOK,
-			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
-				var response generated.DronePlanResponse
-				err := json.Unmarshal(rec.Body.Bytes(), &response)
-				assert.NoError(t, err)
-				assert.NotNil(t, response.Distance)
-				assert.Nil(t, response.Rest)
-			},
-		},
-		{
-			name: "Success - With Max Distance",
-			maxDistance: func() *int32 { val := int32(50); return &val }(),
-			mockSetup: func(mockSvc *mocks.MockService) {
+					CancelJob(gomock.Any(), jobID).
+					Return(nil)
 				mockSvc.EXPECT().
-					CalculateDronePathWithRest(gomock.Any(), estateID, 50).
-					Return(50, 25, 30, nil)
+					GetJob(gomock.Any(), jobID).
+					Return(&service.Job{ID: jobID, Status: "cancelled", Progress: 10}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			checkResponse: func(t *testing.T, rec *httptest.ResponseRecorder) {
-				var response generated.DronePlanResponse
+				var response generated.JobResponse
 				err := json.Unmarshal(rec.Body.Bytes(), &response)
 				assert.NoError(t, err)
-				assert.NotNil(t, response.Distance)
-				assert.NotNil(t, response.Rest)
-				assert.NotNil(t, response.Rest.X)
-				assert.NotNil(t, response.Rest.Y)
+				assert.Equal(t, "cancelled", *response.Status)
 			},
 		},
 		{
-			name: "Invalid Max Distance",
-			maxDistance: func() *int32 { val := int32(0); return &val }(),
-			mockSetup: func(mockSvc *mocks.MockService) {},
-			expectedStatus: http.StatusBadRequest,
-		},
-		{
-			name: "Estate Not Found",
+			name: "Job Not Found",
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					CalculateDronePath(gomock.Any(), estateID).
-					Return(0, errors.New("estate not found"))
+					CancelJob(gomock.Any(), jobID).
+					Return(service.ErrJobNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
 		},
 		{
-			name: "Repository Error",
+			name: "Job Already Finished",
 			mockSetup: func(mockSvc *mocks.MockService) {
 				mockSvc.EXPECT().
-					CalculateDronePath(gomock.Any(), estateID).
-					Return(0, errors.New("database error"))
+					CancelJob(gomock.Any(), jobID).
+					Return(service.ErrJobFinished)
 			},
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusConflict,
 		},
 	}
 
@@ -443,43 +3383,33 @@ func TestGetDronePlan(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			// Initialize Echo
 			e := echo.New()
-			
+
 			// Setup test request
-			path := "/estate/" + estateID.String() + "/drone-plan"
-			if tc.maxDistance != nil {
-				path += "?max_distance=" + string(rune(*tc.maxDistance+'0'))
-			}
-			
-			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req := httptest.NewRequest(http.MethodDelete, "/jobs/"+jobID.String(), nil)
 			rec := httptest.NewRecorder()
 			c := e.NewContext(req, rec)
-			c.SetParamNames("id")
-			c.SetParamValues(estateID.String())
-			
+			c.SetParamNames("jobId")
+			c.SetParamValues(jobID.String())
+
 			// Setup mock controller
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
-			
+
 			// Create mock service
 			mockSvc := mocks.NewMockService(ctrl)
-			
+
 			// Setup mock expectations
 			tc.mockSetup(mockSvc)
-			
+
 			// Create handler with mock service
 			h := NewHandler(mockSvc)
-			
-			// Prepare params
-			params := generated.GetDronePlanParams{
-				MaxDistance: tc.maxDistance,
-			}
-			
+
 			// Perform the test
-			_ = h.GetDronePlan(c, estateUUID, params)
-			
+			respond(c, h.CancelJob(c, jobUUID))
+
 			// Assert the results
 			assert.Equal(t, tc.expectedStatus, rec.Code)
-			
+
 			// Additional response checks if provided
 			if tc.checkResponse != nil {
 				tc.checkResponse(t, rec)
@@ -515,4 +3445,12 @@ func TestPing(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Contains(t, rec.Body.String(), "ok")
 	assert.Contains(t, rec.Body.String(), "API is running")
-} 
\ No newline at end of file
+} 
+
+// respond passes the error returned by a handler to the error handler, as
+// Echo does when serving a request
+func respond(c echo.Context, err error) {
+	if err != nil {
+		ErrorHandler(err, c)
+	}
+}
@@ -2,29 +2,50 @@ package config
 
 import (
 	"os"
+	"strconv"
+)
+
+// Storage backends
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
 )
 
 // Config holds the application configuration
 type Config struct {
 	ServerPort  string
+	// Storage is the backend keeping the data, StoragePostgres,
+	// StorageSQLite or StorageMemory
+	Storage     string
+	// SQLitePath is the database file of StorageSQLite, created if missing
+	SQLitePath  string
 	DBHost      string
 	DBPort      string
 	DBUser      string
 	DBPassword  string
 	DBName      string
 	DBSSLMode   string
+	// JobWorkers is the number of drone plan jobs calculated concurrently
+	JobWorkers  int
+	// AutoMigrate applies pending schema migrations when the server starts
+	AutoMigrate bool
 }
 
 // Load loads the configuration from environment variables
 func Load() *Config {
 	return &Config{
 		ServerPort:  getEnv("SERVER_PORT", "8080"),
+		Storage:     getEnv("STORAGE", StoragePostgres),
+		SQLitePath:  getEnv("SQLITE_PATH", "plantation.db"),
 		DBHost:      getEnv("DB_HOST", "localhost"),
 		DBPort:      getEnv("DB_PORT", "5432"),
 		DBUser:      getEnv("DB_USER", "postgres"),
 		DBPassword:  getEnv("DB_PASSWORD", "postgres"),
 		DBName:      getEnv("DB_NAME", "plantation"),
 		DBSSLMode:   getEnv("DB_SSLMODE", "disable"),
+		JobWorkers:  getEnvInt("JOB_WORKERS", 2),
+		AutoMigrate: getEnvBool("DB_AUTO_MIGRATE", true),
 	}
 }
 
@@ -35,4 +56,24 @@ func getEnv(key, defaultValue string) string {
 		return defaultValue
 	}
 	return value
-} 
\ No newline at end of file
+}
+
+// getEnvInt returns the value of an environment variable as a positive integer
+// or a default value if not set or invalid
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 1 {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvBool returns the value of an environment variable as a boolean or a
+// default value if not set or invalid
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
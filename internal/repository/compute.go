@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"bytes"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// This file holds the calculations that Postgres does in SQL, for the
+// repositories doing them in Go
+
+// compareIDs orders UUIDs like Postgres does, byte by byte
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// firstN returns the first n items at most
+func firstN[T any](items []T, n int) []T {
+	if len(items) > n {
+		return items[:n]
+	}
+	return items
+}
+
+// containsPoint reports whether a point lies inside or on the edge of a
+// polygon
+func containsPoint(vertices []Point, p Point) bool {
+	inside := false
+	for i := range vertices {
+		a, b := vertices[i], vertices[(i+1)%len(vertices)]
+		onLine := (b.X-a.X)*(p.Y-a.Y) == (b.Y-a.Y)*(p.X-a.X)
+		if onLine && p.X >= min(a.X, b.X) && p.X <= max(a.X, b.X) && p.Y >= min(a.Y, b.Y) && p.Y <= max(a.Y, b.Y) {
+			return true
+		}
+		// Count the edges crossed by a ray going right from the point
+		if (a.Y > p.Y) != (b.Y > p.Y) &&
+			float64(p.X) < float64(a.X)+float64(p.Y-a.Y)*float64(b.X-a.X)/float64(b.Y-a.Y) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// zonesAt returns the names of the zones holding a point, in order
+func zonesAt(zones []Zone, p Point) []string {
+	names := []string{}
+	for _, zone := range zones {
+		if containsPoint(zone.Vertices, p) {
+			names = append(names, zone.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// heightStats calculates the statistics of tree heights. The percentiles are
+// interpolated like the median.
+func heightStats(heights []int, percentiles []float64) Stats {
+	stats := Stats{Percentiles: make([]float64, len(percentiles))}
+	if len(heights) == 0 {
+		return stats
+	}
+
+	sorted := slices.Clone(heights)
+	sort.Ints(sorted)
+	stats.Count = len(sorted)
+	stats.MinHeight = sorted[0]
+	stats.MaxHeight = sorted[len(sorted)-1]
+	stats.MedianHeight = percentile(sorted, 0.5)
+	stats.MeanHeight = mean(sorted)
+	for i, fraction := range percentiles {
+		stats.Percentiles[i] = percentile(sorted, fraction)
+	}
+
+	variance := 0.0
+	for _, height := range sorted {
+		variance += math.Pow(float64(height)-stats.MeanHeight, 2)
+		if height >= 1 && height <= len(stats.Histogram) {
+			stats.Histogram[height-1]++
+		}
+	}
+	stats.StdDevHeight = math.Sqrt(variance / float64(len(sorted)))
+
+	return stats
+}
+
+// percentile interpolates the value at a fraction of sorted values, like
+// percentile_cont of Postgres
+func percentile(sorted []int, fraction float64) float64 {
+	position := fraction * float64(len(sorted)-1)
+	lower, upper := int(math.Floor(position)), int(math.Ceil(position))
+	return float64(sorted[lower]) + (position-float64(lower))*float64(sorted[upper]-sorted[lower])
+}
+
+// mean returns the mean of values
+func mean(values []int) float64 {
+	sum := 0
+	for _, value := range values {
+		sum += value
+	}
+	return float64(sum) / float64(len(values))
+}
+
+// cellStats groups trees into the cells of a grid and calculates the
+// statistics of each cell, ordered by row and then by column. The trees must
+// lie within the region of the grid.
+func cellStats(trees []Tree, query GridQuery) []CellStats {
+	type cell struct{ column, row int }
+	heights := make(map[cell][]int)
+	for _, tree := range trees {
+		c := cell{column: (tree.X - query.XMin) / query.CellSize, row: (tree.Y - query.YMin) / query.CellSize}
+		heights[c] = append(heights[c], tree.Height)
+	}
+
+	var cells []CellStats
+	for c, values := range heights {
+		sort.Ints(values)
+		cells = append(cells, CellStats{
+			Column:       c.column,
+			Row:          c.row,
+			Count:        len(values),
+			MaxHeight:    values[len(values)-1],
+			MinHeight:    values[0],
+			MedianHeight: percentile(values, 0.5),
+			MeanHeight:   mean(values),
+		})
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Row != cells[j].Row {
+			return cells[i].Row < cells[j].Row
+		}
+		return cells[i].Column < cells[j].Column
+	})
+	return cells
+}
+
+// treeGrowth calculates the growth of a tree from its height history, oldest
+// first. It reports false if the tree was not measured at two different times.
+func treeGrowth(tree Tree, measurements []TreeMeasurement) (TreeGrowth, bool) {
+	if len(measurements) == 0 {
+		return TreeGrowth{}, false
+	}
+	first, last := measurements[0], measurements[len(measurements)-1]
+	if !last.MeasuredAt.After(first.MeasuredAt) {
+		return TreeGrowth{}, false
+	}
+
+	// A month is a twelfth of an average year, 2629746 seconds
+	months := last.MeasuredAt.Sub(first.MeasuredAt).Seconds() / 2629746
+	return TreeGrowth{
+		TreeID:          tree.ID,
+		X:               tree.X,
+		Y:               tree.Y,
+		FirstHeight:     first.Height,
+		LastHeight:      last.Height,
+		FirstMeasuredAt: first.MeasuredAt,
+		LastMeasuredAt:  last.MeasuredAt,
+		MonthlyGrowth:   float64(last.Height-first.Height) / months,
+	}, true
+}
+
+// growthStats summarizes the growth of trees, listing up to limit of the
+// fastest and of the slowest growing ones
+func growthStats(growths []TreeGrowth, limit int) GrowthStats {
+	var stats GrowthStats
+	if len(growths) == 0 {
+		return stats
+	}
+
+	total := 0.0
+	for _, growth := range growths {
+		total += growth.MonthlyGrowth
+	}
+	stats.Trees = len(growths)
+	stats.AverageMonthlyGrowth = total / float64(len(growths))
+
+	sorted := slices.Clone(growths)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].MonthlyGrowth != sorted[j].MonthlyGrowth {
+			return sorted[i].MonthlyGrowth > sorted[j].MonthlyGrowth
+		}
+		return compareIDs(sorted[i].TreeID, sorted[j].TreeID) < 0
+	})
+	stats.Fastest = slices.Clone(firstN(sorted, limit))
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].MonthlyGrowth != sorted[j].MonthlyGrowth {
+			return sorted[i].MonthlyGrowth < sorted[j].MonthlyGrowth
+		}
+		return compareIDs(sorted[i].TreeID, sorted[j].TreeID) < 0
+	})
+	stats.Slowest = slices.Clone(firstN(sorted, limit))
+
+	return stats
+}
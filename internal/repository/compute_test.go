@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainsPoint(t *testing.T) {
+	triangle := []Point{{X: 1, Y: 1}, {X: 7, Y: 1}, {X: 1, Y: 7}}
+	square := []Point{{X: 2, Y: 2}, {X: 5, Y: 2}, {X: 5, Y: 5}, {X: 2, Y: 5}}
+
+	testCases := []struct {
+		name     string
+		vertices []Point
+		point    Point
+		expected bool
+	}{
+		{name: "Inside Triangle", vertices: triangle, point: Point{X: 2, Y: 3}, expected: true},
+		{name: "On Slanted Edge", vertices: triangle, point: Point{X: 4, Y: 4}, expected: true},
+		{name: "Beyond Slanted Edge", vertices: triangle, point: Point{X: 4, Y: 5}, expected: false},
+		{name: "On Vertex", vertices: triangle, point: Point{X: 7, Y: 1}, expected: true},
+		{name: "In Line With Edge", vertices: triangle, point: Point{X: 8, Y: 1}, expected: false},
+		{name: "Inside Square", vertices: square, point: Point{X: 3, Y: 4}, expected: true},
+		{name: "On Square Edge", vertices: square, point: Point{X: 5, Y: 3}, expected: true},
+		{name: "Left Of Square", vertices: square, point: Point{X: 1, Y: 3}, expected: false},
+		{name: "Above Square", vertices: square, point: Point{X: 3, Y: 6}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, containsPoint(tc.vertices, tc.point))
+		})
+	}
+}
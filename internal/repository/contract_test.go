@@ -0,0 +1,712 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// testRepository checks the behaviour every Repository implementation shares.
+// newRepo returns an empty repository for each test.
+func testRepository(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+
+	t.Run("Estate Dimensions", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id := createEstate(t, repo, 10, 20)
+		width, length, err := repo.GetEstate(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, width)
+		assert.Equal(t, 20, length)
+
+		for _, size := range [][2]int{{0, 10}, {10, 0}, {50001, 10}, {10, 50001}} {
+			_, err := repo.CreateEstate(ctx, size[0], size[1])
+			assert.Error(t, err, "%dx%d", size[0], size[1])
+		}
+
+		_, _, err = repo.GetEstate(ctx, uuid.New())
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		_, err = repo.GetEstateDetail(ctx, uuid.New())
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("Query Estates", func(t *testing.T) {
+		repo := newRepo(t)
+
+		small := createEstate(t, repo, 2, 3)
+		large := createEstate(t, repo, 100, 100)
+		medium := createEstate(t, repo, 10, 10)
+		createTree(t, repo, Tree{EstateID: medium, X: 1, Y: 1, Height: 5})
+		createTree(t, repo, Tree{EstateID: medium, X: 2, Y: 1, Height: 5})
+
+		estates, err := repo.QueryEstates(ctx, EstateQuery{SortByArea: true, Descending: true, WithTreeCount: true, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{large, medium}, estateIDs(estates))
+		assert.Equal(t, 2, estates[1].TreeCount)
+
+		estates, err = repo.QueryEstates(ctx, EstateQuery{SortByArea: true, Descending: true, After: &estates[1], Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{small}, estateIDs(estates))
+
+		estates, err = repo.QueryEstates(ctx, EstateQuery{MinArea: 100, MaxWidth: 50, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{medium}, estateIDs(estates))
+		assert.Zero(t, estates[0].TreeCount)
+
+		// Estates are listed in the order they were created by default
+		estates, err = repo.QueryEstates(ctx, EstateQuery{Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{small, large, medium}, estateIDs(estates))
+
+		// Without a limit every estate is listed
+		estates, err = repo.QueryEstates(ctx, EstateQuery{})
+		assert.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{small, large, medium}, estateIDs(estates))
+
+		estate, err := repo.GetEstateDetail(ctx, medium)
+		assert.NoError(t, err)
+		assert.Equal(t, Estate{ID: medium, Width: 10, Length: 10, TreeCount: 2}, estate)
+	})
+
+	t.Run("One Tree Per Plot", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		otherEstateID := createEstate(t, repo, 10, 10)
+
+		treeID := createTree(t, repo, Tree{EstateID: estateID, X: 1, Y: 1, Height: 5})
+		_, err := repo.CreateTree(ctx, Tree{EstateID: estateID, X: 1, Y: 1, Height: 7})
+		assert.ErrorIs(t, err, ErrPlotOccupied)
+
+		// The same plot of another estate is free
+		createTree(t, repo, Tree{EstateID: otherEstateID, X: 1, Y: 1, Height: 7})
+
+		otherID := createTree(t, repo, Tree{EstateID: estateID, X: 2, Y: 1, Height: 7})
+		err = repo.UpdateTree(ctx, Tree{ID: otherID, EstateID: estateID, X: 1, Y: 1, Height: 7})
+		assert.ErrorIs(t, err, ErrPlotOccupied)
+
+		// A tree moved away frees its plot
+		assert.NoError(t, repo.UpdateTree(ctx, Tree{ID: treeID, EstateID: estateID, X: 3, Y: 3, Height: 5}))
+		assert.NoError(t, repo.UpdateTree(ctx, Tree{ID: otherID, EstateID: estateID, X: 1, Y: 1, Height: 7}))
+
+		err = repo.UpdateTree(ctx, Tree{ID: treeID, EstateID: otherEstateID, X: 5, Y: 5, Height: 5})
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("Tree Constraints", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+
+		for name, tree := range map[string]Tree{
+			"Unknown Estate": {EstateID: uuid.New(), X: 1, Y: 1, Height: 5},
+			"Zero X":         {EstateID: estateID, X: 0, Y: 1, Height: 5},
+			"Zero Y":         {EstateID: estateID, X: 1, Y: 0, Height: 5},
+			"Too Short":      {EstateID: estateID, X: 1, Y: 1, Height: 0},
+			"Too Tall":       {EstateID: estateID, X: 1, Y: 1, Height: 31},
+			"Unknown Health": {EstateID: estateID, X: 1, Y: 1, Height: 5, Health: "wilting"},
+		} {
+			_, err := repo.CreateTree(ctx, tree)
+			assert.Error(t, err, name)
+		}
+
+		trees, err := repo.GetTrees(ctx, estateID)
+		assert.NoError(t, err)
+		assert.Empty(t, trees)
+	})
+
+	t.Run("Tree Attributes", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		plantedOn := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+
+		treeID := createTree(t, repo, Tree{EstateID: estateID, X: 2, Y: 3, Height: 12,
+			Species: "oak", PlantedOn: &plantedOn, Health: TreeHealthHealthy, Tags: []string{"north", "irrigated"}})
+		bareID := createTree(t, repo, Tree{EstateID: estateID, X: 4, Y: 3, Height: 6})
+
+		tree, err := repo.GetTree(ctx, estateID, treeID)
+		assert.NoError(t, err)
+		assert.Equal(t, treeID, tree.ID)
+		assert.Equal(t, "oak", tree.Species)
+		if assert.NotNil(t, tree.PlantedOn) {
+			assert.True(t, plantedOn.Equal(*tree.PlantedOn), tree.PlantedOn)
+		}
+		assert.Equal(t, TreeHealthHealthy, tree.Health)
+		assert.Equal(t, []string{"north", "irrigated"}, tree.Tags)
+		assert.Equal(t, []string{}, tree.Zones)
+
+		bare, err := repo.GetTree(ctx, estateID, bareID)
+		assert.NoError(t, err)
+		assert.Equal(t, Tree{ID: bareID, EstateID: estateID, X: 4, Y: 3, Height: 6, Tags: []string{}, Zones: []string{}}, bare)
+
+		_, err = repo.GetTree(ctx, uuid.New(), treeID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("List Trees", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		createTree(t, repo, Tree{EstateID: estateID, X: 1, Y: 1, Height: 9, Tags: []string{"a"}})
+		createTree(t, repo, Tree{EstateID: estateID, X: 2, Y: 1, Height: 3, Tags: []string{"a", "b"}})
+		createTree(t, repo, Tree{EstateID: estateID, X: 1, Y: 2, Height: 9, Species: "pine"})
+		createTree(t, repo, Tree{EstateID: estateID, X: 5, Y: 5, Height: 20})
+
+		trees, err := repo.ListTrees(ctx, estateID, TreeQuery{SortByHeight: true, Descending: true, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]int{{5, 5}, {1, 2}}, treePlots(trees))
+
+		trees, err = repo.ListTrees(ctx, estateID, TreeQuery{SortByHeight: true, Descending: true, After: &trees[1], Limit: 5})
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]int{{1, 1}, {2, 1}}, treePlots(trees))
+
+		trees, err = repo.ListTrees(ctx, estateID, TreeQuery{XMax: 2, YMax: 2, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]int{{1, 1}, {1, 2}, {2, 1}}, treePlots(trees))
+
+		trees, err = repo.ListTrees(ctx, estateID, TreeQuery{MinHeight: 5, Filter: TreeFilter{Species: "pine"}, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]int{{1, 2}}, treePlots(trees))
+
+		trees, err = repo.ListTrees(ctx, estateID, TreeQuery{Filter: TreeFilter{Tags: []string{"b", "a"}}, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]int{{2, 1}}, treePlots(trees))
+
+		var rows [][2]int
+		err = repo.EachTree(ctx, estateID, func(tree Tree) error {
+			rows = append(rows, [2]int{tree.X, tree.Y})
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]int{{1, 1}, {2, 1}, {1, 2}, {5, 5}}, rows)
+
+		stop := errors.New("stop")
+		assert.ErrorIs(t, repo.EachTree(ctx, estateID, func(Tree) error { return stop }), stop)
+	})
+
+	t.Run("Create Trees", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		createTree(t, repo, Tree{EstateID: estateID, X: 5, Y: 5, Height: 5})
+
+		// A batch is planted as a whole or not at all
+		_, err := repo.CreateTrees(ctx, estateID, []Tree{{X: 1, Y: 1, Height: 3}, {X: 5, Y: 5, Height: 3}})
+		assert.ErrorIs(t, err, ErrPlotOccupied)
+		_, err = repo.CreateTrees(ctx, estateID, []Tree{{X: 1, Y: 1, Height: 3}, {X: 1, Y: 1, Height: 4}})
+		assert.ErrorIs(t, err, ErrPlotOccupied)
+		_, err = repo.CreateTrees(ctx, estateID, []Tree{{X: 1, Y: 1, Height: 3}, {X: 2, Y: 1, Height: 40}})
+		assert.Error(t, err)
+
+		trees, err := repo.GetTrees(ctx, estateID)
+		assert.NoError(t, err)
+		assert.Len(t, trees, 1)
+
+		count, err := repo.CreateTrees(ctx, estateID, []Tree{{X: 1, Y: 1, Height: 3}, {X: 2, Y: 1, Height: 4, Tags: []string{"new"}}})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+
+		trees, err = repo.GetTrees(ctx, estateID)
+		assert.NoError(t, err)
+		assert.Len(t, trees, 3)
+	})
+
+	t.Run("Occupied Plots", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		otherEstateID := createEstate(t, repo, 10, 10)
+		createTree(t, repo, Tree{EstateID: estateID, X: 1, Y: 2, Height: 5})
+		createTree(t, repo, Tree{EstateID: estateID, X: 3, Y: 3, Height: 5})
+		createTree(t, repo, Tree{EstateID: estateID, X: 9, Y: 9, Height: 5})
+		createTree(t, repo, Tree{EstateID: otherEstateID, X: 4, Y: 4, Height: 5})
+
+		// Only the given plots of the estate are reported
+		occupied, err := repo.OccupiedPlots(ctx, estateID, []Point{{X: 2, Y: 1}, {X: 1, Y: 2}, {X: 4, Y: 4}, {X: 3, Y: 3}})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []Point{{X: 1, Y: 2}, {X: 3, Y: 3}}, occupied)
+
+		occupied, err = repo.OccupiedPlots(ctx, estateID, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, occupied)
+	})
+
+	t.Run("Resize Estate", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		createTree(t, repo, Tree{EstateID: estateID, X: 2, Y: 2, Height: 5})
+		createTree(t, repo, Tree{EstateID: estateID, X: 8, Y: 2, Height: 5})
+		createTree(t, repo, Tree{EstateID: estateID, X: 2, Y: 9, Height: 5})
+
+		_, err := repo.ResizeEstate(ctx, estateID, 5, 5, false)
+		assert.ErrorIs(t, err, ErrTreesOutOfBounds)
+		width, length, err := repo.GetEstate(ctx, estateID)
+		assert.NoError(t, err)
+		assert.Equal(t, [2]int{10, 10}, [2]int{width, length})
+
+		_, err = repo.ResizeEstate(ctx, estateID, 0, 5, true)
+		assert.Error(t, err)
+		trees, err := repo.GetTrees(ctx, estateID)
+		assert.NoError(t, err)
+		assert.Len(t, trees, 3)
+
+		pruned, err := repo.ResizeEstate(ctx, estateID, 5, 5, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, pruned)
+		width, length, err = repo.GetEstate(ctx, estateID)
+		assert.NoError(t, err)
+		assert.Equal(t, [2]int{5, 5}, [2]int{width, length})
+		trees, err = repo.GetTrees(ctx, estateID)
+		assert.NoError(t, err)
+		assert.Equal(t, [][2]int{{2, 2}}, treePlots(trees))
+
+		_, err = repo.ResizeEstate(ctx, uuid.New(), 5, 5, true)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("Resize Estate With Zones And Obstacles", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		insideID, err := repo.CreateObstacle(ctx, Obstacle{EstateID: estateID, XMin: 4, XMax: 5, YMin: 1, YMax: 5, Impassable: true})
+		assert.NoError(t, err)
+		_, err = repo.CreateObstacle(ctx, Obstacle{EstateID: estateID, XMin: 5, XMax: 6, YMin: 1, YMax: 1, Impassable: true})
+		assert.NoError(t, err)
+
+		_, err = repo.ResizeEstate(ctx, estateID, 5, 5, false)
+		assert.ErrorIs(t, err, ErrObstaclesOutOfBounds)
+
+		_, err = repo.CreateZone(ctx, Zone{EstateID: estateID, Name: "inside", Shape: ZoneShapeRect, Vertices: rect(1, 1, 5, 5)})
+		assert.NoError(t, err)
+		_, err = repo.CreateZone(ctx, Zone{
+			EstateID: estateID, Name: "across", Shape: ZoneShapePolygon,
+			Vertices: []Point{{X: 1, Y: 1}, {X: 7, Y: 1}, {X: 1, Y: 3}},
+		})
+		assert.NoError(t, err)
+
+		_, err = repo.ResizeEstate(ctx, estateID, 6, 6, false)
+		assert.ErrorIs(t, err, ErrZonesOutOfBounds)
+		width, length, err := repo.GetEstate(ctx, estateID)
+		assert.NoError(t, err)
+		assert.Equal(t, [2]int{10, 10}, [2]int{width, length})
+
+		// A zone reaching exactly to the new edge is kept
+		pruned, err := repo.ResizeEstate(ctx, estateID, 5, 5, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, pruned)
+		zones, err := repo.ListZones(ctx, estateID)
+		assert.NoError(t, err)
+		if assert.Len(t, zones, 1) {
+			assert.Equal(t, "inside", zones[0].Name)
+		}
+		obstacles, err := repo.ListObstacles(ctx, estateID)
+		assert.NoError(t, err)
+		if assert.Len(t, obstacles, 1) {
+			assert.Equal(t, insideID, obstacles[0].ID)
+		}
+	})
+
+	t.Run("Cascade Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		keptID := createEstate(t, repo, 10, 10)
+		treeID := createTree(t, repo, Tree{EstateID: estateID, X: 1, Y: 1, Height: 5})
+		keptTreeID := createTree(t, repo, Tree{EstateID: keptID, X: 1, Y: 1, Height: 5})
+		zoneID, err := repo.CreateZone(ctx, Zone{EstateID: estateID, Name: "north", Shape: ZoneShapeRect, Vertices: rect(1, 1, 5, 5)})
+		assert.NoError(t, err)
+		_, err = repo.CreateObstacle(ctx, Obstacle{EstateID: estateID, XMin: 2, XMax: 3, YMin: 2, YMax: 3, MinAltitude: 10})
+		assert.NoError(t, err)
+		jobID, err := repo.CreateJob(ctx, estateID, []byte(`{}`))
+		assert.NoError(t, err)
+
+		assert.NoError(t, repo.DeleteEstate(ctx, estateID))
+
+		_, _, err = repo.GetEstate(ctx, estateID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		_, err = repo.GetTree(ctx, estateID, treeID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		measurements, err := repo.ListTreeMeasurements(ctx, treeID)
+		assert.NoError(t, err)
+		assert.Empty(t, measurements)
+		_, err = repo.GetZone(ctx, estateID, zoneID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		obstacles, err := repo.ListObstacles(ctx, estateID)
+		assert.NoError(t, err)
+		assert.Empty(t, obstacles)
+		_, err = repo.GetJob(ctx, jobID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+		// Other estates are left alone, and their plots can be reused
+		_, err = repo.GetTree(ctx, keptID, keptTreeID)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, repo.DeleteEstate(ctx, estateID), pgx.ErrNoRows)
+	})
+
+	t.Run("Delete Tree", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		treeID := createTree(t, repo, Tree{EstateID: estateID, X: 1, Y: 1, Height: 5})
+
+		assert.ErrorIs(t, repo.DeleteTree(ctx, uuid.New(), treeID), pgx.ErrNoRows)
+		assert.NoError(t, repo.DeleteTree(ctx, estateID, treeID))
+		assert.ErrorIs(t, repo.DeleteTree(ctx, estateID, treeID), pgx.ErrNoRows)
+
+		// The plot is free again
+		createTree(t, repo, Tree{EstateID: estateID, X: 1, Y: 1, Height: 5})
+	})
+
+	t.Run("Transactions", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		failed := errors.New("failed")
+
+		err := repo.WithTx(ctx, func(tx Repository) error {
+			width, length, err := tx.LockEstate(ctx, estateID)
+			assert.NoError(t, err)
+			assert.Equal(t, [2]int{10, 10}, [2]int{width, length})
+			createTree(t, tx, Tree{EstateID: estateID, X: 1, Y: 1, Height: 5})
+			return failed
+		})
+		assert.ErrorIs(t, err, failed)
+		trees, err := repo.GetTrees(ctx, estateID)
+		assert.NoError(t, err)
+		assert.Empty(t, trees)
+
+		err = repo.WithTx(ctx, func(tx Repository) error {
+			createTree(t, tx, Tree{EstateID: estateID, X: 1, Y: 1, Height: 5})
+			// A nested transaction is rolled back on its own
+			err := tx.WithTx(ctx, func(nested Repository) error {
+				createTree(t, nested, Tree{EstateID: estateID, X: 2, Y: 2, Height: 5})
+				return failed
+			})
+			assert.ErrorIs(t, err, failed)
+			return tx.WithTx(ctx, func(nested Repository) error {
+				createTree(t, nested, Tree{EstateID: estateID, X: 3, Y: 3, Height: 5})
+				return nil
+			})
+		})
+		assert.NoError(t, err)
+		trees, err = repo.GetTrees(ctx, estateID)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, [][2]int{{1, 1}, {3, 3}}, treePlots(trees))
+
+		// Updates and deletes are rolled back along with their side effects
+		movedID, deletedID := trees[0].ID, trees[1].ID
+		err = repo.WithTx(ctx, func(tx Repository) error {
+			assert.NoError(t, tx.UpdateTree(ctx, Tree{ID: movedID, EstateID: estateID, X: 5, Y: 5, Height: 9}))
+			assert.NoError(t, tx.DeleteTree(ctx, estateID, deletedID))
+			createTree(t, tx, Tree{EstateID: estateID, X: trees[1].X, Y: trees[1].Y, Height: 5})
+			return failed
+		})
+		assert.ErrorIs(t, err, failed)
+		after, err := repo.GetTrees(ctx, estateID)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, trees, after)
+		measurements, err := repo.ListTreeMeasurements(ctx, movedID)
+		assert.NoError(t, err)
+		assert.Len(t, measurements, 1)
+		_, err = repo.GetTree(ctx, estateID, deletedID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Tree Stats", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		for i, height := range []int{2, 4, 6, 8} {
+			createTree(t, repo, Tree{EstateID: estateID, X: i + 1, Y: i + 1, Height: height})
+		}
+
+		stats, err := repo.GetTreeStats(ctx, estateID, StatsQuery{Percentiles: []float64{0.25, 1}})
+		assert.NoError(t, err)
+		assert.Equal(t, 4, stats.Count)
+		assert.Equal(t, 8, stats.MaxHeight)
+		assert.Equal(t, 2, stats.MinHeight)
+		assert.InDelta(t, 5, stats.MedianHeight, 1e-9)
+		assert.InDelta(t, 5, stats.MeanHeight, 1e-9)
+		assert.InDelta(t, 2.2360679775, stats.StdDevHeight, 1e-9)
+		assert.InDeltaSlice(t, []float64{3.5, 8}, stats.Percentiles, 1e-9)
+		assert.Equal(t, 1, stats.Histogram[1])
+		assert.Equal(t, 1, stats.Histogram[7])
+
+		stats, err = repo.GetTreeStats(ctx, estateID, StatsQuery{XMin: 2, XMax: 3})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, stats.Count)
+		assert.InDelta(t, 5, stats.MeanHeight, 1e-9)
+
+		zoneID, err := repo.CreateZone(ctx, Zone{EstateID: estateID, Name: "corner", Shape: ZoneShapeRect, Vertices: rect(1, 1, 2, 2)})
+		assert.NoError(t, err)
+		stats, err = repo.GetTreeStats(ctx, estateID, StatsQuery{ZoneID: zoneID})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, stats.Count)
+		assert.InDelta(t, 3, stats.MeanHeight, 1e-9)
+
+		stats, err = repo.GetTreeStats(ctx, createEstate(t, repo, 5, 5), StatsQuery{Percentiles: []float64{0.5}})
+		assert.NoError(t, err)
+		assert.Equal(t, Stats{Percentiles: []float64{0}}, stats)
+	})
+
+	t.Run("Grid Stats", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		createTree(t, repo, Tree{EstateID: estateID, X: 1, Y: 1, Height: 4})
+		createTree(t, repo, Tree{EstateID: estateID, X: 2, Y: 2, Height: 8})
+		createTree(t, repo, Tree{EstateID: estateID, X: 6, Y: 1, Height: 10})
+		createTree(t, repo, Tree{EstateID: estateID, X: 1, Y: 6, Height: 3})
+		createTree(t, repo, Tree{EstateID: estateID, X: 10, Y: 10, Height: 1})
+
+		cells, err := repo.GetGridStats(ctx, estateID, GridQuery{CellSize: 5, XMin: 1, XMax: 9, YMin: 1, YMax: 9})
+		assert.NoError(t, err)
+		assert.Equal(t, []CellStats{
+			{Column: 0, Row: 0, Count: 2, MaxHeight: 8, MinHeight: 4, MedianHeight: 6, MeanHeight: 6},
+			{Column: 1, Row: 0, Count: 1, MaxHeight: 10, MinHeight: 10, MedianHeight: 10, MeanHeight: 10},
+			{Column: 0, Row: 1, Count: 1, MaxHeight: 3, MinHeight: 3, MedianHeight: 3, MeanHeight: 3},
+		}, cells)
+	})
+
+	t.Run("Growth", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		grownID := createTree(t, repo, Tree{EstateID: estateID, X: 1, Y: 1, Height: 5})
+		shrunkID := createTree(t, repo, Tree{EstateID: estateID, X: 2, Y: 1, Height: 5})
+		createTree(t, repo, Tree{EstateID: estateID, X: 3, Y: 1, Height: 5})
+
+		assert.NoError(t, repo.UpdateTree(ctx, Tree{ID: grownID, EstateID: estateID, X: 1, Y: 1, Height: 9}))
+		assert.NoError(t, repo.UpdateTree(ctx, Tree{ID: shrunkID, EstateID: estateID, X: 2, Y: 1, Height: 4}))
+		// Moving a tree does not measure it again
+		assert.NoError(t, repo.UpdateTree(ctx, Tree{ID: grownID, EstateID: estateID, X: 1, Y: 2, Height: 9}))
+
+		measurements, err := repo.ListTreeMeasurements(ctx, grownID)
+		assert.NoError(t, err)
+		if assert.Len(t, measurements, 2) {
+			assert.Equal(t, 5, measurements[0].Height)
+			assert.Equal(t, 9, measurements[1].Height)
+			assert.True(t, measurements[1].MeasuredAt.After(measurements[0].MeasuredAt))
+		}
+
+		stats, err := repo.GetGrowthStats(ctx, estateID, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, stats.Trees)
+		if assert.Len(t, stats.Fastest, 1) && assert.Len(t, stats.Slowest, 1) {
+			assert.Equal(t, grownID, stats.Fastest[0].TreeID)
+			assert.Equal(t, [2]int{1, 2}, [2]int{stats.Fastest[0].X, stats.Fastest[0].Y})
+			assert.Equal(t, [2]int{5, 9}, [2]int{stats.Fastest[0].FirstHeight, stats.Fastest[0].LastHeight})
+			assert.Positive(t, stats.Fastest[0].MonthlyGrowth)
+			assert.Equal(t, shrunkID, stats.Slowest[0].TreeID)
+			assert.Negative(t, stats.Slowest[0].MonthlyGrowth)
+		}
+	})
+
+	t.Run("Zones", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+		triangle := []Point{{X: 1, Y: 1}, {X: 7, Y: 1}, {X: 1, Y: 7}}
+		southID, err := repo.CreateZone(ctx, Zone{EstateID: estateID, Name: "south", Shape: ZoneShapePolygon, Vertices: triangle})
+		assert.NoError(t, err)
+		eastID, err := repo.CreateZone(ctx, Zone{EstateID: estateID, Name: "east", Shape: ZoneShapeRect, Vertices: rect(4, 1, 10, 10)})
+		assert.NoError(t, err)
+
+		_, err = repo.CreateZone(ctx, Zone{EstateID: estateID, Name: "east", Shape: ZoneShapeRect, Vertices: rect(1, 1, 2, 2)})
+		assert.ErrorIs(t, err, ErrZoneExists)
+		_, err = repo.CreateZone(ctx, Zone{EstateID: estateID, Name: "", Shape: ZoneShapeRect, Vertices: rect(1, 1, 2, 2)})
+		assert.Error(t, err)
+		_, err = repo.CreateZone(ctx, Zone{EstateID: uuid.New(), Name: "west", Shape: ZoneShapeRect, Vertices: rect(1, 1, 2, 2)})
+		assert.Error(t, err)
+
+		zone, err := repo.GetZoneByName(ctx, estateID, "south")
+		assert.NoError(t, err)
+		assert.Equal(t, southID, zone.ID)
+		assert.Equal(t, triangle, zone.Vertices)
+		assert.Equal(t, ZoneShapePolygon, zone.Shape)
+
+		zones, err := repo.ListZones(ctx, estateID)
+		assert.NoError(t, err)
+		if assert.Len(t, zones, 2) {
+			assert.Equal(t, []string{"east", "south"}, []string{zones[0].Name, zones[1].Name})
+		}
+
+		// Plots on the edge of a zone belong to it
+		edgeID := createTree(t, repo, Tree{EstateID: estateID, X: 4, Y: 4, Height: 5})
+		outsideID := createTree(t, repo, Tree{EstateID: estateID, X: 3, Y: 6, Height: 5})
+		tree, err := repo.GetTree(ctx, estateID, edgeID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"east", "south"}, tree.Zones)
+		tree, err = repo.GetTree(ctx, estateID, outsideID)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{}, tree.Zones)
+
+		err = repo.UpdateZone(ctx, Zone{ID: eastID, EstateID: estateID, Name: "south", Shape: ZoneShapeRect, Vertices: rect(4, 1, 10, 10)})
+		assert.ErrorIs(t, err, ErrZoneExists)
+		err = repo.UpdateZone(ctx, Zone{ID: eastID, EstateID: estateID, Name: "far east", Shape: ZoneShapeRect, Vertices: rect(8, 1, 10, 10)})
+		assert.NoError(t, err)
+		err = repo.UpdateZone(ctx, Zone{ID: uuid.New(), EstateID: estateID, Name: "nowhere", Shape: ZoneShapeRect, Vertices: rect(8, 1, 10, 10)})
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+		trees, err := repo.ListTrees(ctx, estateID, TreeQuery{Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, trees, 2) {
+			assert.Equal(t, []string{}, trees[0].Zones)
+			assert.Equal(t, []string{"south"}, trees[1].Zones)
+		}
+
+		assert.NoError(t, repo.DeleteZone(ctx, estateID, southID))
+		assert.ErrorIs(t, repo.DeleteZone(ctx, estateID, southID), pgx.ErrNoRows)
+		_, err = repo.GetZone(ctx, estateID, southID)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		_, err = repo.GetZoneByName(ctx, estateID, "south")
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("Obstacles", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+
+		towerID, err := repo.CreateObstacle(ctx, Obstacle{EstateID: estateID, XMin: 2, XMax: 2, YMin: 3, YMax: 3, MinAltitude: 40})
+		assert.NoError(t, err)
+		wallID, err := repo.CreateObstacle(ctx, Obstacle{EstateID: estateID, XMin: 5, XMax: 6, YMin: 1, YMax: 10, Impassable: true})
+		assert.NoError(t, err)
+
+		for name, obstacle := range map[string]Obstacle{
+			"Inverted":       {EstateID: estateID, XMin: 3, XMax: 2, YMin: 1, YMax: 1, MinAltitude: 5},
+			"No Altitude":    {EstateID: estateID, XMin: 1, XMax: 2, YMin: 1, YMax: 1},
+			"Unknown Estate": {EstateID: uuid.New(), XMin: 1, XMax: 2, YMin: 1, YMax: 1, MinAltitude: 5},
+		} {
+			_, err := repo.CreateObstacle(ctx, obstacle)
+			assert.Error(t, err, name)
+		}
+
+		obstacles, err := repo.ListObstacles(ctx, estateID)
+		assert.NoError(t, err)
+		if assert.Len(t, obstacles, 2) {
+			assert.Equal(t, towerID, obstacles[0].ID)
+			assert.Equal(t, 40, obstacles[0].MinAltitude)
+			assert.Equal(t, wallID, obstacles[1].ID)
+			assert.True(t, obstacles[1].Impassable)
+			assert.Zero(t, obstacles[1].MinAltitude)
+		}
+
+		assert.ErrorIs(t, repo.DeleteObstacle(ctx, uuid.New(), towerID), pgx.ErrNoRows)
+		assert.NoError(t, repo.DeleteObstacle(ctx, estateID, towerID))
+		obstacles, err = repo.ListObstacles(ctx, estateID)
+		assert.NoError(t, err)
+		assert.Len(t, obstacles, 1)
+	})
+
+	t.Run("Jobs", func(t *testing.T) {
+		repo := newRepo(t)
+		estateID := createEstate(t, repo, 10, 10)
+
+		_, err := repo.ClaimJob(ctx)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		_, err = repo.CreateJob(ctx, uuid.New(), []byte(`{}`))
+		assert.Error(t, err)
+
+		firstID, err := repo.CreateJob(ctx, estateID, []byte(`{"strategy":"zigzag"}`))
+		assert.NoError(t, err)
+		secondID, err := repo.CreateJob(ctx, estateID, []byte(`{}`))
+		assert.NoError(t, err)
+
+		job, err := repo.ClaimJob(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, firstID, job.ID)
+		assert.Equal(t, JobStatusRunning, job.Status)
+		assert.JSONEq(t, `{"strategy":"zigzag"}`, string(job.Options))
+
+		running, err := repo.UpdateJobProgress(ctx, firstID, 40)
+		assert.NoError(t, err)
+		assert.True(t, running)
+		assert.NoError(t, repo.FinishJob(ctx, firstID, JobStatusSucceeded, []byte(`{"distance":10}`), ""))
+		job, err = repo.GetJob(ctx, firstID)
+		assert.NoError(t, err)
+		assert.Equal(t, JobStatusSucceeded, job.Status)
+		assert.Equal(t, 100, job.Progress)
+		assert.JSONEq(t, `{"distance":10}`, string(job.Result))
+		assert.ErrorIs(t, repo.CancelJob(ctx, firstID), pgx.ErrNoRows)
+
+		// A job cancelled while running stays cancelled
+		_, err = repo.ClaimJob(ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.CancelJob(ctx, secondID))
+		running, err = repo.UpdateJobProgress(ctx, secondID, 50)
+		assert.NoError(t, err)
+		assert.False(t, running)
+		assert.NoError(t, repo.FinishJob(ctx, secondID, JobStatusFailed, nil, "too late"))
+		job, err = repo.GetJob(ctx, secondID)
+		assert.NoError(t, err)
+		assert.Equal(t, JobStatusCancelled, job.Status)
+		assert.Empty(t, job.Error)
+
+		thirdID, err := repo.CreateJob(ctx, estateID, []byte(`{}`))
+		assert.NoError(t, err)
+		_, err = repo.ClaimJob(ctx)
+		assert.NoError(t, err)
+		_, err = repo.UpdateJobProgress(ctx, thirdID, 70)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.RequeueRunningJobs(ctx))
+		job, err = repo.GetJob(ctx, thirdID)
+		assert.NoError(t, err)
+		assert.Equal(t, JobStatusQueued, job.Status)
+		assert.Zero(t, job.Progress)
+
+		// A single running job can be put back into the queue, but a
+		// cancelled one stays cancelled
+		_, err = repo.ClaimJob(ctx)
+		assert.NoError(t, err)
+		_, err = repo.UpdateJobProgress(ctx, thirdID, 30)
+		assert.NoError(t, err)
+		assert.NoError(t, repo.RequeueJob(ctx, thirdID))
+		job, err = repo.GetJob(ctx, thirdID)
+		assert.NoError(t, err)
+		assert.Equal(t, JobStatusQueued, job.Status)
+		assert.Zero(t, job.Progress)
+		assert.NoError(t, repo.RequeueJob(ctx, secondID))
+		job, err = repo.GetJob(ctx, secondID)
+		assert.NoError(t, err)
+		assert.Equal(t, JobStatusCancelled, job.Status)
+
+		_, err = repo.GetJob(ctx, uuid.New())
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+}
+
+// createEstate creates an estate, failing the test if it cannot
+func createEstate(t *testing.T, repo Repository, width, length int) uuid.UUID {
+	t.Helper()
+	id, err := repo.CreateEstate(context.Background(), width, length)
+	if err != nil {
+		t.Fatalf("creating estate: %v", err)
+	}
+	return id
+}
+
+// createTree plants a tree, failing the test if it cannot
+func createTree(t *testing.T, repo Repository, tree Tree) uuid.UUID {
+	t.Helper()
+	id, err := repo.CreateTree(context.Background(), tree)
+	if err != nil {
+		t.Fatalf("creating tree at (%d, %d): %v", tree.X, tree.Y, err)
+	}
+	return id
+}
+
+// rect returns the corners of a rectangle of plots
+func rect(xMin, yMin, xMax, yMax int) []Point {
+	return []Point{{X: xMin, Y: yMin}, {X: xMax, Y: yMin}, {X: xMax, Y: yMax}, {X: xMin, Y: yMax}}
+}
+
+// estateIDs returns the IDs of estates in order
+func estateIDs(estates []Estate) []uuid.UUID {
+	var ids []uuid.UUID
+	for _, estate := range estates {
+		ids = append(ids, estate.ID)
+	}
+	return ids
+}
+
+// treePlots returns the plots of trees in order
+func treePlots(trees []Tree) [][2]int {
+	var plots [][2]int
+	for _, tree := range trees {
+		plots = append(plots, [2]int{tree.X, tree.Y})
+	}
+	return plots
+}
@@ -0,0 +1,1061 @@
+package repository
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// errConstraint is returned by the in-memory repository when a write breaks a
+// constraint of the database schema, where Postgres reports a check or
+// foreign key violation
+var errConstraint = errors.New("constraint violated")
+
+// maxEstateSize is the largest width and length of an estate
+const maxEstateSize = 50000
+
+// memoryRepository implements the Repository interface in memory, enforcing
+// the constraints of the database schema. Its data is lost when the process
+// exits.
+type memoryRepository struct {
+	// mu is held by every call outside of WithTx and for the whole of a
+	// transaction, so transactions run one at a time
+	mu   *sync.Mutex
+	data *memoryData
+	// tx is set on the repositories passed to WithTx, which hold mu already
+	tx bool
+}
+
+// memoryData holds the records of the in-memory repository. Records are
+// stored by value and their slices are never modified in place, so putting
+// back the old value of a key is enough to undo a write.
+type memoryData struct {
+	estates map[uuid.UUID]Estate
+	trees   map[uuid.UUID]Tree
+	// plots holds the ID of the tree standing on every planted plot
+	plots        map[memoryPlot]uuid.UUID
+	measurements map[uuid.UUID][]TreeMeasurement
+	zones        map[uuid.UUID]Zone
+	obstacles    map[uuid.UUID]Obstacle
+	jobs         map[uuid.UUID]Job
+	// lastTime is the latest time returned by now
+	lastTime time.Time
+	// undo reverts the writes of the running transaction, latest last. It is
+	// nil outside of transactions.
+	undo []func()
+}
+
+// memoryPlot is a plot of an estate
+type memoryPlot struct {
+	estateID uuid.UUID
+	x, y     int
+}
+
+// NewMemoryRepository creates a new empty repository keeping its data in
+// memory
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		mu: &sync.Mutex{},
+		data: &memoryData{
+			estates:      make(map[uuid.UUID]Estate),
+			trees:        make(map[uuid.UUID]Tree),
+			plots:        make(map[memoryPlot]uuid.UUID),
+			measurements: make(map[uuid.UUID][]TreeMeasurement),
+			zones:        make(map[uuid.UUID]Zone),
+			obstacles:    make(map[uuid.UUID]Obstacle),
+			jobs:         make(map[uuid.UUID]Job),
+		},
+	}
+}
+
+// lock locks the data, unless the repository runs in a transaction holding
+// the lock already, and returns the function unlocking it
+func (r *memoryRepository) lock() func() {
+	if r.tx {
+		return func() {}
+	}
+	r.mu.Lock()
+	return r.mu.Unlock
+}
+
+// WithTx runs fn on the data, undoing its writes unless it succeeds. Other
+// callers wait until the transaction ends. A transaction started within
+// another only undoes its own writes when it fails, like a savepoint.
+func (r *memoryRepository) WithTx(ctx context.Context, fn func(repo Repository) error) error {
+	defer r.lock()()
+
+	if !r.tx {
+		r.data.undo = []func(){}
+		defer func() { r.data.undo = nil }()
+	}
+	start := len(r.data.undo)
+	committed := false
+	defer func() {
+		if !committed {
+			r.data.rollback(start)
+		}
+	}()
+
+	if err := fn(&memoryRepository{mu: r.mu, data: r.data, tx: true}); err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// rollback undoes the writes of the running transaction made since its undo
+// log had the given length
+func (d *memoryData) rollback(length int) {
+	for i := len(d.undo) - 1; i >= length; i-- {
+		d.undo[i]()
+	}
+	d.undo = d.undo[:length]
+}
+
+// put stores v under k in m, logging how to undo it in a transaction
+func put[K comparable, V any](d *memoryData, m map[K]V, k K, v V) {
+	logUndo(d, m, k)
+	m[k] = v
+}
+
+// remove deletes k from m, logging how to undo it in a transaction
+func remove[K comparable, V any](d *memoryData, m map[K]V, k K) {
+	logUndo(d, m, k)
+	delete(m, k)
+}
+
+// removeFunc deletes the values of m for which del returns true
+func removeFunc[K comparable, V any](d *memoryData, m map[K]V, del func(V) bool) {
+	for k, v := range m {
+		if del(v) {
+			remove(d, m, k)
+		}
+	}
+}
+
+// logUndo records how to restore the value of k in m, unless no transaction
+// is running
+func logUndo[K comparable, V any](d *memoryData, m map[K]V, k K) {
+	if d.undo == nil {
+		return
+	}
+	old, ok := m[k]
+	d.undo = append(d.undo, func() {
+		if ok {
+			m[k] = old
+		} else {
+			delete(m, k)
+		}
+	})
+}
+
+// now returns the current time to the microsecond, as Postgres stores it,
+// and later than any time returned before so that records keep the order
+// they were written in
+func (d *memoryData) now() time.Time {
+	t := time.Now().UTC().Truncate(time.Microsecond)
+	if !t.After(d.lastTime) {
+		t = d.lastTime.Add(time.Microsecond)
+	}
+	d.lastTime = t
+	return t
+}
+
+// check returns errConstraint described by message unless ok
+func check(ok bool, message string) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", errConstraint, message)
+}
+
+// within reports whether v lies between min and max, bounds of 0 not being
+// applied
+func within(v, min, max int) bool {
+	return (min <= 0 || v >= min) && (max <= 0 || v <= max)
+}
+
+// checkEstateSize checks the dimensions of an estate
+func checkEstateSize(width, length int) error {
+	return check(width >= 1 && width <= maxEstateSize && length >= 1 && length <= maxEstateSize,
+		fmt.Sprintf("estate dimensions %dx%d outside 1 to %d", width, length, maxEstateSize))
+}
+
+// CreateEstate creates a new estate in memory
+func (r *memoryRepository) CreateEstate(ctx context.Context, width, length int) (uuid.UUID, error) {
+	defer r.lock()()
+
+	if err := checkEstateSize(width, length); err != nil {
+		return uuid.Nil, err
+	}
+	id := uuid.New()
+	put(r.data, r.data.estates, id, Estate{ID: id, Width: width, Length: length, CreatedAt: r.data.now()})
+	return id, nil
+}
+
+// GetEstate retrieves an estate by ID
+func (r *memoryRepository) GetEstate(ctx context.Context, id uuid.UUID) (width, length int, err error) {
+	defer r.lock()()
+
+	estate, ok := r.data.estates[id]
+	if !ok {
+		return 0, 0, pgx.ErrNoRows
+	}
+	return estate.Width, estate.Length, nil
+}
+
+// LockEstate retrieves an estate like GetEstate. Transactions run one at a
+// time, so the estate cannot change until the end of the transaction anyway.
+func (r *memoryRepository) LockEstate(ctx context.Context, id uuid.UUID) (width, length int, err error) {
+	return r.GetEstate(ctx, id)
+}
+
+// compareEstates orders estates by creation time or area, and then by ID
+func compareEstates(a, b Estate, byArea bool) int {
+	if byArea {
+		if c := cmp.Compare(int64(a.Width)*int64(a.Length), int64(b.Width)*int64(b.Length)); c != 0 {
+			return c
+		}
+	} else if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return compareIDs(a.ID, b.ID)
+}
+
+// QueryEstates retrieves a page of estates ordered by the sort key and ID
+func (r *memoryRepository) QueryEstates(ctx context.Context, query EstateQuery) ([]Estate, error) {
+	defer r.lock()()
+
+	direction := 1
+	if query.Descending {
+		direction = -1
+	}
+
+	var estates []Estate
+	for _, estate := range r.data.estates {
+		area := int64(estate.Width) * int64(estate.Length)
+		if !within(estate.Width, query.MinWidth, query.MaxWidth) ||
+			!within(estate.Length, query.MinLength, query.MaxLength) ||
+			(query.MinArea > 0 && area < query.MinArea) ||
+			(query.MaxArea > 0 && area > query.MaxArea) {
+			continue
+		}
+		if query.After != nil && compareEstates(estate, *query.After, query.SortByArea)*direction <= 0 {
+			continue
+		}
+		if query.WithTreeCount {
+			estate.TreeCount = r.data.treeCount(estate.ID)
+		}
+		estates = append(estates, estate)
+	}
+
+	sort.Slice(estates, func(i, j int) bool {
+		return compareEstates(estates[i], estates[j], query.SortByArea)*direction < 0
+	})
+	if query.Limit == 0 {
+		return estates, nil
+	}
+	return firstN(estates, query.Limit), nil
+}
+
+// treeCount returns the number of trees of an estate
+func (d *memoryData) treeCount(estateID uuid.UUID) int {
+	count := 0
+	for _, tree := range d.trees {
+		if tree.EstateID == estateID {
+			count++
+		}
+	}
+	return count
+}
+
+// GetEstateDetail retrieves an estate by ID, with the number of trees
+// planted on it
+func (r *memoryRepository) GetEstateDetail(ctx context.Context, id uuid.UUID) (Estate, error) {
+	defer r.lock()()
+
+	estate, ok := r.data.estates[id]
+	if !ok {
+		return Estate{}, pgx.ErrNoRows
+	}
+	return Estate{ID: estate.ID, Width: estate.Width, Length: estate.Length, TreeCount: r.data.treeCount(id)}, nil
+}
+
+// ResizeEstate changes the dimensions of an estate. Trees, zones and obstacles
+// left outside the new dimensions are removed if prune is set, otherwise the
+// estate is left as is and ErrTreesOutOfBounds, ErrZonesOutOfBounds or
+// ErrObstaclesOutOfBounds is returned. It returns the number of trees removed,
+// or pgx.ErrNoRows if there is no such estate.
+func (r *memoryRepository) ResizeEstate(ctx context.Context, id uuid.UUID, width, length int, prune bool) (int, error) {
+	defer r.lock()()
+
+	estate, ok := r.data.estates[id]
+	if !ok {
+		return 0, pgx.ErrNoRows
+	}
+
+	var outside []Tree
+	for _, tree := range r.data.trees {
+		if tree.EstateID == id && (tree.X > width || tree.Y > length) {
+			outside = append(outside, tree)
+		}
+	}
+	if len(outside) > 0 && !prune {
+		return 0, ErrTreesOutOfBounds
+	}
+	zoneOutside := func(zone Zone) bool {
+		return zone.EstateID == id && slices.ContainsFunc(zone.Vertices, func(v Point) bool { return v.X > width || v.Y > length })
+	}
+	for _, zone := range r.data.zones {
+		if zoneOutside(zone) && !prune {
+			return 0, ErrZonesOutOfBounds
+		}
+	}
+	obstacleOutside := func(obstacle Obstacle) bool {
+		return obstacle.EstateID == id && (obstacle.XMax > width || obstacle.YMax > length)
+	}
+	for _, obstacle := range r.data.obstacles {
+		if obstacleOutside(obstacle) && !prune {
+			return 0, ErrObstaclesOutOfBounds
+		}
+	}
+	if err := checkEstateSize(width, length); err != nil {
+		return 0, err
+	}
+
+	for _, tree := range outside {
+		r.data.deleteTree(tree)
+	}
+	removeFunc(r.data, r.data.zones, zoneOutside)
+	removeFunc(r.data, r.data.obstacles, obstacleOutside)
+	estate.Width, estate.Length = width, length
+	put(r.data, r.data.estates, id, estate)
+	return len(outside), nil
+}
+
+// DeleteEstate deletes an estate along with its trees, zones, obstacles and
+// drone plan jobs. It returns pgx.ErrNoRows if there is no such estate.
+func (r *memoryRepository) DeleteEstate(ctx context.Context, id uuid.UUID) error {
+	defer r.lock()()
+
+	if _, ok := r.data.estates[id]; !ok {
+		return pgx.ErrNoRows
+	}
+	for _, tree := range r.data.trees {
+		if tree.EstateID == id {
+			r.data.deleteTree(tree)
+		}
+	}
+	removeFunc(r.data, r.data.zones, func(zone Zone) bool { return zone.EstateID == id })
+	removeFunc(r.data, r.data.obstacles, func(obstacle Obstacle) bool { return obstacle.EstateID == id })
+	removeFunc(r.data, r.data.jobs, func(job Job) bool { return job.EstateID == id })
+	remove(r.data, r.data.estates, id)
+	return nil
+}
+
+// plot returns the plot a tree stands on
+func (tree Tree) plot() memoryPlot {
+	return memoryPlot{estateID: tree.EstateID, x: tree.X, y: tree.Y}
+}
+
+// checkTree checks the position, height and health of a tree, and that its
+// estate exists
+func (d *memoryData) checkTree(tree Tree) error {
+	if err := check(tree.X >= 1 && tree.Y >= 1, fmt.Sprintf("tree position (%d, %d) outside estate", tree.X, tree.Y)); err != nil {
+		return err
+	}
+	if err := check(tree.Height >= 1 && tree.Height <= 30, fmt.Sprintf("tree height %d outside 1 to 30", tree.Height)); err != nil {
+		return err
+	}
+	switch tree.Health {
+	case "", TreeHealthHealthy, TreeHealthDiseased, TreeHealthDead:
+	default:
+		return check(false, fmt.Sprintf("unknown tree health %q", tree.Health))
+	}
+	_, ok := d.estates[tree.EstateID]
+	return check(ok, "estate does not exist")
+}
+
+// storedTree returns a tree as it is stored: with its planting date only, its
+// own copy of its tags and without zones
+func storedTree(tree Tree) Tree {
+	if tree.PlantedOn != nil {
+		plantedOn := time.Date(tree.PlantedOn.Year(), tree.PlantedOn.Month(), tree.PlantedOn.Day(), 0, 0, 0, 0, time.UTC)
+		tree.PlantedOn = &plantedOn
+	}
+	tree.Tags = append([]string{}, tree.Tags...)
+	tree.Zones = nil
+	return tree
+}
+
+// copyTree returns a copy of a stored tree that the caller may modify
+func copyTree(tree Tree) Tree {
+	if tree.PlantedOn != nil {
+		plantedOn := *tree.PlantedOn
+		tree.PlantedOn = &plantedOn
+	}
+	tree.Tags = append([]string{}, tree.Tags...)
+	return tree
+}
+
+// plantTree stores a new tree and starts its height history
+func (d *memoryData) plantTree(tree Tree) {
+	put(d, d.trees, tree.ID, tree)
+	put(d, d.plots, tree.plot(), tree.ID)
+	put(d, d.measurements, tree.ID, []TreeMeasurement{{Height: tree.Height, MeasuredAt: d.now()}})
+}
+
+// deleteTree removes a tree along with its height history
+func (d *memoryData) deleteTree(tree Tree) {
+	remove(d, d.trees, tree.ID)
+	remove(d, d.plots, tree.plot())
+	remove(d, d.measurements, tree.ID)
+}
+
+// estateTrees returns copies of the trees of an estate ordered by row and then
+// by column
+func (d *memoryData) estateTrees(estateID uuid.UUID) []Tree {
+	var trees []Tree
+	for _, tree := range d.trees {
+		if tree.EstateID == estateID {
+			trees = append(trees, copyTree(tree))
+		}
+	}
+	sort.Slice(trees, func(i, j int) bool {
+		if trees[i].Y != trees[j].Y {
+			return trees[i].Y < trees[j].Y
+		}
+		return trees[i].X < trees[j].X
+	})
+	return trees
+}
+
+// treeZones returns the names of the zones a tree stands in, in order
+func (d *memoryData) treeZones(tree Tree) []string {
+	var zones []Zone
+	for _, zone := range d.zones {
+		if zone.EstateID == tree.EstateID {
+			zones = append(zones, zone)
+		}
+	}
+	return zonesAt(zones, Point{X: tree.X, Y: tree.Y})
+}
+
+// matches reports whether a tree has the attributes selected by the filter
+func (f TreeFilter) matches(tree Tree) bool {
+	if f.Species != "" && tree.Species != f.Species {
+		return false
+	}
+	if f.Health != "" && tree.Health != f.Health {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !slices.Contains(tree.Tags, tag) {
+			return false
+		}
+	}
+	if !f.PlantedFrom.IsZero() && (tree.PlantedOn == nil || tree.PlantedOn.Before(f.PlantedFrom)) {
+		return false
+	}
+	if !f.PlantedTo.IsZero() && (tree.PlantedOn == nil || tree.PlantedOn.After(f.PlantedTo)) {
+		return false
+	}
+	return true
+}
+
+// CreateTree creates a new tree, returning ErrPlotOccupied if the plot
+// already has a tree
+func (r *memoryRepository) CreateTree(ctx context.Context, tree Tree) (uuid.UUID, error) {
+	defer r.lock()()
+
+	if err := r.data.checkTree(tree); err != nil {
+		return uuid.Nil, err
+	}
+	if _, ok := r.data.plots[tree.plot()]; ok {
+		return uuid.Nil, ErrPlotOccupied
+	}
+
+	tree.ID = uuid.New()
+	r.data.plantTree(storedTree(tree))
+	return tree.ID, nil
+}
+
+// GetTrees retrieves all trees for an estate
+func (r *memoryRepository) GetTrees(ctx context.Context, estateID uuid.UUID) ([]Tree, error) {
+	defer r.lock()()
+
+	return r.data.estateTrees(estateID), nil
+}
+
+// EachTree calls fn for every tree of an estate, ordered by row and then by
+// column. The trees are copied first, so fn may use the repository. It stops
+// at the first error returned by fn.
+func (r *memoryRepository) EachTree(ctx context.Context, estateID uuid.UUID, fn func(tree Tree) error) error {
+	unlock := r.lock()
+	trees := r.data.estateTrees(estateID)
+	unlock()
+
+	for _, tree := range trees {
+		if err := fn(tree); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareTrees orders trees by height if byHeight is set, and then by x and y
+func compareTrees(a, b Tree, byHeight bool) int {
+	if byHeight && a.Height != b.Height {
+		return cmp.Compare(a.Height, b.Height)
+	}
+	if a.X != b.X {
+		return cmp.Compare(a.X, b.X)
+	}
+	return cmp.Compare(a.Y, b.Y)
+}
+
+// ListTrees retrieves a page of the trees of an estate ordered by the sort
+// columns
+func (r *memoryRepository) ListTrees(ctx context.Context, estateID uuid.UUID, query TreeQuery) ([]Tree, error) {
+	defer r.lock()()
+
+	direction := 1
+	if query.Descending {
+		direction = -1
+	}
+
+	var trees []Tree
+	for _, tree := range r.data.trees {
+		if tree.EstateID != estateID ||
+			!within(tree.Height, query.MinHeight, query.MaxHeight) ||
+			!within(tree.X, query.XMin, query.XMax) ||
+			!within(tree.Y, query.YMin, query.YMax) ||
+			!query.Filter.matches(tree) {
+			continue
+		}
+		if query.After != nil && compareTrees(tree, *query.After, query.SortByHeight)*direction <= 0 {
+			continue
+		}
+		trees = append(trees, tree)
+	}
+
+	sort.Slice(trees, func(i, j int) bool {
+		return compareTrees(trees[i], trees[j], query.SortByHeight)*direction < 0
+	})
+	trees = firstN(trees, query.Limit)
+	for i, tree := range trees {
+		trees[i] = copyTree(tree)
+		trees[i].Zones = r.data.treeZones(tree)
+	}
+	return trees, nil
+}
+
+// GetTree retrieves a tree of an estate by ID
+func (r *memoryRepository) GetTree(ctx context.Context, estateID, treeID uuid.UUID) (Tree, error) {
+	defer r.lock()()
+
+	tree, ok := r.data.trees[treeID]
+	if !ok || tree.EstateID != estateID {
+		return Tree{}, pgx.ErrNoRows
+	}
+	found := copyTree(tree)
+	found.Zones = r.data.treeZones(tree)
+	return found, nil
+}
+
+// UpdateTree moves a tree of an estate and changes its height and attributes.
+// It returns pgx.ErrNoRows if there is no such tree and ErrPlotOccupied if
+// another tree stands on the target plot.
+func (r *memoryRepository) UpdateTree(ctx context.Context, tree Tree) error {
+	defer r.lock()()
+
+	old, ok := r.data.trees[tree.ID]
+	if !ok || old.EstateID != tree.EstateID {
+		return pgx.ErrNoRows
+	}
+	if err := r.data.checkTree(tree); err != nil {
+		return err
+	}
+	if other, ok := r.data.plots[tree.plot()]; ok && other != tree.ID {
+		return ErrPlotOccupied
+	}
+
+	remove(r.data, r.data.plots, old.plot())
+	put(r.data, r.data.trees, tree.ID, storedTree(tree))
+	put(r.data, r.data.plots, tree.plot(), tree.ID)
+	if tree.Height != old.Height {
+		put(r.data, r.data.measurements, tree.ID, append(r.data.measurements[tree.ID],
+			TreeMeasurement{Height: tree.Height, MeasuredAt: r.data.now()}))
+	}
+	return nil
+}
+
+// DeleteTree deletes a tree of an estate. It returns pgx.ErrNoRows if there
+// is no such tree.
+func (r *memoryRepository) DeleteTree(ctx context.Context, estateID, treeID uuid.UUID) error {
+	defer r.lock()()
+
+	tree, ok := r.data.trees[treeID]
+	if !ok || tree.EstateID != estateID {
+		return pgx.ErrNoRows
+	}
+	r.data.deleteTree(tree)
+	return nil
+}
+
+// CreateTrees plants many trees on an estate at once. Either all trees are
+// created or, if any plot already has a tree, none is and ErrPlotOccupied is
+// returned.
+func (r *memoryRepository) CreateTrees(ctx context.Context, estateID uuid.UUID, trees []Tree) (int, error) {
+	defer r.lock()()
+
+	// Check every tree before planting any, so that none is planted on error
+	planted := make(map[memoryPlot]bool, len(trees))
+	for _, tree := range trees {
+		tree.EstateID = estateID
+		if err := r.data.checkTree(tree); err != nil {
+			return 0, err
+		}
+		if _, ok := r.data.plots[tree.plot()]; ok || planted[tree.plot()] {
+			return 0, ErrPlotOccupied
+		}
+		planted[tree.plot()] = true
+	}
+
+	for _, tree := range trees {
+		tree.ID = uuid.New()
+		tree.EstateID = estateID
+		r.data.plantTree(storedTree(tree))
+	}
+	return len(trees), nil
+}
+
+// OccupiedPlots returns those of the given plots of an estate that have a
+// tree, in the order they are given
+func (r *memoryRepository) OccupiedPlots(ctx context.Context, estateID uuid.UUID, plots []Point) ([]Point, error) {
+	defer r.lock()()
+
+	var occupied []Point
+	for _, plot := range plots {
+		if _, ok := r.data.plots[memoryPlot{estateID: estateID, x: plot.X, y: plot.Y}]; ok {
+			occupied = append(occupied, plot)
+		}
+	}
+	return occupied, nil
+}
+
+// GetTreeStats calculates the tree statistics of an estate. The percentiles
+// are interpolated like the median.
+func (r *memoryRepository) GetTreeStats(ctx context.Context, estateID uuid.UUID, query StatsQuery) (Stats, error) {
+	defer r.lock()()
+
+	zone, inZone := Zone{}, true
+	if query.ZoneID != uuid.Nil {
+		// An unknown zone holds no trees
+		zone, inZone = r.data.zones[query.ZoneID]
+	}
+
+	var heights []int
+	for _, tree := range r.data.trees {
+		if tree.EstateID == estateID && inZone &&
+			within(tree.X, query.XMin, query.XMax) &&
+			within(tree.Y, query.YMin, query.YMax) &&
+			(query.ZoneID == uuid.Nil || containsPoint(zone.Vertices, Point{X: tree.X, Y: tree.Y})) &&
+			query.Filter.matches(tree) {
+			heights = append(heights, tree.Height)
+		}
+	}
+	return heightStats(heights, query.Percentiles), nil
+}
+
+// GetGridStats calculates the tree statistics of each cell of a grid over an
+// estate. Cells without trees are left out.
+func (r *memoryRepository) GetGridStats(ctx context.Context, estateID uuid.UUID, query GridQuery) ([]CellStats, error) {
+	defer r.lock()()
+
+	if err := check(query.CellSize > 0, "cell size must be positive"); err != nil {
+		return nil, err
+	}
+
+	var trees []Tree
+	for _, tree := range r.data.trees {
+		if tree.EstateID == estateID &&
+			tree.X >= query.XMin && tree.X <= query.XMax && tree.Y >= query.YMin && tree.Y <= query.YMax &&
+			query.Filter.matches(tree) {
+			trees = append(trees, tree)
+		}
+	}
+	return cellStats(trees, query), nil
+}
+
+// ListTreeMeasurements retrieves the height history of a tree, oldest first
+func (r *memoryRepository) ListTreeMeasurements(ctx context.Context, treeID uuid.UUID) ([]TreeMeasurement, error) {
+	defer r.lock()()
+
+	measurements := r.data.measurements[treeID]
+	if len(measurements) == 0 {
+		return nil, nil
+	}
+	return slices.Clone(measurements), nil
+}
+
+// GetGrowthStats calculates how fast the trees of an estate grow, listing up
+// to limit of the fastest and of the slowest growing trees
+func (r *memoryRepository) GetGrowthStats(ctx context.Context, estateID uuid.UUID, limit int) (GrowthStats, error) {
+	defer r.lock()()
+
+	var growths []TreeGrowth
+	for _, tree := range r.data.trees {
+		if tree.EstateID != estateID {
+			continue
+		}
+		if growth, ok := treeGrowth(tree, r.data.measurements[tree.ID]); ok {
+			growths = append(growths, growth)
+		}
+	}
+	return growthStats(growths, limit), nil
+}
+
+// checkZone checks the name and shape of a zone, and that its estate exists
+func (d *memoryData) checkZone(zone Zone) error {
+	if err := check(utf8.RuneCountInString(zone.Name) >= 1 && utf8.RuneCountInString(zone.Name) <= 100,
+		"zone name must be 1 to 100 characters long"); err != nil {
+		return err
+	}
+	if err := check(zone.Shape == ZoneShapeRect || zone.Shape == ZoneShapePolygon,
+		fmt.Sprintf("unknown zone shape %q", zone.Shape)); err != nil {
+		return err
+	}
+	_, ok := d.estates[zone.EstateID]
+	return check(ok, "estate does not exist")
+}
+
+// nameTaken reports whether another zone of the estate of zone has its name
+func (d *memoryData) nameTaken(zone Zone) bool {
+	for _, other := range d.zones {
+		if other.ID != zone.ID && other.EstateID == zone.EstateID && other.Name == zone.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// copyZone returns a copy of a zone that the caller may modify
+func copyZone(zone Zone) Zone {
+	zone.Vertices = slices.Clone(zone.Vertices)
+	return zone
+}
+
+// CreateZone creates a new zone. It returns ErrZoneExists if the estate
+// already has a zone with the same name.
+func (r *memoryRepository) CreateZone(ctx context.Context, zone Zone) (uuid.UUID, error) {
+	defer r.lock()()
+
+	zone.ID = uuid.New()
+	if err := r.data.checkZone(zone); err != nil {
+		return uuid.Nil, err
+	}
+	if r.data.nameTaken(zone) {
+		return uuid.Nil, ErrZoneExists
+	}
+
+	zone.CreatedAt = r.data.now()
+	put(r.data, r.data.zones, zone.ID, copyZone(zone))
+	return zone.ID, nil
+}
+
+// GetZone retrieves a zone of an estate by ID
+func (r *memoryRepository) GetZone(ctx context.Context, estateID, zoneID uuid.UUID) (Zone, error) {
+	defer r.lock()()
+
+	zone, ok := r.data.zones[zoneID]
+	if !ok || zone.EstateID != estateID {
+		return Zone{}, pgx.ErrNoRows
+	}
+	return copyZone(zone), nil
+}
+
+// GetZoneByName retrieves a zone of an estate by name
+func (r *memoryRepository) GetZoneByName(ctx context.Context, estateID uuid.UUID, name string) (Zone, error) {
+	defer r.lock()()
+
+	for _, zone := range r.data.zones {
+		if zone.EstateID == estateID && zone.Name == name {
+			return copyZone(zone), nil
+		}
+	}
+	return Zone{}, pgx.ErrNoRows
+}
+
+// ListZones retrieves all zones of an estate, ordered by name
+func (r *memoryRepository) ListZones(ctx context.Context, estateID uuid.UUID) ([]Zone, error) {
+	defer r.lock()()
+
+	var zones []Zone
+	for _, zone := range r.data.zones {
+		if zone.EstateID == estateID {
+			zones = append(zones, copyZone(zone))
+		}
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Name < zones[j].Name })
+	return zones, nil
+}
+
+// UpdateZone renames and reshapes a zone of an estate. It returns
+// pgx.ErrNoRows if there is no such zone and ErrZoneExists if another zone of
+// the estate has the new name.
+func (r *memoryRepository) UpdateZone(ctx context.Context, zone Zone) error {
+	defer r.lock()()
+
+	old, ok := r.data.zones[zone.ID]
+	if !ok || old.EstateID != zone.EstateID {
+		return pgx.ErrNoRows
+	}
+	if err := r.data.checkZone(zone); err != nil {
+		return err
+	}
+	if r.data.nameTaken(zone) {
+		return ErrZoneExists
+	}
+
+	zone.CreatedAt = old.CreatedAt
+	put(r.data, r.data.zones, zone.ID, copyZone(zone))
+	return nil
+}
+
+// DeleteZone deletes a zone of an estate. The trees in it are left alone. It
+// returns pgx.ErrNoRows if there is no such zone.
+func (r *memoryRepository) DeleteZone(ctx context.Context, estateID, zoneID uuid.UUID) error {
+	defer r.lock()()
+
+	zone, ok := r.data.zones[zoneID]
+	if !ok || zone.EstateID != estateID {
+		return pgx.ErrNoRows
+	}
+	remove(r.data, r.data.zones, zoneID)
+	return nil
+}
+
+// CreateObstacle creates a new obstacle
+func (r *memoryRepository) CreateObstacle(ctx context.Context, obstacle Obstacle) (uuid.UUID, error) {
+	defer r.lock()()
+
+	if err := check(obstacle.XMin >= 1 && obstacle.YMin >= 1 && obstacle.XMin <= obstacle.XMax && obstacle.YMin <= obstacle.YMax,
+		"obstacle must cover a rectangle of plots"); err != nil {
+		return uuid.Nil, err
+	}
+	if obstacle.Impassable {
+		obstacle.MinAltitude = 0
+	} else if err := check(obstacle.MinAltitude >= 1, "obstacle minimum altitude must be positive"); err != nil {
+		return uuid.Nil, err
+	}
+	if _, ok := r.data.estates[obstacle.EstateID]; !ok {
+		return uuid.Nil, check(false, "estate does not exist")
+	}
+
+	obstacle.ID = uuid.New()
+	obstacle.CreatedAt = r.data.now()
+	put(r.data, r.data.obstacles, obstacle.ID, obstacle)
+	return obstacle.ID, nil
+}
+
+// ListObstacles retrieves all obstacles of an estate, in the order they were
+// created
+func (r *memoryRepository) ListObstacles(ctx context.Context, estateID uuid.UUID) ([]Obstacle, error) {
+	defer r.lock()()
+
+	var obstacles []Obstacle
+	for _, obstacle := range r.data.obstacles {
+		if obstacle.EstateID == estateID {
+			obstacles = append(obstacles, obstacle)
+		}
+	}
+	sort.Slice(obstacles, func(i, j int) bool {
+		if c := obstacles[i].CreatedAt.Compare(obstacles[j].CreatedAt); c != 0 {
+			return c < 0
+		}
+		return compareIDs(obstacles[i].ID, obstacles[j].ID) < 0
+	})
+	return obstacles, nil
+}
+
+// DeleteObstacle deletes an obstacle of an estate. It returns pgx.ErrNoRows
+// if there is no such obstacle.
+func (r *memoryRepository) DeleteObstacle(ctx context.Context, estateID, obstacleID uuid.UUID) error {
+	defer r.lock()()
+
+	obstacle, ok := r.data.obstacles[obstacleID]
+	if !ok || obstacle.EstateID != estateID {
+		return pgx.ErrNoRows
+	}
+	remove(r.data, r.data.obstacles, obstacleID)
+	return nil
+}
+
+// copyJob returns a copy of a job that the caller may modify
+func copyJob(job Job) Job {
+	job.Options = bytes.Clone(job.Options)
+	job.Result = bytes.Clone(job.Result)
+	return job
+}
+
+// CreateJob creates a new queued drone plan job
+func (r *memoryRepository) CreateJob(ctx context.Context, estateID uuid.UUID, options []byte) (uuid.UUID, error) {
+	defer r.lock()()
+
+	if err := check(json.Valid(options), "job options must be JSON"); err != nil {
+		return uuid.Nil, err
+	}
+	if _, ok := r.data.estates[estateID]; !ok {
+		return uuid.Nil, check(false, "estate does not exist")
+	}
+
+	now := r.data.now()
+	job := Job{ID: uuid.New(), EstateID: estateID, Status: JobStatusQueued, Options: options, CreatedAt: now, UpdatedAt: now}
+	put(r.data, r.data.jobs, job.ID, copyJob(job))
+	return job.ID, nil
+}
+
+// GetJob retrieves a drone plan job by ID
+func (r *memoryRepository) GetJob(ctx context.Context, id uuid.UUID) (Job, error) {
+	defer r.lock()()
+
+	job, ok := r.data.jobs[id]
+	if !ok {
+		return Job{}, pgx.ErrNoRows
+	}
+	return copyJob(job), nil
+}
+
+// ClaimJob marks the oldest queued job as running and returns it. It returns
+// pgx.ErrNoRows if no job is queued.
+func (r *memoryRepository) ClaimJob(ctx context.Context) (Job, error) {
+	defer r.lock()()
+
+	var (
+		oldest Job
+		found  bool
+	)
+	for _, job := range r.data.jobs {
+		if job.Status != JobStatusQueued {
+			continue
+		}
+		if !found || job.CreatedAt.Before(oldest.CreatedAt) ||
+			(job.CreatedAt.Equal(oldest.CreatedAt) && compareIDs(job.ID, oldest.ID) < 0) {
+			oldest, found = job, true
+		}
+	}
+	if !found {
+		return Job{}, pgx.ErrNoRows
+	}
+
+	oldest.Status = JobStatusRunning
+	oldest.UpdatedAt = r.data.now()
+	put(r.data, r.data.jobs, oldest.ID, oldest)
+	return copyJob(oldest), nil
+}
+
+// UpdateJobProgress records the progress of a running job. It reports whether
+// the job is still running, which it is not once it has been cancelled.
+func (r *memoryRepository) UpdateJobProgress(ctx context.Context, id uuid.UUID, progress int) (bool, error) {
+	defer r.lock()()
+
+	job, ok := r.data.jobs[id]
+	if !ok || job.Status != JobStatusRunning {
+		return false, nil
+	}
+	if err := check(progress >= 0 && progress <= 100, fmt.Sprintf("job progress %d outside 0 to 100", progress)); err != nil {
+		return false, err
+	}
+
+	job.Progress = progress
+	job.UpdatedAt = r.data.now()
+	put(r.data, r.data.jobs, id, job)
+	return true, nil
+}
+
+// FinishJob records the outcome of a running job. A job cancelled in the
+// meantime stays cancelled.
+func (r *memoryRepository) FinishJob(ctx context.Context, id uuid.UUID, status string, result []byte, errMsg string) error {
+	defer r.lock()()
+
+	job, ok := r.data.jobs[id]
+	if !ok || job.Status != JobStatusRunning {
+		return nil
+	}
+	switch status {
+	case JobStatusQueued, JobStatusRunning, JobStatusSucceeded, JobStatusFailed, JobStatusCancelled:
+	default:
+		return check(false, fmt.Sprintf("unknown job status %q", status))
+	}
+	if err := check(result == nil || json.Valid(result), "job result must be JSON"); err != nil {
+		return err
+	}
+
+	job.Status = status
+	job.Result = bytes.Clone(result)
+	job.Error = errMsg
+	if status == JobStatusSucceeded {
+		job.Progress = 100
+	}
+	job.UpdatedAt = r.data.now()
+	put(r.data, r.data.jobs, id, job)
+	return nil
+}
+
+// CancelJob marks a queued or running job as cancelled. It returns
+// pgx.ErrNoRows if there is no such job or the job has already finished.
+func (r *memoryRepository) CancelJob(ctx context.Context, id uuid.UUID) error {
+	defer r.lock()()
+
+	job, ok := r.data.jobs[id]
+	if !ok || (job.Status != JobStatusQueued && job.Status != JobStatusRunning) {
+		return pgx.ErrNoRows
+	}
+
+	job.Status = JobStatusCancelled
+	job.UpdatedAt = r.data.now()
+	put(r.data, r.data.jobs, id, job)
+	return nil
+}
+
+// RequeueRunningJobs puts running jobs back into the queue
+func (r *memoryRepository) RequeueRunningJobs(ctx context.Context) error {
+	defer r.lock()()
+
+	for id, job := range r.data.jobs {
+		if job.Status == JobStatusRunning {
+			job.Status = JobStatusQueued
+			job.Progress = 0
+			job.UpdatedAt = r.data.now()
+			put(r.data, r.data.jobs, id, job)
+		}
+	}
+	return nil
+}
+
+// RequeueJob puts a running job back into the queue. A job cancelled in the
+// meantime stays cancelled.
+func (r *memoryRepository) RequeueJob(ctx context.Context, id uuid.UUID) error {
+	defer r.lock()()
+
+	job, ok := r.data.jobs[id]
+	if ok && job.Status == JobStatusRunning {
+		job.Status = JobStatusQueued
+		job.Progress = 0
+		job.UpdatedAt = r.data.now()
+		put(r.data, r.data.jobs, id, job)
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+package repository
+
+import "testing"
+
+func TestMemoryRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository { return NewMemoryRepository() })
+}
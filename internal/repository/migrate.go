@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationFiles holds the schema migrations, an up and a down script per
+// version named like 0001_create_estates.up.sql and 0001_create_estates.down.sql
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// sqliteMigrationFiles holds the schema migrations of SQLite databases, named
+// like migrationFiles
+//
+//go:embed sqlite_migrations/*.sql
+var sqliteMigrationFiles embed.FS
+
+// migrationLockID is the key of the advisory lock held while migrating, so
+// that servers started together apply every migration once
+const migrationLockID = 7_238_104_556
+
+// Migration is a versioned change of the database schema
+type Migration struct {
+	Version int
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus is a migration and when it was applied, nil if it is pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the schema migrations compiled into the server
+type Migrator struct {
+	db         migrationDB
+	migrations []Migration
+}
+
+// migrationDB is a database the Migrator applies migrations to
+type migrationDB interface {
+	// lock runs fn holding the migration lock of the database, passing it
+	// when each applied migration was applied
+	lock(ctx context.Context, fn func(apply applyFunc, done map[int]time.Time) error) error
+}
+
+// applyFunc runs the up script of a migration and records it as applied, or
+// runs its down script and removes the record if up is false
+type applyFunc func(ctx context.Context, migration Migration, up bool) error
+
+// NewMigrator creates a migrator of the given database
+func NewMigrator(db *pgxpool.Pool) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: postgresMigrationDB{db: db}, migrations: migrations}, nil
+}
+
+// NewSQLiteMigrator creates a migrator of the given SQLite database, opened
+// with OpenSQLite
+func NewSQLiteMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(sqliteMigrationFiles, "sqlite_migrations")
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: sqliteMigrationDB{db: db}, migrations: migrations}, nil
+}
+
+// Up applies the pending migrations in order of version, each in its own
+// transaction, and returns them
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.db.lock(ctx, func(apply applyFunc, done map[int]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if err := apply(ctx, migration, true); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the latest applied migration and returns it, or nil if no
+// migration is applied
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+	err := m.db.lock(ctx, func(apply applyFunc, done map[int]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if err := apply(ctx, migration, false); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = &migration
+			return nil
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every migration in order of version with when it was applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.db.lock(ctx, func(apply applyFunc, done map[int]time.Time) error {
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if appliedAt, ok := done[migration.Version]; ok {
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// postgresMigrationDB applies migrations to a Postgres database
+type postgresMigrationDB struct {
+	db *pgxpool.Pool
+}
+
+// lock runs fn on a connection holding the migration lock, an advisory lock
+// of the session
+func (p postgresMigrationDB) lock(ctx context.Context, fn func(apply applyFunc, done map[int]time.Time) error) error {
+	conn, err := p.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	// The lock belongs to the session, so it is taken and released on the
+	// same connection
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+
+	if _, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+	)`); err != nil {
+		return err
+	}
+
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	done := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return err
+		}
+		done[version] = appliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return fn(func(ctx context.Context, migration Migration, up bool) error {
+		if up {
+			return runMigration(ctx, conn, migration.up,
+				"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+		}
+		return runMigration(ctx, conn, migration.down,
+			"DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+	}, done)
+}
+
+// runMigration runs a migration script and records it in schema_migrations
+// in one transaction
+func runMigration(ctx context.Context, conn *pgxpool.Conn, script, record string, args ...any) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Without arguments the script is sent as is, so it may hold many statements
+	if _, err := tx.Exec(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// sqliteMigrationDB applies migrations to a SQLite database
+type sqliteMigrationDB struct {
+	db *sql.DB
+}
+
+// lock runs fn in a transaction, which holds the write lock of the database
+// from its start. Each migration is applied in a savepoint of it, and the
+// migrations applied before one fails are kept.
+func (s sqliteMigrationDB) lock(ctx context.Context, fn func(apply applyFunc, done map[int]time.Time) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at INTEGER NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	done := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt int64
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return err
+		}
+		done[version] = parseSQLiteTime(appliedAt)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	fnErr := fn(func(ctx context.Context, migration Migration, up bool) error {
+		if up {
+			return runSQLiteMigration(ctx, tx, migration.up,
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+				migration.Version, migration.Name, sqliteTime(time.Now()))
+		}
+		return runSQLiteMigration(ctx, tx, migration.down,
+			"DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+	}, done)
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return fnErr
+}
+
+// runSQLiteMigration runs a migration script and records it in
+// schema_migrations in a savepoint, which is rolled back if either fails
+func runSQLiteMigration(ctx context.Context, tx *sql.Tx, script, record string, args ...any) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT migration"); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, script)
+	if err == nil {
+		_, err = tx.ExecContext(ctx, record, args...)
+	}
+	if err != nil {
+		// Rolling back to a savepoint keeps it open, so it is released as well
+		tx.ExecContext(ctx, "ROLLBACK TO migration")
+		tx.ExecContext(ctx, "RELEASE migration")
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "RELEASE migration")
+	return err
+}
+
+// loadMigrations reads the migrations in a directory, sorted by version.
+// Every version must have both an up and a down script.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		prefix, name, named := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if entry.IsDir() || !ok || !named || err != nil || version < 1 || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		script, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, found := byVersion[version]
+		if !found {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.up = string(script)
+		} else {
+			migration.down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.up == "" || migration.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
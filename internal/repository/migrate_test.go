@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(script string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(script)} }
+
+	testCases := []struct {
+		name          string
+		files         fstest.MapFS
+		expected      []Migration
+		expectedError string
+	}{
+		{
+			name: "Sorted By Version",
+			files: fstest.MapFS{
+				"migrations/0010_add_zones.up.sql":     file("CREATE TABLE zones ();"),
+				"migrations/0010_add_zones.down.sql":   file("DROP TABLE zones;"),
+				"migrations/0002_add_trees.up.sql":     file("CREATE TABLE trees ();"),
+				"migrations/0002_add_trees.down.sql":   file("DROP TABLE trees;"),
+				"migrations/0001_add_estates.down.sql": file("DROP TABLE estates;"),
+				"migrations/0001_add_estates.up.sql":   file("CREATE TABLE estates ();"),
+			},
+			expected: []Migration{
+				{Version: 1, Name: "add_estates", up: "CREATE TABLE estates ();", down: "DROP TABLE estates;"},
+				{Version: 2, Name: "add_trees", up: "CREATE TABLE trees ();", down: "DROP TABLE trees;"},
+				{Version: 10, Name: "add_zones", up: "CREATE TABLE zones ();", down: "DROP TABLE zones;"},
+			},
+		},
+		{
+			name: "Missing Down Script",
+			files: fstest.MapFS{
+				"migrations/0001_add_estates.up.sql": file("CREATE TABLE estates ();"),
+			},
+			expectedError: "migration 0001_add_estates needs both an up and a down script",
+		},
+		{
+			name: "Invalid File Name",
+			files: fstest.MapFS{
+				"migrations/add_estates.up.sql": file("CREATE TABLE estates ();"),
+			},
+			expectedError: `invalid migration file name "add_estates.up.sql"`,
+		},
+		{
+			name: "Unknown Direction",
+			files: fstest.MapFS{
+				"migrations/0001_add_estates.sideways.sql": file("CREATE TABLE estates ();"),
+			},
+			expectedError: `invalid migration file name "0001_add_estates.sideways.sql"`,
+		},
+		{
+			name: "Version With Two Names",
+			files: fstest.MapFS{
+				"migrations/0001_add_estates.up.sql": file("CREATE TABLE estates ();"),
+				"migrations/0001_add_plots.down.sql": file("DROP TABLE plots;"),
+			},
+			expectedError: "migration 1 has two names, add_estates and add_plots",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tc.files, "migrations")
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, migrations)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files fs.FS
+		dir   string
+	}{
+		{name: "Postgres", files: migrationFiles, dir: "migrations"},
+		{name: "SQLite", files: sqliteMigrationFiles, dir: "sqlite_migrations"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tc.files, tc.dir)
+			assert.NoError(t, err)
+
+			// Versions are numbered from 1 without gaps, so that a missing file is noticed
+			for i, migration := range migrations {
+				assert.Equal(t, i+1, migration.Version, migration.Name)
+			}
+		})
+	}
+}
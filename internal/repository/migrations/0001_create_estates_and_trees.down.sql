@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS trees;
+DROP TABLE IF EXISTS estates;
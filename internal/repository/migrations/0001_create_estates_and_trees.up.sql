@@ -9,6 +9,9 @@ CREATE TABLE IF NOT EXISTS estates (
     created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
 );
 
+-- Estate listings sorted by creation time
+CREATE INDEX IF NOT EXISTS estates_created_at_id_idx ON estates (created_at, id);
+
 -- Create tree table
 CREATE TABLE IF NOT EXISTS trees (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -20,4 +23,7 @@ CREATE TABLE IF NOT EXISTS trees (
     -- Coordinate validation will be handled at application level
     -- Ensure only one tree per plot
     UNIQUE (estate_id, x, y)
-); 
\ No newline at end of file
+);
+
+-- Tree listings sorted or filtered by height
+CREATE INDEX IF NOT EXISTS trees_estate_id_height_idx ON trees (estate_id, height);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS drone_plan_jobs;
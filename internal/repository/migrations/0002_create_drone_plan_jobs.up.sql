@@ -0,0 +1,16 @@
+-- Create drone plan job table
+CREATE TABLE IF NOT EXISTS drone_plan_jobs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    estate_id UUID NOT NULL REFERENCES estates(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'cancelled')),
+    progress INTEGER NOT NULL DEFAULT 0 CHECK (progress BETWEEN 0 AND 100),
+    -- Plan options the job was submitted with, and the plan once it succeeded
+    options JSONB NOT NULL,
+    result JSONB,
+    error TEXT,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW()
+);
+
+-- Workers claim the oldest queued job first
+CREATE INDEX IF NOT EXISTS drone_plan_jobs_status_created_at_idx ON drone_plan_jobs (status, created_at);
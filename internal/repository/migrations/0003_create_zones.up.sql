@@ -0,0 +1,13 @@
+-- Create zone table
+CREATE TABLE IF NOT EXISTS zones (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    estate_id UUID NOT NULL REFERENCES estates(id) ON DELETE CASCADE,
+    name TEXT NOT NULL CHECK (length(name) BETWEEN 1 AND 100),
+    shape TEXT NOT NULL CHECK (shape IN ('rect', 'polygon')),
+    -- Vertices in plot coordinates; a plot belongs to the zone if it lies
+    -- inside or on the edge of the polygon, so trees are tagged by their x/y
+    area POLYGON NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    -- Zones are looked up by name within an estate
+    UNIQUE (estate_id, name)
+);
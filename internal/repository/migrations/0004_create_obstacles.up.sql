@@ -0,0 +1,21 @@
+-- Create obstacle table
+CREATE TABLE IF NOT EXISTS obstacles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    estate_id UUID NOT NULL REFERENCES estates(id) ON DELETE CASCADE,
+    -- Rectangle of plots covered by the obstacle, a single plot when the
+    -- minimum and maximum coincide
+    x_min INTEGER NOT NULL CHECK (x_min >= 1),
+    x_max INTEGER NOT NULL,
+    y_min INTEGER NOT NULL CHECK (y_min >= 1),
+    y_max INTEGER NOT NULL,
+    -- The drone either flies over the obstacle at min_altitude or above, or
+    -- cannot fly over it at all
+    min_altitude INTEGER CHECK (min_altitude >= 1),
+    impassable BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT NOW(),
+    CHECK (x_min <= x_max AND y_min <= y_max),
+    CHECK (impassable = (min_altitude IS NULL))
+);
+
+-- Every plan loads all obstacles of its estate
+CREATE INDEX IF NOT EXISTS obstacles_estate_id_idx ON obstacles (estate_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS trees_tags_idx;
+ALTER TABLE trees DROP COLUMN IF EXISTS tags;
+ALTER TABLE trees DROP COLUMN IF EXISTS health;
+ALTER TABLE trees DROP COLUMN IF EXISTS planted_on;
+ALTER TABLE trees DROP COLUMN IF EXISTS species;
@@ -0,0 +1,8 @@
+-- Optional tree attributes
+ALTER TABLE trees ADD COLUMN IF NOT EXISTS species TEXT;
+ALTER TABLE trees ADD COLUMN IF NOT EXISTS planted_on DATE;
+ALTER TABLE trees ADD COLUMN IF NOT EXISTS health TEXT CHECK (health IN ('healthy', 'diseased', 'dead'));
+ALTER TABLE trees ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- Tree listings and statistics filtered by tag
+CREATE INDEX IF NOT EXISTS trees_tags_idx ON trees USING GIN (tags);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trees_height_changed ON trees;
+DROP TRIGGER IF EXISTS trees_planted ON trees;
+DROP FUNCTION IF EXISTS record_tree_measurement();
+DROP TABLE IF EXISTS tree_measurements;
@@ -0,0 +1,30 @@
+-- Create tree measurement table, the history of the height of every tree
+CREATE TABLE IF NOT EXISTS tree_measurements (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tree_id UUID NOT NULL REFERENCES trees(id) ON DELETE CASCADE,
+    height INTEGER NOT NULL CHECK (height BETWEEN 1 AND 30),
+    measured_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Tree histories are read in the order they were measured
+CREATE INDEX IF NOT EXISTS tree_measurements_tree_id_measured_at_idx ON tree_measurements (tree_id, measured_at);
+
+-- Record the height a tree is planted with and every change of it, however
+-- the tree is written
+CREATE OR REPLACE FUNCTION record_tree_measurement() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO tree_measurements (tree_id, height) VALUES (NEW.id, NEW.height);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER trees_planted AFTER INSERT ON trees
+    FOR EACH ROW EXECUTE FUNCTION record_tree_measurement();
+
+CREATE OR REPLACE TRIGGER trees_height_changed AFTER UPDATE OF height ON trees
+    FOR EACH ROW WHEN (OLD.height IS DISTINCT FROM NEW.height) EXECUTE FUNCTION record_tree_measurement();
+
+-- Trees planted before the history was kept start it with their current height
+INSERT INTO tree_measurements (tree_id, height, measured_at)
+SELECT id, height, COALESCE(created_at, NOW()) FROM trees
+WHERE NOT EXISTS (SELECT 1 FROM tree_measurements m WHERE m.tree_id = trees.id);
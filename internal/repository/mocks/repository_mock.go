@@ -35,6 +35,20 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// WithTx mocks base method.
+func (m *MockRepository) WithTx(ctx context.Context, fn func(repository.Repository) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockRepositoryMockRecorder) WithTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockRepository)(nil).WithTx), ctx, fn)
+}
+
 // CreateEstate mocks base method.
 func (m *MockRepository) CreateEstate(ctx context.Context, width, length int) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -51,18 +65,18 @@ func (mr *MockRepositoryMockRecorder) CreateEstate(ctx, width, length interface{
 }
 
 // CreateTree mocks base method.
-func (m *MockRepository) CreateTree(ctx context.Context, estateID uuid.UUID, x, y, height int) (uuid.UUID, error) {
+func (m *MockRepository) CreateTree(ctx context.Context, tree repository.Tree) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTree", ctx, estateID, x, y, height)
+	ret := m.ctrl.Call(m, "CreateTree", ctx, tree)
 	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateTree indicates an expected call of CreateTree.
-func (mr *MockRepositoryMockRecorder) CreateTree(ctx, estateID, x, y, height interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) CreateTree(ctx, tree interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTree", reflect.TypeOf((*MockRepository)(nil).CreateTree), ctx, estateID, x, y, height)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTree", reflect.TypeOf((*MockRepository)(nil).CreateTree), ctx, tree)
 }
 
 // GetEstate mocks base method.
@@ -81,6 +95,22 @@ func (mr *MockRepositoryMockRecorder) GetEstate(ctx, id interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEstate", reflect.TypeOf((*MockRepository)(nil).GetEstate), ctx, id)
 }
 
+// LockEstate mocks base method.
+func (m *MockRepository) LockEstate(ctx context.Context, id uuid.UUID) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockEstate", ctx, id)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// LockEstate indicates an expected call of LockEstate.
+func (mr *MockRepositoryMockRecorder) LockEstate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockEstate", reflect.TypeOf((*MockRepository)(nil).LockEstate), ctx, id)
+}
+
 // GetTrees mocks base method.
 func (m *MockRepository) GetTrees(ctx context.Context, estateID uuid.UUID) ([]repository.Tree, error) {
 	m.ctrl.T.Helper()
@@ -94,4 +124,473 @@ func (m *MockRepository) GetTrees(ctx context.Context, estateID uuid.UUID) ([]re
 func (mr *MockRepositoryMockRecorder) GetTrees(ctx, estateID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrees", reflect.TypeOf((*MockRepository)(nil).GetTrees), ctx, estateID)
-} 
\ No newline at end of file
+} 
+
+// OccupiedPlots mocks base method.
+func (m *MockRepository) OccupiedPlots(ctx context.Context, estateID uuid.UUID, plots []repository.Point) ([]repository.Point, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OccupiedPlots", ctx, estateID, plots)
+	ret0, _ := ret[0].([]repository.Point)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OccupiedPlots indicates an expected call of OccupiedPlots.
+func (mr *MockRepositoryMockRecorder) OccupiedPlots(ctx, estateID, plots interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OccupiedPlots", reflect.TypeOf((*MockRepository)(nil).OccupiedPlots), ctx, estateID, plots)
+}
+
+// CreateJob mocks base method.
+func (m *MockRepository) CreateJob(ctx context.Context, estateID uuid.UUID, options []byte) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJob", ctx, estateID, options)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateJob indicates an expected call of CreateJob.
+func (mr *MockRepositoryMockRecorder) CreateJob(ctx, estateID, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJob", reflect.TypeOf((*MockRepository)(nil).CreateJob), ctx, estateID, options)
+}
+
+// GetJob mocks base method.
+func (m *MockRepository) GetJob(ctx context.Context, id uuid.UUID) (repository.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", ctx, id)
+	ret0, _ := ret[0].(repository.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockRepositoryMockRecorder) GetJob(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockRepository)(nil).GetJob), ctx, id)
+}
+
+// ClaimJob mocks base method.
+func (m *MockRepository) ClaimJob(ctx context.Context) (repository.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimJob", ctx)
+	ret0, _ := ret[0].(repository.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimJob indicates an expected call of ClaimJob.
+func (mr *MockRepositoryMockRecorder) ClaimJob(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimJob", reflect.TypeOf((*MockRepository)(nil).ClaimJob), ctx)
+}
+
+// UpdateJobProgress mocks base method.
+func (m *MockRepository) UpdateJobProgress(ctx context.Context, id uuid.UUID, progress int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateJobProgress", ctx, id, progress)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateJobProgress indicates an expected call of UpdateJobProgress.
+func (mr *MockRepositoryMockRecorder) UpdateJobProgress(ctx, id, progress interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateJobProgress", reflect.TypeOf((*MockRepository)(nil).UpdateJobProgress), ctx, id, progress)
+}
+
+// FinishJob mocks base method.
+func (m *MockRepository) FinishJob(ctx context.Context, id uuid.UUID, status string, result []byte, errMsg string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishJob", ctx, id, status, result, errMsg)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FinishJob indicates an expected call of FinishJob.
+func (mr *MockRepositoryMockRecorder) FinishJob(ctx, id, status, result, errMsg interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishJob", reflect.TypeOf((*MockRepository)(nil).FinishJob), ctx, id, status, result, errMsg)
+}
+
+// CancelJob mocks base method.
+func (m *MockRepository) CancelJob(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelJob", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelJob indicates an expected call of CancelJob.
+func (mr *MockRepositoryMockRecorder) CancelJob(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelJob", reflect.TypeOf((*MockRepository)(nil).CancelJob), ctx, id)
+}
+
+// RequeueRunningJobs mocks base method.
+func (m *MockRepository) RequeueRunningJobs(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueRunningJobs", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequeueRunningJobs indicates an expected call of RequeueRunningJobs.
+func (mr *MockRepositoryMockRecorder) RequeueRunningJobs(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueRunningJobs", reflect.TypeOf((*MockRepository)(nil).RequeueRunningJobs), ctx)
+}
+
+// RequeueJob mocks base method.
+func (m *MockRepository) RequeueJob(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueJob", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequeueJob indicates an expected call of RequeueJob.
+func (mr *MockRepositoryMockRecorder) RequeueJob(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueJob", reflect.TypeOf((*MockRepository)(nil).RequeueJob), ctx, id)
+}
+
+// GetTree mocks base method.
+func (m *MockRepository) GetTree(ctx context.Context, estateID, treeID uuid.UUID) (repository.Tree, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTree", ctx, estateID, treeID)
+	ret0, _ := ret[0].(repository.Tree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTree indicates an expected call of GetTree.
+func (mr *MockRepositoryMockRecorder) GetTree(ctx, estateID, treeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTree", reflect.TypeOf((*MockRepository)(nil).GetTree), ctx, estateID, treeID)
+}
+
+// UpdateTree mocks base method.
+func (m *MockRepository) UpdateTree(ctx context.Context, tree repository.Tree) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTree", ctx, tree)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTree indicates an expected call of UpdateTree.
+func (mr *MockRepositoryMockRecorder) UpdateTree(ctx, tree interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTree", reflect.TypeOf((*MockRepository)(nil).UpdateTree), ctx, tree)
+}
+
+// DeleteTree mocks base method.
+func (m *MockRepository) DeleteTree(ctx context.Context, estateID, treeID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTree", ctx, estateID, treeID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTree indicates an expected call of DeleteTree.
+func (mr *MockRepositoryMockRecorder) DeleteTree(ctx, estateID, treeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTree", reflect.TypeOf((*MockRepository)(nil).DeleteTree), ctx, estateID, treeID)
+}
+
+// GetEstateDetail mocks base method.
+func (m *MockRepository) GetEstateDetail(ctx context.Context, id uuid.UUID) (repository.Estate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEstateDetail", ctx, id)
+	ret0, _ := ret[0].(repository.Estate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEstateDetail indicates an expected call of GetEstateDetail.
+func (mr *MockRepositoryMockRecorder) GetEstateDetail(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEstateDetail", reflect.TypeOf((*MockRepository)(nil).GetEstateDetail), ctx, id)
+}
+
+// ResizeEstate mocks base method.
+func (m *MockRepository) ResizeEstate(ctx context.Context, id uuid.UUID, width, length int, prune bool) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResizeEstate", ctx, id, width, length, prune)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResizeEstate indicates an expected call of ResizeEstate.
+func (mr *MockRepositoryMockRecorder) ResizeEstate(ctx, id, width, length, prune interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeEstate", reflect.TypeOf((*MockRepository)(nil).ResizeEstate), ctx, id, width, length, prune)
+}
+
+// DeleteEstate mocks base method.
+func (m *MockRepository) DeleteEstate(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEstate", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEstate indicates an expected call of DeleteEstate.
+func (mr *MockRepositoryMockRecorder) DeleteEstate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEstate", reflect.TypeOf((*MockRepository)(nil).DeleteEstate), ctx, id)
+}
+
+// CreateTrees mocks base method.
+func (m *MockRepository) CreateTrees(ctx context.Context, estateID uuid.UUID, trees []repository.Tree) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTrees", ctx, estateID, trees)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTrees indicates an expected call of CreateTrees.
+func (mr *MockRepositoryMockRecorder) CreateTrees(ctx, estateID, trees interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTrees", reflect.TypeOf((*MockRepository)(nil).CreateTrees), ctx, estateID, trees)
+}
+
+// EachTree mocks base method.
+func (m *MockRepository) EachTree(ctx context.Context, estateID uuid.UUID, fn func(tree repository.Tree) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EachTree", ctx, estateID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EachTree indicates an expected call of EachTree.
+func (mr *MockRepositoryMockRecorder) EachTree(ctx, estateID, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EachTree", reflect.TypeOf((*MockRepository)(nil).EachTree), ctx, estateID, fn)
+}
+
+// ListTrees mocks base method.
+func (m *MockRepository) ListTrees(ctx context.Context, estateID uuid.UUID, query repository.TreeQuery) ([]repository.Tree, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrees", ctx, estateID, query)
+	ret0, _ := ret[0].([]repository.Tree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrees indicates an expected call of ListTrees.
+func (mr *MockRepositoryMockRecorder) ListTrees(ctx, estateID, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrees", reflect.TypeOf((*MockRepository)(nil).ListTrees), ctx, estateID, query)
+}
+
+// QueryEstates mocks base method.
+func (m *MockRepository) QueryEstates(ctx context.Context, query repository.EstateQuery) ([]repository.Estate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryEstates", ctx, query)
+	ret0, _ := ret[0].([]repository.Estate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryEstates indicates an expected call of QueryEstates.
+func (mr *MockRepositoryMockRecorder) QueryEstates(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryEstates", reflect.TypeOf((*MockRepository)(nil).QueryEstates), ctx, query)
+}
+
+// GetTreeStats mocks base method.
+func (m *MockRepository) GetTreeStats(ctx context.Context, estateID uuid.UUID, query repository.StatsQuery) (repository.Stats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTreeStats", ctx, estateID, query)
+	ret0, _ := ret[0].(repository.Stats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTreeStats indicates an expected call of GetTreeStats.
+func (mr *MockRepositoryMockRecorder) GetTreeStats(ctx, estateID, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTreeStats", reflect.TypeOf((*MockRepository)(nil).GetTreeStats), ctx, estateID, query)
+}
+
+// GetGridStats mocks base method.
+func (m *MockRepository) GetGridStats(ctx context.Context, estateID uuid.UUID, query repository.GridQuery) ([]repository.CellStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGridStats", ctx, estateID, query)
+	ret0, _ := ret[0].([]repository.CellStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGridStats indicates an expected call of GetGridStats.
+func (mr *MockRepositoryMockRecorder) GetGridStats(ctx, estateID, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGridStats", reflect.TypeOf((*MockRepository)(nil).GetGridStats), ctx, estateID, query)
+}
+
+// CreateZone mocks base method.
+func (m *MockRepository) CreateZone(ctx context.Context, zone repository.Zone) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateZone", ctx, zone)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateZone indicates an expected call of CreateZone.
+func (mr *MockRepositoryMockRecorder) CreateZone(ctx, zone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateZone", reflect.TypeOf((*MockRepository)(nil).CreateZone), ctx, zone)
+}
+
+// GetZone mocks base method.
+func (m *MockRepository) GetZone(ctx context.Context, estateID, zoneID uuid.UUID) (repository.Zone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetZone", ctx, estateID, zoneID)
+	ret0, _ := ret[0].(repository.Zone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetZone indicates an expected call of GetZone.
+func (mr *MockRepositoryMockRecorder) GetZone(ctx, estateID, zoneID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetZone", reflect.TypeOf((*MockRepository)(nil).GetZone), ctx, estateID, zoneID)
+}
+
+// GetZoneByName mocks base method.
+func (m *MockRepository) GetZoneByName(ctx context.Context, estateID uuid.UUID, name string) (repository.Zone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetZoneByName", ctx, estateID, name)
+	ret0, _ := ret[0].(repository.Zone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetZoneByName indicates an expected call of GetZoneByName.
+func (mr *MockRepositoryMockRecorder) GetZoneByName(ctx, estateID, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetZoneByName", reflect.TypeOf((*MockRepository)(nil).GetZoneByName), ctx, estateID, name)
+}
+
+// ListZones mocks base method.
+func (m *MockRepository) ListZones(ctx context.Context, estateID uuid.UUID) ([]repository.Zone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListZones", ctx, estateID)
+	ret0, _ := ret[0].([]repository.Zone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListZones indicates an expected call of ListZones.
+func (mr *MockRepositoryMockRecorder) ListZones(ctx, estateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListZones", reflect.TypeOf((*MockRepository)(nil).ListZones), ctx, estateID)
+}
+
+// UpdateZone mocks base method.
+func (m *MockRepository) UpdateZone(ctx context.Context, zone repository.Zone) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateZone", ctx, zone)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateZone indicates an expected call of UpdateZone.
+func (mr *MockRepositoryMockRecorder) UpdateZone(ctx, zone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateZone", reflect.TypeOf((*MockRepository)(nil).UpdateZone), ctx, zone)
+}
+
+// DeleteZone mocks base method.
+func (m *MockRepository) DeleteZone(ctx context.Context, estateID, zoneID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteZone", ctx, estateID, zoneID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteZone indicates an expected call of DeleteZone.
+func (mr *MockRepositoryMockRecorder) DeleteZone(ctx, estateID, zoneID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteZone", reflect.TypeOf((*MockRepository)(nil).DeleteZone), ctx, estateID, zoneID)
+}
+
+// CreateObstacle mocks base method.
+func (m *MockRepository) CreateObstacle(ctx context.Context, obstacle repository.Obstacle) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateObstacle", ctx, obstacle)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateObstacle indicates an expected call of CreateObstacle.
+func (mr *MockRepositoryMockRecorder) CreateObstacle(ctx, obstacle interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateObstacle", reflect.TypeOf((*MockRepository)(nil).CreateObstacle), ctx, obstacle)
+}
+
+// ListObstacles mocks base method.
+func (m *MockRepository) ListObstacles(ctx context.Context, estateID uuid.UUID) ([]repository.Obstacle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListObstacles", ctx, estateID)
+	ret0, _ := ret[0].([]repository.Obstacle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListObstacles indicates an expected call of ListObstacles.
+func (mr *MockRepositoryMockRecorder) ListObstacles(ctx, estateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListObstacles", reflect.TypeOf((*MockRepository)(nil).ListObstacles), ctx, estateID)
+}
+
+// DeleteObstacle mocks base method.
+func (m *MockRepository) DeleteObstacle(ctx context.Context, estateID, obstacleID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteObstacle", ctx, estateID, obstacleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteObstacle indicates an expected call of DeleteObstacle.
+func (mr *MockRepositoryMockRecorder) DeleteObstacle(ctx, estateID, obstacleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteObstacle", reflect.TypeOf((*MockRepository)(nil).DeleteObstacle), ctx, estateID, obstacleID)
+}
+
+// ListTreeMeasurements mocks base method.
+func (m *MockRepository) ListTreeMeasurements(ctx context.Context, treeID uuid.UUID) ([]repository.TreeMeasurement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTreeMeasurements", ctx, treeID)
+	ret0, _ := ret[0].([]repository.TreeMeasurement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTreeMeasurements indicates an expected call of ListTreeMeasurements.
+func (mr *MockRepositoryMockRecorder) ListTreeMeasurements(ctx, treeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTreeMeasurements", reflect.TypeOf((*MockRepository)(nil).ListTreeMeasurements), ctx, treeID)
+}
+
+// GetGrowthStats mocks base method.
+func (m *MockRepository) GetGrowthStats(ctx context.Context, estateID uuid.UUID, limit int) (repository.GrowthStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGrowthStats", ctx, estateID, limit)
+	ret0, _ := ret[0].(repository.GrowthStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGrowthStats indicates an expected call of GetGrowthStats.
+func (mr *MockRepositoryMockRecorder) GetGrowthStats(ctx, estateID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGrowthStats", reflect.TypeOf((*MockRepository)(nil).GetGrowthStats), ctx, estateID, limit)
+}
@@ -2,28 +2,105 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // Repository defines the interface for database operations
 type Repository interface {
+	// WithTx runs fn in a transaction, passing it a Repository whose methods
+	// all run in that transaction. The transaction is committed if fn returns
+	// nil and rolled back otherwise, returning the error of fn. Calling WithTx
+	// on the Repository passed to fn nests a transaction that can be rolled
+	// back on its own.
+	WithTx(ctx context.Context, fn func(repo Repository) error) error
+
 	// Estate methods
 	CreateEstate(ctx context.Context, width, length int) (uuid.UUID, error)
 	GetEstate(ctx context.Context, id uuid.UUID) (width, length int, err error)
-	ListEstates(ctx context.Context) ([]Estate, error)
+	LockEstate(ctx context.Context, id uuid.UUID) (width, length int, err error)
+	QueryEstates(ctx context.Context, query EstateQuery) ([]Estate, error)
+	GetEstateDetail(ctx context.Context, id uuid.UUID) (Estate, error)
+	ResizeEstate(ctx context.Context, id uuid.UUID, width, length int, prune bool) (pruned int, err error)
+	DeleteEstate(ctx context.Context, id uuid.UUID) error
 	
 	// Tree methods
-	CreateTree(ctx context.Context, estateID uuid.UUID, x, y, height int) (uuid.UUID, error)
+	CreateTree(ctx context.Context, tree Tree) (uuid.UUID, error)
 	GetTrees(ctx context.Context, estateID uuid.UUID) ([]Tree, error)
+	EachTree(ctx context.Context, estateID uuid.UUID, fn func(tree Tree) error) error
+	ListTrees(ctx context.Context, estateID uuid.UUID, query TreeQuery) ([]Tree, error)
+	GetTree(ctx context.Context, estateID, treeID uuid.UUID) (Tree, error)
+	UpdateTree(ctx context.Context, tree Tree) error
+	DeleteTree(ctx context.Context, estateID, treeID uuid.UUID) error
+	CreateTrees(ctx context.Context, estateID uuid.UUID, trees []Tree) (int, error)
+	OccupiedPlots(ctx context.Context, estateID uuid.UUID, plots []Point) ([]Point, error)
+	GetTreeStats(ctx context.Context, estateID uuid.UUID, query StatsQuery) (Stats, error)
+	GetGridStats(ctx context.Context, estateID uuid.UUID, query GridQuery) ([]CellStats, error)
+	ListTreeMeasurements(ctx context.Context, treeID uuid.UUID) ([]TreeMeasurement, error)
+	GetGrowthStats(ctx context.Context, estateID uuid.UUID, limit int) (GrowthStats, error)
+
+	// Zone methods
+	CreateZone(ctx context.Context, zone Zone) (uuid.UUID, error)
+	GetZone(ctx context.Context, estateID, zoneID uuid.UUID) (Zone, error)
+	GetZoneByName(ctx context.Context, estateID uuid.UUID, name string) (Zone, error)
+	ListZones(ctx context.Context, estateID uuid.UUID) ([]Zone, error)
+	UpdateZone(ctx context.Context, zone Zone) error
+	DeleteZone(ctx context.Context, estateID, zoneID uuid.UUID) error
+
+	// Obstacle methods
+	CreateObstacle(ctx context.Context, obstacle Obstacle) (uuid.UUID, error)
+	ListObstacles(ctx context.Context, estateID uuid.UUID) ([]Obstacle, error)
+	DeleteObstacle(ctx context.Context, estateID, obstacleID uuid.UUID) error
+
+	// Drone plan job methods
+	CreateJob(ctx context.Context, estateID uuid.UUID, options []byte) (uuid.UUID, error)
+	GetJob(ctx context.Context, id uuid.UUID) (Job, error)
+	ClaimJob(ctx context.Context) (Job, error)
+	UpdateJobProgress(ctx context.Context, id uuid.UUID, progress int) (running bool, err error)
+	FinishJob(ctx context.Context, id uuid.UUID, status string, result []byte, errMsg string) error
+	CancelJob(ctx context.Context, id uuid.UUID) error
+	RequeueRunningJobs(ctx context.Context) error
+	RequeueJob(ctx context.Context, id uuid.UUID) error
 }
 
 // Estate represents an estate in the database
 type Estate struct {
-	ID     uuid.UUID
-	Width  int
-	Length int
+	ID        uuid.UUID
+	Width     int
+	Length    int
+	CreatedAt time.Time
+	// TreeCount is only filled in by GetEstateDetail, and by QueryEstates
+	// when asked to
+	TreeCount int
+}
+
+// EstateQuery selects and orders a page of estates. Zero bounds are not applied.
+type EstateQuery struct {
+	// SortByArea orders the estates by area instead of by creation time; ties
+	// are ordered by ID
+	SortByArea bool
+	Descending bool
+	MinWidth   int
+	MaxWidth   int
+	MinLength  int
+	MaxLength  int
+	MinArea    int64
+	MaxArea    int64
+	// WithTreeCount fills in the tree count of every estate
+	WithTreeCount bool
+	// After is the last estate of the previous page, if any
+	After *Estate
+	// Limit is the number of estates on the page; 0 lists them all
+	Limit int
 }
 
 // Tree represents a tree in the database
@@ -33,19 +110,233 @@ type Tree struct {
 	X      int
 	Y      int
 	Height int
+	// Species, PlantedOn, Health and Tags are optional; Species and Health
+	// are empty and PlantedOn nil when unknown
+	Species   string
+	PlantedOn *time.Time
+	Health    string
+	Tags      []string
+	// Zones holds the names of the zones the tree stands in; it is only
+	// filled in by GetTree and ListTrees
+	Zones []string
+}
+
+// Tree health statuses
+const (
+	TreeHealthHealthy  = "healthy"
+	TreeHealthDiseased = "diseased"
+	TreeHealthDead     = "dead"
+)
+
+// TreeFilter selects trees by their optional attributes. Empty fields are not
+// applied.
+type TreeFilter struct {
+	Species string
+	Health  string
+	// Tags only selects the trees having every one of them
+	Tags []string
+	// PlantedFrom and PlantedTo bound the planting date, both included;
+	// trees without one are left out when either is set
+	PlantedFrom time.Time
+	PlantedTo   time.Time
+}
+
+// TreeQuery selects and orders a page of the trees of an estate. Zero bounds
+// are not applied.
+type TreeQuery struct {
+	// SortByHeight orders the trees by height instead of by position; ties
+	// are ordered by position, which is x and then y
+	SortByHeight bool
+	Descending   bool
+	MinHeight    int
+	MaxHeight    int
+	XMin         int
+	XMax         int
+	YMin         int
+	YMax         int
+	Filter       TreeFilter
+	// After is the last tree of the previous page, if any
+	After *Tree
+	Limit int
+}
+
+// StatsQuery selects the trees that statistics are calculated over. Zero
+// bounds are not applied.
+type StatsQuery struct {
+	// Percentiles are fractions between 0 and 1 to report the height at
+	Percentiles []float64
+	XMin        int
+	XMax        int
+	YMin        int
+	YMax        int
+	// ZoneID, if set, only counts the trees standing in that zone
+	ZoneID uuid.UUID
+	Filter TreeFilter
+}
+
+// GridQuery splits a region of an estate into square cells of CellSize
+// plots, counting columns and rows from XMin and YMin
+type GridQuery struct {
+	CellSize int
+	XMin     int
+	XMax     int
+	YMin     int
+	YMax     int
+	Filter   TreeFilter
+}
+
+// CellStats represents the tree statistics of a cell of a grid
+type CellStats struct {
+	Column       int
+	Row          int
+	Count        int
+	MaxHeight    int
+	MinHeight    int
+	MedianHeight float64
+	MeanHeight   float64
+}
+
+// Zone shapes
+const (
+	ZoneShapeRect    = "rect"
+	ZoneShapePolygon = "polygon"
+)
+
+// Zone represents a named area of an estate in the database. A plot belongs
+// to the zone if its coordinates lie inside or on the edge of the polygon
+// with the zone's vertices; a rectangle is stored as its four corners.
+type Zone struct {
+	ID        uuid.UUID
+	EstateID  uuid.UUID
+	Name      string
+	Shape     string
+	Vertices  []Point
+	CreatedAt time.Time
+}
+
+// Point is a position on an estate in plot coordinates
+type Point struct {
+	X, Y int
 }
 
-// Stats represents tree statistics for an estate
+// Obstacle represents a rectangle of plots in the database that the drone
+// must fly over at MinAltitude or above, or that it cannot fly over at all if
+// Impassable. MinAltitude is 0 for impassable obstacles.
+type Obstacle struct {
+	ID          uuid.UUID
+	EstateID    uuid.UUID
+	XMin, XMax  int
+	YMin, YMax  int
+	MinAltitude int
+	Impassable  bool
+	CreatedAt   time.Time
+}
+
+// ErrZoneExists is returned when a zone is given the name of another zone of
+// the same estate
+var ErrZoneExists = errors.New("zone name already taken")
+
+// ErrTreesOutOfBounds is returned when resizing an estate would leave trees
+// outside of it
+var ErrTreesOutOfBounds = errors.New("trees outside new estate boundaries")
+
+// ErrZonesOutOfBounds is returned when resizing an estate would leave part of
+// a zone outside of it
+var ErrZonesOutOfBounds = errors.New("zones outside new estate boundaries")
+
+// ErrObstaclesOutOfBounds is returned when resizing an estate would leave
+// part of an obstacle outside of it
+var ErrObstaclesOutOfBounds = errors.New("obstacles outside new estate boundaries")
+
+// ErrPlotOccupied is returned when a tree is placed on a plot that already has one
+var ErrPlotOccupied = errors.New("plot already has a tree")
+
+// Job statuses of a drone plan job
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// Job represents a drone plan job in the database
+type Job struct {
+	ID        uuid.UUID
+	EstateID  uuid.UUID
+	Status    string
+	Progress  int
+	Options   []byte
+	Result    []byte
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Stats represents tree statistics for an estate. All heights are 0 when the
+// estate has no trees.
 type Stats struct {
-	Count       int
-	MaxHeight   int
-	MinHeight   int
-	MedianHeight int
+	Count        int
+	MaxHeight    int
+	MinHeight    int
+	MedianHeight float64
+	MeanHeight   float64
+	// StdDevHeight is the population standard deviation of the heights
+	StdDevHeight float64
+	// Percentiles holds the height at each of the requested percentiles, in
+	// the order they were requested
+	Percentiles []float64
+	// Histogram holds the number of trees of each height, from 1 to 30
+	Histogram [30]int
+}
+
+// TreeMeasurement is a height of a tree in the database and when it was
+// measured. A measurement is recorded whenever a tree is planted or its
+// height changes.
+type TreeMeasurement struct {
+	Height     int
+	MeasuredAt time.Time
+}
+
+// GrowthStats summarizes how fast the trees of an estate grow. Only the trees
+// measured at two different times at least are counted.
+type GrowthStats struct {
+	Trees int
+	// AverageMonthlyGrowth is the mean of the monthly growth of the trees
+	AverageMonthlyGrowth float64
+	// Fastest and Slowest hold the trees growing the fastest and slowest, in
+	// that order
+	Fastest []TreeGrowth
+	Slowest []TreeGrowth
+}
+
+// TreeGrowth is the growth of a tree between its first and last measurement
+type TreeGrowth struct {
+	TreeID          uuid.UUID
+	X               int
+	Y               int
+	FirstHeight     int
+	LastHeight      int
+	FirstMeasuredAt time.Time
+	LastMeasuredAt  time.Time
+	// MonthlyGrowth is the height gained per month in meters, months being a
+	// twelfth of an average year
+	MonthlyGrowth float64
 }
 
 // repository implements the Repository interface
 type repository struct {
-	db *pgxpool.Pool
+	db dbtx
+}
+
+// dbtx is the part of a connection pool or transaction used by the
+// repository, so that its methods run the same way within WithTx
+type dbtx interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
 }
 
 // NewRepository creates a new repository with the given database connection
@@ -55,6 +346,21 @@ func NewRepository(db *pgxpool.Pool) Repository {
 	}
 }
 
+// WithTx runs fn in a transaction, or in a savepoint of the transaction the
+// repository already runs in
+func (r *repository) WithTx(ctx context.Context, fn func(repo Repository) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&repository{db: tx}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
 // CreateEstate creates a new estate in the database
 func (r *repository) CreateEstate(ctx context.Context, width, length int) (uuid.UUID, error) {
 	var id uuid.UUID
@@ -72,19 +378,80 @@ func (r *repository) GetEstate(ctx context.Context, id uuid.UUID) (width, length
 	return
 }
 
-// CreateTree creates a new tree in the database
-func (r *repository) CreateTree(ctx context.Context, estateID uuid.UUID, x, y, height int) (uuid.UUID, error) {
+// LockEstate retrieves an estate like GetEstate and keeps it from being
+// resized or deleted until the end of the transaction. Outside of WithTx the
+// lock is released as soon as the estate is read.
+func (r *repository) LockEstate(ctx context.Context, id uuid.UUID) (width, length int, err error) {
+	err = r.db.QueryRow(ctx,
+		"SELECT width, length FROM estates WHERE id = $1 FOR SHARE",
+		id).Scan(&width, &length)
+	return
+}
+
+// treeColumns lists the columns scanned by treeFields
+const treeColumns = "id, estate_id, x, y, height, COALESCE(species, ''), planted_on, COALESCE(health, ''), tags"
+
+// treeFields returns the fields of a tree to scan a row selected with
+// treeColumns into
+func treeFields(tree *Tree) []any {
+	return []any{&tree.ID, &tree.EstateID, &tree.X, &tree.Y, &tree.Height,
+		&tree.Species, &tree.PlantedOn, &tree.Health, &tree.Tags}
+}
+
+// treeValues returns the optional attributes of a tree as stored in the
+// database, empty ones being NULL
+func treeValues(tree Tree) (species, health *string, tags []string) {
+	if tree.Species != "" {
+		species = &tree.Species
+	}
+	if tree.Health != "" {
+		health = &tree.Health
+	}
+	tags = tree.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return species, health, tags
+}
+
+// apply adds the conditions of the filter to a query through where
+func (f TreeFilter) apply(where func(condition string, value any)) {
+	if f.Species != "" {
+		where("species = $%d", f.Species)
+	}
+	if f.Health != "" {
+		where("health = $%d", f.Health)
+	}
+	if len(f.Tags) > 0 {
+		where("tags @> $%d", f.Tags)
+	}
+	if !f.PlantedFrom.IsZero() {
+		where("planted_on >= $%d", f.PlantedFrom)
+	}
+	if !f.PlantedTo.IsZero() {
+		where("planted_on <= $%d", f.PlantedTo)
+	}
+}
+
+// CreateTree creates a new tree in the database, returning ErrPlotOccupied if
+// the plot already has a tree
+func (r *repository) CreateTree(ctx context.Context, tree Tree) (uuid.UUID, error) {
+	species, health, tags := treeValues(tree)
+
 	var id uuid.UUID
 	err := r.db.QueryRow(ctx,
-		"INSERT INTO trees (estate_id, x, y, height) VALUES ($1, $2, $3, $4) RETURNING id",
-		estateID, x, y, height).Scan(&id)
+		"INSERT INTO trees (estate_id, x, y, height, species, planted_on, health, tags) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		tree.EstateID, tree.X, tree.Y, tree.Height, species, tree.PlantedOn, health, tags).Scan(&id)
+	if isUniqueViolation(err) {
+		return uuid.Nil, ErrPlotOccupied
+	}
 	return id, err
 }
 
 // GetTrees retrieves all trees for an estate from the database
 func (r *repository) GetTrees(ctx context.Context, estateID uuid.UUID) ([]Tree, error) {
 	rows, err := r.db.Query(ctx,
-		"SELECT id, estate_id, x, y, height FROM trees WHERE estate_id = $1",
+		"SELECT "+treeColumns+" FROM trees WHERE estate_id = $1",
 		estateID)
 	if err != nil {
 		return nil, err
@@ -94,7 +461,7 @@ func (r *repository) GetTrees(ctx context.Context, estateID uuid.UUID) ([]Tree,
 	var trees []Tree
 	for rows.Next() {
 		var tree Tree
-		if err := rows.Scan(&tree.ID, &tree.EstateID, &tree.X, &tree.Y, &tree.Height); err != nil {
+		if err := rows.Scan(treeFields(&tree)...); err != nil {
 			return nil, err
 		}
 		trees = append(trees, tree)
@@ -107,9 +474,72 @@ func (r *repository) GetTrees(ctx context.Context, estateID uuid.UUID) ([]Tree,
 	return trees, nil
 }
 
-// ListEstates retrieves all estates from the database
-func (r *repository) ListEstates(ctx context.Context) ([]Estate, error) {
-	rows, err := r.db.Query(ctx, "SELECT id, width, length FROM estates")
+// QueryEstates retrieves a page of estates, using keyset pagination on the
+// sort columns
+func (r *repository) QueryEstates(ctx context.Context, query EstateQuery) ([]Estate, error) {
+	var conditions []string
+	var args []any
+	where := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.MinWidth > 0 {
+		where("e.width >= $%d", query.MinWidth)
+	}
+	if query.MaxWidth > 0 {
+		where("e.width <= $%d", query.MaxWidth)
+	}
+	if query.MinLength > 0 {
+		where("e.length >= $%d", query.MinLength)
+	}
+	if query.MaxLength > 0 {
+		where("e.length <= $%d", query.MaxLength)
+	}
+	// The area is computed in bigint as it overflows an integer on large estates
+	if query.MinArea > 0 {
+		where("e.width::bigint * e.length >= $%d", query.MinArea)
+	}
+	if query.MaxArea > 0 {
+		where("e.width::bigint * e.length <= $%d", query.MaxArea)
+	}
+
+	key := "e.created_at"
+	if query.SortByArea {
+		key = "e.width::bigint * e.length"
+	}
+	direction, compare := "ASC", ">"
+	if query.Descending {
+		direction, compare = "DESC", "<"
+	}
+
+	if query.After != nil {
+		var after any = query.After.CreatedAt
+		if query.SortByArea {
+			after = int64(query.After.Width) * int64(query.After.Length)
+		}
+		args = append(args, after, query.After.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, e.id) %s ($%d, $%d)", key, compare, len(args)-1, len(args)))
+	}
+
+	treeCount := "0"
+	if query.WithTreeCount {
+		treeCount = "(SELECT COUNT(*) FROM trees t WHERE t.estate_id = e.id)"
+	}
+
+	sql := fmt.Sprintf("SELECT e.id, e.width, e.length, e.created_at, %s FROM estates e", treeCount)
+	if len(conditions) > 0 {
+		sql += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// LIMIT NULL is no limit
+	var limit *int
+	if query.Limit > 0 {
+		limit = &query.Limit
+	}
+	args = append(args, limit)
+	sql += fmt.Sprintf(" ORDER BY %s %s, e.id %s LIMIT $%d", key, direction, direction, len(args))
+
+	rows, err := r.db.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +548,7 @@ func (r *repository) ListEstates(ctx context.Context) ([]Estate, error) {
 	var estates []Estate
 	for rows.Next() {
 		var estate Estate
-		if err := rows.Scan(&estate.ID, &estate.Width, &estate.Length); err != nil {
+		if err := rows.Scan(&estate.ID, &estate.Width, &estate.Length, &estate.CreatedAt, &estate.TreeCount); err != nil {
 			return nil, err
 		}
 		estates = append(estates, estate)
@@ -129,4 +559,815 @@ func (r *repository) ListEstates(ctx context.Context) ([]Estate, error) {
 	}
 
 	return estates, nil
-} 
\ No newline at end of file
+}
+
+// GetEstateDetail retrieves an estate from the database by ID, with the
+// number of trees planted on it
+func (r *repository) GetEstateDetail(ctx context.Context, id uuid.UUID) (Estate, error) {
+	var estate Estate
+	err := r.db.QueryRow(ctx,
+		`SELECT e.id, e.width, e.length, (SELECT COUNT(*) FROM trees t WHERE t.estate_id = e.id)
+		FROM estates e WHERE e.id = $1`,
+		id).Scan(&estate.ID, &estate.Width, &estate.Length, &estate.TreeCount)
+	return estate, err
+}
+
+// ResizeEstate changes the dimensions of an estate. Trees, zones and obstacles
+// left outside the new dimensions are removed if prune is set, otherwise the
+// estate is left as is and ErrTreesOutOfBounds, ErrZonesOutOfBounds or
+// ErrObstaclesOutOfBounds is returned. It returns the number of trees removed,
+// or pgx.ErrNoRows if there is no such estate.
+func (r *repository) ResizeEstate(ctx context.Context, id uuid.UUID, width, length int, prune bool) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the estate so that no tree is planted on it while it is resized
+	var locked uuid.UUID
+	if err := tx.QueryRow(ctx, "SELECT id FROM estates WHERE id = $1 FOR UPDATE", id).Scan(&locked); err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	if prune {
+		tag, err := tx.Exec(ctx,
+			"DELETE FROM trees WHERE estate_id = $1 AND (x > $2 OR y > $3)",
+			id, width, length)
+		if err != nil {
+			return 0, err
+		}
+		pruned = int(tag.RowsAffected())
+
+		if _, err := tx.Exec(ctx,
+			"DELETE FROM zones WHERE estate_id = $1 AND NOT box(area) <@ box(point(1, 1), point($2, $3))",
+			id, width, length); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(ctx,
+			"DELETE FROM obstacles WHERE estate_id = $1 AND (x_max > $2 OR y_max > $3)",
+			id, width, length); err != nil {
+			return 0, err
+		}
+	} else {
+		var outside bool
+		err := tx.QueryRow(ctx,
+			"SELECT EXISTS (SELECT 1 FROM trees WHERE estate_id = $1 AND (x > $2 OR y > $3))",
+			id, width, length).Scan(&outside)
+		if err != nil {
+			return 0, err
+		}
+		if outside {
+			return 0, ErrTreesOutOfBounds
+		}
+
+		err = tx.QueryRow(ctx,
+			"SELECT EXISTS (SELECT 1 FROM zones WHERE estate_id = $1 AND NOT box(area) <@ box(point(1, 1), point($2, $3)))",
+			id, width, length).Scan(&outside)
+		if err != nil {
+			return 0, err
+		}
+		if outside {
+			return 0, ErrZonesOutOfBounds
+		}
+
+		err = tx.QueryRow(ctx,
+			"SELECT EXISTS (SELECT 1 FROM obstacles WHERE estate_id = $1 AND (x_max > $2 OR y_max > $3))",
+			id, width, length).Scan(&outside)
+		if err != nil {
+			return 0, err
+		}
+		if outside {
+			return 0, ErrObstaclesOutOfBounds
+		}
+	}
+
+	if _, err := tx.Exec(ctx,
+		"UPDATE estates SET width = $2, length = $3 WHERE id = $1",
+		id, width, length); err != nil {
+		return 0, err
+	}
+
+	return pruned, tx.Commit(ctx)
+}
+
+// DeleteEstate deletes an estate along with its trees and drone plan jobs. It
+// returns pgx.ErrNoRows if there is no such estate.
+func (r *repository) DeleteEstate(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, "DELETE FROM estates WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// EachTree calls fn for every tree of an estate, ordered by row and then by
+// column, without holding them all in memory. It stops at the first error
+// returned by fn.
+func (r *repository) EachTree(ctx context.Context, estateID uuid.UUID, fn func(tree Tree) error) error {
+	rows, err := r.db.Query(ctx,
+		"SELECT "+treeColumns+" FROM trees WHERE estate_id = $1 ORDER BY y, x",
+		estateID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tree Tree
+		if err := rows.Scan(treeFields(&tree)...); err != nil {
+			return err
+		}
+		if err := fn(tree); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ListTrees retrieves a page of the trees of an estate, using keyset
+// pagination on the sort columns
+func (r *repository) ListTrees(ctx context.Context, estateID uuid.UUID, query TreeQuery) ([]Tree, error) {
+	conditions := []string{"estate_id = $1"}
+	args := []any{estateID}
+	where := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.MinHeight > 0 {
+		where("height >= $%d", query.MinHeight)
+	}
+	if query.MaxHeight > 0 {
+		where("height <= $%d", query.MaxHeight)
+	}
+	if query.XMin > 0 {
+		where("x >= $%d", query.XMin)
+	}
+	if query.XMax > 0 {
+		where("x <= $%d", query.XMax)
+	}
+	if query.YMin > 0 {
+		where("y >= $%d", query.YMin)
+	}
+	if query.YMax > 0 {
+		where("y <= $%d", query.YMax)
+	}
+	query.Filter.apply(where)
+
+	columns := "x, y"
+	if query.SortByHeight {
+		columns = "height, x, y"
+	}
+	direction, compare := "ASC", ">"
+	if query.Descending {
+		direction, compare = "DESC", "<"
+	}
+
+	if query.After != nil {
+		if query.SortByHeight {
+			args = append(args, query.After.Height, query.After.X, query.After.Y)
+			conditions = append(conditions, fmt.Sprintf("(height, x, y) %s ($%d, $%d, $%d)", compare, len(args)-2, len(args)-1, len(args)))
+		} else {
+			args = append(args, query.After.X, query.After.Y)
+			conditions = append(conditions, fmt.Sprintf("(x, y) %s ($%d, $%d)", compare, len(args)-1, len(args)))
+		}
+	}
+
+	order := strings.ReplaceAll(columns, ",", " "+direction+",") + " " + direction
+	args = append(args, query.Limit)
+	rows, err := r.db.Query(ctx,
+		fmt.Sprintf("SELECT %s, %s FROM trees WHERE %s ORDER BY %s LIMIT $%d",
+			treeColumns, treeZonesColumn, strings.Join(conditions, " AND "), order, len(args)),
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trees []Tree
+	for rows.Next() {
+		var tree Tree
+		if err := rows.Scan(append(treeFields(&tree), &tree.Zones)...); err != nil {
+			return nil, err
+		}
+		trees = append(trees, tree)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return trees, nil
+}
+
+// treeZonesColumn selects the names of the zones a tree stands in
+const treeZonesColumn = `ARRAY(
+	SELECT z.name FROM zones z
+	WHERE z.estate_id = trees.estate_id AND point(trees.x, trees.y) <@ z.area
+	ORDER BY z.name
+)`
+
+// GetTree retrieves a tree of an estate from the database by ID
+func (r *repository) GetTree(ctx context.Context, estateID, treeID uuid.UUID) (Tree, error) {
+	var tree Tree
+	err := r.db.QueryRow(ctx,
+		"SELECT "+treeColumns+", "+treeZonesColumn+" FROM trees WHERE id = $1 AND estate_id = $2",
+		treeID, estateID).Scan(append(treeFields(&tree), &tree.Zones)...)
+	return tree, err
+}
+
+// UpdateTree moves a tree of an estate and changes its height and attributes.
+// It returns pgx.ErrNoRows if there is no such tree and ErrPlotOccupied if
+// another tree stands on the target plot.
+func (r *repository) UpdateTree(ctx context.Context, tree Tree) error {
+	species, health, tags := treeValues(tree)
+	tag, err := r.db.Exec(ctx,
+		"UPDATE trees SET x = $3, y = $4, height = $5, species = $6, planted_on = $7, health = $8, tags = $9 WHERE id = $1 AND estate_id = $2",
+		tree.ID, tree.EstateID, tree.X, tree.Y, tree.Height, species, tree.PlantedOn, health, tags)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrPlotOccupied
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteTree deletes a tree of an estate. It returns pgx.ErrNoRows if there
+// is no such tree.
+func (r *repository) DeleteTree(ctx context.Context, estateID, treeID uuid.UUID) error {
+	tag, err := r.db.Exec(ctx,
+		"DELETE FROM trees WHERE id = $1 AND estate_id = $2",
+		treeID, estateID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// CreateTrees plants many trees on an estate at once. Either all trees are
+// created or, if any plot already has a tree, none is and ErrPlotOccupied is
+// returned.
+func (r *repository) CreateTrees(ctx context.Context, estateID uuid.UUID, trees []Tree) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	count, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"trees"},
+		[]string{"estate_id", "x", "y", "height", "species", "planted_on", "health", "tags"},
+		pgx.CopyFromSlice(len(trees), func(i int) ([]any, error) {
+			species, health, tags := treeValues(trees[i])
+			return []any{estateID, trees[i].X, trees[i].Y, trees[i].Height, species, trees[i].PlantedOn, health, tags}, nil
+		}))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrPlotOccupied
+		}
+		return 0, err
+	}
+
+	return int(count), tx.Commit(ctx)
+}
+
+// OccupiedPlots returns those of the given plots of an estate that have a
+// tree, in no particular order. Only the given plots are looked up, through
+// the unique index on the plots of an estate.
+func (r *repository) OccupiedPlots(ctx context.Context, estateID uuid.UUID, plots []Point) ([]Point, error) {
+	if len(plots) == 0 {
+		return nil, nil
+	}
+
+	xs, ys := make([]int, len(plots)), make([]int, len(plots))
+	for i, plot := range plots {
+		xs[i], ys[i] = plot.X, plot.Y
+	}
+
+	rows, err := r.db.Query(ctx,
+		"SELECT x, y FROM trees WHERE estate_id = $1 AND (x, y) IN (SELECT * FROM unnest($2::int[], $3::int[]))",
+		estateID, xs, ys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var occupied []Point
+	for rows.Next() {
+		var plot Point
+		if err := rows.Scan(&plot.X, &plot.Y); err != nil {
+			return nil, err
+		}
+		occupied = append(occupied, plot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return occupied, nil
+}
+
+// isUniqueViolation reports whether an error is a violated unique constraint
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// jobColumns lists the columns scanned by scanJob
+const jobColumns = "id, estate_id, status, progress, options, result, COALESCE(error, ''), created_at, updated_at"
+
+// scanJob scans a row selected with jobColumns into a Job
+func scanJob(row pgx.Row) (Job, error) {
+	var job Job
+	err := row.Scan(&job.ID, &job.EstateID, &job.Status, &job.Progress, &job.Options, &job.Result,
+		&job.Error, &job.CreatedAt, &job.UpdatedAt)
+	return job, err
+}
+
+// CreateJob creates a new queued drone plan job in the database
+func (r *repository) CreateJob(ctx context.Context, estateID uuid.UUID, options []byte) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx,
+		"INSERT INTO drone_plan_jobs (estate_id, status, options) VALUES ($1, $2, $3) RETURNING id",
+		estateID, JobStatusQueued, options).Scan(&id)
+	return id, err
+}
+
+// GetJob retrieves a drone plan job from the database by ID
+func (r *repository) GetJob(ctx context.Context, id uuid.UUID) (Job, error) {
+	return scanJob(r.db.QueryRow(ctx,
+		"SELECT "+jobColumns+" FROM drone_plan_jobs WHERE id = $1",
+		id))
+}
+
+// ClaimJob marks the oldest queued job as running and returns it. Concurrent
+// callers never claim the same job. It returns pgx.ErrNoRows if no job is queued.
+func (r *repository) ClaimJob(ctx context.Context) (Job, error) {
+	return scanJob(r.db.QueryRow(ctx,
+		`UPDATE drone_plan_jobs SET status = $1, updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM drone_plan_jobs WHERE status = $2
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING `+jobColumns,
+		JobStatusRunning, JobStatusQueued))
+}
+
+// UpdateJobProgress records the progress of a running job. It reports whether
+// the job is still running, which it is not once it has been cancelled.
+func (r *repository) UpdateJobProgress(ctx context.Context, id uuid.UUID, progress int) (bool, error) {
+	tag, err := r.db.Exec(ctx,
+		"UPDATE drone_plan_jobs SET progress = $2, updated_at = NOW() WHERE id = $1 AND status = $3",
+		id, progress, JobStatusRunning)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// FinishJob records the outcome of a running job. A job cancelled in the
+// meantime stays cancelled.
+func (r *repository) FinishJob(ctx context.Context, id uuid.UUID, status string, result []byte, errMsg string) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE drone_plan_jobs
+		SET status = $2, result = $3, error = NULLIF($4, ''),
+			progress = CASE WHEN $2 = $5 THEN 100 ELSE progress END, updated_at = NOW()
+		WHERE id = $1 AND status = $6`,
+		id, status, result, errMsg, JobStatusSucceeded, JobStatusRunning)
+	return err
+}
+
+// CancelJob marks a queued or running job as cancelled. It returns
+// pgx.ErrNoRows if there is no such job or the job has already finished.
+func (r *repository) CancelJob(ctx context.Context, id uuid.UUID) error {
+	var cancelled uuid.UUID
+	return r.db.QueryRow(ctx,
+		`UPDATE drone_plan_jobs SET status = $2, updated_at = NOW()
+		WHERE id = $1 AND status IN ($3, $4)
+		RETURNING id`,
+		id, JobStatusCancelled, JobStatusQueued, JobStatusRunning).Scan(&cancelled)
+}
+
+// RequeueRunningJobs puts jobs left running by a previous run of the server
+// back into the queue
+func (r *repository) RequeueRunningJobs(ctx context.Context) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE drone_plan_jobs SET status = $1, progress = 0, updated_at = NOW() WHERE status = $2",
+		JobStatusQueued, JobStatusRunning)
+	return err
+}
+
+// RequeueJob puts a running job back into the queue, such as one interrupted
+// by the server shutting down. A job cancelled in the meantime stays cancelled.
+func (r *repository) RequeueJob(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Exec(ctx,
+		"UPDATE drone_plan_jobs SET status = $1, progress = 0, updated_at = NOW() WHERE id = $2 AND status = $3",
+		JobStatusQueued, id, JobStatusRunning)
+	return err
+}
+
+// GetTreeStats calculates the tree statistics of an estate. The percentiles
+// are interpolated like the median.
+func (r *repository) GetTreeStats(ctx context.Context, estateID uuid.UUID, query StatsQuery) (Stats, error) {
+	conditions := []string{"estate_id = $1"}
+	args := []any{estateID}
+	where := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if query.XMin > 0 {
+		where("x >= $%d", query.XMin)
+	}
+	if query.XMax > 0 {
+		where("x <= $%d", query.XMax)
+	}
+	if query.YMin > 0 {
+		where("y >= $%d", query.YMin)
+	}
+	if query.YMax > 0 {
+		where("y <= $%d", query.YMax)
+	}
+	if query.ZoneID != uuid.Nil {
+		where("point(x, y) <@ (SELECT area FROM zones WHERE id = $%d)", query.ZoneID)
+	}
+	query.Filter.apply(where)
+
+	percentiles := query.Percentiles
+	if percentiles == nil {
+		percentiles = []float64{}
+	}
+	args = append(args, percentiles)
+
+	var stats Stats
+	var histogram []int
+	err := r.db.QueryRow(ctx, fmt.Sprintf(
+		`WITH selected AS (SELECT height FROM trees WHERE %s)
+		SELECT count(*),
+			COALESCE(max(height), 0),
+			COALESCE(min(height), 0),
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY height), 0),
+			COALESCE(avg(height)::float8, 0),
+			COALESCE(stddev_pop(height)::float8, 0),
+			COALESCE(percentile_cont($%d::float8[]) WITHIN GROUP (ORDER BY height), '{}'),
+			ARRAY(
+				SELECT count(s.height)
+				FROM generate_series(1, 30) AS h
+				LEFT JOIN selected s ON s.height = h
+				GROUP BY h
+				ORDER BY h
+			)
+		FROM selected`, strings.Join(conditions, " AND "), len(args)),
+		args...).Scan(&stats.Count, &stats.MaxHeight, &stats.MinHeight, &stats.MedianHeight,
+		&stats.MeanHeight, &stats.StdDevHeight, &stats.Percentiles, &histogram)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	// Without trees percentile_cont returns NULL rather than an array of them
+	if len(stats.Percentiles) < len(percentiles) {
+		stats.Percentiles = make([]float64, len(percentiles))
+	}
+	copy(stats.Histogram[:], histogram)
+
+	return stats, nil
+}
+
+// GetGridStats calculates the tree statistics of each cell of a grid over an
+// estate. Cells without trees are left out.
+func (r *repository) GetGridStats(ctx context.Context, estateID uuid.UUID, query GridQuery) ([]CellStats, error) {
+	conditions := []string{"estate_id = $1 AND x BETWEEN $2 AND $3 AND y BETWEEN $4 AND $5"}
+	args := []any{estateID, query.XMin, query.XMax, query.YMin, query.YMax, query.CellSize}
+	query.Filter.apply(func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	})
+
+	rows, err := r.db.Query(ctx, fmt.Sprintf(
+		`SELECT (x - $2) / $6 AS col, (y - $4) / $6 AS row,
+			count(*), max(height), min(height),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY height),
+			avg(height)::float8
+		FROM trees
+		WHERE %s
+		GROUP BY col, row
+		ORDER BY row, col`, strings.Join(conditions, " AND ")),
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cells []CellStats
+	for rows.Next() {
+		var cell CellStats
+		if err := rows.Scan(&cell.Column, &cell.Row, &cell.Count, &cell.MaxHeight, &cell.MinHeight,
+			&cell.MedianHeight, &cell.MeanHeight); err != nil {
+			return nil, err
+		}
+		cells = append(cells, cell)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cells, nil
+}
+
+// ListTreeMeasurements retrieves the height history of a tree from the
+// database, oldest first
+func (r *repository) ListTreeMeasurements(ctx context.Context, treeID uuid.UUID) ([]TreeMeasurement, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT height, measured_at FROM tree_measurements WHERE tree_id = $1 ORDER BY measured_at, id",
+		treeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var measurements []TreeMeasurement
+	for rows.Next() {
+		var measurement TreeMeasurement
+		if err := rows.Scan(&measurement.Height, &measurement.MeasuredAt); err != nil {
+			return nil, err
+		}
+		measurements = append(measurements, measurement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return measurements, nil
+}
+
+// treeGrowths calculates the monthly growth of every tree of the estate $1
+// measured at two different times at least
+const treeGrowths = `WITH spans AS (
+	SELECT m.tree_id,
+		(array_agg(m.height ORDER BY m.measured_at, m.id))[1] AS first_height,
+		(array_agg(m.height ORDER BY m.measured_at DESC, m.id DESC))[1] AS last_height,
+		min(m.measured_at) AS first_measured_at,
+		max(m.measured_at) AS last_measured_at
+	FROM tree_measurements m
+	JOIN trees t ON t.id = m.tree_id
+	WHERE t.estate_id = $1
+	GROUP BY m.tree_id
+	HAVING max(m.measured_at) > min(m.measured_at)
+), growths AS (
+	SELECT s.*, t.x, t.y,
+		(s.last_height - s.first_height)
+			/ (EXTRACT(EPOCH FROM s.last_measured_at - s.first_measured_at)::float8 / 2629746) AS monthly_growth
+	FROM spans s
+	JOIN trees t ON t.id = s.tree_id
+)`
+
+// GetGrowthStats calculates how fast the trees of an estate grow, listing up
+// to limit of the fastest and of the slowest growing trees
+func (r *repository) GetGrowthStats(ctx context.Context, estateID uuid.UUID, limit int) (GrowthStats, error) {
+	var stats GrowthStats
+	err := r.db.QueryRow(ctx,
+		treeGrowths+" SELECT count(*), COALESCE(avg(monthly_growth), 0) FROM growths",
+		estateID).Scan(&stats.Trees, &stats.AverageMonthlyGrowth)
+	if err != nil {
+		return GrowthStats{}, err
+	}
+
+	rows, err := r.db.Query(ctx, treeGrowths+`
+		(SELECT true AS fastest, tree_id, x, y, first_height, last_height, first_measured_at, last_measured_at, monthly_growth
+		FROM growths ORDER BY monthly_growth DESC, tree_id LIMIT $2)
+		UNION ALL
+		(SELECT false, tree_id, x, y, first_height, last_height, first_measured_at, last_measured_at, monthly_growth
+		FROM growths ORDER BY monthly_growth, tree_id LIMIT $2)
+		ORDER BY fastest DESC, CASE WHEN fastest THEN -monthly_growth ELSE monthly_growth END, tree_id`,
+		estateID, limit)
+	if err != nil {
+		return GrowthStats{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			fastest bool
+			growth  TreeGrowth
+		)
+		if err := rows.Scan(&fastest, &growth.TreeID, &growth.X, &growth.Y, &growth.FirstHeight, &growth.LastHeight,
+			&growth.FirstMeasuredAt, &growth.LastMeasuredAt, &growth.MonthlyGrowth); err != nil {
+			return GrowthStats{}, err
+		}
+		if fastest {
+			stats.Fastest = append(stats.Fastest, growth)
+		} else {
+			stats.Slowest = append(stats.Slowest, growth)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return GrowthStats{}, err
+	}
+
+	return stats, nil
+}
+
+// zoneColumns lists the columns scanned by scanZone
+const zoneColumns = "id, estate_id, name, shape, area, created_at"
+
+// scanZone scans a row selected with zoneColumns into a Zone
+func scanZone(row pgx.Row) (Zone, error) {
+	var zone Zone
+	var area pgtype.Polygon
+	if err := row.Scan(&zone.ID, &zone.EstateID, &zone.Name, &zone.Shape, &area, &zone.CreatedAt); err != nil {
+		return Zone{}, err
+	}
+	zone.Vertices = make([]Point, len(area.P))
+	for i, p := range area.P {
+		zone.Vertices[i] = Point{X: int(math.Round(p.X)), Y: int(math.Round(p.Y))}
+	}
+	return zone, nil
+}
+
+// zoneArea converts the vertices of a zone to a polygon
+func zoneArea(vertices []Point) pgtype.Polygon {
+	area := pgtype.Polygon{P: make([]pgtype.Vec2, len(vertices)), Valid: true}
+	for i, v := range vertices {
+		area.P[i] = pgtype.Vec2{X: float64(v.X), Y: float64(v.Y)}
+	}
+	return area
+}
+
+// CreateZone creates a new zone in the database. It returns ErrZoneExists if
+// the estate already has a zone with the same name.
+func (r *repository) CreateZone(ctx context.Context, zone Zone) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx,
+		"INSERT INTO zones (estate_id, name, shape, area) VALUES ($1, $2, $3, $4) RETURNING id",
+		zone.EstateID, zone.Name, zone.Shape, zoneArea(zone.Vertices)).Scan(&id)
+	if isUniqueViolation(err) {
+		return uuid.Nil, ErrZoneExists
+	}
+	return id, err
+}
+
+// GetZone retrieves a zone of an estate from the database by ID
+func (r *repository) GetZone(ctx context.Context, estateID, zoneID uuid.UUID) (Zone, error) {
+	return scanZone(r.db.QueryRow(ctx,
+		"SELECT "+zoneColumns+" FROM zones WHERE id = $1 AND estate_id = $2",
+		zoneID, estateID))
+}
+
+// GetZoneByName retrieves a zone of an estate from the database by name
+func (r *repository) GetZoneByName(ctx context.Context, estateID uuid.UUID, name string) (Zone, error) {
+	return scanZone(r.db.QueryRow(ctx,
+		"SELECT "+zoneColumns+" FROM zones WHERE estate_id = $1 AND name = $2",
+		estateID, name))
+}
+
+// ListZones retrieves all zones of an estate from the database, ordered by name
+func (r *repository) ListZones(ctx context.Context, estateID uuid.UUID) ([]Zone, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT "+zoneColumns+" FROM zones WHERE estate_id = $1 ORDER BY name",
+		estateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var zones []Zone
+	for rows.Next() {
+		zone, err := scanZone(rows)
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, zone)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+// UpdateZone renames and reshapes a zone of an estate. It returns
+// pgx.ErrNoRows if there is no such zone and ErrZoneExists if another zone of
+// the estate has the new name.
+func (r *repository) UpdateZone(ctx context.Context, zone Zone) error {
+	tag, err := r.db.Exec(ctx,
+		"UPDATE zones SET name = $3, shape = $4, area = $5 WHERE id = $1 AND estate_id = $2",
+		zone.ID, zone.EstateID, zone.Name, zone.Shape, zoneArea(zone.Vertices))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrZoneExists
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteZone deletes a zone of an estate. The trees in it are left alone. It
+// returns pgx.ErrNoRows if there is no such zone.
+func (r *repository) DeleteZone(ctx context.Context, estateID, zoneID uuid.UUID) error {
+	tag, err := r.db.Exec(ctx,
+		"DELETE FROM zones WHERE id = $1 AND estate_id = $2",
+		zoneID, estateID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// obstacleColumns lists the columns scanned by scanObstacle
+const obstacleColumns = "id, estate_id, x_min, x_max, y_min, y_max, min_altitude, impassable, created_at"
+
+// scanObstacle scans a row selected with obstacleColumns into an Obstacle
+func scanObstacle(row pgx.Row) (Obstacle, error) {
+	var (
+		obstacle    Obstacle
+		minAltitude *int
+	)
+	err := row.Scan(&obstacle.ID, &obstacle.EstateID, &obstacle.XMin, &obstacle.XMax, &obstacle.YMin, &obstacle.YMax,
+		&minAltitude, &obstacle.Impassable, &obstacle.CreatedAt)
+	if minAltitude != nil {
+		obstacle.MinAltitude = *minAltitude
+	}
+	return obstacle, err
+}
+
+// CreateObstacle inserts a new obstacle into the database
+func (r *repository) CreateObstacle(ctx context.Context, obstacle Obstacle) (uuid.UUID, error) {
+	var minAltitude *int
+	if !obstacle.Impassable {
+		minAltitude = &obstacle.MinAltitude
+	}
+
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx,
+		"INSERT INTO obstacles (estate_id, x_min, x_max, y_min, y_max, min_altitude, impassable) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		obstacle.EstateID, obstacle.XMin, obstacle.XMax, obstacle.YMin, obstacle.YMax, minAltitude, obstacle.Impassable).Scan(&id)
+	return id, err
+}
+
+// ListObstacles retrieves all obstacles of an estate from the database, in
+// the order they were created
+func (r *repository) ListObstacles(ctx context.Context, estateID uuid.UUID) ([]Obstacle, error) {
+	rows, err := r.db.Query(ctx,
+		"SELECT "+obstacleColumns+" FROM obstacles WHERE estate_id = $1 ORDER BY created_at, id",
+		estateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var obstacles []Obstacle
+	for rows.Next() {
+		obstacle, err := scanObstacle(rows)
+		if err != nil {
+			return nil, err
+		}
+		obstacles = append(obstacles, obstacle)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return obstacles, nil
+}
+
+// DeleteObstacle deletes an obstacle of an estate. It returns pgx.ErrNoRows
+// if there is no such obstacle.
+func (r *repository) DeleteObstacle(ctx context.Context, estateID, obstacleID uuid.UUID) error {
+	tag, err := r.db.Exec(ctx,
+		"DELETE FROM obstacles WHERE id = $1 AND estate_id = $2",
+		obstacleID, estateID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
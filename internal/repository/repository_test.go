@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestPostgresRepository runs the repository contract against the database at
+// TEST_DATABASE_URL, which it migrates and empties before every test. It is
+// skipped when the variable is not set.
+func TestPostgresRepository(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	db, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connecting to database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("migrating database: %v", err)
+	}
+
+	testRepository(t, func(t *testing.T) Repository {
+		if _, err := db.Exec(ctx, "TRUNCATE estates CASCADE"); err != nil {
+			t.Fatalf("emptying database: %v", err)
+		}
+		return NewRepository(db)
+	})
+}
@@ -0,0 +1,1148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteRepository implements the Repository interface on an embedded SQLite
+// database. Not found errors are reported as pgx.ErrNoRows, like the Postgres
+// repository does.
+type sqliteRepository struct {
+	db *sql.DB
+	// tx is the transaction the repository runs in within WithTx, and
+	// savepoints the number of transactions nested in it
+	tx         *sql.Tx
+	savepoints int
+}
+
+// sqliteQuerier is the part of a database or transaction used by the SQLite
+// repository
+type sqliteQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// sqliteScanner is a row of a query, either a *sql.Row or *sql.Rows
+type sqliteScanner interface {
+	Scan(dest ...any) error
+}
+
+// OpenSQLite opens the SQLite database at path, creating it if needed.
+// Foreign keys are enforced, and transactions take the write lock when they
+// begin so that they never fail halfway through to a concurrent writer.
+func OpenSQLite(path string) (*sql.DB, error) {
+	query := url.Values{
+		"_pragma": {"busy_timeout(5000)", "foreign_keys(1)", "journal_mode(WAL)"},
+		"_txlock": {"immediate"},
+	}
+	return sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+}
+
+// NewSQLiteRepository creates a new repository with the given SQLite
+// database, opened with OpenSQLite
+func NewSQLiteRepository(db *sql.DB) Repository {
+	return &sqliteRepository{
+		db: db,
+	}
+}
+
+// q returns the transaction the repository runs in, or the database outside
+// of WithTx
+func (r *sqliteRepository) q() sqliteQuerier {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// WithTx runs fn in a transaction, or in a savepoint of the transaction the
+// repository already runs in
+func (r *sqliteRepository) WithTx(ctx context.Context, fn func(repo Repository) error) error {
+	if r.tx == nil {
+		tx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := fn(&sqliteRepository{db: r.db, tx: tx}); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	savepoint := fmt.Sprintf("savepoint_%d", r.savepoints+1)
+	if _, err := r.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return err
+	}
+	if err := fn(&sqliteRepository{db: r.db, tx: r.tx, savepoints: r.savepoints + 1}); err != nil {
+		// Rolling back to a savepoint keeps it open, so it is released as well
+		r.tx.ExecContext(ctx, "ROLLBACK TO "+savepoint)
+		r.tx.ExecContext(ctx, "RELEASE "+savepoint)
+		return err
+	}
+	_, err := r.tx.ExecContext(ctx, "RELEASE "+savepoint)
+	return err
+}
+
+// noRows returns pgx.ErrNoRows for sql.ErrNoRows, and any other error as is
+func noRows(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgx.ErrNoRows
+	}
+	return err
+}
+
+// isSQLiteUniqueViolation reports whether an error is a violated unique
+// constraint
+func isSQLiteUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// affected returns pgx.ErrNoRows if a statement changed no row
+func affected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// sqliteTime returns a time as stored in the database
+func sqliteTime(t time.Time) int64 {
+	return t.UnixNano()
+}
+
+// parseSQLiteTime returns a time stored in the database
+func parseSQLiteTime(nanos int64) time.Time {
+	return time.Unix(0, nanos).UTC()
+}
+
+// sqliteDate returns the date of a time as stored in the database
+func sqliteDate(t time.Time) string {
+	return t.Format(time.DateOnly)
+}
+
+// CreateEstate creates a new estate in the database
+func (r *sqliteRepository) CreateEstate(ctx context.Context, width, length int) (uuid.UUID, error) {
+	id := uuid.New()
+	_, err := r.q().ExecContext(ctx,
+		"INSERT INTO estates (id, width, length, created_at) VALUES (?, ?, ?, ?)",
+		id, width, length, sqliteTime(time.Now()))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// GetEstate retrieves an estate from the database by ID
+func (r *sqliteRepository) GetEstate(ctx context.Context, id uuid.UUID) (width, length int, err error) {
+	err = r.q().QueryRowContext(ctx,
+		"SELECT width, length FROM estates WHERE id = ?",
+		id).Scan(&width, &length)
+	return width, length, noRows(err)
+}
+
+// LockEstate retrieves an estate like GetEstate. Transactions hold the write
+// lock of the whole database from their start, so within WithTx the estate
+// cannot change until the end of the transaction anyway.
+func (r *sqliteRepository) LockEstate(ctx context.Context, id uuid.UUID) (width, length int, err error) {
+	return r.GetEstate(ctx, id)
+}
+
+// QueryEstates retrieves a page of estates, using keyset pagination on the
+// sort columns
+func (r *sqliteRepository) QueryEstates(ctx context.Context, query EstateQuery) ([]Estate, error) {
+	var conditions []string
+	var args []any
+	where := func(condition string, value any) {
+		conditions = append(conditions, condition)
+		args = append(args, value)
+	}
+
+	if query.MinWidth > 0 {
+		where("e.width >= ?", query.MinWidth)
+	}
+	if query.MaxWidth > 0 {
+		where("e.width <= ?", query.MaxWidth)
+	}
+	if query.MinLength > 0 {
+		where("e.length >= ?", query.MinLength)
+	}
+	if query.MaxLength > 0 {
+		where("e.length <= ?", query.MaxLength)
+	}
+	if query.MinArea > 0 {
+		where("e.width * e.length >= ?", query.MinArea)
+	}
+	if query.MaxArea > 0 {
+		where("e.width * e.length <= ?", query.MaxArea)
+	}
+
+	key := "e.created_at"
+	if query.SortByArea {
+		key = "e.width * e.length"
+	}
+	direction, compare := "ASC", ">"
+	if query.Descending {
+		direction, compare = "DESC", "<"
+	}
+
+	if query.After != nil {
+		var after any = sqliteTime(query.After.CreatedAt)
+		if query.SortByArea {
+			after = int64(query.After.Width) * int64(query.After.Length)
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, e.id) %s (?, ?)", key, compare))
+		args = append(args, after, query.After.ID)
+	}
+
+	treeCount := "0"
+	if query.WithTreeCount {
+		treeCount = "(SELECT COUNT(*) FROM trees t WHERE t.estate_id = e.id)"
+	}
+
+	statement := fmt.Sprintf("SELECT e.id, e.width, e.length, e.created_at, %s FROM estates e", treeCount)
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += fmt.Sprintf(" ORDER BY %s %s, e.id %s LIMIT ?", key, direction, direction)
+	// A negative limit is no limit
+	limit := query.Limit
+	if limit == 0 {
+		limit = -1
+	}
+	args = append(args, limit)
+
+	rows, err := r.q().QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var estates []Estate
+	for rows.Next() {
+		var (
+			estate    Estate
+			createdAt int64
+		)
+		if err := rows.Scan(&estate.ID, &estate.Width, &estate.Length, &createdAt, &estate.TreeCount); err != nil {
+			return nil, err
+		}
+		estate.CreatedAt = parseSQLiteTime(createdAt)
+		estates = append(estates, estate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return estates, nil
+}
+
+// GetEstateDetail retrieves an estate from the database by ID, with the
+// number of trees planted on it
+func (r *sqliteRepository) GetEstateDetail(ctx context.Context, id uuid.UUID) (Estate, error) {
+	var estate Estate
+	err := r.q().QueryRowContext(ctx,
+		`SELECT e.id, e.width, e.length, (SELECT COUNT(*) FROM trees t WHERE t.estate_id = e.id)
+		FROM estates e WHERE e.id = ?`,
+		id).Scan(&estate.ID, &estate.Width, &estate.Length, &estate.TreeCount)
+	return estate, noRows(err)
+}
+
+// sqliteZonesOutside selects the zones of an estate with a vertex beyond a
+// width and length
+const sqliteZonesOutside = `SELECT zones.id FROM zones, json_each(zones.vertices) vertex
+	WHERE zones.estate_id = ? AND (json_extract(vertex.value, '$.X') > ? OR json_extract(vertex.value, '$.Y') > ?)`
+
+// ResizeEstate changes the dimensions of an estate. Trees, zones and obstacles
+// left outside the new dimensions are removed if prune is set, otherwise the
+// estate is left as is and ErrTreesOutOfBounds, ErrZonesOutOfBounds or
+// ErrObstaclesOutOfBounds is returned. It returns the number of trees removed,
+// or pgx.ErrNoRows if there is no such estate.
+func (r *sqliteRepository) ResizeEstate(ctx context.Context, id uuid.UUID, width, length int, prune bool) (int, error) {
+	pruned := 0
+	err := r.WithTx(ctx, func(repo Repository) error {
+		q := repo.(*sqliteRepository).q()
+
+		var found uuid.UUID
+		if err := q.QueryRowContext(ctx, "SELECT id FROM estates WHERE id = ?", id).Scan(&found); err != nil {
+			return noRows(err)
+		}
+
+		if prune {
+			result, err := q.ExecContext(ctx,
+				"DELETE FROM trees WHERE estate_id = ? AND (x > ? OR y > ?)",
+				id, width, length)
+			if err != nil {
+				return err
+			}
+			count, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			pruned = int(count)
+
+			if _, err := q.ExecContext(ctx,
+				"DELETE FROM zones WHERE id IN ("+sqliteZonesOutside+")",
+				id, width, length); err != nil {
+				return err
+			}
+			if _, err := q.ExecContext(ctx,
+				"DELETE FROM obstacles WHERE estate_id = ? AND (x_max > ? OR y_max > ?)",
+				id, width, length); err != nil {
+				return err
+			}
+		} else {
+			var outside bool
+			err := q.QueryRowContext(ctx,
+				"SELECT EXISTS (SELECT 1 FROM trees WHERE estate_id = ? AND (x > ? OR y > ?))",
+				id, width, length).Scan(&outside)
+			if err != nil {
+				return err
+			}
+			if outside {
+				return ErrTreesOutOfBounds
+			}
+
+			err = q.QueryRowContext(ctx,
+				"SELECT EXISTS ("+sqliteZonesOutside+")",
+				id, width, length).Scan(&outside)
+			if err != nil {
+				return err
+			}
+			if outside {
+				return ErrZonesOutOfBounds
+			}
+
+			err = q.QueryRowContext(ctx,
+				"SELECT EXISTS (SELECT 1 FROM obstacles WHERE estate_id = ? AND (x_max > ? OR y_max > ?))",
+				id, width, length).Scan(&outside)
+			if err != nil {
+				return err
+			}
+			if outside {
+				return ErrObstaclesOutOfBounds
+			}
+		}
+
+		_, err := q.ExecContext(ctx,
+			"UPDATE estates SET width = ?, length = ? WHERE id = ?",
+			width, length, id)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return pruned, nil
+}
+
+// DeleteEstate deletes an estate along with its trees, zones, obstacles and
+// drone plan jobs. It returns pgx.ErrNoRows if there is no such estate.
+func (r *sqliteRepository) DeleteEstate(ctx context.Context, id uuid.UUID) error {
+	return affected(r.q().ExecContext(ctx, "DELETE FROM estates WHERE id = ?", id))
+}
+
+// sqliteTreeColumns lists the columns scanned by scanSQLiteTree
+const sqliteTreeColumns = "id, estate_id, x, y, height, COALESCE(species, ''), planted_on, COALESCE(health, ''), tags"
+
+// scanSQLiteTree scans a row selected with sqliteTreeColumns into a Tree
+func scanSQLiteTree(row sqliteScanner) (Tree, error) {
+	var (
+		tree      Tree
+		plantedOn sql.NullString
+		tags      string
+	)
+	if err := row.Scan(&tree.ID, &tree.EstateID, &tree.X, &tree.Y, &tree.Height,
+		&tree.Species, &plantedOn, &tree.Health, &tags); err != nil {
+		return Tree{}, err
+	}
+	if plantedOn.Valid {
+		date, err := time.Parse(time.DateOnly, plantedOn.String)
+		if err != nil {
+			return Tree{}, err
+		}
+		tree.PlantedOn = &date
+	}
+	if err := json.Unmarshal([]byte(tags), &tree.Tags); err != nil {
+		return Tree{}, err
+	}
+	return tree, nil
+}
+
+// sqliteTreeValues returns the optional attributes of a tree as stored in the
+// database, empty ones being NULL
+func sqliteTreeValues(tree Tree) (species, plantedOn, health *string, tags string) {
+	if tree.Species != "" {
+		species = &tree.Species
+	}
+	if tree.PlantedOn != nil {
+		date := sqliteDate(*tree.PlantedOn)
+		plantedOn = &date
+	}
+	if tree.Health != "" {
+		health = &tree.Health
+	}
+	if tree.Tags == nil {
+		tree.Tags = []string{}
+	}
+	// Marshalling a slice of strings cannot fail
+	encoded, _ := json.Marshal(tree.Tags)
+	return species, plantedOn, health, string(encoded)
+}
+
+// applySQLite adds the conditions of the filter to a query through where
+func (f TreeFilter) applySQLite(where func(condition string, value any)) {
+	if f.Species != "" {
+		where("species = ?", f.Species)
+	}
+	if f.Health != "" {
+		where("health = ?", f.Health)
+	}
+	if len(f.Tags) > 0 {
+		// Marshalling a slice of strings cannot fail
+		tags, _ := json.Marshal(f.Tags)
+		where("NOT EXISTS (SELECT 1 FROM json_each(?) wanted WHERE wanted.value NOT IN (SELECT value FROM json_each(trees.tags)))", string(tags))
+	}
+	if !f.PlantedFrom.IsZero() {
+		where("planted_on >= ?", sqliteDate(f.PlantedFrom))
+	}
+	if !f.PlantedTo.IsZero() {
+		where("planted_on <= ?", sqliteDate(f.PlantedTo))
+	}
+}
+
+// CreateTree creates a new tree in the database, returning ErrPlotOccupied if
+// the plot already has a tree
+func (r *sqliteRepository) CreateTree(ctx context.Context, tree Tree) (uuid.UUID, error) {
+	id := uuid.New()
+	if err := r.insertTree(ctx, r.q(), id, tree); err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// insertTree inserts a tree with the given ID, returning ErrPlotOccupied if
+// the plot already has a tree
+func (r *sqliteRepository) insertTree(ctx context.Context, q sqliteQuerier, id uuid.UUID, tree Tree) error {
+	species, plantedOn, health, tags := sqliteTreeValues(tree)
+	now := sqliteTime(time.Now())
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO trees (id, estate_id, x, y, height, species, planted_on, health, tags, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, tree.EstateID, tree.X, tree.Y, tree.Height, species, plantedOn, health, tags, now, now)
+	if isSQLiteUniqueViolation(err) {
+		return ErrPlotOccupied
+	}
+	return err
+}
+
+// GetTrees retrieves all trees for an estate from the database
+func (r *sqliteRepository) GetTrees(ctx context.Context, estateID uuid.UUID) ([]Tree, error) {
+	var trees []Tree
+	err := r.EachTree(ctx, estateID, func(tree Tree) error {
+		trees = append(trees, tree)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return trees, nil
+}
+
+// EachTree calls fn for every tree of an estate, ordered by row and then by
+// column, without holding them all in memory. It stops at the first error
+// returned by fn.
+func (r *sqliteRepository) EachTree(ctx context.Context, estateID uuid.UUID, fn func(tree Tree) error) error {
+	rows, err := r.q().QueryContext(ctx,
+		"SELECT "+sqliteTreeColumns+" FROM trees WHERE estate_id = ? ORDER BY y, x",
+		estateID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tree, err := scanSQLiteTree(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(tree); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ListTrees retrieves a page of the trees of an estate, using keyset
+// pagination on the sort columns
+func (r *sqliteRepository) ListTrees(ctx context.Context, estateID uuid.UUID, query TreeQuery) ([]Tree, error) {
+	conditions := []string{"estate_id = ?"}
+	args := []any{estateID}
+	where := func(condition string, value any) {
+		conditions = append(conditions, condition)
+		args = append(args, value)
+	}
+
+	if query.MinHeight > 0 {
+		where("height >= ?", query.MinHeight)
+	}
+	if query.MaxHeight > 0 {
+		where("height <= ?", query.MaxHeight)
+	}
+	if query.XMin > 0 {
+		where("x >= ?", query.XMin)
+	}
+	if query.XMax > 0 {
+		where("x <= ?", query.XMax)
+	}
+	if query.YMin > 0 {
+		where("y >= ?", query.YMin)
+	}
+	if query.YMax > 0 {
+		where("y <= ?", query.YMax)
+	}
+	query.Filter.applySQLite(where)
+
+	columns := "x, y"
+	if query.SortByHeight {
+		columns = "height, x, y"
+	}
+	direction, compare := "ASC", ">"
+	if query.Descending {
+		direction, compare = "DESC", "<"
+	}
+
+	if query.After != nil {
+		if query.SortByHeight {
+			conditions = append(conditions, fmt.Sprintf("(height, x, y) %s (?, ?, ?)", compare))
+			args = append(args, query.After.Height, query.After.X, query.After.Y)
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(x, y) %s (?, ?)", compare))
+			args = append(args, query.After.X, query.After.Y)
+		}
+	}
+
+	order := strings.ReplaceAll(columns, ",", " "+direction+",") + " " + direction
+	args = append(args, query.Limit)
+	rows, err := r.q().QueryContext(ctx,
+		fmt.Sprintf("SELECT %s FROM trees WHERE %s ORDER BY %s LIMIT ?",
+			sqliteTreeColumns, strings.Join(conditions, " AND "), order),
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trees []Tree
+	for rows.Next() {
+		tree, err := scanSQLiteTree(rows)
+		if err != nil {
+			return nil, err
+		}
+		trees = append(trees, tree)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	zones, err := r.ListZones(ctx, estateID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range trees {
+		trees[i].Zones = zonesAt(zones, Point{X: trees[i].X, Y: trees[i].Y})
+	}
+
+	return trees, nil
+}
+
+// GetTree retrieves a tree of an estate from the database by ID
+func (r *sqliteRepository) GetTree(ctx context.Context, estateID, treeID uuid.UUID) (Tree, error) {
+	tree, err := scanSQLiteTree(r.q().QueryRowContext(ctx,
+		"SELECT "+sqliteTreeColumns+" FROM trees WHERE id = ? AND estate_id = ?",
+		treeID, estateID))
+	if err != nil {
+		return Tree{}, noRows(err)
+	}
+
+	zones, err := r.ListZones(ctx, estateID)
+	if err != nil {
+		return Tree{}, err
+	}
+	tree.Zones = zonesAt(zones, Point{X: tree.X, Y: tree.Y})
+	return tree, nil
+}
+
+// UpdateTree moves a tree of an estate and changes its height and attributes.
+// It returns pgx.ErrNoRows if there is no such tree and ErrPlotOccupied if
+// another tree stands on the target plot.
+func (r *sqliteRepository) UpdateTree(ctx context.Context, tree Tree) error {
+	species, plantedOn, health, tags := sqliteTreeValues(tree)
+	result, err := r.q().ExecContext(ctx,
+		`UPDATE trees SET x = ?, y = ?, height = ?, species = ?, planted_on = ?, health = ?, tags = ?, updated_at = ?
+		WHERE id = ? AND estate_id = ?`,
+		tree.X, tree.Y, tree.Height, species, plantedOn, health, tags, sqliteTime(time.Now()), tree.ID, tree.EstateID)
+	if isSQLiteUniqueViolation(err) {
+		return ErrPlotOccupied
+	}
+	return affected(result, err)
+}
+
+// DeleteTree deletes a tree of an estate. It returns pgx.ErrNoRows if there
+// is no such tree.
+func (r *sqliteRepository) DeleteTree(ctx context.Context, estateID, treeID uuid.UUID) error {
+	return affected(r.q().ExecContext(ctx,
+		"DELETE FROM trees WHERE id = ? AND estate_id = ?",
+		treeID, estateID))
+}
+
+// CreateTrees plants many trees on an estate at once. Either all trees are
+// created or, if any plot already has a tree, none is and ErrPlotOccupied is
+// returned.
+func (r *sqliteRepository) CreateTrees(ctx context.Context, estateID uuid.UUID, trees []Tree) (int, error) {
+	err := r.WithTx(ctx, func(repo Repository) error {
+		q := repo.(*sqliteRepository).q()
+		for _, tree := range trees {
+			tree.EstateID = estateID
+			if err := r.insertTree(ctx, q, uuid.New(), tree); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(trees), nil
+}
+
+// OccupiedPlots returns those of the given plots of an estate that have a
+// tree, in no particular order. The plots are passed as one JSON array, as
+// there may be more of them than a statement takes parameters.
+func (r *sqliteRepository) OccupiedPlots(ctx context.Context, estateID uuid.UUID, plots []Point) ([]Point, error) {
+	if len(plots) == 0 {
+		return nil, nil
+	}
+
+	// Marshalling a slice of points cannot fail
+	encoded, _ := json.Marshal(plots)
+	rows, err := r.q().QueryContext(ctx,
+		`SELECT x, y FROM trees WHERE estate_id = ? AND (x, y) IN (
+			SELECT json_extract(value, '$.X'), json_extract(value, '$.Y') FROM json_each(?)
+		)`,
+		estateID, string(encoded))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var occupied []Point
+	for rows.Next() {
+		var plot Point
+		if err := rows.Scan(&plot.X, &plot.Y); err != nil {
+			return nil, err
+		}
+		occupied = append(occupied, plot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return occupied, nil
+}
+
+// sqliteJobColumns lists the columns scanned by scanSQLiteJob
+const sqliteJobColumns = "id, estate_id, status, progress, options, result, COALESCE(error, ''), created_at, updated_at"
+
+// scanSQLiteJob scans a row selected with sqliteJobColumns into a Job
+func scanSQLiteJob(row sqliteScanner) (Job, error) {
+	var (
+		job                  Job
+		createdAt, updatedAt int64
+	)
+	err := row.Scan(&job.ID, &job.EstateID, &job.Status, &job.Progress, &job.Options, &job.Result,
+		&job.Error, &createdAt, &updatedAt)
+	if err != nil {
+		return Job{}, noRows(err)
+	}
+	job.CreatedAt, job.UpdatedAt = parseSQLiteTime(createdAt), parseSQLiteTime(updatedAt)
+	return job, nil
+}
+
+// sqliteJSON returns a JSON document as stored in the database, as text or
+// NULL if there is none
+func sqliteJSON(document []byte) any {
+	if document == nil {
+		return nil
+	}
+	return string(document)
+}
+
+// CreateJob creates a new queued drone plan job in the database
+func (r *sqliteRepository) CreateJob(ctx context.Context, estateID uuid.UUID, options []byte) (uuid.UUID, error) {
+	id := uuid.New()
+	now := sqliteTime(time.Now())
+	_, err := r.q().ExecContext(ctx,
+		"INSERT INTO drone_plan_jobs (id, estate_id, status, options, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)",
+		id, estateID, JobStatusQueued, sqliteJSON(options), now, now)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// GetJob retrieves a drone plan job from the database by ID
+func (r *sqliteRepository) GetJob(ctx context.Context, id uuid.UUID) (Job, error) {
+	return scanSQLiteJob(r.q().QueryRowContext(ctx,
+		"SELECT "+sqliteJobColumns+" FROM drone_plan_jobs WHERE id = ?",
+		id))
+}
+
+// ClaimJob marks the oldest queued job as running and returns it. Concurrent
+// callers never claim the same job, as the statement runs under the write
+// lock. It returns pgx.ErrNoRows if no job is queued.
+func (r *sqliteRepository) ClaimJob(ctx context.Context) (Job, error) {
+	return scanSQLiteJob(r.q().QueryRowContext(ctx,
+		`UPDATE drone_plan_jobs SET status = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM drone_plan_jobs WHERE status = ?
+			ORDER BY created_at, id
+			LIMIT 1
+		)
+		RETURNING `+sqliteJobColumns,
+		JobStatusRunning, sqliteTime(time.Now()), JobStatusQueued))
+}
+
+// UpdateJobProgress records the progress of a running job. It reports whether
+// the job is still running, which it is not once it has been cancelled.
+func (r *sqliteRepository) UpdateJobProgress(ctx context.Context, id uuid.UUID, progress int) (bool, error) {
+	err := affected(r.q().ExecContext(ctx,
+		"UPDATE drone_plan_jobs SET progress = ?, updated_at = ? WHERE id = ? AND status = ?",
+		progress, sqliteTime(time.Now()), id, JobStatusRunning))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// FinishJob records the outcome of a running job. A job cancelled in the
+// meantime stays cancelled.
+func (r *sqliteRepository) FinishJob(ctx context.Context, id uuid.UUID, status string, result []byte, errMsg string) error {
+	_, err := r.q().ExecContext(ctx,
+		`UPDATE drone_plan_jobs
+		SET status = ?1, result = ?2, error = NULLIF(?3, ''),
+			progress = CASE WHEN ?1 = ?4 THEN 100 ELSE progress END, updated_at = ?5
+		WHERE id = ?6 AND status = ?7`,
+		status, sqliteJSON(result), errMsg, JobStatusSucceeded, sqliteTime(time.Now()), id, JobStatusRunning)
+	return err
+}
+
+// CancelJob marks a queued or running job as cancelled. It returns
+// pgx.ErrNoRows if there is no such job or the job has already finished.
+func (r *sqliteRepository) CancelJob(ctx context.Context, id uuid.UUID) error {
+	return affected(r.q().ExecContext(ctx,
+		"UPDATE drone_plan_jobs SET status = ?, updated_at = ? WHERE id = ? AND status IN (?, ?)",
+		JobStatusCancelled, sqliteTime(time.Now()), id, JobStatusQueued, JobStatusRunning))
+}
+
+// RequeueRunningJobs puts jobs left running by a previous run of the server
+// back into the queue
+func (r *sqliteRepository) RequeueRunningJobs(ctx context.Context) error {
+	_, err := r.q().ExecContext(ctx,
+		"UPDATE drone_plan_jobs SET status = ?, progress = 0, updated_at = ? WHERE status = ?",
+		JobStatusQueued, sqliteTime(time.Now()), JobStatusRunning)
+	return err
+}
+
+// RequeueJob puts a running job back into the queue, such as one interrupted
+// by the server shutting down. A job cancelled in the meantime stays cancelled.
+func (r *sqliteRepository) RequeueJob(ctx context.Context, id uuid.UUID) error {
+	_, err := r.q().ExecContext(ctx,
+		"UPDATE drone_plan_jobs SET status = ?, progress = 0, updated_at = ? WHERE id = ? AND status = ?",
+		JobStatusQueued, sqliteTime(time.Now()), id, JobStatusRunning)
+	return err
+}
+
+// selectTrees retrieves the position and height of the trees of an estate
+// matching the given conditions
+func (r *sqliteRepository) selectTrees(ctx context.Context, conditions []string, args []any) ([]Tree, error) {
+	rows, err := r.q().QueryContext(ctx,
+		"SELECT x, y, height FROM trees WHERE "+strings.Join(conditions, " AND "),
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trees []Tree
+	for rows.Next() {
+		var tree Tree
+		if err := rows.Scan(&tree.X, &tree.Y, &tree.Height); err != nil {
+			return nil, err
+		}
+		trees = append(trees, tree)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return trees, nil
+}
+
+// GetTreeStats calculates the tree statistics of an estate. The percentiles
+// are interpolated like the median.
+func (r *sqliteRepository) GetTreeStats(ctx context.Context, estateID uuid.UUID, query StatsQuery) (Stats, error) {
+	conditions := []string{"estate_id = ?"}
+	args := []any{estateID}
+	where := func(condition string, value any) {
+		conditions = append(conditions, condition)
+		args = append(args, value)
+	}
+
+	if query.XMin > 0 {
+		where("x >= ?", query.XMin)
+	}
+	if query.XMax > 0 {
+		where("x <= ?", query.XMax)
+	}
+	if query.YMin > 0 {
+		where("y >= ?", query.YMin)
+	}
+	if query.YMax > 0 {
+		where("y <= ?", query.YMax)
+	}
+	query.Filter.applySQLite(where)
+
+	var zone *Zone
+	if query.ZoneID != uuid.Nil {
+		found, err := scanSQLiteZone(r.q().QueryRowContext(ctx,
+			"SELECT "+sqliteZoneColumns+" FROM zones WHERE id = ?",
+			query.ZoneID))
+		if errors.Is(err, pgx.ErrNoRows) {
+			// An unknown zone holds no trees
+			return heightStats(nil, query.Percentiles), nil
+		}
+		if err != nil {
+			return Stats{}, err
+		}
+		zone = &found
+	}
+
+	trees, err := r.selectTrees(ctx, conditions, args)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	var heights []int
+	for _, tree := range trees {
+		if zone == nil || containsPoint(zone.Vertices, Point{X: tree.X, Y: tree.Y}) {
+			heights = append(heights, tree.Height)
+		}
+	}
+	return heightStats(heights, query.Percentiles), nil
+}
+
+// GetGridStats calculates the tree statistics of each cell of a grid over an
+// estate. Cells without trees are left out.
+func (r *sqliteRepository) GetGridStats(ctx context.Context, estateID uuid.UUID, query GridQuery) ([]CellStats, error) {
+	if query.CellSize < 1 {
+		return nil, errors.New("cell size must be positive")
+	}
+
+	conditions := []string{"estate_id = ? AND x BETWEEN ? AND ? AND y BETWEEN ? AND ?"}
+	args := []any{estateID, query.XMin, query.XMax, query.YMin, query.YMax}
+	query.Filter.applySQLite(func(condition string, value any) {
+		conditions = append(conditions, condition)
+		args = append(args, value)
+	})
+
+	trees, err := r.selectTrees(ctx, conditions, args)
+	if err != nil {
+		return nil, err
+	}
+	return cellStats(trees, query), nil
+}
+
+// ListTreeMeasurements retrieves the height history of a tree from the
+// database, oldest first
+func (r *sqliteRepository) ListTreeMeasurements(ctx context.Context, treeID uuid.UUID) ([]TreeMeasurement, error) {
+	rows, err := r.q().QueryContext(ctx,
+		"SELECT height, measured_at FROM tree_measurements WHERE tree_id = ? ORDER BY measured_at, id",
+		treeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var measurements []TreeMeasurement
+	for rows.Next() {
+		var (
+			measurement TreeMeasurement
+			measuredAt  int64
+		)
+		if err := rows.Scan(&measurement.Height, &measuredAt); err != nil {
+			return nil, err
+		}
+		measurement.MeasuredAt = parseSQLiteTime(measuredAt)
+		measurements = append(measurements, measurement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return measurements, nil
+}
+
+// GetGrowthStats calculates how fast the trees of an estate grow, listing up
+// to limit of the fastest and of the slowest growing trees
+func (r *sqliteRepository) GetGrowthStats(ctx context.Context, estateID uuid.UUID, limit int) (GrowthStats, error) {
+	rows, err := r.q().QueryContext(ctx,
+		`SELECT t.id, t.x, t.y, m.height, m.measured_at
+		FROM tree_measurements m
+		JOIN trees t ON t.id = m.tree_id
+		WHERE t.estate_id = ?
+		ORDER BY t.id, m.measured_at, m.id`,
+		estateID)
+	if err != nil {
+		return GrowthStats{}, err
+	}
+	defer rows.Close()
+
+	var (
+		growths      []TreeGrowth
+		tree         Tree
+		measurements []TreeMeasurement
+	)
+	// The measurements of a tree follow each other, so each tree is done
+	// once the next one starts
+	done := func() {
+		if growth, ok := treeGrowth(tree, measurements); ok {
+			growths = append(growths, growth)
+		}
+	}
+	for rows.Next() {
+		var (
+			measured    Tree
+			measurement TreeMeasurement
+			measuredAt  int64
+		)
+		if err := rows.Scan(&measured.ID, &measured.X, &measured.Y, &measurement.Height, &measuredAt); err != nil {
+			return GrowthStats{}, err
+		}
+		measurement.MeasuredAt = parseSQLiteTime(measuredAt)
+		if measured.ID != tree.ID {
+			done()
+			tree, measurements = measured, nil
+		}
+		measurements = append(measurements, measurement)
+	}
+	done()
+
+	if err := rows.Err(); err != nil {
+		return GrowthStats{}, err
+	}
+
+	return growthStats(growths, limit), nil
+}
+
+// sqliteZoneColumns lists the columns scanned by scanSQLiteZone
+const sqliteZoneColumns = "id, estate_id, name, shape, vertices, created_at"
+
+// scanSQLiteZone scans a row selected with sqliteZoneColumns into a Zone
+func scanSQLiteZone(row sqliteScanner) (Zone, error) {
+	var (
+		zone      Zone
+		vertices  string
+		createdAt int64
+	)
+	if err := row.Scan(&zone.ID, &zone.EstateID, &zone.Name, &zone.Shape, &vertices, &createdAt); err != nil {
+		return Zone{}, noRows(err)
+	}
+	if err := json.Unmarshal([]byte(vertices), &zone.Vertices); err != nil {
+		return Zone{}, err
+	}
+	zone.CreatedAt = parseSQLiteTime(createdAt)
+	return zone, nil
+}
+
+// sqliteVertices returns the vertices of a zone as stored in the database
+func sqliteVertices(vertices []Point) string {
+	if vertices == nil {
+		vertices = []Point{}
+	}
+	// Marshalling a slice of points cannot fail
+	encoded, _ := json.Marshal(vertices)
+	return string(encoded)
+}
+
+// CreateZone creates a new zone in the database. It returns ErrZoneExists if
+// the estate already has a zone with the same name.
+func (r *sqliteRepository) CreateZone(ctx context.Context, zone Zone) (uuid.UUID, error) {
+	id := uuid.New()
+	_, err := r.q().ExecContext(ctx,
+		"INSERT INTO zones (id, estate_id, name, shape, vertices, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		id, zone.EstateID, zone.Name, zone.Shape, sqliteVertices(zone.Vertices), sqliteTime(time.Now()))
+	if isSQLiteUniqueViolation(err) {
+		return uuid.Nil, ErrZoneExists
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// GetZone retrieves a zone of an estate from the database by ID
+func (r *sqliteRepository) GetZone(ctx context.Context, estateID, zoneID uuid.UUID) (Zone, error) {
+	return scanSQLiteZone(r.q().QueryRowContext(ctx,
+		"SELECT "+sqliteZoneColumns+" FROM zones WHERE id = ? AND estate_id = ?",
+		zoneID, estateID))
+}
+
+// GetZoneByName retrieves a zone of an estate from the database by name
+func (r *sqliteRepository) GetZoneByName(ctx context.Context, estateID uuid.UUID, name string) (Zone, error) {
+	return scanSQLiteZone(r.q().QueryRowContext(ctx,
+		"SELECT "+sqliteZoneColumns+" FROM zones WHERE estate_id = ? AND name = ?",
+		estateID, name))
+}
+
+// ListZones retrieves all zones of an estate from the database, ordered by name
+func (r *sqliteRepository) ListZones(ctx context.Context, estateID uuid.UUID) ([]Zone, error) {
+	rows, err := r.q().QueryContext(ctx,
+		"SELECT "+sqliteZoneColumns+" FROM zones WHERE estate_id = ? ORDER BY name",
+		estateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var zones []Zone
+	for rows.Next() {
+		zone, err := scanSQLiteZone(rows)
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, zone)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+// UpdateZone renames and reshapes a zone of an estate. It returns
+// pgx.ErrNoRows if there is no such zone and ErrZoneExists if another zone of
+// the estate has the new name.
+func (r *sqliteRepository) UpdateZone(ctx context.Context, zone Zone) error {
+	result, err := r.q().ExecContext(ctx,
+		"UPDATE zones SET name = ?, shape = ?, vertices = ? WHERE id = ? AND estate_id = ?",
+		zone.Name, zone.Shape, sqliteVertices(zone.Vertices), zone.ID, zone.EstateID)
+	if isSQLiteUniqueViolation(err) {
+		return ErrZoneExists
+	}
+	return affected(result, err)
+}
+
+// DeleteZone deletes a zone of an estate. The trees in it are left alone. It
+// returns pgx.ErrNoRows if there is no such zone.
+func (r *sqliteRepository) DeleteZone(ctx context.Context, estateID, zoneID uuid.UUID) error {
+	return affected(r.q().ExecContext(ctx,
+		"DELETE FROM zones WHERE id = ? AND estate_id = ?",
+		zoneID, estateID))
+}
+
+// sqliteObstacleColumns lists the columns scanned by scanSQLiteObstacle
+const sqliteObstacleColumns = "id, estate_id, x_min, x_max, y_min, y_max, COALESCE(min_altitude, 0), impassable, created_at"
+
+// scanSQLiteObstacle scans a row selected with sqliteObstacleColumns into an
+// Obstacle
+func scanSQLiteObstacle(row sqliteScanner) (Obstacle, error) {
+	var (
+		obstacle  Obstacle
+		createdAt int64
+	)
+	err := row.Scan(&obstacle.ID, &obstacle.EstateID, &obstacle.XMin, &obstacle.XMax, &obstacle.YMin, &obstacle.YMax,
+		&obstacle.MinAltitude, &obstacle.Impassable, &createdAt)
+	obstacle.CreatedAt = parseSQLiteTime(createdAt)
+	return obstacle, err
+}
+
+// CreateObstacle inserts a new obstacle into the database
+func (r *sqliteRepository) CreateObstacle(ctx context.Context, obstacle Obstacle) (uuid.UUID, error) {
+	var minAltitude *int
+	if !obstacle.Impassable {
+		minAltitude = &obstacle.MinAltitude
+	}
+
+	id := uuid.New()
+	_, err := r.q().ExecContext(ctx,
+		"INSERT INTO obstacles (id, estate_id, x_min, x_max, y_min, y_max, min_altitude, impassable, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, obstacle.EstateID, obstacle.XMin, obstacle.XMax, obstacle.YMin, obstacle.YMax, minAltitude, obstacle.Impassable, sqliteTime(time.Now()))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// ListObstacles retrieves all obstacles of an estate from the database, in
+// the order they were created
+func (r *sqliteRepository) ListObstacles(ctx context.Context, estateID uuid.UUID) ([]Obstacle, error) {
+	rows, err := r.q().QueryContext(ctx,
+		"SELECT "+sqliteObstacleColumns+" FROM obstacles WHERE estate_id = ? ORDER BY created_at, id",
+		estateID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var obstacles []Obstacle
+	for rows.Next() {
+		obstacle, err := scanSQLiteObstacle(rows)
+		if err != nil {
+			return nil, err
+		}
+		obstacles = append(obstacles, obstacle)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return obstacles, nil
+}
+
+// DeleteObstacle deletes an obstacle of an estate. It returns pgx.ErrNoRows
+// if there is no such obstacle.
+func (r *sqliteRepository) DeleteObstacle(ctx context.Context, estateID, obstacleID uuid.UUID) error {
+	return affected(r.q().ExecContext(ctx,
+		"DELETE FROM obstacles WHERE id = ? AND estate_id = ?",
+		obstacleID, estateID))
+}
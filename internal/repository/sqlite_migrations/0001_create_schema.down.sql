@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS obstacles;
+DROP TABLE IF EXISTS zones;
+DROP TABLE IF EXISTS drone_plan_jobs;
+DROP TABLE IF EXISTS tree_measurements;
+DROP TABLE IF EXISTS trees;
+DROP TABLE IF EXISTS estates;
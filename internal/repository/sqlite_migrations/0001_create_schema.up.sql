@@ -0,0 +1,112 @@
+-- SQLite has no UUID, array, JSON, polygon or timestamp types: IDs are stored
+-- as text, tags, zone vertices and job documents as JSON text, dates as
+-- YYYY-MM-DD text and times as nanoseconds since the Unix epoch in UTC
+
+-- Create estate table
+CREATE TABLE estates (
+    id TEXT PRIMARY KEY,
+    width INTEGER NOT NULL CHECK (width BETWEEN 1 AND 50000),
+    length INTEGER NOT NULL CHECK (length BETWEEN 1 AND 50000),
+    created_at INTEGER NOT NULL
+);
+
+-- Estate listings sorted by creation time
+CREATE INDEX estates_created_at_id_idx ON estates (created_at, id);
+
+-- Create tree table
+CREATE TABLE trees (
+    id TEXT PRIMARY KEY,
+    estate_id TEXT NOT NULL REFERENCES estates(id) ON DELETE CASCADE,
+    x INTEGER NOT NULL CHECK (x >= 1),
+    y INTEGER NOT NULL CHECK (y >= 1),
+    height INTEGER NOT NULL CHECK (height BETWEEN 1 AND 30),
+    species TEXT,
+    planted_on TEXT,
+    health TEXT CHECK (health IN ('healthy', 'diseased', 'dead')),
+    tags TEXT NOT NULL DEFAULT '[]' CHECK (json_valid(tags)),
+    created_at INTEGER NOT NULL,
+    -- When the tree was last written, which its measurements are taken at
+    updated_at INTEGER NOT NULL,
+    -- Ensure only one tree per plot
+    UNIQUE (estate_id, x, y)
+);
+
+-- Tree listings sorted or filtered by height
+CREATE INDEX trees_estate_id_height_idx ON trees (estate_id, height);
+
+-- Create tree measurement table, the history of the height of every tree
+CREATE TABLE tree_measurements (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tree_id TEXT NOT NULL REFERENCES trees(id) ON DELETE CASCADE,
+    height INTEGER NOT NULL CHECK (height BETWEEN 1 AND 30),
+    measured_at INTEGER NOT NULL
+);
+
+-- Tree histories are read in the order they were measured
+CREATE INDEX tree_measurements_tree_id_measured_at_idx ON tree_measurements (tree_id, measured_at);
+
+-- Record the height a tree is planted with and every change of it, however
+-- the tree is written
+CREATE TRIGGER trees_planted AFTER INSERT ON trees
+BEGIN
+    INSERT INTO tree_measurements (tree_id, height, measured_at) VALUES (NEW.id, NEW.height, NEW.updated_at);
+END;
+
+CREATE TRIGGER trees_height_changed AFTER UPDATE OF height ON trees
+    WHEN OLD.height IS NOT NEW.height
+BEGIN
+    INSERT INTO tree_measurements (tree_id, height, measured_at) VALUES (NEW.id, NEW.height, NEW.updated_at);
+END;
+
+-- Create drone plan job table
+CREATE TABLE drone_plan_jobs (
+    id TEXT PRIMARY KEY,
+    estate_id TEXT NOT NULL REFERENCES estates(id) ON DELETE CASCADE,
+    status TEXT NOT NULL CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'cancelled')),
+    progress INTEGER NOT NULL DEFAULT 0 CHECK (progress BETWEEN 0 AND 100),
+    -- Plan options the job was submitted with, and the plan once it succeeded
+    options TEXT NOT NULL CHECK (json_valid(options)),
+    result TEXT CHECK (result IS NULL OR json_valid(result)),
+    error TEXT,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+-- Workers claim the oldest queued job first
+CREATE INDEX drone_plan_jobs_status_created_at_idx ON drone_plan_jobs (status, created_at);
+
+-- Create zone table
+CREATE TABLE zones (
+    id TEXT PRIMARY KEY,
+    estate_id TEXT NOT NULL REFERENCES estates(id) ON DELETE CASCADE,
+    name TEXT NOT NULL CHECK (length(name) BETWEEN 1 AND 100),
+    shape TEXT NOT NULL CHECK (shape IN ('rect', 'polygon')),
+    -- Vertices in plot coordinates as a JSON array of points; a plot belongs
+    -- to the zone if it lies inside or on the edge of the polygon
+    vertices TEXT NOT NULL CHECK (json_valid(vertices)),
+    created_at INTEGER NOT NULL,
+    -- Zones are looked up by name within an estate
+    UNIQUE (estate_id, name)
+);
+
+-- Create obstacle table
+CREATE TABLE obstacles (
+    id TEXT PRIMARY KEY,
+    estate_id TEXT NOT NULL REFERENCES estates(id) ON DELETE CASCADE,
+    -- Rectangle of plots covered by the obstacle, a single plot when the
+    -- minimum and maximum coincide
+    x_min INTEGER NOT NULL CHECK (x_min >= 1),
+    x_max INTEGER NOT NULL,
+    y_min INTEGER NOT NULL CHECK (y_min >= 1),
+    y_max INTEGER NOT NULL,
+    -- The drone either flies over the obstacle at min_altitude or above, or
+    -- cannot fly over it at all
+    min_altitude INTEGER CHECK (min_altitude >= 1),
+    impassable INTEGER NOT NULL DEFAULT 0,
+    created_at INTEGER NOT NULL,
+    CHECK (x_min <= x_max AND y_min <= y_max),
+    CHECK (impassable = (min_altitude IS NULL))
+);
+
+-- Every plan loads all obstacles of its estate
+CREATE INDEX obstacles_estate_id_idx ON obstacles (estate_id);
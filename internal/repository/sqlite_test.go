@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSQLiteRepository runs the repository contract against a new migrated
+// SQLite database for every test
+func TestSQLiteRepository(t *testing.T) {
+	testRepository(t, func(t *testing.T) Repository {
+		db, err := OpenSQLite(filepath.Join(t.TempDir(), "plantation.db"))
+		if err != nil {
+			t.Fatalf("opening database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		migrator, err := NewSQLiteMigrator(db)
+		if err != nil {
+			t.Fatalf("loading migrations: %v", err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			t.Fatalf("migrating database: %v", err)
+		}
+		return NewSQLiteRepository(db)
+	})
+}
+
+func TestSQLiteMigrator(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenSQLite(filepath.Join(t.TempDir(), "plantation.db"))
+	if err != nil {
+		t.Fatalf("opening database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := NewSQLiteMigrator(db)
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrator.migrations))
+
+	// Applied migrations are not applied again
+	applied, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := migrator.Status(ctx)
+	assert.NoError(t, err)
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, status.Name)
+	}
+
+	for range migrator.migrations {
+		reverted, err := migrator.Down(ctx)
+		assert.NoError(t, err)
+		assert.NotNil(t, reverted)
+	}
+	reverted, err := migrator.Down(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, reverted)
+
+	// Reverting every migration leaves no table behind but schema_migrations
+	var tables []string
+	rows, err := db.QueryContext(ctx, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	assert.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		assert.NoError(t, rows.Scan(&name))
+		tables = append(tables, name)
+	}
+	assert.Equal(t, []string{"schema_migrations"}, tables)
+
+	applied, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, applied, len(migrator.migrations))
+}
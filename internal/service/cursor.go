@@ -0,0 +1,22 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+// encodeCursor turns the position of the last item of a page into an opaque
+// cursor for the next page
+func encodeCursor(position any) string {
+	data, _ := json.Marshal(position)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a cursor made by encodeCursor into position
+func decodeCursor(cursor string, position any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || json.Unmarshal(data, position) != nil {
+		return NewValidationError("invalid cursor", "cursor")
+	}
+	return nil
+}
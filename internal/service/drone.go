@@ -3,7 +3,7 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
+	"slices"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -17,26 +17,26 @@ func (s *service) CalculateDronePath(ctx context.Context, estateID uuid.UUID) (d
 	width, length, err := s.repo.GetEstate(ctx, estateID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, errors.New("estate not found")
+			return 0, ErrEstateNotFound
 		}
 		return 0, err
 	}
 
-	// Get all trees for the estate
+	// Get all trees and obstacles for the estate
 	trees, err := s.repo.GetTrees(ctx, estateID)
 	if err != nil {
 		return 0, err
 	}
-
-	// Create a map for quick tree lookup by coordinates
-	treeMap := make(map[string]repository.Tree)
-	for _, tree := range trees {
-		key := coordKey(tree.X, tree.Y)
-		treeMap[key] = tree
+	obstacles, err := s.repo.ListObstacles(ctx, estateID)
+	if err != nil {
+		return 0, err
 	}
 
+	model := newFlightModel(trees, defaultClearance, 0)
+	model.addObstacles(width, length, obstacles)
+
 	// Calculate the drone path
-	return calculateDroneTravelDistance(width, length, treeMap), nil
+	return calculateDroneTravelDistance(ctx, rowZigzag{}, width, length, model, nil)
 }
 
 // CalculateDronePathWithRest implements the DroneService.CalculateDronePathWithRest method
@@ -45,32 +45,259 @@ func (s *service) CalculateDronePathWithRest(ctx context.Context, estateID uuid.
 	width, length, err := s.repo.GetEstate(ctx, estateID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, 0, 0, errors.New("estate not found")
+			return 0, 0, 0, ErrEstateNotFound
 		}
 		return 0, 0, 0, err
 	}
 
-	// Get all trees for the estate
+	// Get all trees and obstacles for the estate
 	trees, err := s.repo.GetTrees(ctx, estateID)
 	if err != nil {
 		return 0, 0, 0, err
 	}
-
-	// Create a map for quick tree lookup by coordinates
-	treeMap := make(map[string]repository.Tree)
-	for _, tree := range trees {
-		key := coordKey(tree.X, tree.Y)
-		treeMap[key] = tree
+	obstacles, err := s.repo.ListObstacles(ctx, estateID)
+	if err != nil {
+		return 0, 0, 0, err
 	}
 
+	model := newFlightModel(trees, defaultClearance, 0)
+	model.addObstacles(width, length, obstacles)
+
 	// Calculate drone path with rest point
-	totalDistance, restPos := calculateDronePathWithRest(width, length, treeMap, maxDistance)
+	totalDistance, restPos, err := calculateDronePathWithRest(ctx, rowZigzag{}, width, length, model, maxDistance, nil)
+	if err != nil {
+		return 0, 0, 0, err
+	}
 	return totalDistance, restPos.x, restPos.y, nil
 }
 
-// Helper function to create a key for the tree map
-func coordKey(x, y int) string {
-	return fmt.Sprintf("%d,%d", x, y)
+// PlanDronePath implements the DroneService.PlanDronePath method
+func (s *service) PlanDronePath(ctx context.Context, estateID uuid.UUID, opts DronePlanOptions) (*DronePlan, error) {
+	return s.planEstate(ctx, estateID, opts, nil)
+}
+
+// planEstate calculates a drone plan over an estate, reporting its progress
+// through report if it is not nil
+func (s *service) planEstate(ctx context.Context, estateID uuid.UUID, opts DronePlanOptions, report func(percent int)) (*DronePlan, error) {
+	// First check if estate exists
+	width, length, err := s.repo.GetEstate(ctx, estateID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEstateNotFound
+		}
+		return nil, err
+	}
+
+	// Get all trees and obstacles for the estate
+	trees, err := s.repo.GetTrees(ctx, estateID)
+	if err != nil {
+		return nil, err
+	}
+	obstacles, err := s.repo.ListObstacles(ctx, estateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Zone == "" {
+		return planDronePath(ctx, width, length, trees, obstacles, opts, report)
+	}
+
+	zone, err := s.findZone(ctx, estateID, opts.Zone)
+	if err != nil {
+		return nil, err
+	}
+	return planZone(ctx, zone, trees, obstacles, opts, report)
+}
+
+// planZone calculates a drone plan over the plots of a zone, as if its
+// bounding box were an estate of its own. The plots of the bounding box
+// outside the zone are left out of the patrol, but their trees and obstacles
+// are still flown over or around on the way between plots of the zone.
+func planZone(ctx context.Context, zone repository.Zone, trees []repository.Tree, obstacles []repository.Obstacle, opts DronePlanOptions, report func(percent int)) (*DronePlan, error) {
+	xMin, xMax, yMin, yMax := zoneBounds(zone.Vertices)
+
+	// Shift the zone so that its south-west corner is plot (1,1)
+	dx, dy := xMin-1, yMin-1
+	var zoneTrees []repository.Tree
+	for _, tree := range trees {
+		if tree.X >= xMin && tree.X <= xMax && tree.Y >= yMin && tree.Y <= yMax {
+			tree.X, tree.Y = tree.X-dx, tree.Y-dy
+			zoneTrees = append(zoneTrees, tree)
+		}
+	}
+
+	var zoneObstacles []repository.Obstacle
+	for _, obstacle := range obstacles {
+		if obstacle.XMax < xMin || obstacle.XMin > xMax || obstacle.YMax < yMin || obstacle.YMin > yMax {
+			continue
+		}
+		obstacle.XMin, obstacle.XMax = max(obstacle.XMin, xMin)-dx, min(obstacle.XMax, xMax)-dx
+		obstacle.YMin, obstacle.YMax = max(obstacle.YMin, yMin)-dy, min(obstacle.YMax, yMax)-dy
+		zoneObstacles = append(zoneObstacles, obstacle)
+	}
+
+	vertices := make([]repository.Point, len(zone.Vertices))
+	for i, v := range zone.Vertices {
+		vertices[i] = repository.Point{X: v.X - dx, Y: v.Y - dy}
+	}
+
+	plan, err := planArea(ctx, xMax-dx, yMax-dy, vertices, zoneTrees, zoneObstacles, opts, report)
+	if err != nil {
+		return nil, err
+	}
+
+	// Shift the plan back onto the estate
+	if plan.Rest != nil {
+		plan.Rest.X, plan.Rest.Y = plan.Rest.X+dx, plan.Rest.Y+dy
+	}
+	for i := range plan.Legs {
+		leg := &plan.Legs[i]
+		leg.Start.X, leg.Start.Y = leg.Start.X+dx, leg.Start.Y+dy
+		leg.Landing.X, leg.Landing.Y = leg.Landing.X+dx, leg.Landing.Y+dy
+	}
+	for i := range plan.Path {
+		plan.Path[i].X, plan.Path[i].Y = plan.Path[i].X+dx, plan.Path[i].Y+dy
+	}
+	for i := range plan.Unreachable {
+		rect := &plan.Unreachable[i]
+		rect.XMin, rect.XMax = rect.XMin+dx, rect.XMax+dx
+		rect.YMin, rect.YMax = rect.YMin+dy, rect.YMax+dy
+	}
+
+	return plan, nil
+}
+
+// planDronePath calculates a drone plan over the given estate, trees and
+// obstacles. If report is not nil it is called with the percentage of the
+// calculation done whenever that percentage grows.
+func planDronePath(ctx context.Context, width, length int, trees []repository.Tree, obstacles []repository.Obstacle, opts DronePlanOptions, report func(percent int)) (*DronePlan, error) {
+	return planArea(ctx, width, length, nil, trees, obstacles, opts, report)
+}
+
+// planArea calculates a drone plan like planDronePath, only patrolling the
+// plots inside or on the edge of the zone polygon if it is not nil
+func planArea(ctx context.Context, width, length int, zone []repository.Point, trees []repository.Tree, obstacles []repository.Obstacle, opts DronePlanOptions, report func(percent int)) (*DronePlan, error) {
+	clearance := opts.Clearance
+	if clearance == 0 {
+		clearance = defaultClearance
+	}
+	if clearance < 0 {
+		return nil, NewValidationError("invalid clearance", "clearance")
+	}
+
+	lookahead := 0
+	if opts.SmoothAltitude {
+		lookahead = opts.Lookahead
+		if lookahead == 0 {
+			lookahead = defaultLookahead
+		}
+		if lookahead < 0 {
+			return nil, NewValidationError("invalid lookahead", "lookahead")
+		}
+	}
+
+	if opts.ExcludeDead {
+		trees = slices.DeleteFunc(slices.Clone(trees), func(tree repository.Tree) bool {
+			return tree.Health == repository.TreeHealthDead
+		})
+	}
+
+	model := newFlightModel(trees, clearance, lookahead)
+	model.addObstacles(width, length, obstacles)
+	model.zone = zone
+
+	if report != nil {
+		// Every walk over the estate covers all of its plots, and an optimized
+		// plan walks once per variant plus once with the winner
+		passes := 1
+		if opts.Optimize {
+			passes += len(startCorners) * 2
+		}
+		if opts.SmoothAltitude {
+			passes += 2
+		}
+		model.progress = &planProgress{total: passes * width * length, report: report}
+	}
+
+	plan := &DronePlan{}
+
+	var (
+		strategy PathStrategy
+		err      error
+	)
+	if opts.Optimize {
+		var best DronePlanVariant
+		strategy, best, plan.Alternatives, err = optimizePathStrategy(ctx, width, length, model)
+		if err != nil {
+			return nil, err
+		}
+		plan.Pattern, plan.StartCorner = best.Pattern, best.StartCorner
+	} else {
+		strategy, err = getPathStrategy(opts.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		plan.Pattern, plan.StartCorner = opts.Pattern, defaultStartCorner
+		if plan.Pattern == "" {
+			plan.Pattern = defaultPattern
+		}
+	}
+
+	if opts.SmoothAltitude {
+		// Smoothing never changes the horizontal route, so the difference to
+		// following the terrain plot by plot is all vertical
+		terrain := *model
+		terrain.lookahead = 0
+		terrainDistance, err := calculateDroneTravelDistance(ctx, strategy, width, length, &terrain, nil)
+		if err != nil {
+			return nil, err
+		}
+		smoothedDistance, err := calculateDroneTravelDistance(ctx, strategy, width, length, model, nil)
+		if err != nil {
+			return nil, err
+		}
+		plan.VerticalSaved = terrainDistance - smoothedDistance
+	}
+
+	var path *pathRecorder
+	if opts.IncludePath {
+		path = &pathRecorder{}
+	}
+
+	switch {
+	case opts.Legs:
+		if opts.MaxDistance <= 0 {
+			return nil, NewValidationError("max distance is required for multi-leg plans", "max_distance")
+		}
+
+		plan.Legs, err = calculateDroneLegs(ctx, strategy, width, length, model, opts.MaxDistance, path)
+		if err != nil {
+			return nil, err
+		}
+		for _, leg := range plan.Legs {
+			plan.Distance += leg.Distance
+		}
+	case opts.MaxDistance > 0:
+		distance, restPos, err := calculateDronePathWithRest(ctx, strategy, width, length, model, opts.MaxDistance, path)
+		if err != nil {
+			return nil, err
+		}
+		plan.Distance = distance
+		plan.Rest = &Plot{X: restPos.x, Y: restPos.y}
+	default:
+		plan.Distance, err = calculateDroneTravelDistance(ctx, strategy, width, length, model, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if path != nil {
+		plan.Path = path.waypoints
+	}
+
+	plan.Unreachable, plan.UnreachablePlots = model.unreachable(strategy)
+
+	return plan, nil
 }
 
 // Position represents a 3D position (x, y, z)
@@ -78,108 +305,296 @@ type position struct {
 	x, y, z int
 }
 
-// calculateDroneTravelDistance calculates the total distance the drone travels
-func calculateDroneTravelDistance(width, length int, treeMap map[string]repository.Tree) int {
+// calculateDroneTravelDistance calculates the total distance the drone travels.
+// If path is not nil the waypoints of the flight are recorded into it.
+func calculateDroneTravelDistance(ctx context.Context, strategy PathStrategy, width, length int, model *flightModel, path *pathRecorder) (int, error) {
 	totalDistance := 0
-	
-	// Start at ground level at the southwestern-most plot (1,1)
-	currentPos := position{x: 1, y: 1, z: 0}
-	
-	// The drone travels in a zigzag pattern from south to north
-	for y := 1; y <= length; y++ {
-		// For even-numbered rows, go from west to east
-		if y%2 == 1 {
-			for x := 1; x <= width; x++ {
-				totalDistance += visitPlot(x, y, &currentPos, treeMap)
-			}
-		} else { // For odd-numbered rows, go from east to west
-			for x := width; x >= 1; x-- {
-				totalDistance += visitPlot(x, y, &currentPos, treeMap)
-			}
+
+	// Start at ground level on the first plot of the pattern
+	startX, startY, err := model.firstPlot(strategy, width, length)
+	if err != nil {
+		return 0, err
+	}
+	currentPos := position{x: startX, y: startY, z: 0}
+	path.add(currentPos)
+
+	err = model.walk(ctx, strategy, width, length, func(r run) bool {
+		distance, detour := model.visitPlot(r.x, r.y, r.z, &currentPos)
+		totalDistance += distance
+		path.add(detour...)
+		path.add(currentPos)
+
+		// The rest of the run is flat, costing 1 per plot
+		if r.count > 1 {
+			x, y := r.plot(r.count - 1)
+			totalDistance += visitPlot(x, y, r.z, &currentPos)
+			path.add(currentPos)
 		}
+		return true
+	})
+	if err != nil {
+		return 0, err
 	}
-	
+
 	// Return to ground level at the last plot
 	totalDistance += currentPos.z
-	
-	return totalDistance
+	path.add(position{x: currentPos.x, y: currentPos.y, z: 0})
+
+	return totalDistance, nil
 }
 
-// calculateDronePathWithRest calculates the drone path and determines the rest position
-func calculateDronePathWithRest(width, length int, treeMap map[string]repository.Tree, maxDistance int) (int, position) {
+// calculateDronePathWithRest calculates the drone path and determines the rest position.
+// If path is not nil the waypoints of the flight up to the rest are recorded into it.
+func calculateDronePathWithRest(ctx context.Context, strategy PathStrategy, width, length int, model *flightModel, maxDistance int, path *pathRecorder) (int, position, error) {
 	totalDistance := 0
 	restPos := position{x: 0, y: 0, z: 0}
-	
-	// Start at ground level at the southwestern-most plot (1,1)
-	currentPos := position{x: 1, y: 1, z: 0}
-	
-	// The drone travels in a zigzag pattern from south to north
-	outerLoop:
-	for y := 1; y <= length; y++ {
-		// For even-numbered rows, go from west to east
-		if y%2 == 1 {
-			for x := 1; x <= width; x++ {
-				distance := visitPlot(x, y, &currentPos, treeMap)
-				totalDistance += distance
-				
-				// Check if we've reached max distance
-				if totalDistance >= maxDistance {
-					// If we go over the max distance exactly at the plot, the rest position is the current plot
-					restPos = position{x: currentPos.x, y: currentPos.y, z: 0} // z=0 because we land on the ground
-					break outerLoop
-				}
-			}
-		} else { // For odd-numbered rows, go from east to west
-			for x := width; x >= 1; x-- {
-				distance := visitPlot(x, y, &currentPos, treeMap)
-				totalDistance += distance
-				
-				// Check if we've reached max distance
-				if totalDistance >= maxDistance {
-					// If we go over the max distance exactly at the plot, the rest position is the current plot
-					restPos = position{x: currentPos.x, y: currentPos.y, z: 0} // z=0 because we land on the ground
-					break outerLoop
-				}
-			}
+	rested := false
+
+	// Start at ground level on the first plot of the pattern
+	startX, startY, err := model.firstPlot(strategy, width, length)
+	if err != nil {
+		return 0, restPos, err
+	}
+	currentPos := position{x: startX, y: startY, z: 0}
+	path.add(currentPos)
+
+	err = model.walk(ctx, strategy, width, length, func(r run) bool {
+		distance, detour := model.visitPlot(r.x, r.y, r.z, &currentPos)
+		totalDistance += distance
+		path.add(detour...)
+		path.add(currentPos)
+
+		// Check if we've reached max distance
+		if totalDistance < maxDistance && r.count > 1 {
+			// The rest of the run is flat, costing 1 per plot, so fly on until
+			// either the run ends or the max distance is reached
+			steps := min(r.count-1, maxDistance-totalDistance)
+			x, y := r.plot(steps)
+			totalDistance += visitPlot(x, y, r.z, &currentPos)
+			path.add(currentPos)
+		}
+		if totalDistance >= maxDistance {
+			// If we go over the max distance exactly at the plot, the rest position is the current plot
+			restPos = position{x: currentPos.x, y: currentPos.y, z: 0} // z=0 because we land on the ground
+			rested = true
+			return false
 		}
+		return true
+	})
+	if err != nil {
+		return 0, restPos, err
 	}
-	
+
 	// If we've completed the entire path without reaching max distance
-	if totalDistance < maxDistance {
+	if !rested {
 		// The drone rests at the final plot
 		restPos = position{x: currentPos.x, y: currentPos.y, z: 0}
 	}
-	
+
 	// For both cases, we need to descend to ground level for the rest, but we've already
 	// accounted for this in the totalDistance calculation for the rest position
-	
-	return totalDistance, restPos
+	path.add(restPos)
+
+	return totalDistance, restPos, nil
+}
+
+// optimizePathStrategy evaluates the row and column zigzags from each of the
+// four corners of the estate and returns the strategy with the least total
+// distance, together with the winning variant and all evaluated variants
+func optimizePathStrategy(ctx context.Context, width, length int, model *flightModel) (PathStrategy, DronePlanVariant, []DronePlanVariant, error) {
+	var (
+		bestStrategy PathStrategy
+		best         DronePlanVariant
+		variants     []DronePlanVariant
+	)
+
+	for _, pattern := range []string{"row", "column"} {
+		for _, corner := range startCorners {
+			strategy := mirrored{base: pathStrategies[pattern], flipX: corner.flipX, flipY: corner.flipY}
+			distance, err := calculateDroneTravelDistance(ctx, strategy, width, length, model, nil)
+			if err != nil {
+				return nil, best, nil, err
+			}
+
+			variant := DronePlanVariant{
+				Pattern:     pattern,
+				StartCorner: corner.name,
+				Distance:    distance,
+			}
+			variants = append(variants, variant)
+
+			// Ties keep the earlier variant, so the default pattern wins when nothing is gained
+			if bestStrategy == nil || variant.Distance < best.Distance {
+				bestStrategy, best = strategy, variant
+			}
+		}
+	}
+
+	return bestStrategy, best, variants, nil
 }
 
-// visitPlot calculates the distance to visit a single plot
-func visitPlot(x, y int, currentPos *position, treeMap map[string]repository.Tree) int {
+// calculateDroneLegs splits the patrol into legs of at most maxDistance each.
+// Every leg takes off from the ground, patrols as many plots as the battery
+// allows while keeping enough charge to land, and lands on the last plot it
+// reached. The next leg takes off from that same plot. If path is not nil the
+// waypoints of all legs are recorded into it.
+func calculateDroneLegs(ctx context.Context, strategy PathStrategy, width, length int, model *flightModel, maxDistance int, path *pathRecorder) ([]DroneLeg, error) {
+	var legs []DroneLeg
+
+	// Start at ground level on the first plot of the pattern
+	startX, startY, err := model.firstPlot(strategy, width, length)
+	if err != nil {
+		return nil, err
+	}
+	currentPos := position{x: startX, y: startY, z: 0}
+	leg := DroneLeg{Start: Plot{X: startX, Y: startY}}
+	// fresh reports whether the current leg has not yet moved past its take-off plot
+	fresh := true
+	path.add(currentPos)
+
+	// swapBattery lands on the current plot, closing the leg, and takes off
+	// again from the same plot, climbing back to patrol altitude
+	swapBattery := func() {
+		leg.Distance += currentPos.z
+		leg.Landing = Plot{X: currentPos.x, Y: currentPos.y}
+		legs = append(legs, leg)
+		path.add(position{x: currentPos.x, y: currentPos.y, z: 0})
+
+		leg = DroneLeg{Start: Plot{X: currentPos.x, Y: currentPos.y}, Distance: currentPos.z}
+		fresh = true
+		path.add(currentPos)
+	}
+
+	errTooShort := NewValidationError("max distance too short to complete a leg", "max_distance")
+
+	var legErr error
+	err = model.walk(ctx, strategy, width, length, func(r run) bool {
+		nextPos := currentPos
+		distance, detour := model.visitPlot(r.x, r.y, r.z, &nextPos)
+
+		// Land here if flying on to the next plot would not leave enough
+		// battery to land there
+		if leg.Distance+distance+nextPos.z > maxDistance {
+			if fresh {
+				legErr = errTooShort
+				return false
+			}
+
+			swapBattery()
+
+			nextPos = currentPos
+			distance, detour = model.visitPlot(r.x, r.y, r.z, &nextPos)
+			if leg.Distance+distance+nextPos.z > maxDistance {
+				legErr = errTooShort
+				return false
+			}
+		}
+
+		path.add(detour...)
+		path.add(nextPos)
+		leg.Distance += distance
+		currentPos = nextPos
+		if r.x != leg.Start.X || r.y != leg.Start.Y {
+			fresh = false
+		}
+
+		// The rest of the run is flat, costing 1 per plot, so fly on as far as
+		// the battery allows, swapping batteries as often as needed
+		for done := 1; done < r.count; {
+			steps := min(r.count-done, maxDistance-r.z-leg.Distance)
+			if steps <= 0 {
+				if fresh {
+					legErr = errTooShort
+					return false
+				}
+				swapBattery()
+				continue
+			}
+
+			done += steps
+			x, y := r.plot(done - 1)
+			leg.Distance += visitPlot(x, y, r.z, &currentPos)
+			path.add(currentPos)
+			fresh = false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if legErr != nil {
+		return nil, legErr
+	}
+
+	// Land at the last plot
+	leg.Distance += currentPos.z
+	leg.Landing = Plot{X: currentPos.x, Y: currentPos.y}
+	legs = append(legs, leg)
+	path.add(position{x: currentPos.x, y: currentPos.y, z: 0})
+
+	return legs, nil
+}
+
+// pathRecorder collects drone waypoints, run-length compressing flat stretches:
+// when the drone flies straight along a sweep without changing altitude, only the
+// first and last plots of that stretch are kept
+type pathRecorder struct {
+	waypoints []Waypoint
+}
+
+// add appends positions to the recorded path. It does nothing on a nil
+// recorder, so callers need not check whether a path was requested.
+func (r *pathRecorder) add(positions ...position) {
+	if r == nil {
+		return
+	}
+
+	for _, pos := range positions {
+		next := Waypoint{X: pos.x, Y: pos.y, Z: pos.z}
+
+		if n := len(r.waypoints); n >= 2 {
+			prev, last := r.waypoints[n-2], r.waypoints[n-1]
+			// The drone flies from prev to next at prev's altitude, so last adds
+			// nothing if it sits on that straight line at the same altitude
+			if last.Z == prev.Z && isBetween(prev, last, next) {
+				r.waypoints[n-1] = next
+				continue
+			}
+		}
+
+		r.waypoints = append(r.waypoints, next)
+	}
+}
+
+// isBetween reports whether mid lies strictly between a and b on a line along
+// the x or y axis
+func isBetween(a, mid, b Waypoint) bool {
+	if a.Y == mid.Y && mid.Y == b.Y {
+		return (a.X < mid.X && mid.X < b.X) || (a.X > mid.X && mid.X > b.X)
+	}
+	if a.X == mid.X && mid.X == b.X {
+		return (a.Y < mid.Y && mid.Y < b.Y) || (a.Y > mid.Y && mid.Y > b.Y)
+	}
+	return false
+}
+
+// visitPlot calculates the distance to fly to a plot and reach altitude z over it
+func visitPlot(x, y, z int, currentPos *position) int {
 	distance := 0
-	
+
 	// First move horizontally to the plot
-	distance += abs(x - currentPos.x) + abs(y - currentPos.y)
-	
+	distance += abs(x-currentPos.x) + abs(y-currentPos.y)
+
 	// Update current position horizontally
 	currentPos.x = x
 	currentPos.y = y
-	
-	// Determine target height
-	targetHeight := 1 // 1m above an empty plot
-	key := coordKey(x, y)
-	if tree, exists := treeMap[key]; exists {
-		targetHeight = tree.Height + 1 // 1m above the tree
-	}
-	
+
 	// Move vertically to the target height
-	distance += abs(targetHeight - currentPos.z)
-	
+	distance += abs(z - currentPos.z)
+
 	// Update current position vertically
-	currentPos.z = targetHeight
-	
+	currentPos.z = z
+
 	return distance
 }
 
@@ -189,4 +604,4 @@ func abs(x int) int {
 		return -x
 	}
 	return x
-} 
\ No newline at end of file
+}
@@ -0,0 +1,955 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+)
+
+// referencePlots lists every plot of a patrol with the altitude flown over it,
+// visiting the estate plot by plot as the planner originally did
+func referencePlots(strategy PathStrategy, width, length int, trees []repository.Tree, clearance, lookahead int) []position {
+	heights := make(map[[2]int]int)
+	for _, tree := range trees {
+		heights[[2]int{tree.X, tree.Y}] = tree.Height
+	}
+	required := func(x, y int) int {
+		if height, ok := heights[[2]int{x, y}]; ok {
+			return height + clearance
+		}
+		return clearance
+	}
+
+	var plots []position
+	altitude := 0
+	strategy.Sweeps(width, length, func(s Sweep) bool {
+		var sweep []position
+		s.plots(func(x, y int) bool {
+			sweep = append(sweep, position{x: x, y: y})
+			return true
+		})
+
+		for i, plot := range sweep {
+			ahead := 0
+			for j := i + 1; j <= i+lookahead && j < len(sweep); j++ {
+				ahead = max(ahead, required(sweep[j].x, sweep[j].y))
+			}
+			altitude = max(required(plot.x, plot.y), min(altitude, ahead))
+			plots = append(plots, position{x: plot.x, y: plot.y, z: altitude})
+		}
+		return true
+	})
+	return plots
+}
+
+// referenceTravelDistance calculates the total distance by visiting every plot
+func referenceTravelDistance(plots []position) int {
+	totalDistance := 0
+	currentPos := position{x: plots[0].x, y: plots[0].y}
+	for _, plot := range plots {
+		totalDistance += visitPlot(plot.x, plot.y, plot.z, &currentPos)
+	}
+	return totalDistance + currentPos.z
+}
+
+// referenceRest finds the rest plot by visiting every plot
+func referenceRest(plots []position, maxDistance int) (int, position) {
+	totalDistance := 0
+	currentPos := position{x: plots[0].x, y: plots[0].y}
+	for _, plot := range plots {
+		totalDistance += visitPlot(plot.x, plot.y, plot.z, &currentPos)
+		if totalDistance >= maxDistance {
+			break
+		}
+	}
+	return totalDistance, position{x: currentPos.x, y: currentPos.y}
+}
+
+// referenceLegs splits the patrol into legs by visiting every plot
+func referenceLegs(plots []position, maxDistance int) []DroneLeg {
+	currentPos := position{x: plots[0].x, y: plots[0].y}
+	leg := DroneLeg{Start: Plot{X: currentPos.x, Y: currentPos.y}}
+	fresh := true
+
+	var legs []DroneLeg
+	for _, plot := range plots {
+		nextPos := currentPos
+		distance := visitPlot(plot.x, plot.y, plot.z, &nextPos)
+		if leg.Distance+distance+nextPos.z > maxDistance {
+			if fresh {
+				return nil
+			}
+			leg.Distance += currentPos.z
+			leg.Landing = Plot{X: currentPos.x, Y: currentPos.y}
+			legs = append(legs, leg)
+			leg = DroneLeg{Start: leg.Landing, Distance: currentPos.z}
+			fresh = true
+
+			nextPos = currentPos
+			distance = visitPlot(plot.x, plot.y, plot.z, &nextPos)
+			if leg.Distance+distance+nextPos.z > maxDistance {
+				return nil
+			}
+		}
+		leg.Distance += distance
+		currentPos = nextPos
+		if plot.x != leg.Start.X || plot.y != leg.Start.Y {
+			fresh = false
+		}
+	}
+	leg.Distance += currentPos.z
+	leg.Landing = Plot{X: currentPos.x, Y: currentPos.y}
+	return append(legs, leg)
+}
+
+// randomTrees plants count trees on distinct random plots
+func randomTrees(rng *rand.Rand, width, length, count int) []repository.Tree {
+	planted := make(map[[2]int]bool)
+	var trees []repository.Tree
+	for len(trees) < count && len(planted) < width*length {
+		x, y := rng.Intn(width)+1, rng.Intn(length)+1
+		if planted[[2]int{x, y}] {
+			continue
+		}
+		planted[[2]int{x, y}] = true
+		trees = append(trees, repository.Tree{X: x, Y: y, Height: rng.Intn(30) + 1})
+	}
+	return trees
+}
+
+func TestDronePlanMatchesPlotByPlotReference(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 300; i++ {
+		width, length := rng.Intn(9)+1, rng.Intn(9)+1
+		trees := randomTrees(rng, width, length, rng.Intn(width*length+1))
+		clearance := rng.Intn(3) + 1
+		lookahead := rng.Intn(4)
+		model := newFlightModel(trees, clearance, lookahead)
+
+		for name, strategy := range pathStrategies {
+			plots := referencePlots(strategy, width, length, trees, clearance, lookahead)
+			label := fmt.Sprintf("%s %dx%d clearance=%d lookahead=%d", name, width, length, clearance, lookahead)
+
+			distance, err := calculateDroneTravelDistance(ctx, strategy, width, length, model, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, referenceTravelDistance(plots), distance, label)
+
+			maxDistance := rng.Intn(distance+5) + 1
+			restDistance, restPos, err := calculateDronePathWithRest(ctx, strategy, width, length, model, maxDistance, nil)
+			assert.NoError(t, err)
+			wantDistance, wantPos := referenceRest(plots, maxDistance)
+			assert.Equal(t, wantDistance, restDistance, label)
+			assert.Equal(t, wantPos, restPos, label)
+
+			legs, err := calculateDroneLegs(ctx, strategy, width, length, model, maxDistance, nil)
+			wantLegs := referenceLegs(plots, maxDistance)
+			if wantLegs == nil {
+				assert.Error(t, err, label)
+			} else {
+				assert.NoError(t, err, label)
+				assert.Equal(t, wantLegs, legs, label)
+			}
+		}
+	}
+}
+
+func TestDronePlanClimbsOverObstacles(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 300; i++ {
+		width, length := rng.Intn(9)+1, rng.Intn(9)+1
+		trees := randomTrees(rng, width, length, rng.Intn(width*length+1))
+		clearance := rng.Intn(3) + 1
+		lookahead := rng.Intn(4)
+
+		// Climbing over an obstacle is the same as over a tree reaching its
+		// minimum altitude less the clearance
+		heights := make(map[[2]int]int)
+		for _, tree := range trees {
+			heights[[2]int{tree.X, tree.Y}] = tree.Height
+		}
+		var obstacles []repository.Obstacle
+		for j := rng.Intn(4); j > 0; j-- {
+			xMin, yMin := rng.Intn(width)+1, rng.Intn(length)+1
+			obstacle := repository.Obstacle{
+				XMin: xMin, XMax: xMin + rng.Intn(width-xMin+1),
+				YMin: yMin, YMax: yMin + rng.Intn(length-yMin+1),
+				MinAltitude: rng.Intn(40) + 1,
+			}
+			obstacles = append(obstacles, obstacle)
+			for x := obstacle.XMin; x <= obstacle.XMax; x++ {
+				for y := obstacle.YMin; y <= obstacle.YMax; y++ {
+					heights[[2]int{x, y}] = max(heights[[2]int{x, y}], obstacle.MinAltitude-clearance)
+				}
+			}
+		}
+		var equivalent []repository.Tree
+		for plot, height := range heights {
+			if height > 0 {
+				equivalent = append(equivalent, repository.Tree{X: plot[0], Y: plot[1], Height: height})
+			}
+		}
+
+		model := newFlightModel(trees, clearance, lookahead)
+		model.addObstacles(width, length, obstacles)
+
+		for name, strategy := range pathStrategies {
+			plots := referencePlots(strategy, width, length, equivalent, clearance, lookahead)
+			label := fmt.Sprintf("%s %dx%d clearance=%d lookahead=%d", name, width, length, clearance, lookahead)
+
+			distance, err := calculateDroneTravelDistance(ctx, strategy, width, length, model, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, referenceTravelDistance(plots), distance, label)
+		}
+	}
+}
+
+func TestDronePlanAroundImpassableObstacles(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name                string
+		width, length       int
+		trees               []repository.Tree
+		obstacles           []repository.Obstacle
+		expectedDistance    int
+		expectedPath        []Waypoint
+		expectedUnreachable []PlotRect
+		expectedPlots       int
+		expectedErr         string
+	}{
+		{
+			// The second row is flown from east to west and goes around the
+			// obstacle through the first row, over the tree on it
+			name:   "Detour",
+			width:  5,
+			length: 3,
+			trees:  []repository.Tree{{X: 3, Y: 1, Height: 4}},
+			obstacles: []repository.Obstacle{
+				{XMin: 3, XMax: 3, YMin: 2, YMax: 2, Impassable: true},
+				{XMin: 1, XMax: 5, YMin: 3, YMax: 3, MinAltitude: 2},
+			},
+			expectedDistance: 36,
+			expectedPath: []Waypoint{
+				{X: 1, Y: 1, Z: 0},
+				{X: 1, Y: 1, Z: 1},
+				{X: 3, Y: 1, Z: 5},
+				{X: 4, Y: 1, Z: 1},
+				{X: 5, Y: 1, Z: 1},
+				{X: 5, Y: 2, Z: 1},
+				{X: 4, Y: 2, Z: 1},
+				{X: 4, Y: 2, Z: 5},
+				{X: 4, Y: 1, Z: 5},
+				{X: 2, Y: 1, Z: 5},
+				{X: 2, Y: 2, Z: 1},
+				{X: 1, Y: 2, Z: 1},
+				{X: 1, Y: 3, Z: 2},
+				{X: 5, Y: 3, Z: 2},
+				{X: 5, Y: 3, Z: 0},
+			},
+		},
+		{
+			name:   "Blocked Start",
+			width:  3,
+			length: 1,
+			obstacles: []repository.Obstacle{
+				{XMin: 1, XMax: 1, YMin: 1, YMax: 1, Impassable: true},
+			},
+			expectedDistance: 3,
+			expectedPath: []Waypoint{
+				{X: 2, Y: 1, Z: 0},
+				{X: 2, Y: 1, Z: 1},
+				{X: 3, Y: 1, Z: 1},
+				{X: 3, Y: 1, Z: 0},
+			},
+		},
+		{
+			// A wall across the estate cuts off its eastern side, which is
+			// left out of the patrol
+			name:   "Unreachable Plots",
+			width:  5,
+			length: 3,
+			obstacles: []repository.Obstacle{
+				{XMin: 3, XMax: 3, YMin: 1, YMax: 9, Impassable: true},
+			},
+			expectedDistance: 7,
+			expectedPath: []Waypoint{
+				{X: 1, Y: 1, Z: 0},
+				{X: 1, Y: 1, Z: 1},
+				{X: 2, Y: 1, Z: 1},
+				{X: 2, Y: 2, Z: 1},
+				{X: 1, Y: 2, Z: 1},
+				{X: 1, Y: 3, Z: 1},
+				{X: 2, Y: 3, Z: 1},
+				{X: 2, Y: 3, Z: 0},
+			},
+			expectedUnreachable: []PlotRect{{XMin: 4, XMax: 5, YMin: 1, YMax: 3}},
+			expectedPlots:       6,
+		},
+		{
+			name:   "Every Plot Impassable",
+			width:  2,
+			length: 2,
+			obstacles: []repository.Obstacle{
+				{XMin: 1, XMax: 2, YMin: 1, YMax: 2, Impassable: true},
+			},
+			expectedErr: "every plot is impassable",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plan, err := planDronePath(ctx, tc.width, tc.length, tc.trees, tc.obstacles, DronePlanOptions{IncludePath: true}, nil)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDistance, plan.Distance)
+			assert.Equal(t, tc.expectedPath, plan.Path)
+			assert.Equal(t, tc.expectedUnreachable, plan.Unreachable)
+			assert.Equal(t, tc.expectedPlots, plan.UnreachablePlots)
+		})
+	}
+}
+
+func TestObstacleMapRouteIsShortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 300; i++ {
+		width, length := rng.Intn(12)+1, rng.Intn(12)+1
+		var obstacles []repository.Obstacle
+		for j := rng.Intn(5) + 1; j > 0; j-- {
+			xMin, yMin := rng.Intn(width)+1, rng.Intn(length)+1
+			obstacles = append(obstacles, repository.Obstacle{
+				XMin: xMin, XMax: xMin + rng.Intn(min(3, width-xMin+1)),
+				YMin: yMin, YMax: yMin + rng.Intn(min(3, length-yMin+1)),
+				Impassable: true,
+			})
+		}
+		m := newObstacleMap(width, length, obstacles)
+
+		// Breadth-first search over every plot from a random passable plot
+		fromX, fromY := rng.Intn(width)+1, rng.Intn(length)+1
+		if m.areaAt(fromX, fromY) == impassableCell {
+			continue
+		}
+		distances := map[[2]int]int{{fromX, fromY}: 0}
+		queue := [][2]int{{fromX, fromY}}
+		for len(queue) > 0 {
+			plot := queue[0]
+			queue = queue[1:]
+			for _, next := range [][2]int{{plot[0] - 1, plot[1]}, {plot[0] + 1, plot[1]}, {plot[0], plot[1] - 1}, {plot[0], plot[1] + 1}} {
+				if next[0] < 1 || next[0] > width || next[1] < 1 || next[1] > length || !m.clear(next[0], next[1], next[0], next[1]) {
+					continue
+				}
+				if _, seen := distances[next]; !seen {
+					distances[next] = distances[plot] + 1
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		for x := 1; x <= width; x++ {
+			for y := 1; y <= length; y++ {
+				label := fmt.Sprintf("%dx%d %v from (%d,%d) to (%d,%d)", width, length, obstacles, fromX, fromY, x, y)
+				want, reachable := distances[[2]int{x, y}]
+				if m.areaAt(x, y) == impassableCell {
+					continue
+				}
+				assert.Equal(t, reachable, m.areaAt(x, y) == m.areaAt(fromX, fromY), label)
+				if !reachable {
+					continue
+				}
+
+				turns, distance := m.route(fromX, fromY, x, y)
+				if turns == nil {
+					distance = abs(x-fromX) + abs(y-fromY)
+				}
+				assert.Equal(t, want, distance, label)
+			}
+		}
+	}
+}
+
+func TestObstacleMapBandRouteIsShortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 300; i++ {
+		width, length := rng.Intn(12)+1, rng.Intn(12)+1
+		var obstacles []repository.Obstacle
+		for j := rng.Intn(5) + 1; j > 0; j-- {
+			xMin, yMin := rng.Intn(width)+1, rng.Intn(length)+1
+			obstacles = append(obstacles, repository.Obstacle{
+				XMin: xMin, XMax: xMin + rng.Intn(min(3, width-xMin+1)),
+				YMin: yMin, YMax: yMin + rng.Intn(min(3, length-yMin+1)),
+				Impassable: true,
+			})
+		}
+		m := newObstacleMap(width, length, obstacles)
+
+		// Every pair of plots of an area on the same row or column, the cache
+		// filling up as the bands are crossed
+		for fromX := 1; fromX <= width; fromX++ {
+			for fromY := 1; fromY <= length; fromY++ {
+				for _, to := range [][2]int{{rng.Intn(width) + 1, fromY}, {fromX, rng.Intn(length) + 1}} {
+					area := m.areaAt(fromX, fromY)
+					if area == impassableCell || m.areaAt(to[0], to[1]) != area {
+						continue
+					}
+					label := fmt.Sprintf("%dx%d %v from (%d,%d) to (%d,%d)", width, length, obstacles, fromX, fromY, to[0], to[1])
+
+					want, wantDistance := m.route(fromX, fromY, to[0], to[1])
+					turns, distance := m.bandRoute(fromX, fromY, to[0], to[1])
+					if want == nil {
+						assert.Nil(t, turns, label)
+						continue
+					}
+					assert.Equal(t, wantDistance, distance, label)
+
+					// The turns make a way of that distance around the obstacles
+					flown, from := 0, Plot{X: fromX, Y: fromY}
+					for _, turn := range turns {
+						assert.True(t, turn.X == from.X || turn.Y == from.Y, label)
+						assert.True(t, m.clear(from.X, from.Y, turn.X, turn.Y), label)
+						flown += abs(turn.X-from.X) + abs(turn.Y-from.Y)
+						from = turn
+					}
+					assert.Equal(t, Plot{X: to[0], Y: to[1]}, from, label)
+					assert.Equal(t, distance, flown, label)
+				}
+			}
+		}
+	}
+}
+
+func TestDronePathWaypoints(t *testing.T) {
+	trees := []repository.Tree{{X: 2, Y: 1, Height: 5}}
+	model := newFlightModel(trees, defaultClearance, 0)
+
+	path := &pathRecorder{}
+	distance, err := calculateDroneTravelDistance(context.Background(), rowZigzag{}, 3, 2, model, path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 17, distance)
+	assert.Equal(t, []Waypoint{
+		{X: 1, Y: 1, Z: 0},
+		{X: 1, Y: 1, Z: 1},
+		{X: 2, Y: 1, Z: 6},
+		{X: 3, Y: 1, Z: 1},
+		{X: 3, Y: 2, Z: 1},
+		{X: 1, Y: 2, Z: 1},
+		{X: 1, Y: 2, Z: 0},
+	}, path.waypoints)
+}
+
+func TestPathRecorder(t *testing.T) {
+	testCases := []struct {
+		name              string
+		positions         []position
+		expectedWaypoints []Waypoint
+	}{
+		{
+			name:              "Flat Stretch",
+			positions:         []position{{x: 1, y: 1, z: 1}, {x: 2, y: 1, z: 1}, {x: 3, y: 1, z: 1}, {x: 4, y: 1, z: 1}},
+			expectedWaypoints: []Waypoint{{X: 1, Y: 1, Z: 1}, {X: 4, Y: 1, Z: 1}},
+		},
+		{
+			// The drone reaches a plot before climbing over it, so a climb at
+			// the end of a stretch still compresses it
+			name:              "Climb At End Of Stretch",
+			positions:         []position{{x: 1, y: 1, z: 0}, {x: 1, y: 1, z: 1}, {x: 2, y: 1, z: 1}, {x: 3, y: 1, z: 6}},
+			expectedWaypoints: []Waypoint{{X: 1, Y: 1, Z: 0}, {X: 1, Y: 1, Z: 1}, {X: 3, Y: 1, Z: 6}},
+		},
+		{
+			name:              "Altitude Change Mid-Stretch",
+			positions:         []position{{x: 1, y: 1, z: 1}, {x: 2, y: 1, z: 6}, {x: 3, y: 1, z: 1}, {x: 4, y: 1, z: 1}, {x: 5, y: 1, z: 1}},
+			expectedWaypoints: []Waypoint{{X: 1, Y: 1, Z: 1}, {X: 2, Y: 1, Z: 6}, {X: 3, Y: 1, Z: 1}, {X: 5, Y: 1, Z: 1}},
+		},
+		{
+			name:              "Turns",
+			positions:         []position{{x: 1, y: 1, z: 1}, {x: 2, y: 1, z: 1}, {x: 3, y: 1, z: 1}, {x: 3, y: 2, z: 1}, {x: 3, y: 3, z: 1}, {x: 1, y: 3, z: 1}},
+			expectedWaypoints: []Waypoint{{X: 1, Y: 1, Z: 1}, {X: 3, Y: 1, Z: 1}, {X: 3, Y: 3, Z: 1}, {X: 1, Y: 3, Z: 1}},
+		},
+		{
+			name:              "Turning Back",
+			positions:         []position{{x: 1, y: 1, z: 1}, {x: 3, y: 1, z: 1}, {x: 2, y: 1, z: 1}},
+			expectedWaypoints: []Waypoint{{X: 1, Y: 1, Z: 1}, {X: 3, Y: 1, Z: 1}, {X: 2, Y: 1, Z: 1}},
+		},
+		{
+			name:              "Landing",
+			positions:         []position{{x: 1, y: 1, z: 1}, {x: 2, y: 1, z: 1}, {x: 2, y: 1, z: 0}},
+			expectedWaypoints: []Waypoint{{X: 1, Y: 1, Z: 1}, {X: 2, Y: 1, Z: 1}, {X: 2, Y: 1, Z: 0}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := &pathRecorder{}
+			// Positions are added one at a time, as the planner does
+			for _, pos := range tc.positions {
+				path.add(pos)
+			}
+			assert.Equal(t, tc.expectedWaypoints, path.waypoints)
+		})
+	}
+
+	// Without a path requested there is nothing to record into
+	var path *pathRecorder
+	assert.NotPanics(t, func() { path.add(position{x: 1, y: 1, z: 1}) })
+}
+
+func TestCalculateDroneLegs(t *testing.T) {
+	testCases := []struct {
+		name         string
+		width        int
+		trees        []repository.Tree
+		maxDistance  int
+		expectedLegs []DroneLeg
+		expectedPath []Waypoint
+		expectedErr  string
+	}{
+		{
+			name:        "Single Leg",
+			width:       5,
+			maxDistance: 6,
+			expectedLegs: []DroneLeg{
+				{Start: Plot{X: 1, Y: 1}, Landing: Plot{X: 5, Y: 1}, Distance: 6},
+			},
+			expectedPath: []Waypoint{
+				{X: 1, Y: 1, Z: 0},
+				{X: 1, Y: 1, Z: 1},
+				{X: 5, Y: 1, Z: 1},
+				{X: 5, Y: 1, Z: 0},
+			},
+		},
+		{
+			// Each leg lands as soon as flying on would leave too little
+			// battery to land, and the next takes off from the same plot
+			name:        "Split Along A Flat Row",
+			width:       5,
+			maxDistance: 4,
+			expectedLegs: []DroneLeg{
+				{Start: Plot{X: 1, Y: 1}, Landing: Plot{X: 3, Y: 1}, Distance: 4},
+				{Start: Plot{X: 3, Y: 1}, Landing: Plot{X: 5, Y: 1}, Distance: 4},
+			},
+			expectedPath: []Waypoint{
+				{X: 1, Y: 1, Z: 0},
+				{X: 1, Y: 1, Z: 1},
+				{X: 3, Y: 1, Z: 1},
+				{X: 3, Y: 1, Z: 0},
+				{X: 3, Y: 1, Z: 1},
+				{X: 5, Y: 1, Z: 1},
+				{X: 5, Y: 1, Z: 0},
+			},
+		},
+		{
+			// Landing on top of the tree costs its full height, and so does
+			// climbing back from it
+			name:        "Split Over A Tree",
+			width:       3,
+			trees:       []repository.Tree{{X: 2, Y: 1, Height: 3}},
+			maxDistance: 9,
+			expectedLegs: []DroneLeg{
+				{Start: Plot{X: 1, Y: 1}, Landing: Plot{X: 2, Y: 1}, Distance: 9},
+				{Start: Plot{X: 2, Y: 1}, Landing: Plot{X: 3, Y: 1}, Distance: 9},
+			},
+			expectedPath: []Waypoint{
+				{X: 1, Y: 1, Z: 0},
+				{X: 1, Y: 1, Z: 1},
+				{X: 2, Y: 1, Z: 4},
+				{X: 2, Y: 1, Z: 0},
+				{X: 2, Y: 1, Z: 4},
+				{X: 3, Y: 1, Z: 1},
+				{X: 3, Y: 1, Z: 0},
+			},
+		},
+		{
+			name:        "Too Short To Take Off",
+			width:       3,
+			maxDistance: 1,
+			expectedErr: "max distance too short to complete a leg",
+		},
+		{
+			// Going over the tree and landing again takes 9, one more than allowed
+			name:        "Too Short To Cross A Tree",
+			width:       3,
+			trees:       []repository.Tree{{X: 2, Y: 1, Height: 3}},
+			maxDistance: 8,
+			expectedErr: "max distance too short to complete a leg",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			model := newFlightModel(tc.trees, defaultClearance, 0)
+			path := &pathRecorder{}
+
+			legs, err := calculateDroneLegs(context.Background(), rowZigzag{}, tc.width, 1, model, tc.maxDistance, path)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedLegs, legs)
+			assert.Equal(t, tc.expectedPath, path.waypoints)
+		})
+	}
+}
+
+func TestOptimizePathStrategy(t *testing.T) {
+	// Tall trees along the western edge of a 3x3 estate
+	westEdge := []repository.Tree{{X: 1, Y: 1, Height: 10}, {X: 1, Y: 2, Height: 10}, {X: 1, Y: 3, Height: 10}}
+
+	testCases := []struct {
+		name             string
+		trees            []repository.Tree
+		expectedStrategy PathStrategy
+		expectedBest     DronePlanVariant
+		expectedVariants []DronePlanVariant
+	}{
+		{
+			// Rows cross the tall trees three times, columns fly over them
+			// in one go
+			name:             "Columns Along Tall Trees",
+			trees:            westEdge,
+			expectedStrategy: mirrored{base: columnZigzag{}},
+			expectedBest:     DronePlanVariant{Pattern: "column", StartCorner: "southwest", Distance: 30},
+			expectedVariants: []DronePlanVariant{
+				{Pattern: "row", StartCorner: "southwest", Distance: 50},
+				{Pattern: "row", StartCorner: "southeast", Distance: 50},
+				{Pattern: "row", StartCorner: "northwest", Distance: 50},
+				{Pattern: "row", StartCorner: "northeast", Distance: 50},
+				{Pattern: "column", StartCorner: "southwest", Distance: 30},
+				{Pattern: "column", StartCorner: "southeast", Distance: 30},
+				{Pattern: "column", StartCorner: "northwest", Distance: 30},
+				{Pattern: "column", StartCorner: "northeast", Distance: 30},
+			},
+		},
+		{
+			// Only the first row is planted, which the southern starts fly
+			// over once at the start and the northern ones at the end
+			name:             "Row Along Tall Trees",
+			trees:            []repository.Tree{{X: 1, Y: 1, Height: 10}, {X: 2, Y: 1, Height: 10}, {X: 3, Y: 1, Height: 10}},
+			expectedStrategy: mirrored{base: rowZigzag{}},
+			expectedBest:     DronePlanVariant{Pattern: "row", StartCorner: "southwest", Distance: 30},
+			expectedVariants: []DronePlanVariant{
+				{Pattern: "row", StartCorner: "southwest", Distance: 30},
+				{Pattern: "row", StartCorner: "southeast", Distance: 30},
+				{Pattern: "row", StartCorner: "northwest", Distance: 30},
+				{Pattern: "row", StartCorner: "northeast", Distance: 30},
+				{Pattern: "column", StartCorner: "southwest", Distance: 50},
+				{Pattern: "column", StartCorner: "southeast", Distance: 50},
+				{Pattern: "column", StartCorner: "northwest", Distance: 50},
+				{Pattern: "column", StartCorner: "northeast", Distance: 50},
+			},
+		},
+		{
+			// Nothing is gained anywhere, so the default pattern is kept
+			name:             "Tie",
+			expectedStrategy: mirrored{base: rowZigzag{}},
+			expectedBest:     DronePlanVariant{Pattern: "row", StartCorner: "southwest", Distance: 10},
+			expectedVariants: []DronePlanVariant{
+				{Pattern: "row", StartCorner: "southwest", Distance: 10},
+				{Pattern: "row", StartCorner: "southeast", Distance: 10},
+				{Pattern: "row", StartCorner: "northwest", Distance: 10},
+				{Pattern: "row", StartCorner: "northeast", Distance: 10},
+				{Pattern: "column", StartCorner: "southwest", Distance: 10},
+				{Pattern: "column", StartCorner: "southeast", Distance: 10},
+				{Pattern: "column", StartCorner: "northwest", Distance: 10},
+				{Pattern: "column", StartCorner: "northeast", Distance: 10},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			model := newFlightModel(tc.trees, defaultClearance, 0)
+
+			strategy, best, variants, err := optimizePathStrategy(context.Background(), 3, 3, model)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStrategy, strategy)
+			assert.Equal(t, tc.expectedBest, best)
+			assert.Equal(t, tc.expectedVariants, variants)
+		})
+	}
+}
+
+func TestOptimizedDronePlan(t *testing.T) {
+	// Starting from the southeast flies over both tall trees in one go, where
+	// the default start crosses them twice. The winning variant is the one
+	// flown, starting from its corner.
+	trees := []repository.Tree{{X: 1, Y: 1, Height: 10}, {X: 1, Y: 2, Height: 10}}
+
+	plan, err := planDronePath(context.Background(), 2, 3, trees, nil, DronePlanOptions{Optimize: true, IncludePath: true}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "row", plan.Pattern)
+	assert.Equal(t, "southeast", plan.StartCorner)
+	assert.Equal(t, 27, plan.Distance)
+	assert.Equal(t, DronePlanVariant{Pattern: "row", StartCorner: "southwest", Distance: 47}, plan.Alternatives[0])
+	assert.Len(t, plan.Alternatives, 8)
+	for _, variant := range plan.Alternatives {
+		assert.GreaterOrEqual(t, variant.Distance, plan.Distance)
+	}
+	assert.Equal(t, Waypoint{X: 2, Y: 1, Z: 0}, plan.Path[0])
+}
+
+func TestDronePlanExcludesDeadTrees(t *testing.T) {
+	trees := []repository.Tree{
+		{X: 2, Y: 1, Height: 5, Health: repository.TreeHealthDead},
+		{X: 3, Y: 2, Height: 2, Health: repository.TreeHealthHealthy},
+	}
+
+	plan, err := planDronePath(context.Background(), 3, 2, trees, nil, DronePlanOptions{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 21, plan.Distance)
+
+	// Only the healthy tree is climbed over
+	plan, err = planDronePath(context.Background(), 3, 2, trees, nil, DronePlanOptions{ExcludeDead: true}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 11, plan.Distance)
+	// The trees of the caller are left alone
+	assert.Equal(t, repository.TreeHealthDead, trees[0].Health)
+}
+
+func TestPlanZone(t *testing.T) {
+	// The zone is patrolled as a 3x2 estate of its own, ignoring the tall
+	// tree outside of it
+	zone := repository.Zone{
+		Shape:    repository.ZoneShapeRect,
+		Vertices: []repository.Point{{X: 4, Y: 3}, {X: 6, Y: 3}, {X: 6, Y: 4}, {X: 4, Y: 4}},
+	}
+	trees := []repository.Tree{{X: 5, Y: 3, Height: 5}, {X: 1, Y: 1, Height: 30}}
+
+	plan, err := planZone(context.Background(), zone, trees, nil, DronePlanOptions{IncludePath: true}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 17, plan.Distance)
+	assert.Equal(t, []Waypoint{
+		{X: 4, Y: 3, Z: 0},
+		{X: 4, Y: 3, Z: 1},
+		{X: 5, Y: 3, Z: 6},
+		{X: 6, Y: 3, Z: 1},
+		{X: 6, Y: 4, Z: 1},
+		{X: 4, Y: 4, Z: 1},
+		{X: 4, Y: 4, Z: 0},
+	}, plan.Path)
+
+	plan, err = planZone(context.Background(), zone, trees, nil, DronePlanOptions{MaxDistance: 8}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &Plot{X: 6, Y: 3}, plan.Rest)
+}
+
+func TestPlanPolygonZone(t *testing.T) {
+	testCases := []struct {
+		name             string
+		vertices         []repository.Point
+		trees            []repository.Tree
+		expectedDistance int
+		expectedPath     []Waypoint
+	}{
+		{
+			// Only the plots on or under the hypotenuse are patrolled. The tall
+			// tree in the corner of the bounding box is never flown over.
+			name:             "Triangle",
+			vertices:         []repository.Point{{X: 2, Y: 2}, {X: 5, Y: 2}, {X: 2, Y: 5}},
+			trees:            []repository.Tree{{X: 3, Y: 3, Height: 5}, {X: 5, Y: 5, Height: 30}},
+			expectedDistance: 23,
+			expectedPath: []Waypoint{
+				{X: 2, Y: 2, Z: 0},
+				{X: 2, Y: 2, Z: 1},
+				{X: 5, Y: 2, Z: 1},
+				{X: 4, Y: 2, Z: 1},
+				{X: 4, Y: 3, Z: 1},
+				{X: 3, Y: 3, Z: 6},
+				{X: 2, Y: 3, Z: 1},
+				{X: 2, Y: 4, Z: 1},
+				{X: 3, Y: 4, Z: 1},
+				{X: 2, Y: 4, Z: 1},
+				{X: 2, Y: 5, Z: 1},
+				{X: 2, Y: 5, Z: 0},
+			},
+		},
+		{
+			// The third row is patrolled along both arms of the U, climbing
+			// over the tall tree between them without patrolling its plot
+			name:             "U Shape",
+			vertices:         []repository.Point{{X: 1, Y: 1}, {X: 6, Y: 1}, {X: 6, Y: 4}, {X: 5, Y: 4}, {X: 5, Y: 2}, {X: 2, Y: 2}, {X: 2, Y: 4}, {X: 1, Y: 4}},
+			trees:            []repository.Tree{{X: 3, Y: 3, Height: 10}},
+			expectedDistance: 45,
+			expectedPath: []Waypoint{
+				{X: 1, Y: 1, Z: 0},
+				{X: 1, Y: 1, Z: 1},
+				{X: 6, Y: 1, Z: 1},
+				{X: 6, Y: 2, Z: 1},
+				{X: 1, Y: 2, Z: 1},
+				{X: 1, Y: 3, Z: 1},
+				{X: 2, Y: 3, Z: 1},
+				{X: 2, Y: 3, Z: 11},
+				{X: 5, Y: 3, Z: 1},
+				{X: 6, Y: 3, Z: 1},
+				{X: 6, Y: 4, Z: 1},
+				{X: 1, Y: 4, Z: 1},
+				{X: 1, Y: 4, Z: 0},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			zone := repository.Zone{Shape: repository.ZoneShapePolygon, Vertices: tc.vertices}
+
+			plan, err := planZone(context.Background(), zone, tc.trees, nil, DronePlanOptions{IncludePath: true}, nil)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDistance, plan.Distance)
+			assert.Equal(t, tc.expectedPath, plan.Path)
+		})
+	}
+}
+
+func TestZonePatrolCoversZonePlots(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 300; i++ {
+		var vertices []repository.Point
+		for j := rng.Intn(6) + 3; j > 0; j-- {
+			vertices = append(vertices, repository.Point{X: rng.Intn(9) + 1, Y: rng.Intn(9) + 1})
+		}
+		if doubleArea(vertices) == 0 || selfIntersecting(vertices) {
+			continue
+		}
+		model := newFlightModel(nil, defaultClearance, 0)
+		model.zone = vertices
+
+		// Every plot of the zone is patrolled once, and no other plot
+		for name, strategy := range pathStrategies {
+			label := fmt.Sprintf("%s %v", name, vertices)
+			patrolled := make(map[Plot]bool)
+			err := model.walk(context.Background(), strategy, 9, 9, func(r run) bool {
+				for j := 0; j < r.count; j++ {
+					x, y := r.plot(j)
+					assert.False(t, patrolled[Plot{X: x, Y: y}], label)
+					patrolled[Plot{X: x, Y: y}] = true
+				}
+				return true
+			})
+			assert.NoError(t, err, label)
+			assert.Len(t, patrolled, zonePlots(vertices), label)
+
+			x, y, err := model.firstPlot(strategy, 9, 9)
+			assert.NoError(t, err, label)
+			assert.True(t, patrolled[Plot{X: x, Y: y}], label)
+		}
+	}
+}
+
+func TestDronePlanCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	model := newFlightModel(nil, defaultClearance, 0)
+	_, err := calculateDroneTravelDistance(ctx, rowZigzag{}, 50000, 50000, model, nil)
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// benchmarkEstates are the estate sizes the planner is benchmarked on, each
+// planted with one tree per hundred plots up to a million trees
+var benchmarkEstates = []struct {
+	width, length int
+}{
+	{100, 100},
+	{1000, 1000},
+	{50000, 50000},
+}
+
+func BenchmarkCalculateDroneTravelDistance(b *testing.B) {
+	for _, estate := range benchmarkEstates {
+		trees := randomTrees(rand.New(rand.NewSource(1)), estate.width, estate.length, min(estate.width*estate.length/100, 1000000))
+
+		for name, strategy := range pathStrategies {
+			b.Run(fmt.Sprintf("%s/%dx%d", name, estate.width, estate.length), func(b *testing.B) {
+				model := newFlightModel(trees, defaultClearance, 0)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err := calculateDroneTravelDistance(context.Background(), strategy, estate.width, estate.length, model, nil); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkCalculateDroneTravelDistanceSmoothed(b *testing.B) {
+	for _, estate := range benchmarkEstates {
+		trees := randomTrees(rand.New(rand.NewSource(1)), estate.width, estate.length, min(estate.width*estate.length/100, 1000000))
+
+		b.Run(fmt.Sprintf("%dx%d", estate.width, estate.length), func(b *testing.B) {
+			model := newFlightModel(trees, defaultClearance, defaultLookahead)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := calculateDroneTravelDistance(context.Background(), rowZigzag{}, estate.width, estate.length, model, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkCalculateDroneTravelDistanceObstacles measures the walk around
+// 100 impassable obstacles, where the way across every gap has to be found
+func BenchmarkCalculateDroneTravelDistanceObstacles(b *testing.B) {
+	estate := benchmarkEstates[len(benchmarkEstates)-1]
+	rng := rand.New(rand.NewSource(1))
+	trees := randomTrees(rng, estate.width, estate.length, 1000000)
+	obstacles := make([]repository.Obstacle, 100)
+	for i := range obstacles {
+		xMin, yMin := rng.Intn(estate.width-1000)+1, rng.Intn(estate.length-1000)+1
+		obstacles[i] = repository.Obstacle{
+			XMin: xMin, XMax: xMin + rng.Intn(1000),
+			YMin: yMin, YMax: yMin + rng.Intn(1000),
+			Impassable: true,
+		}
+	}
+
+	for _, name := range []string{"row", "column"} {
+		b.Run(fmt.Sprintf("%s/%dx%d", name, estate.width, estate.length), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				// The model is set up on every run so that no way is cached yet
+				model := newFlightModel(trees, defaultClearance, 0)
+				model.addObstacles(estate.width, estate.length, obstacles)
+				if _, err := calculateDroneTravelDistance(context.Background(), pathStrategies[name], estate.width, estate.length, model, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkPlotByPlotTravelDistance measures the original plot by plot walk
+// for comparison; the largest estate is left out as it takes minutes per run
+func BenchmarkPlotByPlotTravelDistance(b *testing.B) {
+	for _, estate := range benchmarkEstates[:2] {
+		trees := randomTrees(rand.New(rand.NewSource(1)), estate.width, estate.length, estate.width*estate.length/100)
+
+		b.Run(fmt.Sprintf("row/%dx%d", estate.width, estate.length), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				referenceTravelDistance(referencePlots(rowZigzag{}, estate.width, estate.length, trees, defaultClearance, 0))
+			}
+		})
+	}
+}
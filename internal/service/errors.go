@@ -0,0 +1,83 @@
+package service
+
+import "errors"
+
+// Kinds of errors reported by the service, matched with errors.Is. Errors
+// callers can act on are an *Error of one of these kinds; any other error is
+// a failure of the service or the database.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrInternal   = errors.New("internal error")
+)
+
+// Errors of the resources managed by the service
+var (
+	ErrEstateNotFound   = &Error{Kind: ErrNotFound, Code: "estate_not_found", Message: "estate not found"}
+	ErrTreeNotFound     = &Error{Kind: ErrNotFound, Code: "tree_not_found", Message: "tree not found"}
+	ErrZoneNotFound     = &Error{Kind: ErrNotFound, Code: "zone_not_found", Message: "zone not found"}
+	ErrObstacleNotFound = &Error{Kind: ErrNotFound, Code: "obstacle_not_found", Message: "obstacle not found"}
+	ErrJobNotFound      = &Error{Kind: ErrNotFound, Code: "job_not_found", Message: "job not found"}
+
+	ErrPlotOccupied         = &Error{Kind: ErrConflict, Code: "plot_occupied", Message: "plot already has a tree"}
+	ErrZoneNameTaken        = &Error{Kind: ErrConflict, Code: "zone_name_taken", Message: "zone name already taken"}
+	ErrTreesOutOfBounds     = &Error{Kind: ErrConflict, Code: "trees_out_of_bounds", Message: "trees outside new estate boundaries"}
+	ErrZonesOutOfBounds     = &Error{Kind: ErrConflict, Code: "zones_out_of_bounds", Message: "zones outside new estate boundaries"}
+	ErrObstaclesOutOfBounds = &Error{Kind: ErrConflict, Code: "obstacles_out_of_bounds", Message: "obstacles outside new estate boundaries"}
+	ErrTooManyObstacles     = &Error{Kind: ErrConflict, Code: "too_many_obstacles", Message: "too many obstacles"}
+	ErrJobFinished          = &Error{Kind: ErrConflict, Code: "job_finished", Message: "job already finished"}
+	ErrImpassable           = &Error{Kind: ErrConflict, Code: "estate_impassable", Message: "every plot is impassable"}
+)
+
+// Error is an error of the service with a stable machine-readable code
+type Error struct {
+	// Kind is ErrNotFound, ErrConflict, ErrValidation or ErrInternal
+	Kind error
+	// Code identifies the error to clients, such as "estate_not_found"
+	Code    string
+	Message string
+	// Fields lists the invalid input fields of a validation error
+	Fields []FieldError
+	// Err is the cause of an internal error; it is not shown to clients
+	Err error
+}
+
+// FieldError describes why an input field is invalid
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error returns the message of the error, followed by its cause if any
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Is reports whether the error is of the target kind
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap returns the cause of the error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewValidationError creates a validation error with the given message about
+// each of the fields
+func NewValidationError(message string, fields ...string) *Error {
+	err := &Error{Kind: ErrValidation, Code: "validation_failed", Message: message}
+	for _, field := range fields {
+		err.Fields = append(err.Fields, FieldError{Field: field, Message: message})
+	}
+	return err
+}
+
+// NewInternalError wraps an error the service cannot recover from
+func NewInternalError(err error) *Error {
+	return &Error{Kind: ErrInternal, Code: "internal_error", Message: "internal error", Err: err}
+}
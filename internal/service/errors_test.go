@@ -0,0 +1,61 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKinds(t *testing.T) {
+	cause := errors.New("connection reset")
+
+	testCases := []struct {
+		name         string
+		err          error
+		expectedKind error
+		expectedMsg  string
+	}{
+		{
+			name:         "Not Found",
+			err:          ErrEstateNotFound,
+			expectedKind: ErrNotFound,
+			expectedMsg:  "estate not found",
+		},
+		{
+			name:         "Conflict",
+			err:          fmt.Errorf("planting: %w", ErrPlotOccupied),
+			expectedKind: ErrConflict,
+			expectedMsg:  "planting: plot already has a tree",
+		},
+		{
+			name:         "Validation",
+			err:          NewValidationError("invalid tree height", "height"),
+			expectedKind: ErrValidation,
+			expectedMsg:  "invalid tree height",
+		},
+		{
+			name:         "Internal",
+			err:          NewInternalError(cause),
+			expectedKind: ErrInternal,
+			expectedMsg:  "internal error: connection reset",
+		},
+	}
+
+	kinds := []error{ErrNotFound, ErrConflict, ErrValidation, ErrInternal}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.EqualError(t, tc.err, tc.expectedMsg)
+			for _, kind := range kinds {
+				assert.Equal(t, kind == tc.expectedKind, errors.Is(tc.err, kind), kind.Error())
+			}
+		})
+	}
+
+	assert.ErrorIs(t, NewInternalError(cause), cause)
+	assert.Equal(t, []FieldError{
+		{Field: "width", Message: "invalid estate dimensions"},
+		{Field: "length", Message: "invalid estate dimensions"},
+	}, NewValidationError("invalid estate dimensions", "width", "length").Fields)
+}
@@ -6,13 +6,15 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+
+	"drone/internal/repository"
 )
 
 // CreateEstate implements the EstateService.CreateEstate method
 func (s *service) CreateEstate(ctx context.Context, width, length int) (uuid.UUID, error) {
 	// Validate inputs (although this should also be validated at the API level and DB constraint level)
 	if width < 1 || width > 50000 || length < 1 || length > 50000 {
-		return uuid.Nil, errors.New("invalid estate dimensions")
+		return uuid.Nil, NewValidationError("invalid estate dimensions", "width", "length")
 	}
 
 	return s.repo.CreateEstate(ctx, width, length)
@@ -24,10 +26,68 @@ func (s *service) GetEstate(ctx context.Context, id uuid.UUID) (width, length in
 	width, length, err = s.repo.GetEstate(ctx, id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, 0, errors.New("estate not found")
+			return 0, 0, ErrEstateNotFound
 		}
 		return 0, 0, err
 	}
 
 	return width, length, nil
-} 
\ No newline at end of file
+} 
+
+// GetEstateDetail implements the EstateService.GetEstateDetail method
+func (s *service) GetEstateDetail(ctx context.Context, id uuid.UUID) (repository.Estate, error) {
+	estate, err := s.repo.GetEstateDetail(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.Estate{}, ErrEstateNotFound
+		}
+		return repository.Estate{}, err
+	}
+
+	return estate, nil
+}
+
+// ResizeEstate implements the EstateService.ResizeEstate method
+func (s *service) ResizeEstate(ctx context.Context, id uuid.UUID, width, length int, prune bool) (repository.Estate, int, error) {
+	// Validate inputs the same way as when the estate was created
+	if width < 1 || width > 50000 || length < 1 || length > 50000 {
+		return repository.Estate{}, 0, NewValidationError("invalid estate dimensions", "width", "length")
+	}
+
+	pruned, err := s.repo.ResizeEstate(ctx, id, width, length, prune)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.Estate{}, 0, ErrEstateNotFound
+		}
+		if errors.Is(err, repository.ErrTreesOutOfBounds) {
+			return repository.Estate{}, 0, ErrTreesOutOfBounds
+		}
+		if errors.Is(err, repository.ErrZonesOutOfBounds) {
+			return repository.Estate{}, 0, ErrZonesOutOfBounds
+		}
+		if errors.Is(err, repository.ErrObstaclesOutOfBounds) {
+			return repository.Estate{}, 0, ErrObstaclesOutOfBounds
+		}
+		return repository.Estate{}, 0, err
+	}
+
+	estate, err := s.GetEstateDetail(ctx, id)
+	if err != nil {
+		return repository.Estate{}, 0, err
+	}
+
+	return estate, pruned, nil
+}
+
+// DeleteEstate implements the EstateService.DeleteEstate method
+func (s *service) DeleteEstate(ctx context.Context, id uuid.UUID) error {
+	// Trees and drone plan jobs of the estate are removed by the database
+	if err := s.repo.DeleteEstate(ctx, id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrEstateNotFound
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+	"drone/internal/repository/mocks"
+)
+
+func TestResizeEstate(t *testing.T) {
+	estateID := uuid.New()
+	resized := repository.Estate{ID: estateID, Width: 5, Length: 4, TreeCount: 2}
+
+	testCases := []struct {
+		name           string
+		width, length  int
+		prune          bool
+		mockSetup      func(*mocks.MockRepository)
+		expectedEstate repository.Estate
+		expectedPruned int
+		expectedErr    string
+	}{
+		{
+			name:   "Resized",
+			width:  5,
+			length: 4,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().ResizeEstate(gomock.Any(), estateID, 5, 4, false).Return(0, nil)
+				mockRepo.EXPECT().GetEstateDetail(gomock.Any(), estateID).Return(resized, nil)
+			},
+			expectedEstate: resized,
+		},
+		{
+			name:   "Pruned",
+			width:  5,
+			length: 4,
+			prune:  true,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().ResizeEstate(gomock.Any(), estateID, 5, 4, true).Return(3, nil)
+				mockRepo.EXPECT().GetEstateDetail(gomock.Any(), estateID).Return(resized, nil)
+			},
+			expectedEstate: resized,
+			expectedPruned: 3,
+		},
+		{
+			name:   "Trees Out Of Bounds",
+			width:  5,
+			length: 4,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().ResizeEstate(gomock.Any(), estateID, 5, 4, false).Return(0, repository.ErrTreesOutOfBounds)
+			},
+			expectedErr: "trees outside new estate boundaries",
+		},
+		{
+			name:   "Zones Out Of Bounds",
+			width:  5,
+			length: 4,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().ResizeEstate(gomock.Any(), estateID, 5, 4, false).Return(0, repository.ErrZonesOutOfBounds)
+			},
+			expectedErr: "zones outside new estate boundaries",
+		},
+		{
+			name:   "Obstacles Out Of Bounds",
+			width:  5,
+			length: 4,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().ResizeEstate(gomock.Any(), estateID, 5, 4, false).Return(0, repository.ErrObstaclesOutOfBounds)
+			},
+			expectedErr: "obstacles outside new estate boundaries",
+		},
+		{
+			name:        "Invalid Dimensions",
+			width:       0,
+			length:      4,
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid estate dimensions",
+		},
+		{
+			name:        "Too Large",
+			width:       5,
+			length:      50001,
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid estate dimensions",
+		},
+		{
+			name:   "Estate Not Found",
+			width:  5,
+			length: 4,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().ResizeEstate(gomock.Any(), estateID, 5, 4, false).Return(0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+		{
+			name:   "Deleted While Resizing",
+			width:  5,
+			length: 4,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().ResizeEstate(gomock.Any(), estateID, 5, 4, false).Return(0, nil)
+				mockRepo.EXPECT().GetEstateDetail(gomock.Any(), estateID).Return(repository.Estate{}, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+		{
+			name:   "Repository Error",
+			width:  5,
+			length: 4,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().ResizeEstate(gomock.Any(), estateID, 5, 4, false).Return(0, errors.New("database error"))
+			},
+			expectedErr: "database error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			estate, pruned, err := NewService(mockRepo).ResizeEstate(context.Background(), estateID, tc.width, tc.length, tc.prune)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedEstate, estate)
+				assert.Equal(t, tc.expectedPruned, pruned)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestDeleteEstate(t *testing.T) {
+	estateID := uuid.New()
+
+	testCases := []struct {
+		name        string
+		mockSetup   func(*mocks.MockRepository)
+		expectedErr string
+	}{
+		{
+			name: "Deleted",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().DeleteEstate(gomock.Any(), estateID).Return(nil)
+			},
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().DeleteEstate(gomock.Any(), estateID).Return(pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+		{
+			name: "Repository Error",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().DeleteEstate(gomock.Any(), estateID).Return(errors.New("database error"))
+			},
+			expectedErr: "database error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			err := NewService(mockRepo).DeleteEstate(context.Background(), estateID)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
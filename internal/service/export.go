@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"drone/internal/repository"
+)
+
+// Formats accepted by ExportEstate
+const (
+	ExportJSON    = "json"
+	ExportCSV     = "csv"
+	ExportGeoJSON = "geojson"
+)
+
+// defaultPlotSize is the length in meters of the side of a plot
+const defaultPlotSize = 10
+
+// earthRadius is the mean radius of the earth in meters
+const earthRadius = 6371008.8
+
+// ExportEstate implements the EstateService.ExportEstate method
+func (s *service) ExportEstate(ctx context.Context, estateID uuid.UUID, format string, geo GeoReference, attributes bool, w io.Writer) error {
+	if format != ExportJSON && format != ExportCSV && format != ExportGeoJSON {
+		return NewValidationError("unsupported export format", "format")
+	}
+	if geo.PlotSize == 0 {
+		geo.PlotSize = defaultPlotSize
+	}
+	if geo.PlotSize < 0 || geo.OriginLat < -90 || geo.OriginLat > 90 || geo.OriginLon < -180 || geo.OriginLon > 180 {
+		return NewValidationError("invalid geo reference", "origin_lat", "origin_lon", "plot_size")
+	}
+
+	width, length, err := s.repo.GetEstate(ctx, estateID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrEstateNotFound
+		}
+		return err
+	}
+
+	buf := bufio.NewWriter(w)
+	switch format {
+	case ExportJSON:
+		err = s.exportJSON(ctx, estateID, width, length, buf)
+	case ExportCSV:
+		err = s.exportCSV(ctx, estateID, attributes, buf)
+	case ExportGeoJSON:
+		err = s.exportGeoJSON(ctx, estateID, width, length, geo, buf)
+	}
+	if err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// exportTree is a tree as written by the JSON and GeoJSON exports. The
+// optional attributes are left out when unknown.
+type exportTree struct {
+	ID        uuid.UUID `json:"id"`
+	X         int       `json:"x"`
+	Y         int       `json:"y"`
+	Height    int       `json:"height"`
+	Species   string    `json:"species,omitempty"`
+	PlantedOn string    `json:"planted_on,omitempty"`
+	Health    string    `json:"health,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+}
+
+// newExportTree returns the exported form of a tree
+func newExportTree(tree repository.Tree) exportTree {
+	return exportTree{
+		ID:        tree.ID,
+		X:         tree.X,
+		Y:         tree.Y,
+		Height:    tree.Height,
+		Species:   tree.Species,
+		PlantedOn: plantedOn(tree),
+		Health:    tree.Health,
+		Tags:      tree.Tags,
+	}
+}
+
+// plantedOn formats the planting date of a tree, or returns "" when unknown
+func plantedOn(tree repository.Tree) string {
+	if tree.PlantedOn == nil {
+		return ""
+	}
+	return tree.PlantedOn.Format(time.DateOnly)
+}
+
+// exportJSON writes the estate as an object with its dimensions and trees
+func (s *service) exportJSON(ctx context.Context, estateID uuid.UUID, width, length int, w *bufio.Writer) error {
+	fmt.Fprintf(w, `{"id":%q,"width":%d,"length":%d,"trees":[`, estateID, width, length)
+
+	encoder := json.NewEncoder(w)
+	first := true
+	err := s.repo.EachTree(ctx, estateID, func(tree repository.Tree) error {
+		if !first {
+			w.WriteByte(',')
+		}
+		first = false
+		return encoder.Encode(newExportTree(tree))
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.WriteString("]}\n")
+	return err
+}
+
+// exportCSV writes the trees as x,y,height records, the format accepted by
+// the bulk tree import. With attributes, the species, planting date, health
+// and tags separated by semicolons follow in columns of their own, which the
+// import does not read.
+func (s *service) exportCSV(ctx context.Context, estateID uuid.UUID, attributes bool, w *bufio.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"x", "y", "height"}
+	if attributes {
+		header = append(header, "species", "planted_on", "health", "tags")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	err := s.repo.EachTree(ctx, estateID, func(tree repository.Tree) error {
+		record := []string{strconv.Itoa(tree.X), strconv.Itoa(tree.Y), strconv.Itoa(tree.Height)}
+		if attributes {
+			record = append(record, tree.Species, plantedOn(tree), tree.Health, strings.Join(tree.Tags, ";"))
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportGeoJSON writes a FeatureCollection with the estate boundary as a
+// polygon followed by a point at the centre of every tree's plot
+func (s *service) exportGeoJSON(ctx context.Context, estateID uuid.UUID, width, length int, geo GeoReference, w *bufio.Writer) error {
+	w.WriteString(`{"type":"FeatureCollection","features":[`)
+
+	boundary := [][2]float64{
+		geo.locate(0, 0),
+		geo.locate(float64(width), 0),
+		geo.locate(float64(width), float64(length)),
+		geo.locate(0, float64(length)),
+		geo.locate(0, 0),
+	}
+	encoder := json.NewEncoder(w)
+	err := encoder.Encode(map[string]any{
+		"type":       "Feature",
+		"geometry":   map[string]any{"type": "Polygon", "coordinates": [][][2]float64{boundary}},
+		"properties": map[string]any{"id": estateID, "width": width, "length": length},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.EachTree(ctx, estateID, func(tree repository.Tree) error {
+		w.WriteByte(',')
+		return encoder.Encode(map[string]any{
+			"type":       "Feature",
+			"geometry":   map[string]any{"type": "Point", "coordinates": geo.locate(float64(tree.X)-0.5, float64(tree.Y)-0.5)},
+			"properties": newExportTree(tree),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.WriteString("]}\n")
+	return err
+}
+
+// locate returns the longitude and latitude of a point given in plots east and
+// north of the south-west corner of the estate
+func (g GeoReference) locate(east, north float64) [2]float64 {
+	lat := g.OriginLat + north*g.PlotSize/earthRadius*180/math.Pi
+	lon := g.OriginLon + east*g.PlotSize/(earthRadius*math.Cos(g.OriginLat*math.Pi/180))*180/math.Pi
+	// Round to about a millimeter to keep the output compact
+	return [2]float64{math.Round(lon*1e8) / 1e8, math.Round(lat*1e8) / 1e8}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+	"drone/internal/repository/mocks"
+)
+
+func TestExportEstate(t *testing.T) {
+	estateID := uuid.New()
+	treeIDs := []uuid.UUID{uuid.New(), uuid.New()}
+	plantedOn := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	trees := []repository.Tree{
+		{ID: treeIDs[0], EstateID: estateID, X: 1, Y: 1, Height: 5},
+		{
+			ID: treeIDs[1], EstateID: estateID, X: 3, Y: 2, Height: 12,
+			Species: "Oil palm", PlantedOn: &plantedOn, Health: repository.TreeHealthDiseased, Tags: []string{"north", "fenced"},
+		},
+	}
+	eachTree := func(_ context.Context, _ uuid.UUID, fn func(tree repository.Tree) error) error {
+		for _, tree := range trees {
+			if err := fn(tree); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	testCases := []struct {
+		name        string
+		format      string
+		geo         GeoReference
+		attributes  bool
+		mockSetup   func(*mocks.MockRepository)
+		checkOutput func(t *testing.T, output []byte)
+		expectedErr string
+	}{
+		{
+			name:   "JSON",
+			format: ExportJSON,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(4, 3, nil)
+				mockRepo.EXPECT().EachTree(gomock.Any(), estateID, gomock.Any()).DoAndReturn(eachTree)
+			},
+			checkOutput: func(t *testing.T, output []byte) {
+				var export struct {
+					ID     uuid.UUID    `json:"id"`
+					Width  int          `json:"width"`
+					Length int          `json:"length"`
+					Trees  []exportTree `json:"trees"`
+				}
+				assert.NoError(t, json.Unmarshal(output, &export))
+				assert.Equal(t, estateID, export.ID)
+				assert.Equal(t, 4, export.Width)
+				assert.Equal(t, 3, export.Length)
+				assert.Equal(t, []exportTree{
+					{ID: treeIDs[0], X: 1, Y: 1, Height: 5},
+					{
+						ID: treeIDs[1], X: 3, Y: 2, Height: 12,
+						Species: "Oil palm", PlantedOn: "2020-03-01", Health: "diseased", Tags: []string{"north", "fenced"},
+					},
+				}, export.Trees)
+
+				// Unknown attributes are left out
+				var raw struct {
+					Trees []map[string]any `json:"trees"`
+				}
+				assert.NoError(t, json.Unmarshal(output, &raw))
+				assert.Len(t, raw.Trees[0], 4)
+			},
+		},
+		{
+			name:   "JSON Without Trees",
+			format: ExportJSON,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(4, 3, nil)
+				mockRepo.EXPECT().EachTree(gomock.Any(), estateID, gomock.Any()).Return(nil)
+			},
+			checkOutput: func(t *testing.T, output []byte) {
+				var export map[string]any
+				assert.NoError(t, json.Unmarshal(output, &export))
+				assert.Equal(t, []any{}, export["trees"])
+			},
+		},
+		{
+			name:   "CSV",
+			format: ExportCSV,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(4, 3, nil)
+				mockRepo.EXPECT().EachTree(gomock.Any(), estateID, gomock.Any()).DoAndReturn(eachTree)
+			},
+			checkOutput: func(t *testing.T, output []byte) {
+				assert.Equal(t, "x,y,height\n1,1,5\n3,2,12\n", string(output))
+			},
+		},
+		{
+			name:       "CSV With Attributes",
+			format:     ExportCSV,
+			attributes: true,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(4, 3, nil)
+				mockRepo.EXPECT().EachTree(gomock.Any(), estateID, gomock.Any()).DoAndReturn(eachTree)
+			},
+			checkOutput: func(t *testing.T, output []byte) {
+				assert.Equal(t, "x,y,height,species,planted_on,health,tags\n"+
+					"1,1,5,,,,\n"+
+					"3,2,12,Oil palm,2020-03-01,diseased,north;fenced\n", string(output))
+			},
+		},
+		{
+			name:   "GeoJSON",
+			format: ExportGeoJSON,
+			geo:    GeoReference{OriginLat: 1, OriginLon: 100},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(4, 3, nil)
+				mockRepo.EXPECT().EachTree(gomock.Any(), estateID, gomock.Any()).DoAndReturn(eachTree)
+			},
+			checkOutput: func(t *testing.T, output []byte) {
+				var collection struct {
+					Type     string `json:"type"`
+					Features []struct {
+						Geometry struct {
+							Type        string          `json:"type"`
+							Coordinates json.RawMessage `json:"coordinates"`
+						} `json:"geometry"`
+						Properties map[string]any `json:"properties"`
+					} `json:"features"`
+				}
+				assert.NoError(t, json.Unmarshal(output, &collection))
+				assert.Equal(t, "FeatureCollection", collection.Type)
+				assert.Len(t, collection.Features, 3)
+				assert.Equal(t, "Polygon", collection.Features[0].Geometry.Type)
+
+				// The first tree stands in the middle of the plot at the origin,
+				// 5 meters north and east of it
+				var point [2]float64
+				assert.NoError(t, json.Unmarshal(collection.Features[1].Geometry.Coordinates, &point))
+				assert.InDelta(t, 100.0000449, point[0], 1e-7)
+				assert.InDelta(t, 1.0000450, point[1], 1e-7)
+				assert.Equal(t, float64(5), collection.Features[1].Properties["height"])
+				assert.Equal(t, "Oil palm", collection.Features[2].Properties["species"])
+				assert.Equal(t, "2020-03-01", collection.Features[2].Properties["planted_on"])
+				assert.Equal(t, "diseased", collection.Features[2].Properties["health"])
+				assert.Equal(t, []any{"north", "fenced"}, collection.Features[2].Properties["tags"])
+			},
+		},
+		{
+			name:        "Unsupported Format",
+			format:      "xml",
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "unsupported export format",
+		},
+		{
+			name:        "Invalid Geo Reference",
+			format:      ExportGeoJSON,
+			geo:         GeoReference{OriginLat: 91},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid geo reference",
+		},
+		{
+			name:   "Estate Not Found",
+			format: ExportJSON,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(0, 0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			var output bytes.Buffer
+			err := NewService(mockRepo).ExportEstate(context.Background(), estateID, tc.format, tc.geo, tc.attributes, &output)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				tc.checkOutput(t, output.Bytes())
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+				assert.Empty(t, output.Bytes())
+			}
+		})
+	}
+}
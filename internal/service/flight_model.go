@@ -0,0 +1,500 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"drone/internal/repository"
+)
+
+const (
+	// defaultClearance is the altitude the drone keeps above the ground or a tree
+	defaultClearance = 1
+	// defaultLookahead is the number of plots a smoothed flight looks ahead
+	defaultLookahead = 3
+)
+
+// flightModel decides the altitude the drone flies at over each plot
+type flightModel struct {
+	// rows and columns index the trees by y and x, each line sorted by position
+	rows    map[int][]lineTree
+	columns map[int][]lineTree
+	// clearance is the altitude kept above the ground or a tree
+	clearance int
+	// lookahead is the number of plots ahead along a sweep the drone considers
+	// before descending; 0 follows the terrain plot by plot
+	lookahead int
+	// progress, if set, is told about every sweep walked
+	progress *planProgress
+	// high holds the obstacles the drone has to climb over, and obstacles
+	// tracks the impassable ones if there are any
+	high      []repository.Obstacle
+	obstacles *obstacleMap
+	// zone, if set, holds the polygon the patrol is confined to. The plots
+	// outside it are only flown over on the way between plots of the zone.
+	zone []repository.Point
+}
+
+// planProgress tracks how many plots a plan calculation has walked over out
+// of the total it will walk, reporting each new whole percentage
+type planProgress struct {
+	total   int
+	done    int
+	percent int
+	report  func(percent int)
+}
+
+// add records that a number of plots have been walked
+func (p *planProgress) add(plots int) {
+	p.done += plots
+	// 100 is only reached once the plan is complete, not when the last walk ends
+	if percent := p.done * 100 / p.total; percent > p.percent && percent < 100 {
+		p.percent = percent
+		p.report(percent)
+	}
+}
+
+// lineTree is a tree on a row or column of the estate. A plot under an
+// obstacle the drone has to climb over is a tree reaching the obstacle's
+// minimum altitude less the clearance.
+type lineTree struct {
+	pos    int
+	height int
+}
+
+// run is a stretch of consecutive plots along a sweep that the drone flies
+// over at the same altitude
+type run struct {
+	x, y   int
+	dx, dy int
+	count  int
+	z      int
+}
+
+// plot returns the coordinates of the i-th plot of the run
+func (r run) plot(i int) (x, y int) {
+	return r.x + i*r.dx, r.y + i*r.dy
+}
+
+// newFlightModel creates a flight model over the given trees
+func newFlightModel(trees []repository.Tree, clearance, lookahead int) *flightModel {
+	// Index the trees by row and by column for quick lookup along a sweep
+	rows := make(map[int][]lineTree)
+	columns := make(map[int][]lineTree)
+	for _, tree := range trees {
+		rows[tree.Y] = append(rows[tree.Y], lineTree{pos: tree.X, height: tree.Height})
+		columns[tree.X] = append(columns[tree.X], lineTree{pos: tree.Y, height: tree.Height})
+	}
+	for _, line := range rows {
+		sort.Slice(line, func(i, j int) bool { return line[i].pos < line[j].pos })
+	}
+	for _, line := range columns {
+		sort.Slice(line, func(i, j int) bool { return line[i].pos < line[j].pos })
+	}
+
+	return &flightModel{
+		rows:      rows,
+		columns:   columns,
+		clearance: clearance,
+		lookahead: lookahead,
+	}
+}
+
+// addObstacles makes the drone climb over or fly around the given obstacles
+// of an estate
+func (m *flightModel) addObstacles(width, length int, obstacles []repository.Obstacle) {
+	m.high = nil
+	for _, obstacle := range obstacles {
+		// Obstacles lower than the clearance never change the altitude
+		if !obstacle.Impassable && obstacle.MinAltitude > m.clearance {
+			m.high = append(m.high, obstacle)
+		}
+	}
+	m.obstacles = newObstacleMap(width, length, obstacles)
+}
+
+// firstPlot returns the plot a patrol following the strategy takes off from,
+// the first plot of the zone the drone can fly over
+func (m *flightModel) firstPlot(strategy PathStrategy, width, length int) (x, y int, err error) {
+	x, y, _, ok := m.start(strategy, width, length)
+	if !ok {
+		return 0, 0, ErrImpassable
+	}
+	return x, y, nil
+}
+
+// start returns the first plot of the zone the drone can fly over in the
+// order of the strategy, and the area of the obstacle map it lies in. It
+// returns false if there is no such plot.
+func (m *flightModel) start(strategy PathStrategy, width, length int) (x, y, area int, ok bool) {
+	if m.zone == nil {
+		if m.obstacles == nil {
+			x, y = firstPlot(strategy, width, length)
+			return x, y, 0, true
+		}
+		return m.obstacles.start(strategy)
+	}
+
+	strategy.Sweeps(width, length, func(s Sweep) bool {
+		dx, dy := sign(s.ToX-s.FromX), sign(s.ToY-s.FromY)
+		for _, stretch := range m.zoneStretches(s) {
+			i := stretch.from
+			if m.obstacles != nil {
+				i = -1
+				for _, seg := range m.obstacles.segments(s) {
+					if seg.area != impassableCell && seg.to >= stretch.from && seg.from <= stretch.to {
+						i, area = max(seg.from, stretch.from), seg.area
+						break
+					}
+				}
+				if i < 0 {
+					continue
+				}
+			}
+			x, y, ok = s.FromX+i*dx, s.FromY+i*dy, true
+			return false
+		}
+		return true
+	})
+	return x, y, area, ok
+}
+
+// zoneStretches returns the stretches of a sweep lying in the zone, as
+// indexes along the sweep in flight order
+func (m *flightModel) zoneStretches(s Sweep) []segment {
+	line, from, to := s.FromY, s.FromX, s.ToX
+	column := s.FromX == s.ToX && s.FromY != s.ToY
+	if column {
+		line, from, to = s.FromX, s.FromY, s.ToY
+	}
+	lo, hi := min(from, to), max(from, to)
+
+	var stretches []segment
+	for _, stretch := range zoneLine(m.zone, line, column) {
+		first, last := max(stretch[0], lo), min(stretch[1], hi)
+		if first > last {
+			continue
+		}
+		a, b := abs(first-from), abs(last-from)
+		stretches = append(stretches, segment{from: min(a, b), to: max(a, b)})
+	}
+	if from > to {
+		slices.Reverse(stretches)
+	}
+	return stretches
+}
+
+// unreachable returns the plots a patrol following the strategy cannot get
+// to, and their number
+func (m *flightModel) unreachable(strategy PathStrategy) ([]PlotRect, int) {
+	if m.obstacles == nil {
+		return nil, 0
+	}
+
+	_, _, area, ok := m.start(strategy, m.obstacles.width, m.obstacles.length)
+	if !ok {
+		return nil, 0
+	}
+	return m.obstacles.unreachable(area)
+}
+
+// visitPlot calculates the distance to fly to a plot and reach altitude z
+// over it. If impassable plots stand in the way, the drone climbs to the
+// highest altitude required along the shortest way around them and flies it;
+// the positions it turns at are returned, except for the plot itself. Plots
+// left out of the patrol on the way, outside the zone, are flown over the
+// same way.
+func (m *flightModel) visitPlot(x, y, z int, currentPos *position) (int, []position) {
+	var (
+		turns      []Plot
+		horizontal int
+	)
+	if m.obstacles != nil {
+		turns, horizontal = m.obstacles.bandRoute(currentPos.x, currentPos.y, x, y)
+	}
+	if turns == nil && abs(x-currentPos.x)+abs(y-currentPos.y) > 1 {
+		// Fly along the row first, then along the column
+		if x != currentPos.x && y != currentPos.y {
+			turns = append(turns, Plot{X: x, Y: currentPos.y})
+		}
+		turns = append(turns, Plot{X: x, Y: y})
+		horizontal = abs(x-currentPos.x) + abs(y-currentPos.y)
+	}
+	if turns == nil {
+		return visitPlot(x, y, z, currentPos), nil
+	}
+
+	altitude := currentPos.z
+	from := Plot{X: currentPos.x, Y: currentPos.y}
+	for _, turn := range turns {
+		for _, tree := range m.treesAlong(Sweep{FromX: from.X, FromY: from.Y, ToX: turn.X, ToY: turn.Y}) {
+			altitude = max(altitude, tree.height+m.clearance)
+		}
+		from = turn
+	}
+	altitude = max(altitude, m.clearance)
+
+	var detour []position
+	if altitude != currentPos.z {
+		detour = append(detour, position{x: currentPos.x, y: currentPos.y, z: altitude})
+	}
+	for _, turn := range turns[:len(turns)-1] {
+		detour = append(detour, position{x: turn.X, y: turn.Y, z: altitude})
+	}
+
+	distance := altitude - currentPos.z + horizontal + abs(z-altitude)
+	*currentPos = position{x: x, y: y, z: z}
+	return distance, detour
+}
+
+// walk calls visit for every run of plots in the order given by the strategy,
+// stopping early if visit returns false. Flat stretches without trees are
+// reported as a single run, so the work done is proportional to the number of
+// sweeps and trees rather than to the area of the estate. The walk is aborted
+// with the context's error once the context is done.
+//
+// With a lookahead the drone holds its altitude across short dips: it only
+// descends as far as the highest plot within the next lookahead plots of the
+// current sweep requires.
+//
+// Impassable plots, the plots that cannot be reached from the first plot of
+// the patrol without crossing one and the plots outside the zone are left out
+// of the runs.
+func (m *flightModel) walk(ctx context.Context, strategy PathStrategy, width, length int, visit func(r run) bool) error {
+	var err error
+	altitude := 0
+
+	area := 0
+	if m.obstacles != nil || m.zone != nil {
+		var ok bool
+		if _, _, area, ok = m.start(strategy, width, length); !ok {
+			return ErrImpassable
+		}
+	}
+
+	strategy.Sweeps(width, length, func(s Sweep) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+
+		dx, dy := sign(s.ToX-s.FromX), sign(s.ToY-s.FromY)
+		n := abs(s.ToX-s.FromX) + abs(s.ToY-s.FromY) + 1
+		trees := m.treesAlong(s)
+		if m.progress != nil {
+			m.progress.add(n)
+		}
+
+		// The plots the drone cannot fly over or get to, and those outside
+		// the zone, split the sweep
+		var gaps []segment
+		if m.obstacles != nil {
+			for _, seg := range m.obstacles.segments(s) {
+				if seg.area != area {
+					gaps = append(gaps, seg)
+				}
+			}
+		}
+		if m.zone != nil {
+			next := 0
+			for _, stretch := range m.zoneStretches(s) {
+				if stretch.from > next {
+					gaps = append(gaps, segment{from: next, to: stretch.from - 1})
+				}
+				next = stretch.to + 1
+			}
+			if next < n {
+				gaps = append(gaps, segment{from: next, to: n - 1})
+			}
+			sort.Slice(gaps, func(i, j int) bool { return gaps[i].from < gaps[j].from })
+		}
+
+		// Consecutive runs at the same altitude are merged before being
+		// visited, unless there is a gap between them
+		var pending run
+		gapped := false
+		join := func(i, count, z int) bool {
+			if pending.count > 0 && pending.z == z && !gapped {
+				pending.count += count
+				return true
+			}
+			if pending.count > 0 && !visit(pending) {
+				return false
+			}
+			pending = run{x: s.FromX + i*dx, y: s.FromY + i*dy, dx: dx, dy: dy, count: count, z: z}
+			gapped = false
+			return true
+		}
+		g := 0
+		emit := func(i, count, z int) bool {
+			for count > 0 {
+				for g < len(gaps) && gaps[g].to < i {
+					g++
+				}
+				if g < len(gaps) && gaps[g].from <= i {
+					skipped := min(count, gaps[g].to-i+1)
+					i, count = i+skipped, count-skipped
+					gapped = true
+					continue
+				}
+
+				n := count
+				if g < len(gaps) {
+					n = min(n, gaps[g].from-i)
+				}
+				if !join(i, n, z) {
+					return false
+				}
+				i, count = i+n, count-n
+			}
+			return true
+		}
+
+		// Only the trees and the lookahead plots before them can differ from
+		// flying at the clearance, so everything in between is one flat run.
+		// window holds the trees ahead of the current plot within the
+		// lookahead, highest first.
+		var window []int
+		next, current, pos := 0, 0, 0
+		for current < len(trees) {
+			// Find the stretch of plots influenced by the next trees
+			from := max(pos, trees[current].pos-m.lookahead)
+			to := trees[current].pos
+			for last := current + 1; last < len(trees) && trees[last].pos-m.lookahead <= to+1; last++ {
+				to = trees[last].pos
+			}
+
+			if from > pos {
+				altitude = m.clearance
+				if !emit(pos, from-pos, altitude) {
+					return false
+				}
+			}
+
+			for i := from; i <= to; i++ {
+				for next < len(trees) && trees[next].pos <= i+m.lookahead {
+					for len(window) > 0 && trees[window[len(window)-1]].height <= trees[next].height {
+						window = window[:len(window)-1]
+					}
+					window = append(window, next)
+					next++
+				}
+				for len(window) > 0 && trees[window[0]].pos <= i {
+					window = window[1:]
+				}
+
+				required := m.clearance
+				if current < len(trees) && trees[current].pos == i {
+					required = trees[current].height + m.clearance
+					current++
+				}
+
+				ahead := 0
+				if i < n-1 {
+					ahead = m.clearance
+				}
+				if len(window) > 0 {
+					ahead = max(ahead, trees[window[0]].height+m.clearance)
+				}
+
+				altitude = max(required, min(altitude, ahead))
+				if !emit(i, 1, altitude) {
+					return false
+				}
+			}
+			pos = to + 1
+		}
+
+		if pos < n {
+			altitude = m.clearance
+			if !emit(pos, n-pos, altitude) {
+				return false
+			}
+		}
+
+		if pending.count == 0 {
+			return true
+		}
+		return visit(pending)
+	})
+
+	return err
+}
+
+// treesAlong returns the trees under a sweep, positioned by their index along
+// the sweep and in flight order. The plots under obstacles the drone has to
+// climb over are included as trees, keeping the taller of a tree and an
+// obstacle on the same plot.
+func (m *flightModel) treesAlong(s Sweep) []lineTree {
+	trees := m.lineTreesAlong(s)
+	if len(m.high) == 0 {
+		return trees
+	}
+
+	from, to, line := s.FromX, s.ToX, s.FromY
+	column := s.FromX == s.ToX && s.FromY != s.ToY
+	if column {
+		from, to, line = s.FromY, s.ToY, s.FromX
+	}
+	lo, hi := min(from, to), max(from, to)
+
+	added := false
+	for _, obstacle := range m.high {
+		first, last, lineMin, lineMax := obstacle.XMin, obstacle.XMax, obstacle.YMin, obstacle.YMax
+		if column {
+			first, last, lineMin, lineMax = obstacle.YMin, obstacle.YMax, obstacle.XMin, obstacle.XMax
+		}
+		if line < lineMin || line > lineMax {
+			continue
+		}
+		for pos := max(first, lo); pos <= min(last, hi); pos++ {
+			trees = append(trees, lineTree{pos: abs(pos - from), height: obstacle.MinAltitude - m.clearance})
+			added = true
+		}
+	}
+	if !added {
+		return trees
+	}
+
+	sort.Slice(trees, func(i, j int) bool {
+		if trees[i].pos != trees[j].pos {
+			return trees[i].pos < trees[j].pos
+		}
+		return trees[i].height > trees[j].height
+	})
+	merged := trees[:0]
+	for _, tree := range trees {
+		if len(merged) == 0 || merged[len(merged)-1].pos != tree.pos {
+			merged = append(merged, tree)
+		}
+	}
+	return merged
+}
+
+// lineTreesAlong returns the actual trees under a sweep, positioned by their
+// index along the sweep and in flight order
+func (m *flightModel) lineTreesAlong(s Sweep) []lineTree {
+	line, from, to := m.rows[s.FromY], s.FromX, s.ToX
+	if s.FromX == s.ToX && s.FromY != s.ToY {
+		line, from, to = m.columns[s.FromX], s.FromY, s.ToY
+	}
+
+	lo, hi := min(from, to), max(from, to)
+	start := sort.Search(len(line), func(i int) bool { return line[i].pos >= lo })
+	end := sort.Search(len(line), func(i int) bool { return line[i].pos > hi })
+	if start == end {
+		return nil
+	}
+
+	trees := make([]lineTree, 0, end-start)
+	if from <= to {
+		for _, tree := range line[start:end] {
+			trees = append(trees, lineTree{pos: tree.pos - from, height: tree.height})
+		}
+	} else {
+		for i := end - 1; i >= start; i-- {
+			trees = append(trees, lineTree{pos: from - line[i].pos, height: line[i].height})
+		}
+	}
+	return trees
+}
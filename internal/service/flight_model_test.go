@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+)
+
+// rowAltitudes lists the altitude flown over each plot of an estate a single
+// row long
+func rowAltitudes(t *testing.T, model *flightModel, width int) []int {
+	var altitudes []int
+	err := model.walk(context.Background(), rowZigzag{}, width, 1, func(r run) bool {
+		for i := 0; i < r.count; i++ {
+			altitudes = append(altitudes, r.z)
+		}
+		return true
+	})
+	assert.NoError(t, err)
+	return altitudes
+}
+
+func TestFlightModelAltitudes(t *testing.T) {
+	twoTrees := []repository.Tree{{X: 2, Y: 1, Height: 5}, {X: 4, Y: 1, Height: 5}}
+
+	testCases := []struct {
+		name              string
+		trees             []repository.Tree
+		clearance         int
+		lookahead         int
+		expectedAltitudes []int
+	}{
+		{
+			name:              "Terrain Following",
+			trees:             twoTrees,
+			clearance:         1,
+			expectedAltitudes: []int{1, 6, 1, 6, 1, 1, 1},
+		},
+		{
+			name:              "Clearance",
+			trees:             twoTrees,
+			clearance:         3,
+			expectedAltitudes: []int{3, 8, 3, 8, 3, 3, 3},
+		},
+		{
+			name:              "Holds Across A Short Dip",
+			trees:             twoTrees,
+			clearance:         1,
+			lookahead:         3,
+			expectedAltitudes: []int{1, 6, 6, 6, 1, 1, 1},
+		},
+		{
+			name:              "Descends Across A Dip Longer Than The Lookahead",
+			trees:             []repository.Tree{{X: 2, Y: 1, Height: 5}, {X: 5, Y: 1, Height: 5}},
+			clearance:         1,
+			lookahead:         1,
+			expectedAltitudes: []int{1, 6, 1, 1, 6, 1, 1},
+		},
+		{
+			name:              "Holds Across A Dip Within The Lookahead",
+			trees:             []repository.Tree{{X: 2, Y: 1, Height: 5}, {X: 5, Y: 1, Height: 5}},
+			clearance:         1,
+			lookahead:         2,
+			expectedAltitudes: []int{1, 6, 6, 6, 6, 1, 1},
+		},
+		{
+			// The drone only descends as far as the tallest plot ahead needs
+			name:              "Descends Partway",
+			trees:             []repository.Tree{{X: 2, Y: 1, Height: 5}, {X: 4, Y: 1, Height: 2}},
+			clearance:         1,
+			lookahead:         3,
+			expectedAltitudes: []int{1, 6, 3, 3, 1, 1, 1},
+		},
+		{
+			// Smoothing holds altitude but never climbs ahead of a tree
+			name:              "Never Climbs Early",
+			trees:             []repository.Tree{{X: 6, Y: 1, Height: 5}},
+			clearance:         1,
+			lookahead:         3,
+			expectedAltitudes: []int{1, 1, 1, 1, 1, 6, 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			model := newFlightModel(tc.trees, tc.clearance, tc.lookahead)
+			assert.Equal(t, tc.expectedAltitudes, rowAltitudes(t, model, 7))
+		})
+	}
+}
+
+func TestDronePlanAltitudeOptions(t *testing.T) {
+	trees := []repository.Tree{{X: 2, Y: 1, Height: 5}, {X: 4, Y: 1, Height: 5}}
+
+	testCases := []struct {
+		name                  string
+		opts                  DronePlanOptions
+		expectedDistance      int
+		expectedVerticalSaved int
+		expectedErr           string
+	}{
+		{
+			name:             "Default Clearance",
+			expectedDistance: 28,
+		},
+		{
+			name:             "Clearance",
+			opts:             DronePlanOptions{Clearance: 3},
+			expectedDistance: 32,
+		},
+		{
+			// Holding altitude over the dip between the trees saves climbing
+			// down and back up 5 each way
+			name:                  "Smoothed",
+			opts:                  DronePlanOptions{SmoothAltitude: true},
+			expectedDistance:      18,
+			expectedVerticalSaved: 10,
+		},
+		{
+			// The lookahead is only used by a smoothed plan
+			name:             "Lookahead Without Smoothing",
+			opts:             DronePlanOptions{Lookahead: 3},
+			expectedDistance: 28,
+		},
+		{
+			name:        "Negative Clearance",
+			opts:        DronePlanOptions{Clearance: -1},
+			expectedErr: "invalid clearance",
+		},
+		{
+			name:        "Negative Lookahead",
+			opts:        DronePlanOptions{SmoothAltitude: true, Lookahead: -1},
+			expectedErr: "invalid lookahead",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			plan, err := planDronePath(context.Background(), 7, 1, trees, nil, tc.opts, nil)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDistance, plan.Distance)
+			assert.Equal(t, tc.expectedVerticalSaved, plan.VerticalSaved)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"drone/internal/repository"
+)
+
+const (
+	// defaultGrowthLimit is the number of fastest and of slowest growing trees
+	// in a growth report when no limit is given
+	defaultGrowthLimit = 5
+	// maxGrowthLimit is the largest number of fastest and of slowest growing
+	// trees in a growth report
+	maxGrowthLimit = 100
+)
+
+// GetTreeHistory implements the TreeService.GetTreeHistory method
+func (s *service) GetTreeHistory(ctx context.Context, estateID, treeID uuid.UUID) ([]repository.TreeMeasurement, error) {
+	// Checks that the estate exists and the tree stands on it
+	if _, err := s.GetTree(ctx, estateID, treeID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListTreeMeasurements(ctx, treeID)
+}
+
+// GetGrowthReport implements the TreeService.GetGrowthReport method
+func (s *service) GetGrowthReport(ctx context.Context, estateID uuid.UUID, opts GrowthReportOptions) (*GrowthReport, error) {
+	limit := opts.Limit
+	if limit == 0 {
+		limit = defaultGrowthLimit
+	}
+	if limit < 0 || limit > maxGrowthLimit {
+		return nil, NewValidationError("invalid limit", "limit")
+	}
+
+	// Check if estate exists
+	if _, _, err := s.repo.GetEstate(ctx, estateID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEstateNotFound
+		}
+		return nil, err
+	}
+
+	stats, err := s.repo.GetGrowthStats(ctx, estateID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GrowthReport{
+		Trees:                stats.Trees,
+		AverageMonthlyGrowth: stats.AverageMonthlyGrowth,
+		Fastest:              stats.Fastest,
+		Slowest:              stats.Slowest,
+	}, nil
+}
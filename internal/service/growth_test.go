@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+	"drone/internal/repository/mocks"
+)
+
+func TestGetTreeHistory(t *testing.T) {
+	estateID := uuid.New()
+	treeID := uuid.New()
+	planted := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := []repository.TreeMeasurement{
+		{Height: 3, MeasuredAt: planted},
+		{Height: 5, MeasuredAt: planted.AddDate(0, 6, 0)},
+	}
+
+	testCases := []struct {
+		name        string
+		mockSetup   func(*mocks.MockRepository)
+		expected    []repository.TreeMeasurement
+		expectedErr string
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(repository.Tree{ID: treeID, EstateID: estateID}, nil)
+				mockRepo.EXPECT().ListTreeMeasurements(gomock.Any(), treeID).Return(history, nil)
+			},
+			expected: history,
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(0, 0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+		{
+			name: "Tree Not Found",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(repository.Tree{}, pgx.ErrNoRows)
+			},
+			expectedErr: "tree not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			measurements, err := NewService(mockRepo).GetTreeHistory(context.Background(), estateID, treeID)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, measurements)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestGetGrowthReport(t *testing.T) {
+	estateID := uuid.New()
+	fast := repository.TreeGrowth{TreeID: uuid.New(), X: 1, Y: 1, FirstHeight: 2, LastHeight: 8, MonthlyGrowth: 1}
+	slow := repository.TreeGrowth{TreeID: uuid.New(), X: 2, Y: 1, FirstHeight: 2, LastHeight: 3, MonthlyGrowth: 0.25}
+
+	testCases := []struct {
+		name        string
+		opts        GrowthReportOptions
+		mockSetup   func(*mocks.MockRepository)
+		expected    *GrowthReport
+		expectedErr string
+	}{
+		{
+			name: "Default Limit",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().GetGrowthStats(gomock.Any(), estateID, 5).Return(repository.GrowthStats{
+					Trees:                2,
+					AverageMonthlyGrowth: 0.625,
+					Fastest:              []repository.TreeGrowth{fast, slow},
+					Slowest:              []repository.TreeGrowth{slow, fast},
+				}, nil)
+			},
+			expected: &GrowthReport{
+				Trees:                2,
+				AverageMonthlyGrowth: 0.625,
+				Fastest:              []repository.TreeGrowth{fast, slow},
+				Slowest:              []repository.TreeGrowth{slow, fast},
+			},
+		},
+		{
+			name: "No Trees Measured Twice",
+			opts: GrowthReportOptions{Limit: 1},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().GetGrowthStats(gomock.Any(), estateID, 1).Return(repository.GrowthStats{}, nil)
+			},
+			expected: &GrowthReport{},
+		},
+		{
+			name:        "Limit Too Large",
+			opts:        GrowthReportOptions{Limit: 101},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid limit",
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(0, 0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+		{
+			name: "Repository Error",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().GetGrowthStats(gomock.Any(), estateID, 5).Return(repository.GrowthStats{}, errors.New("database error"))
+			},
+			expectedErr: "database error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			report, err := NewService(mockRepo).GetGrowthReport(context.Background(), estateID, tc.opts)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, report)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
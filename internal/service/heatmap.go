@@ -0,0 +1,321 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"drone/internal/repository"
+)
+
+// Formats accepted by RenderHeatmap
+const (
+	HeatmapPNG = "png"
+	HeatmapSVG = "svg"
+)
+
+const (
+	// defaultHeatmapSize is the number of pixels along the longest side of a
+	// heatmap when no size is given
+	defaultHeatmapSize = 512
+	// maxHeatmapSize is the largest number of pixels along a side of a heatmap
+	maxHeatmapSize = 4096
+)
+
+var (
+	// emptyPlotColor is the colour of plots without a tree
+	emptyPlotColor = color.RGBA{R: 238, G: 232, B: 213, A: 255}
+	// routeColor is the colour of the drone route overlay
+	routeColor = color.RGBA{R: 31, G: 78, B: 156, A: 255}
+	// restColor is the colour of the drone rest point overlay
+	restColor = color.RGBA{R: 20, G: 20, B: 20, A: 255}
+	// heightColorStops ramps the colour of a plot from pale yellow for the
+	// shortest trees through orange to dark red for the tallest
+	heightColorStops = []struct {
+		height int
+		color  color.RGBA
+	}{
+		{height: 1, color: color.RGBA{R: 255, G: 255, B: 178, A: 255}},
+		{height: 15, color: color.RGBA{R: 253, G: 141, B: 60, A: 255}},
+		{height: 30, color: color.RGBA{R: 189, G: 0, B: 38, A: 255}},
+	}
+)
+
+// heatmap is the tallest tree in each cell of an estate, a cell being a
+// square of scale plots. Row 0 is the southernmost row of cells.
+type heatmap struct {
+	width, length int
+	scale         int
+	columns, rows int
+	heights       []int
+	// pixels is the number of pixels along the side of a cell
+	pixels int
+}
+
+// newHeatmap sizes the cells of a heatmap of an estate so that its longest
+// side fits in size pixels, downsampling estates larger than that
+func newHeatmap(width, length, size int) *heatmap {
+	h := &heatmap{width: width, length: length, scale: 1, pixels: 1}
+	side := max(width, length)
+	if side > size {
+		h.scale = (side + size - 1) / size
+	} else {
+		h.pixels = size / side
+	}
+	h.columns = (width + h.scale - 1) / h.scale
+	h.rows = (length + h.scale - 1) / h.scale
+	h.heights = make([]int, h.columns*h.rows)
+	return h
+}
+
+// add marks a tree in the cell of its plot
+func (h *heatmap) add(tree repository.Tree) {
+	if tree.X < 1 || tree.X > h.width || tree.Y < 1 || tree.Y > h.length {
+		return
+	}
+	i := (tree.Y-1)/h.scale*h.columns + (tree.X-1)/h.scale
+	h.heights[i] = max(h.heights[i], tree.Height)
+}
+
+// point returns the position in the image of the centre of a plot
+func (h *heatmap) point(x, y int) (float64, float64) {
+	px := (float64(x) - 0.5) / float64(h.scale) * float64(h.pixels)
+	py := (float64(h.rows) - (float64(y)-0.5)/float64(h.scale)) * float64(h.pixels)
+	return px, py
+}
+
+// heightColor returns the colour of a plot with a tree of the given height,
+// or of an empty plot for a height of 0
+func heightColor(height int) color.RGBA {
+	if height <= 0 {
+		return emptyPlotColor
+	}
+	for i := 1; i < len(heightColorStops); i++ {
+		from, to := heightColorStops[i-1], heightColorStops[i]
+		if height > to.height {
+			continue
+		}
+		t := float64(height-from.height) / float64(to.height-from.height)
+		blend := func(a, b uint8) uint8 {
+			return uint8(math.Round(float64(a) + t*(float64(b)-float64(a))))
+		}
+		return color.RGBA{R: blend(from.color.R, to.color.R), G: blend(from.color.G, to.color.G), B: blend(from.color.B, to.color.B), A: 255}
+	}
+	return heightColorStops[len(heightColorStops)-1].color
+}
+
+// RenderHeatmap implements the EstateService.RenderHeatmap method
+func (s *service) RenderHeatmap(ctx context.Context, estateID uuid.UUID, format string, opts HeatmapOptions, w io.Writer) error {
+	if format != HeatmapPNG && format != HeatmapSVG {
+		return NewValidationError("unsupported heatmap format", "format")
+	}
+	size := opts.Size
+	if size == 0 {
+		size = defaultHeatmapSize
+	}
+	if size < 1 || size > maxHeatmapSize {
+		return NewValidationError("invalid image size", "size")
+	}
+
+	width, length, err := s.repo.GetEstate(ctx, estateID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrEstateNotFound
+		}
+		return err
+	}
+
+	h := newHeatmap(width, length, size)
+
+	// The route needs every tree at once, otherwise they are streamed into
+	// the cells so that large estates are never loaded whole
+	var plan *DronePlan
+	if opts.Route {
+		trees, err := s.repo.GetTrees(ctx, estateID)
+		if err != nil {
+			return err
+		}
+		obstacles, err := s.repo.ListObstacles(ctx, estateID)
+		if err != nil {
+			return err
+		}
+		droneOpts := opts.Drone
+		droneOpts.IncludePath = true
+		plan, err = planDronePath(ctx, width, length, trees, obstacles, droneOpts, nil)
+		if err != nil {
+			return err
+		}
+		for _, tree := range trees {
+			h.add(tree)
+		}
+	} else {
+		err = s.repo.EachTree(ctx, estateID, func(tree repository.Tree) error {
+			h.add(tree)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if format == HeatmapSVG {
+		return writeHeatmapSVG(w, h, plan)
+	}
+	return png.Encode(w, drawHeatmap(h, plan))
+}
+
+// drawHeatmap draws a heatmap into an image, overlaid with the route and
+// rest point of the drone plan if it is not nil
+func drawHeatmap(h *heatmap, plan *DronePlan) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, h.columns*h.pixels, h.rows*h.pixels))
+	for row := 0; row < h.rows; row++ {
+		top := (h.rows - 1 - row) * h.pixels
+		for column := 0; column < h.columns; column++ {
+			c := heightColor(h.heights[row*h.columns+column])
+			left := column * h.pixels
+			for py := top; py < top+h.pixels; py++ {
+				for px := left; px < left+h.pixels; px++ {
+					img.SetRGBA(px, py, c)
+				}
+			}
+		}
+	}
+
+	if plan == nil {
+		return img
+	}
+
+	brush := h.pixels / 8
+	for i := 1; i < len(plan.Path); i++ {
+		from, to := plan.Path[i-1], plan.Path[i]
+		if from.X == to.X && from.Y == to.Y {
+			continue
+		}
+		x0, y0 := h.point(from.X, from.Y)
+		x1, y1 := h.point(to.X, to.Y)
+		drawLine(img, int(x0), int(y0), int(x1), int(y1), brush, routeColor)
+	}
+
+	if plan.Rest != nil {
+		cx, cy := h.point(plan.Rest.X, plan.Rest.Y)
+		radius := max(3, h.pixels/2)
+		for py := int(cy) - radius; py <= int(cy)+radius; py++ {
+			for px := int(cx) - radius; px <= int(cx)+radius; px++ {
+				dx, dy := px-int(cx), py-int(cy)
+				if dx*dx+dy*dy <= radius*radius {
+					setPixel(img, px, py, restColor)
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+// drawLine draws a straight line between two pixels with a square brush
+// reaching brush pixels around each point of the line
+func drawLine(img *image.RGBA, x0, y0, x1, y1, brush int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		for py := y0 - brush; py <= y0+brush; py++ {
+			for px := x0 - brush; px <= x0+brush; px++ {
+				setPixel(img, px, py, c)
+			}
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// setPixel colours a pixel, ignoring pixels outside the image
+func setPixel(img *image.RGBA, x, y int, c color.RGBA) {
+	if (image.Point{X: x, Y: y}).In(img.Rect) {
+		img.SetRGBA(x, y, c)
+	}
+}
+
+// writeHeatmapSVG writes a heatmap as an SVG image, overlaid with the route
+// and rest point of the drone plan if it is not nil. Runs of cells of the
+// same colour along a row are drawn as a single rectangle.
+func writeHeatmapSVG(w io.Writer, h *heatmap, plan *DronePlan) error {
+	buf := bufio.NewWriter(w)
+	imageWidth, imageHeight := h.columns*h.pixels, h.rows*h.pixels
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+"\n",
+		imageWidth, imageHeight, imageWidth, imageHeight)
+	fmt.Fprintf(buf, `<rect width="%d" height="%d" fill="%s"/>`+"\n", imageWidth, imageHeight, hexColor(emptyPlotColor))
+
+	for row := 0; row < h.rows; row++ {
+		top := (h.rows - 1 - row) * h.pixels
+		for column := 0; column < h.columns; {
+			height := h.heights[row*h.columns+column]
+			run := 1
+			for column+run < h.columns && h.heights[row*h.columns+column+run] == height {
+				run++
+			}
+			if height > 0 {
+				fmt.Fprintf(buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+					column*h.pixels, top, run*h.pixels, h.pixels, hexColor(heightColor(height)))
+			}
+			column += run
+		}
+	}
+
+	if plan != nil {
+		if len(plan.Path) > 0 {
+			buf.WriteString(`<polyline fill="none" stroke="` + hexColor(routeColor) + `" stroke-width="` +
+				fmt.Sprint(max(1, h.pixels/4)) + `" shape-rendering="auto" points="`)
+			for i, waypoint := range plan.Path {
+				if i > 0 {
+					// Climbs and descents over a plot do not show from above
+					if previous := plan.Path[i-1]; previous.X == waypoint.X && previous.Y == waypoint.Y {
+						continue
+					}
+					buf.WriteByte(' ')
+				}
+				x, y := h.point(waypoint.X, waypoint.Y)
+				fmt.Fprintf(buf, "%g,%g", x, y)
+			}
+			buf.WriteString("\"/>\n")
+		}
+		if plan.Rest != nil {
+			x, y := h.point(plan.Rest.X, plan.Rest.Y)
+			fmt.Fprintf(buf, `<circle cx="%g" cy="%g" r="%d" fill="%s" shape-rendering="auto"/>`+"\n",
+				x, y, max(3, h.pixels/2), hexColor(restColor))
+		}
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.Flush()
+}
+
+// hexColor formats a colour as a CSS hex colour
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
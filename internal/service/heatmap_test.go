@@ -0,0 +1,184 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+	"drone/internal/repository/mocks"
+)
+
+func TestRenderHeatmap(t *testing.T) {
+	estateID := uuid.New()
+	trees := []repository.Tree{
+		{X: 1, Y: 1, Height: 30},
+		{X: 3, Y: 1, Height: 10},
+		{X: 4, Y: 2, Height: 20},
+	}
+	eachTree := func(_ context.Context, _ uuid.UUID, fn func(tree repository.Tree) error) error {
+		for _, tree := range trees {
+			if err := fn(tree); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	decode := func(t *testing.T, output []byte) image.Image {
+		img, err := png.Decode(bytes.NewReader(output))
+		assert.NoError(t, err)
+		return img
+	}
+
+	testCases := []struct {
+		name        string
+		format      string
+		opts        HeatmapOptions
+		mockSetup   func(*mocks.MockRepository)
+		checkOutput func(t *testing.T, output []byte)
+		expectedErr string
+	}{
+		{
+			name:   "PNG",
+			format: HeatmapPNG,
+			opts:   HeatmapOptions{Size: 8},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(4, 2, nil)
+				mockRepo.EXPECT().EachTree(gomock.Any(), estateID, gomock.Any()).DoAndReturn(eachTree)
+			},
+			checkOutput: func(t *testing.T, output []byte) {
+				img := decode(t, output)
+				// Every plot is 2 by 2 pixels, with row 1 at the bottom
+				assert.Equal(t, image.Rect(0, 0, 8, 4), img.Bounds())
+				assert.Equal(t, heightColor(30), img.At(1, 3))
+				assert.Equal(t, heightColor(10), img.At(4, 2))
+				assert.Equal(t, heightColor(20), img.At(7, 0))
+				assert.Equal(t, emptyPlotColor, img.At(0, 0))
+			},
+		},
+		{
+			name:   "Downsampled PNG",
+			format: HeatmapPNG,
+			opts:   HeatmapOptions{Size: 2},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(4, 2, nil)
+				mockRepo.EXPECT().EachTree(gomock.Any(), estateID, gomock.Any()).DoAndReturn(eachTree)
+			},
+			checkOutput: func(t *testing.T, output []byte) {
+				img := decode(t, output)
+				// Every pixel covers 2 by 2 plots and shows the tallest tree
+				assert.Equal(t, image.Rect(0, 0, 2, 1), img.Bounds())
+				assert.Equal(t, heightColor(30), img.At(0, 0))
+				assert.Equal(t, heightColor(20), img.At(1, 0))
+			},
+		},
+		{
+			name:   "SVG",
+			format: HeatmapSVG,
+			opts:   HeatmapOptions{Size: 4},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(4, 2, nil)
+				mockRepo.EXPECT().EachTree(gomock.Any(), estateID, gomock.Any()).DoAndReturn(eachTree)
+			},
+			checkOutput: func(t *testing.T, output []byte) {
+				svg := string(output)
+				assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="4" height="2"`))
+				assert.Contains(t, svg, `<rect x="0" y="1" width="1" height="1" fill="`+hexColor(heightColor(30))+`"/>`)
+				assert.Contains(t, svg, `<rect x="3" y="0" width="1" height="1" fill="`+hexColor(heightColor(20))+`"/>`)
+				assert.NotContains(t, svg, "<polyline")
+				assert.True(t, strings.HasSuffix(svg, "</svg>\n"))
+			},
+		},
+		{
+			name:   "SVG With Route",
+			format: HeatmapSVG,
+			opts:   HeatmapOptions{Size: 4, Route: true, Drone: DronePlanOptions{MaxDistance: 150}},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(4, 2, nil)
+				mockRepo.EXPECT().GetTrees(gomock.Any(), estateID).Return(trees, nil)
+				mockRepo.EXPECT().ListObstacles(gomock.Any(), estateID).Return(nil, nil)
+			},
+			checkOutput: func(t *testing.T, output []byte) {
+				svg := string(output)
+				assert.Contains(t, svg, `<polyline fill="none" stroke="#1f4e9c" stroke-width="1" shape-rendering="auto" points="0.5,1.5 `)
+				assert.Contains(t, svg, `<circle `)
+			},
+		},
+		{
+			name:   "PNG With Route",
+			format: HeatmapPNG,
+			opts:   HeatmapOptions{Size: 40, Route: true},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(4, 2, nil)
+				mockRepo.EXPECT().GetTrees(gomock.Any(), estateID).Return(trees, nil)
+				mockRepo.EXPECT().ListObstacles(gomock.Any(), estateID).Return(nil, nil)
+			},
+			checkOutput: func(t *testing.T, output []byte) {
+				img := decode(t, output)
+				assert.Equal(t, image.Rect(0, 0, 40, 20), img.Bounds())
+				// The route runs through the middle of the plots of the first row
+				assert.Equal(t, routeColor, img.At(15, 15))
+				assert.Equal(t, heightColor(30), img.At(1, 19))
+			},
+		},
+		{
+			name:        "Unsupported Format",
+			format:      "gif",
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "unsupported heatmap format",
+		},
+		{
+			name:        "Invalid Size",
+			format:      HeatmapPNG,
+			opts:        HeatmapOptions{Size: maxHeatmapSize + 1},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid image size",
+		},
+		{
+			name:   "Unknown Pattern",
+			format: HeatmapPNG,
+			opts:   HeatmapOptions{Route: true, Drone: DronePlanOptions{Pattern: "circle"}},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(4, 2, nil)
+				mockRepo.EXPECT().GetTrees(gomock.Any(), estateID).Return(trees, nil)
+				mockRepo.EXPECT().ListObstacles(gomock.Any(), estateID).Return(nil, nil)
+			},
+			expectedErr: "unknown patrol pattern",
+		},
+		{
+			name:   "Estate Not Found",
+			format: HeatmapPNG,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(0, 0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			var output bytes.Buffer
+			err := NewService(mockRepo).RenderHeatmap(context.Background(), estateID, tc.format, tc.opts, &output)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			tc.checkOutput(t, output.Bytes())
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"drone/internal/repository"
+)
+
+// jobPollInterval is how often an idle worker looks for jobs queued by other
+// instances of the server
+const jobPollInterval = 2 * time.Second
+
+// SubmitDronePlanJob implements the JobService.SubmitDronePlanJob method
+func (s *service) SubmitDronePlanJob(ctx context.Context, estateID uuid.UUID, opts DronePlanOptions) (uuid.UUID, error) {
+	// First check if estate exists
+	if _, _, err := s.repo.GetEstate(ctx, estateID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrEstateNotFound
+		}
+		return uuid.Nil, err
+	}
+
+	// The zone is checked up front, and kept by ID in case it is renamed
+	// before the job runs
+	if opts.Zone != "" {
+		zone, err := s.findZone(ctx, estateID, opts.Zone)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		opts.Zone = zone.ID.String()
+	}
+
+	options, err := json.Marshal(opts)
+	if err != nil {
+		return uuid.Nil, NewInternalError(err)
+	}
+
+	id, err := s.repo.CreateJob(ctx, estateID, options)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// Wake an idle worker, unless one is already about to wake up
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return id, nil
+}
+
+// GetJob implements the JobService.GetJob method
+func (s *service) GetJob(ctx context.Context, id uuid.UUID) (*Job, error) {
+	record, err := s.repo.GetJob(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+
+	job := &Job{
+		ID:        record.ID,
+		EstateID:  record.EstateID,
+		Status:    record.Status,
+		Progress:  record.Progress,
+		Error:     record.Error,
+		CreatedAt: record.CreatedAt,
+		UpdatedAt: record.UpdatedAt,
+	}
+	// A job that cannot be read back was stored by an incompatible version
+	if err := json.Unmarshal(record.Options, &job.Options); err != nil {
+		return nil, NewInternalError(err)
+	}
+	if record.Result != nil {
+		if err := json.Unmarshal(record.Result, &job.Result); err != nil {
+			return nil, NewInternalError(err)
+		}
+	}
+
+	return job, nil
+}
+
+// CancelJob implements the JobService.CancelJob method
+func (s *service) CancelJob(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.CancelJob(ctx, id); err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		// Tell a job that does not exist from one that has already finished
+		if _, err := s.repo.GetJob(ctx, id); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrJobNotFound
+			}
+			return err
+		}
+		return ErrJobFinished
+	}
+
+	// Stop the calculation right away if this process is running the job.
+	// Jobs run by other processes stop at their next progress update.
+	s.mu.Lock()
+	if cancel, ok := s.running[id]; ok {
+		cancel()
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// RunJobWorkers implements the JobService.RunJobWorkers method
+func (s *service) RunJobWorkers(ctx context.Context, workers int) {
+	// Jobs left running by a previous run of the server are picked up again
+	if err := s.repo.RequeueRunningJobs(ctx); err != nil {
+		log.Printf("Error requeueing drone plan jobs: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runJobWorker(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// runJobWorker claims and runs queued jobs one at a time until the context is done
+func (s *service) runJobWorker(ctx context.Context) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := s.repo.ClaimJob(ctx)
+		if err == nil {
+			s.runJob(ctx, job)
+			continue
+		}
+		if !errors.Is(err, pgx.ErrNoRows) && ctx.Err() == nil {
+			log.Printf("Error claiming drone plan job: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runJob calculates the plan of a claimed job and records its outcome
+func (s *service) runJob(ctx context.Context, job repository.Job) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.mu.Lock()
+	s.running[job.ID] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, job.ID)
+		s.mu.Unlock()
+	}()
+
+	var opts DronePlanOptions
+	if err := json.Unmarshal(job.Options, &opts); err != nil {
+		s.finishJob(ctx, job.ID, repository.JobStatusFailed, nil, err.Error())
+		return
+	}
+
+	plan, err := s.planEstate(jobCtx, job.EstateID, opts, func(percent int) {
+		running, err := s.repo.UpdateJobProgress(jobCtx, job.ID, percent)
+		if err != nil {
+			log.Printf("Error updating progress of drone plan job %s: %v", job.ID, err)
+			return
+		}
+		// The job has been cancelled, possibly through another process
+		if !running {
+			cancel()
+		}
+	})
+	if err != nil {
+		// A cancelled job has already been marked as such. A job interrupted
+		// by shutdown goes back into the queue, for another server or the
+		// next start to calculate.
+		if jobCtx.Err() != nil {
+			if ctx.Err() != nil {
+				if err := s.repo.RequeueJob(context.WithoutCancel(ctx), job.ID); err != nil {
+					log.Printf("Error requeueing drone plan job %s: %v", job.ID, err)
+				}
+			}
+			return
+		}
+		s.finishJob(ctx, job.ID, repository.JobStatusFailed, nil, err.Error())
+		return
+	}
+
+	result, err := json.Marshal(plan)
+	if err != nil {
+		s.finishJob(ctx, job.ID, repository.JobStatusFailed, nil, err.Error())
+		return
+	}
+	s.finishJob(ctx, job.ID, repository.JobStatusSucceeded, result, "")
+}
+
+// finishJob records the outcome of a job, logging any failure to do so. It is
+// recorded even if the server is shutting down meanwhile.
+func (s *service) finishJob(ctx context.Context, id uuid.UUID, status string, result []byte, errMsg string) {
+	if err := s.repo.FinishJob(context.WithoutCancel(ctx), id, status, result, errMsg); err != nil {
+		log.Printf("Error finishing drone plan job %s: %v", id, err)
+	}
+}
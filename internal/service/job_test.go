@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+	"drone/internal/repository/mocks"
+)
+
+func TestRunJob(t *testing.T) {
+	estateID := uuid.New()
+	jobID := uuid.New()
+
+	testCases := []struct {
+		name      string
+		options   DronePlanOptions
+		mockSetup func(*mocks.MockRepository)
+	}{
+		{
+			name: "Succeeded",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(3, 2, nil)
+				mockRepo.EXPECT().GetTrees(gomock.Any(), estateID).Return([]repository.Tree{{X: 2, Y: 1, Height: 5}}, nil)
+				mockRepo.EXPECT().ListObstacles(gomock.Any(), estateID).Return(nil, nil)
+				mockRepo.EXPECT().UpdateJobProgress(gomock.Any(), jobID, gomock.Any()).Return(true, nil).AnyTimes()
+				mockRepo.EXPECT().
+					FinishJob(gomock.Any(), jobID, repository.JobStatusSucceeded, gomock.Any(), "").
+					DoAndReturn(func(_ context.Context, _ uuid.UUID, _ string, result []byte, _ string) error {
+						var plan DronePlan
+						assert.NoError(t, json.Unmarshal(result, &plan))
+						assert.Equal(t, 17, plan.Distance)
+						return nil
+					})
+			},
+		},
+		{
+			name:    "Failed",
+			options: DronePlanOptions{MaxDistance: 1, Legs: true},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(3, 2, nil)
+				mockRepo.EXPECT().GetTrees(gomock.Any(), estateID).Return(nil, nil)
+				mockRepo.EXPECT().ListObstacles(gomock.Any(), estateID).Return(nil, nil)
+				mockRepo.EXPECT().UpdateJobProgress(gomock.Any(), jobID, gomock.Any()).Return(true, nil).AnyTimes()
+				mockRepo.EXPECT().
+					FinishJob(gomock.Any(), jobID, repository.JobStatusFailed, nil, "max distance too short to complete a leg").
+					Return(nil)
+			},
+		},
+		{
+			name: "Cancelled",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(1000, 1000, nil)
+				mockRepo.EXPECT().GetTrees(gomock.Any(), estateID).Return(nil, nil)
+				mockRepo.EXPECT().ListObstacles(gomock.Any(), estateID).Return(nil, nil)
+				// The job is cancelled elsewhere before its first progress update
+				mockRepo.EXPECT().UpdateJobProgress(gomock.Any(), jobID, 1).Return(false, nil)
+				// A cancelled job is not finished again
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			options, err := json.Marshal(tc.options)
+			assert.NoError(t, err)
+
+			s := NewService(mockRepo).(*service)
+			s.runJob(context.Background(), repository.Job{ID: jobID, EstateID: estateID, Options: options})
+
+			assert.Empty(t, s.running)
+		})
+	}
+}
+
+func TestRunJobShutdown(t *testing.T) {
+	estateID := uuid.New()
+	jobID := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(1000, 1000, nil)
+	mockRepo.EXPECT().GetTrees(gomock.Any(), estateID).Return(nil, nil)
+	mockRepo.EXPECT().ListObstacles(gomock.Any(), estateID).Return(nil, nil)
+	// The server shuts down during the first progress update
+	mockRepo.EXPECT().
+		UpdateJobProgress(gomock.Any(), jobID, 1).
+		DoAndReturn(func(context.Context, uuid.UUID, int) (bool, error) {
+			shutdown()
+			return true, nil
+		})
+	// The interrupted job goes back into the queue rather than being left
+	// running, even though the context is done
+	mockRepo.EXPECT().
+		RequeueJob(gomock.Any(), jobID).
+		DoAndReturn(func(ctx context.Context, _ uuid.UUID) error {
+			assert.NoError(t, ctx.Err())
+			return nil
+		})
+
+	s := NewService(mockRepo).(*service)
+	s.runJob(ctx, repository.Job{ID: jobID, EstateID: estateID, Options: []byte(`{}`)})
+
+	assert.Empty(t, s.running)
+}
+
+func TestRunJobWorkersStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().RequeueRunningJobs(gomock.Any()).Return(nil)
+	mockRepo.EXPECT().ClaimJob(gomock.Any()).Return(repository.Job{}, pgx.ErrNoRows).AnyTimes()
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewService(mockRepo).RunJobWorkers(ctx, 2)
+	}()
+
+	// The workers return once the context is done
+	stop()
+	<-done
+}
+
+func TestCancelJob(t *testing.T) {
+	jobID := uuid.New()
+
+	testCases := []struct {
+		name        string
+		mockSetup   func(*mocks.MockRepository)
+		expectedErr string
+	}{
+		{
+			name: "Cancelled",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().CancelJob(gomock.Any(), jobID).Return(nil)
+			},
+		},
+		{
+			name: "Job Not Found",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().CancelJob(gomock.Any(), jobID).Return(pgx.ErrNoRows)
+				mockRepo.EXPECT().GetJob(gomock.Any(), jobID).Return(repository.Job{}, pgx.ErrNoRows)
+			},
+			expectedErr: "job not found",
+		},
+		{
+			name: "Job Already Finished",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().CancelJob(gomock.Any(), jobID).Return(pgx.ErrNoRows)
+				mockRepo.EXPECT().GetJob(gomock.Any(), jobID).Return(repository.Job{ID: jobID, Status: repository.JobStatusSucceeded}, nil)
+			},
+			expectedErr: "job already finished",
+		},
+		{
+			name: "Repository Error",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().CancelJob(gomock.Any(), jobID).Return(errors.New("database error"))
+			},
+			expectedErr: "database error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			err := NewService(mockRepo).CancelJob(context.Background(), jobID)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
@@ -2,11 +2,97 @@ package service
 
 import (
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 
 	"drone/internal/repository"
 )
 
+// estateCursor is the position of the last estate of a page in the listing order
+type estateCursor struct {
+	Sort       string    `json:"s"`
+	Descending bool      `json:"d,omitempty"`
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"c,omitempty"`
+	Width      int       `json:"w,omitempty"`
+	Length     int       `json:"l,omitempty"`
+}
+
 // ListEstates implements the EstateService.ListEstates method
-func (s *service) ListEstates(ctx context.Context) ([]repository.Estate, error) {
-	return s.repo.ListEstates(ctx)
-} 
\ No newline at end of file
+func (s *service) ListEstates(ctx context.Context, opts EstateListOptions) (*EstatePage, error) {
+	query := repository.EstateQuery{
+		Descending:    opts.Descending,
+		MinWidth:      opts.MinWidth,
+		MaxWidth:      opts.MaxWidth,
+		MinLength:     opts.MinLength,
+		MaxLength:     opts.MaxLength,
+		MinArea:       opts.MinArea,
+		MaxArea:       opts.MaxArea,
+		WithTreeCount: opts.WithTreeCount,
+		Limit:         opts.Limit,
+	}
+
+	switch opts.Sort {
+	case "", "created_at":
+		opts.Sort = "created_at"
+	case "area":
+		query.SortByArea = true
+	default:
+		return nil, NewValidationError("invalid sort", "sort")
+	}
+
+	// Without a limit or cursor every estate is listed, as it was before the
+	// listing was paginated, so that clients unaware of pages miss none
+	unbounded := opts.Limit == 0 && opts.Cursor == ""
+	if query.Limit == 0 {
+		query.Limit = defaultPageSize
+	}
+	if query.Limit < 0 || query.Limit > maxPageSize {
+		return nil, NewValidationError("invalid limit", "limit")
+	}
+	if query.MinWidth < 0 || query.MaxWidth < 0 || query.MinLength < 0 || query.MaxLength < 0 ||
+		query.MinArea < 0 || query.MaxArea < 0 ||
+		(query.MaxWidth > 0 && query.MinWidth > query.MaxWidth) ||
+		(query.MaxLength > 0 && query.MinLength > query.MaxLength) ||
+		(query.MaxArea > 0 && query.MinArea > query.MaxArea) {
+		return nil, NewValidationError("invalid size range", "width_min", "width_max", "length_min", "length_max", "area_min", "area_max")
+	}
+
+	if opts.Cursor != "" {
+		var cursor estateCursor
+		if err := decodeCursor(opts.Cursor, &cursor); err != nil {
+			return nil, err
+		}
+		// A cursor only makes sense in the order it was made for
+		if cursor.Sort != opts.Sort || cursor.Descending != opts.Descending {
+			return nil, NewValidationError("invalid cursor", "cursor")
+		}
+		query.After = &repository.Estate{ID: cursor.ID, CreatedAt: cursor.CreatedAt, Width: cursor.Width, Length: cursor.Length}
+	}
+
+	// Fetch one more estate than asked for to know whether there is a next page
+	query.Limit++
+	if unbounded {
+		query.Limit = 0
+	}
+	estates, err := s.repo.QueryEstates(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &EstatePage{Estates: estates}
+	if !unbounded && len(estates) == query.Limit {
+		page.Estates = estates[:len(estates)-1]
+		last := page.Estates[len(page.Estates)-1]
+		cursor := estateCursor{Sort: opts.Sort, Descending: opts.Descending, ID: last.ID}
+		if query.SortByArea {
+			cursor.Width, cursor.Length = last.Width, last.Length
+		} else {
+			cursor.CreatedAt = last.CreatedAt
+		}
+		page.NextCursor = encodeCursor(cursor)
+	}
+
+	return page, nil
+}
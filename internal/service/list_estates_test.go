@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+	"drone/internal/repository/mocks"
+)
+
+func TestListEstates(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 8, 30, 0, 123456000, time.UTC)
+	estates := []repository.Estate{
+		{ID: uuid.New(), Width: 10, Length: 10, CreatedAt: createdAt},
+		{ID: uuid.New(), Width: 20, Length: 10, CreatedAt: createdAt.Add(time.Hour)},
+	}
+
+	t.Run("Pages Through Estates By Creation Time", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockRepository(ctrl)
+		mockRepo.EXPECT().
+			QueryEstates(gomock.Any(), repository.EstateQuery{MinWidth: 5, WithTreeCount: true, Limit: 2}).
+			Return(estates, nil)
+		mockRepo.EXPECT().
+			QueryEstates(gomock.Any(), repository.EstateQuery{
+				MinWidth:      5,
+				WithTreeCount: true,
+				After:         &repository.Estate{ID: estates[0].ID, CreatedAt: createdAt},
+				Limit:         2,
+			}).
+			Return(estates[1:], nil)
+
+		svc := NewService(mockRepo)
+		opts := EstateListOptions{MinWidth: 5, WithTreeCount: true, Limit: 1}
+
+		page, err := svc.ListEstates(context.Background(), opts)
+		assert.NoError(t, err)
+		assert.Equal(t, estates[:1], page.Estates)
+		assert.NotEmpty(t, page.NextCursor)
+
+		opts.Cursor = page.NextCursor
+		page, err = svc.ListEstates(context.Background(), opts)
+		assert.NoError(t, err)
+		assert.Equal(t, estates[1:], page.Estates)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("Lists Every Estate Without Limit Or Cursor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockRepository(ctrl)
+		mockRepo.EXPECT().
+			QueryEstates(gomock.Any(), repository.EstateQuery{SortByArea: true}).
+			Return(estates, nil)
+
+		page, err := NewService(mockRepo).ListEstates(context.Background(), EstateListOptions{Sort: "area"})
+		assert.NoError(t, err)
+		assert.Equal(t, estates, page.Estates)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("Pages Through Estates By Area", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockRepository(ctrl)
+		mockRepo.EXPECT().
+			QueryEstates(gomock.Any(), repository.EstateQuery{SortByArea: true, Descending: true, Limit: 2}).
+			Return(estates, nil)
+		mockRepo.EXPECT().
+			QueryEstates(gomock.Any(), repository.EstateQuery{
+				SortByArea: true,
+				Descending: true,
+				After:      &repository.Estate{ID: estates[0].ID, Width: 10, Length: 10},
+				Limit:      2,
+			}).
+			Return(nil, nil)
+
+		svc := NewService(mockRepo)
+		opts := EstateListOptions{Sort: "area", Descending: true, Limit: 1}
+
+		page, err := svc.ListEstates(context.Background(), opts)
+		assert.NoError(t, err)
+		opts.Cursor = page.NextCursor
+		page, err = svc.ListEstates(context.Background(), opts)
+		assert.NoError(t, err)
+		assert.Empty(t, page.Estates)
+	})
+
+	testCases := []struct {
+		name        string
+		opts        EstateListOptions
+		expectedErr string
+	}{
+		{
+			name:        "Invalid Sort",
+			opts:        EstateListOptions{Sort: "name"},
+			expectedErr: "invalid sort",
+		},
+		{
+			name:        "Invalid Limit",
+			opts:        EstateListOptions{Limit: -1},
+			expectedErr: "invalid limit",
+		},
+		{
+			name:        "Invalid Size Range",
+			opts:        EstateListOptions{MinArea: 100, MaxArea: 10},
+			expectedErr: "invalid size range",
+		},
+		{
+			name:        "Cursor Of Another Order",
+			opts:        EstateListOptions{Cursor: encodeCursor(estateCursor{Sort: "created_at", Descending: true})},
+			expectedErr: "invalid cursor",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			_, err := NewService(mocks.NewMockRepository(ctrl)).ListEstates(context.Background(), tc.opts)
+			assert.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
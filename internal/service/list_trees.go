@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"drone/internal/repository"
+)
+
+const (
+	// defaultPageSize is the number of items on a page when no limit is given
+	defaultPageSize = 100
+	// maxPageSize is the largest number of items on a page
+	maxPageSize = 1000
+)
+
+// treeCursor is the position of the last tree of a page in the listing order
+type treeCursor struct {
+	Sort       string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	X          int    `json:"x"`
+	Y          int    `json:"y"`
+	Height     int    `json:"h,omitempty"`
+}
+
+// ListTrees implements the TreeService.ListTrees method
+func (s *service) ListTrees(ctx context.Context, estateID uuid.UUID, opts TreeListOptions) (*TreePage, error) {
+	query := repository.TreeQuery{
+		Descending: opts.Descending,
+		MinHeight:  opts.MinHeight,
+		MaxHeight:  opts.MaxHeight,
+		XMin:       opts.XMin,
+		XMax:       opts.XMax,
+		YMin:       opts.YMin,
+		YMax:       opts.YMax,
+		Filter:     opts.Filter,
+		Limit:      opts.Limit,
+	}
+
+	switch opts.Sort {
+	case "", "position":
+		opts.Sort = "position"
+	case "height":
+		query.SortByHeight = true
+	default:
+		return nil, NewValidationError("invalid sort", "sort")
+	}
+
+	if query.Limit == 0 {
+		query.Limit = defaultPageSize
+	}
+	if query.Limit < 0 || query.Limit > maxPageSize {
+		return nil, NewValidationError("invalid limit", "limit")
+	}
+	if query.MinHeight < 0 || query.MaxHeight < 0 || (query.MaxHeight > 0 && query.MinHeight > query.MaxHeight) {
+		return nil, NewValidationError("invalid height range", "height_min", "height_max")
+	}
+	if query.XMin < 0 || query.XMax < 0 || query.YMin < 0 || query.YMax < 0 ||
+		(query.XMax > 0 && query.XMin > query.XMax) || (query.YMax > 0 && query.YMin > query.YMax) {
+		return nil, NewValidationError("invalid bounding box", "x_min", "x_max", "y_min", "y_max")
+	}
+	if err := validateTreeFilter(query.Filter); err != nil {
+		return nil, err
+	}
+
+	if opts.Cursor != "" {
+		var cursor treeCursor
+		if err := decodeCursor(opts.Cursor, &cursor); err != nil {
+			return nil, err
+		}
+		// A cursor only makes sense in the order it was made for
+		if cursor.Sort != opts.Sort || cursor.Descending != opts.Descending {
+			return nil, NewValidationError("invalid cursor", "cursor")
+		}
+		query.After = &repository.Tree{X: cursor.X, Y: cursor.Y, Height: cursor.Height}
+	}
+
+	// Check if estate exists
+	if _, _, err := s.repo.GetEstate(ctx, estateID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEstateNotFound
+		}
+		return nil, err
+	}
+
+	// Fetch one more tree than asked for to know whether there is a next page
+	query.Limit++
+	trees, err := s.repo.ListTrees(ctx, estateID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &TreePage{Trees: trees}
+	if len(trees) == query.Limit {
+		page.Trees = trees[:len(trees)-1]
+		last := page.Trees[len(page.Trees)-1]
+		cursor := treeCursor{Sort: opts.Sort, Descending: opts.Descending, X: last.X, Y: last.Y}
+		if query.SortByHeight {
+			cursor.Height = last.Height
+		}
+		page.NextCursor = encodeCursor(cursor)
+	}
+
+	return page, nil
+}
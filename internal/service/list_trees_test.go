@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+	"drone/internal/repository/mocks"
+)
+
+func TestListTrees(t *testing.T) {
+	estateID := uuid.New()
+	trees := []repository.Tree{
+		{X: 1, Y: 1, Height: 30},
+		{X: 1, Y: 2, Height: 20},
+		{X: 2, Y: 1, Height: 10},
+	}
+
+	t.Run("Pages Through Trees", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockRepository(ctrl)
+		mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(5, 5, nil).Times(2)
+		mockRepo.EXPECT().
+			ListTrees(gomock.Any(), estateID, repository.TreeQuery{SortByHeight: true, Descending: true, MinHeight: 5, Limit: 3}).
+			Return(trees, nil)
+		mockRepo.EXPECT().
+			ListTrees(gomock.Any(), estateID, repository.TreeQuery{
+				SortByHeight: true,
+				Descending:   true,
+				MinHeight:    5,
+				After:        &repository.Tree{X: 1, Y: 2, Height: 20},
+				Limit:        3,
+			}).
+			Return(trees[2:], nil)
+
+		svc := NewService(mockRepo)
+		opts := TreeListOptions{Sort: "height", Descending: true, MinHeight: 5, Limit: 2}
+
+		page, err := svc.ListTrees(context.Background(), estateID, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, trees[:2], page.Trees)
+		assert.NotEmpty(t, page.NextCursor)
+
+		opts.Cursor = page.NextCursor
+		page, err = svc.ListTrees(context.Background(), estateID, opts)
+		assert.NoError(t, err)
+		assert.Equal(t, trees[2:], page.Trees)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	testCases := []struct {
+		name        string
+		opts        TreeListOptions
+		mockSetup   func(*mocks.MockRepository)
+		expectedErr string
+	}{
+		{
+			name: "Default Page",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(5, 5, nil)
+				mockRepo.EXPECT().ListTrees(gomock.Any(), estateID, repository.TreeQuery{Limit: 101}).Return(nil, nil)
+			},
+		},
+		{
+			name:        "Invalid Sort",
+			opts:        TreeListOptions{Sort: "age"},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid sort",
+		},
+		{
+			name:        "Limit Too Large",
+			opts:        TreeListOptions{Limit: 1001},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid limit",
+		},
+		{
+			name:        "Invalid Height Range",
+			opts:        TreeListOptions{MinHeight: 20, MaxHeight: 10},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid height range",
+		},
+		{
+			name:        "Invalid Bounding Box",
+			opts:        TreeListOptions{YMin: 5, YMax: 4},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid bounding box",
+		},
+		{
+			name: "Filtered By Attributes",
+			opts: TreeListOptions{Filter: repository.TreeFilter{Species: "Oil palm", Health: "dead", Tags: []string{"north"}}},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(5, 5, nil)
+				mockRepo.EXPECT().ListTrees(gomock.Any(), estateID, repository.TreeQuery{
+					Filter: repository.TreeFilter{Species: "Oil palm", Health: "dead", Tags: []string{"north"}},
+					Limit:  101,
+				}).Return(nil, nil)
+			},
+		},
+		{
+			name:        "Invalid Health",
+			opts:        TreeListOptions{Filter: repository.TreeFilter{Health: "sick"}},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid health status",
+		},
+		{
+			name: "Invalid Planting Date Range",
+			opts: TreeListOptions{Filter: repository.TreeFilter{
+				PlantedFrom: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+				PlantedTo:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			}},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid planting date range",
+		},
+		{
+			name:        "Malformed Cursor",
+			opts:        TreeListOptions{Cursor: "not a cursor"},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid cursor",
+		},
+		{
+			name:        "Cursor Of Another Sort",
+			opts:        TreeListOptions{Sort: "height", Cursor: encodeCursor(treeCursor{Sort: "position", X: 1, Y: 1})},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid cursor",
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(0, 0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			_, err := NewService(mockRepo).ListTrees(context.Background(), estateID, tc.opts)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
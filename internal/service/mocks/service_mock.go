@@ -5,11 +5,13 @@ package mocks
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
 	repository "drone/internal/repository"
+	service "drone/internal/service"
 )
 
 // MockService is a mock of Service interface.
@@ -66,52 +68,34 @@ func (mr *MockServiceMockRecorder) GetEstate(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEstate", reflect.TypeOf((*MockService)(nil).GetEstate), ctx, id)
 }
 
-// ListEstates mocks base method.
-func (m *MockService) ListEstates(ctx context.Context) ([]repository.Estate, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListEstates", ctx)
-	ret0, _ := ret[0].([]repository.Estate)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// ListEstates indicates an expected call of ListEstates.
-func (mr *MockServiceMockRecorder) ListEstates(ctx interface{}) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEstates", reflect.TypeOf((*MockService)(nil).ListEstates), ctx)
-}
-
 // CreateTree mocks base method.
-func (m *MockService) CreateTree(ctx context.Context, estateID uuid.UUID, x, y, height int) (uuid.UUID, error) {
+func (m *MockService) CreateTree(ctx context.Context, estateID uuid.UUID, input service.TreeInput) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTree", ctx, estateID, x, y, height)
+	ret := m.ctrl.Call(m, "CreateTree", ctx, estateID, input)
 	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateTree indicates an expected call of CreateTree.
-func (mr *MockServiceMockRecorder) CreateTree(ctx, estateID, x, y, height interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) CreateTree(ctx, estateID, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTree", reflect.TypeOf((*MockService)(nil).CreateTree), ctx, estateID, x, y, height)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTree", reflect.TypeOf((*MockService)(nil).CreateTree), ctx, estateID, input)
 }
 
 // GetTreeStats mocks base method.
-func (m *MockService) GetTreeStats(ctx context.Context, estateID uuid.UUID) (int, int, int, int, error) {
+func (m *MockService) GetTreeStats(ctx context.Context, estateID uuid.UUID, opts service.TreeStatsOptions) (*service.TreeStats, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTreeStats", ctx, estateID)
-	ret0, _ := ret[0].(int)
-	ret1, _ := ret[1].(int)
-	ret2, _ := ret[2].(int)
-	ret3, _ := ret[3].(int)
-	ret4, _ := ret[4].(error)
-	return ret0, ret1, ret2, ret3, ret4
+	ret := m.ctrl.Call(m, "GetTreeStats", ctx, estateID, opts)
+	ret0, _ := ret[0].(*service.TreeStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // GetTreeStats indicates an expected call of GetTreeStats.
-func (mr *MockServiceMockRecorder) GetTreeStats(ctx, estateID interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) GetTreeStats(ctx, estateID, opts interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTreeStats", reflect.TypeOf((*MockService)(nil).GetTreeStats), ctx, estateID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTreeStats", reflect.TypeOf((*MockService)(nil).GetTreeStats), ctx, estateID, opts)
 }
 
 // CalculateDronePath mocks base method.
@@ -144,4 +128,385 @@ func (m *MockService) CalculateDronePathWithRest(ctx context.Context, estateID u
 func (mr *MockServiceMockRecorder) CalculateDronePathWithRest(ctx, estateID, maxDistance interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateDronePathWithRest", reflect.TypeOf((*MockService)(nil).CalculateDronePathWithRest), ctx, estateID, maxDistance)
-} 
\ No newline at end of file
+} 
+
+// PlanDronePath mocks base method.
+func (m *MockService) PlanDronePath(ctx context.Context, estateID uuid.UUID, opts service.DronePlanOptions) (*service.DronePlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PlanDronePath", ctx, estateID, opts)
+	ret0, _ := ret[0].(*service.DronePlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PlanDronePath indicates an expected call of PlanDronePath.
+func (mr *MockServiceMockRecorder) PlanDronePath(ctx, estateID, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PlanDronePath", reflect.TypeOf((*MockService)(nil).PlanDronePath), ctx, estateID, opts)
+}
+
+// SubmitDronePlanJob mocks base method.
+func (m *MockService) SubmitDronePlanJob(ctx context.Context, estateID uuid.UUID, opts service.DronePlanOptions) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubmitDronePlanJob", ctx, estateID, opts)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubmitDronePlanJob indicates an expected call of SubmitDronePlanJob.
+func (mr *MockServiceMockRecorder) SubmitDronePlanJob(ctx, estateID, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubmitDronePlanJob", reflect.TypeOf((*MockService)(nil).SubmitDronePlanJob), ctx, estateID, opts)
+}
+
+// GetJob mocks base method.
+func (m *MockService) GetJob(ctx context.Context, id uuid.UUID) (*service.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", ctx, id)
+	ret0, _ := ret[0].(*service.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockServiceMockRecorder) GetJob(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockService)(nil).GetJob), ctx, id)
+}
+
+// CancelJob mocks base method.
+func (m *MockService) CancelJob(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelJob", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelJob indicates an expected call of CancelJob.
+func (mr *MockServiceMockRecorder) CancelJob(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelJob", reflect.TypeOf((*MockService)(nil).CancelJob), ctx, id)
+}
+
+// RunJobWorkers mocks base method.
+func (m *MockService) RunJobWorkers(ctx context.Context, workers int)  {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RunJobWorkers", ctx, workers)
+}
+
+// RunJobWorkers indicates an expected call of RunJobWorkers.
+func (mr *MockServiceMockRecorder) RunJobWorkers(ctx, workers interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunJobWorkers", reflect.TypeOf((*MockService)(nil).RunJobWorkers), ctx, workers)
+}
+
+// GetTree mocks base method.
+func (m *MockService) GetTree(ctx context.Context, estateID, treeID uuid.UUID) (repository.Tree, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTree", ctx, estateID, treeID)
+	ret0, _ := ret[0].(repository.Tree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTree indicates an expected call of GetTree.
+func (mr *MockServiceMockRecorder) GetTree(ctx, estateID, treeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTree", reflect.TypeOf((*MockService)(nil).GetTree), ctx, estateID, treeID)
+}
+
+// UpdateTree mocks base method.
+func (m *MockService) UpdateTree(ctx context.Context, estateID, treeID uuid.UUID, update service.TreeUpdate) (repository.Tree, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTree", ctx, estateID, treeID, update)
+	ret0, _ := ret[0].(repository.Tree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTree indicates an expected call of UpdateTree.
+func (mr *MockServiceMockRecorder) UpdateTree(ctx, estateID, treeID, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTree", reflect.TypeOf((*MockService)(nil).UpdateTree), ctx, estateID, treeID, update)
+}
+
+// DeleteTree mocks base method.
+func (m *MockService) DeleteTree(ctx context.Context, estateID, treeID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTree", ctx, estateID, treeID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTree indicates an expected call of DeleteTree.
+func (mr *MockServiceMockRecorder) DeleteTree(ctx, estateID, treeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTree", reflect.TypeOf((*MockService)(nil).DeleteTree), ctx, estateID, treeID)
+}
+
+// GetEstateDetail mocks base method.
+func (m *MockService) GetEstateDetail(ctx context.Context, id uuid.UUID) (repository.Estate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEstateDetail", ctx, id)
+	ret0, _ := ret[0].(repository.Estate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEstateDetail indicates an expected call of GetEstateDetail.
+func (mr *MockServiceMockRecorder) GetEstateDetail(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEstateDetail", reflect.TypeOf((*MockService)(nil).GetEstateDetail), ctx, id)
+}
+
+// ResizeEstate mocks base method.
+func (m *MockService) ResizeEstate(ctx context.Context, id uuid.UUID, width, length int, prune bool) (repository.Estate, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResizeEstate", ctx, id, width, length, prune)
+	ret0, _ := ret[0].(repository.Estate)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ResizeEstate indicates an expected call of ResizeEstate.
+func (mr *MockServiceMockRecorder) ResizeEstate(ctx, id, width, length, prune interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResizeEstate", reflect.TypeOf((*MockService)(nil).ResizeEstate), ctx, id, width, length, prune)
+}
+
+// DeleteEstate mocks base method.
+func (m *MockService) DeleteEstate(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEstate", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEstate indicates an expected call of DeleteEstate.
+func (mr *MockServiceMockRecorder) DeleteEstate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEstate", reflect.TypeOf((*MockService)(nil).DeleteEstate), ctx, id)
+}
+
+// ImportTrees mocks base method.
+func (m *MockService) ImportTrees(ctx context.Context, estateID uuid.UUID, format string, r io.Reader, bestEffort bool) (*service.TreeImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportTrees", ctx, estateID, format, r, bestEffort)
+	ret0, _ := ret[0].(*service.TreeImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportTrees indicates an expected call of ImportTrees.
+func (mr *MockServiceMockRecorder) ImportTrees(ctx, estateID, format, r, bestEffort interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportTrees", reflect.TypeOf((*MockService)(nil).ImportTrees), ctx, estateID, format, r, bestEffort)
+}
+
+// ExportEstate mocks base method.
+func (m *MockService) ExportEstate(ctx context.Context, id uuid.UUID, format string, geo service.GeoReference, attributes bool, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportEstate", ctx, id, format, geo, attributes, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportEstate indicates an expected call of ExportEstate.
+func (mr *MockServiceMockRecorder) ExportEstate(ctx, id, format, geo, attributes, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportEstate", reflect.TypeOf((*MockService)(nil).ExportEstate), ctx, id, format, geo, attributes, w)
+}
+
+// ListTrees mocks base method.
+func (m *MockService) ListTrees(ctx context.Context, estateID uuid.UUID, opts service.TreeListOptions) (*service.TreePage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTrees", ctx, estateID, opts)
+	ret0, _ := ret[0].(*service.TreePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTrees indicates an expected call of ListTrees.
+func (mr *MockServiceMockRecorder) ListTrees(ctx, estateID, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrees", reflect.TypeOf((*MockService)(nil).ListTrees), ctx, estateID, opts)
+}
+
+// ListEstates mocks base method.
+func (m *MockService) ListEstates(ctx context.Context, opts service.EstateListOptions) (*service.EstatePage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEstates", ctx, opts)
+	ret0, _ := ret[0].(*service.EstatePage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEstates indicates an expected call of ListEstates.
+func (mr *MockServiceMockRecorder) ListEstates(ctx, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEstates", reflect.TypeOf((*MockService)(nil).ListEstates), ctx, opts)
+}
+
+// RenderHeatmap mocks base method.
+func (m *MockService) RenderHeatmap(ctx context.Context, id uuid.UUID, format string, opts service.HeatmapOptions, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenderHeatmap", ctx, id, format, opts, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenderHeatmap indicates an expected call of RenderHeatmap.
+func (mr *MockServiceMockRecorder) RenderHeatmap(ctx, id, format, opts, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenderHeatmap", reflect.TypeOf((*MockService)(nil).RenderHeatmap), ctx, id, format, opts, w)
+}
+
+// CreateZone mocks base method.
+func (m *MockService) CreateZone(ctx context.Context, estateID uuid.UUID, input service.ZoneInput) (repository.Zone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateZone", ctx, estateID, input)
+	ret0, _ := ret[0].(repository.Zone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateZone indicates an expected call of CreateZone.
+func (mr *MockServiceMockRecorder) CreateZone(ctx, estateID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateZone", reflect.TypeOf((*MockService)(nil).CreateZone), ctx, estateID, input)
+}
+
+// GetZone mocks base method.
+func (m *MockService) GetZone(ctx context.Context, estateID, zoneID uuid.UUID) (repository.Zone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetZone", ctx, estateID, zoneID)
+	ret0, _ := ret[0].(repository.Zone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetZone indicates an expected call of GetZone.
+func (mr *MockServiceMockRecorder) GetZone(ctx, estateID, zoneID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetZone", reflect.TypeOf((*MockService)(nil).GetZone), ctx, estateID, zoneID)
+}
+
+// ListZones mocks base method.
+func (m *MockService) ListZones(ctx context.Context, estateID uuid.UUID) ([]repository.Zone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListZones", ctx, estateID)
+	ret0, _ := ret[0].([]repository.Zone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListZones indicates an expected call of ListZones.
+func (mr *MockServiceMockRecorder) ListZones(ctx, estateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListZones", reflect.TypeOf((*MockService)(nil).ListZones), ctx, estateID)
+}
+
+// UpdateZone mocks base method.
+func (m *MockService) UpdateZone(ctx context.Context, estateID, zoneID uuid.UUID, update service.ZoneUpdate) (repository.Zone, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateZone", ctx, estateID, zoneID, update)
+	ret0, _ := ret[0].(repository.Zone)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateZone indicates an expected call of UpdateZone.
+func (mr *MockServiceMockRecorder) UpdateZone(ctx, estateID, zoneID, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateZone", reflect.TypeOf((*MockService)(nil).UpdateZone), ctx, estateID, zoneID, update)
+}
+
+// DeleteZone mocks base method.
+func (m *MockService) DeleteZone(ctx context.Context, estateID, zoneID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteZone", ctx, estateID, zoneID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteZone indicates an expected call of DeleteZone.
+func (mr *MockServiceMockRecorder) DeleteZone(ctx, estateID, zoneID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteZone", reflect.TypeOf((*MockService)(nil).DeleteZone), ctx, estateID, zoneID)
+}
+
+// CreateObstacle mocks base method.
+func (m *MockService) CreateObstacle(ctx context.Context, estateID uuid.UUID, input service.ObstacleInput) (repository.Obstacle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateObstacle", ctx, estateID, input)
+	ret0, _ := ret[0].(repository.Obstacle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateObstacle indicates an expected call of CreateObstacle.
+func (mr *MockServiceMockRecorder) CreateObstacle(ctx, estateID, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateObstacle", reflect.TypeOf((*MockService)(nil).CreateObstacle), ctx, estateID, input)
+}
+
+// ListObstacles mocks base method.
+func (m *MockService) ListObstacles(ctx context.Context, estateID uuid.UUID) ([]repository.Obstacle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListObstacles", ctx, estateID)
+	ret0, _ := ret[0].([]repository.Obstacle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListObstacles indicates an expected call of ListObstacles.
+func (mr *MockServiceMockRecorder) ListObstacles(ctx, estateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListObstacles", reflect.TypeOf((*MockService)(nil).ListObstacles), ctx, estateID)
+}
+
+// DeleteObstacle mocks base method.
+func (m *MockService) DeleteObstacle(ctx context.Context, estateID, obstacleID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteObstacle", ctx, estateID, obstacleID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteObstacle indicates an expected call of DeleteObstacle.
+func (mr *MockServiceMockRecorder) DeleteObstacle(ctx, estateID, obstacleID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteObstacle", reflect.TypeOf((*MockService)(nil).DeleteObstacle), ctx, estateID, obstacleID)
+}
+
+// GetTreeHistory mocks base method.
+func (m *MockService) GetTreeHistory(ctx context.Context, estateID, treeID uuid.UUID) ([]repository.TreeMeasurement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTreeHistory", ctx, estateID, treeID)
+	ret0, _ := ret[0].([]repository.TreeMeasurement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTreeHistory indicates an expected call of GetTreeHistory.
+func (mr *MockServiceMockRecorder) GetTreeHistory(ctx, estateID, treeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTreeHistory", reflect.TypeOf((*MockService)(nil).GetTreeHistory), ctx, estateID, treeID)
+}
+
+// GetGrowthReport mocks base method.
+func (m *MockService) GetGrowthReport(ctx context.Context, estateID uuid.UUID, opts service.GrowthReportOptions) (*service.GrowthReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGrowthReport", ctx, estateID, opts)
+	ret0, _ := ret[0].(*service.GrowthReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGrowthReport indicates an expected call of GetGrowthReport.
+func (mr *MockServiceMockRecorder) GetGrowthReport(ctx, estateID, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGrowthReport", reflect.TypeOf((*MockService)(nil).GetGrowthReport), ctx, estateID, opts)
+}
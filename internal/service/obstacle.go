@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"drone/internal/repository"
+)
+
+// maxObstacles is the largest number of obstacles on an estate. Every detour
+// around impassable obstacles searches a grid that grows with the square of
+// their number.
+const maxObstacles = 100
+
+// CreateObstacle implements the ObstacleService.CreateObstacle method
+func (s *service) CreateObstacle(ctx context.Context, estateID uuid.UUID, input ObstacleInput) (repository.Obstacle, error) {
+	if input.Impassable == (input.MinAltitude != 0) || input.MinAltitude < 0 {
+		return repository.Obstacle{}, NewValidationError("invalid obstacle altitude", "min_altitude", "impassable")
+	}
+
+	rect := input.Rect
+	obstacle := repository.Obstacle{
+		EstateID:    estateID,
+		XMin:        rect.XMin,
+		XMax:        rect.XMax,
+		YMin:        rect.YMin,
+		YMax:        rect.YMax,
+		MinAltitude: input.MinAltitude,
+		Impassable:  input.Impassable,
+	}
+
+	// The estate is locked until the obstacle is stored, so that it cannot be
+	// shrunk once the bounds have been checked against it
+	err := s.repo.WithTx(ctx, func(repo repository.Repository) error {
+		width, length, err := repo.LockEstate(ctx, estateID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrEstateNotFound
+			}
+			return err
+		}
+
+		if rect.XMin < 1 || rect.XMin > rect.XMax || rect.XMax > width ||
+			rect.YMin < 1 || rect.YMin > rect.YMax || rect.YMax > length {
+			return NewValidationError("invalid obstacle bounds", "cell", "rect")
+		}
+
+		obstacles, err := repo.ListObstacles(ctx, estateID)
+		if err != nil {
+			return err
+		}
+		if len(obstacles) >= maxObstacles {
+			return ErrTooManyObstacles
+		}
+
+		obstacle.ID, err = repo.CreateObstacle(ctx, obstacle)
+		return err
+	})
+	if err != nil {
+		return repository.Obstacle{}, err
+	}
+
+	return obstacle, nil
+}
+
+// ListObstacles implements the ObstacleService.ListObstacles method
+func (s *service) ListObstacles(ctx context.Context, estateID uuid.UUID) ([]repository.Obstacle, error) {
+	// Check if estate exists
+	if _, _, err := s.repo.GetEstate(ctx, estateID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEstateNotFound
+		}
+		return nil, err
+	}
+
+	return s.repo.ListObstacles(ctx, estateID)
+}
+
+// DeleteObstacle implements the ObstacleService.DeleteObstacle method
+func (s *service) DeleteObstacle(ctx context.Context, estateID, obstacleID uuid.UUID) error {
+	// Check if estate exists
+	if _, _, err := s.repo.GetEstate(ctx, estateID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrEstateNotFound
+		}
+		return err
+	}
+
+	if err := s.repo.DeleteObstacle(ctx, estateID, obstacleID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrObstacleNotFound
+		}
+		return err
+	}
+
+	return nil
+}
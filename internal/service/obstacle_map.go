@@ -0,0 +1,457 @@
+package service
+
+import (
+	"container/heap"
+	"sort"
+
+	"drone/internal/repository"
+)
+
+const (
+	// impassableCell marks a cell of an obstacle map the drone cannot fly over
+	impassableCell = -1
+	// unexploredCell marks a passable cell not yet assigned to an area
+	unexploredCell = -2
+)
+
+// obstacleMap tracks the impassable plots of an estate. The estate is cut
+// along the edges of the impassable obstacles into a grid of cells, each a
+// rectangle of plots that are either all impassable or all passable, and the
+// passable cells are grouped into areas the drone can fly within without
+// crossing an impassable plot.
+type obstacleMap struct {
+	width, length int
+	// blocked holds the impassable obstacles, clipped to the estate
+	blocked []repository.Obstacle
+	// xs and ys hold the first plot of each column and row of cells, followed
+	// by width+1 and length+1
+	xs, ys []int
+	// areas holds the area of each cell by row then column, or impassableCell
+	areas []int
+	// bands caches the ways across the gaps of the sweeps, see bandRoute
+	bands map[bandKey]*bandRoutes
+}
+
+// segment is a stretch of plots along a sweep lying in the same cell of an
+// obstacle map, from and to being indexes along the sweep
+type segment struct {
+	from, to int
+	area     int
+}
+
+// newObstacleMap creates an obstacle map of an estate, returning nil if no
+// impassable obstacle lies on the estate
+func newObstacleMap(width, length int, obstacles []repository.Obstacle) *obstacleMap {
+	m := &obstacleMap{width: width, length: length}
+	xs, ys := []int{1, width + 1}, []int{1, length + 1}
+	for _, obstacle := range obstacles {
+		// Only the part of an obstacle that lies on the estate is mapped
+		if !obstacle.Impassable || obstacle.XMin > width || obstacle.YMin > length {
+			continue
+		}
+		obstacle.XMax, obstacle.YMax = min(obstacle.XMax, width), min(obstacle.YMax, length)
+		m.blocked = append(m.blocked, obstacle)
+		xs = append(xs, obstacle.XMin, obstacle.XMax+1)
+		ys = append(ys, obstacle.YMin, obstacle.YMax+1)
+	}
+	if len(m.blocked) == 0 {
+		return nil
+	}
+	m.xs, m.ys = uniqueSorted(xs), uniqueSorted(ys)
+
+	columns, rows := len(m.xs)-1, len(m.ys)-1
+	m.areas = make([]int, columns*rows)
+	for i := range m.areas {
+		m.areas[i] = unexploredCell
+	}
+	for _, obstacle := range m.blocked {
+		first, last := sort.SearchInts(m.xs, obstacle.XMin), sort.SearchInts(m.xs, obstacle.XMax+1)
+		for row := sort.SearchInts(m.ys, obstacle.YMin); m.ys[row] <= obstacle.YMax; row++ {
+			for column := first; column < last; column++ {
+				m.areas[row*columns+column] = impassableCell
+			}
+		}
+	}
+
+	// Flood fill the passable cells sharing an edge into areas
+	area := 0
+	for i := range m.areas {
+		if m.areas[i] != unexploredCell {
+			continue
+		}
+		m.areas[i] = area
+		stack := []int{i}
+		for len(stack) > 0 {
+			cell := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			column, row := cell%columns, cell/columns
+			for _, next := range [4][2]int{{column - 1, row}, {column + 1, row}, {column, row - 1}, {column, row + 1}} {
+				if next[0] < 0 || next[0] >= columns || next[1] < 0 || next[1] >= rows {
+					continue
+				}
+				if n := next[1]*columns + next[0]; m.areas[n] == unexploredCell {
+					m.areas[n] = area
+					stack = append(stack, n)
+				}
+			}
+		}
+		area++
+	}
+
+	return m
+}
+
+// areaAt returns the area of a plot, or impassableCell
+func (m *obstacleMap) areaAt(x, y int) int {
+	column, row := m.cell(x, y)
+	return m.areas[row*(len(m.xs)-1)+column]
+}
+
+// cell returns the column and row of the cell a plot lies in
+func (m *obstacleMap) cell(x, y int) (column, row int) {
+	return sort.SearchInts(m.xs, x+1) - 1, sort.SearchInts(m.ys, y+1) - 1
+}
+
+// clear reports whether no impassable obstacle covers any plot of the
+// straight line between two plots on the same row or column
+func (m *obstacleMap) clear(x1, y1, x2, y2 int) bool {
+	columns := len(m.xs) - 1
+	columnMin, rowMin := m.cell(min(x1, x2), min(y1, y2))
+	columnMax, rowMax := m.cell(max(x1, x2), max(y1, y2))
+	for row := rowMin; row <= rowMax; row++ {
+		for column := columnMin; column <= columnMax; column++ {
+			if m.areas[row*columns+column] == impassableCell {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// segments splits a sweep into the stretches of plots lying in the same cell,
+// in flight order
+func (m *obstacleMap) segments(s Sweep) []segment {
+	dx, dy := sign(s.ToX-s.FromX), sign(s.ToY-s.FromY)
+	n := abs(s.ToX-s.FromX) + abs(s.ToY-s.FromY) + 1
+
+	var segments []segment
+	for i := 0; i < n; {
+		x, y := s.FromX+i*dx, s.FromY+i*dy
+		column, row := m.cell(x, y)
+
+		// Count the plots of the sweep left in this cell
+		left := 1
+		switch {
+		case dx > 0:
+			left = m.xs[column+1] - x
+		case dx < 0:
+			left = x - m.xs[column] + 1
+		case dy > 0:
+			left = m.ys[row+1] - y
+		case dy < 0:
+			left = y - m.ys[row] + 1
+		}
+
+		count := min(left, n-i)
+		segments = append(segments, segment{from: i, to: i + count - 1, area: m.areas[row*(len(m.xs)-1)+column]})
+		i += count
+	}
+	return segments
+}
+
+// start returns the first passable plot of a patrol following the strategy
+// and the area it lies in, or false if every plot is impassable
+func (m *obstacleMap) start(strategy PathStrategy) (x, y, area int, ok bool) {
+	strategy.Sweeps(m.width, m.length, func(s Sweep) bool {
+		for _, seg := range m.segments(s) {
+			if seg.area != impassableCell {
+				dx, dy := sign(s.ToX-s.FromX), sign(s.ToY-s.FromY)
+				x, y, area, ok = s.FromX+seg.from*dx, s.FromY+seg.from*dy, seg.area, true
+				return false
+			}
+		}
+		return true
+	})
+	return x, y, area, ok
+}
+
+// unreachable returns the passable plots outside the given area, as
+// rectangles along each row of cells, and the number of those plots
+func (m *obstacleMap) unreachable(area int) ([]PlotRect, int) {
+	var (
+		rects []PlotRect
+		plots int
+	)
+	columns := len(m.xs) - 1
+	for row := 0; row+1 < len(m.ys); row++ {
+		for column := 0; column < columns; column++ {
+			if a := m.areas[row*columns+column]; a == impassableCell || a == area {
+				continue
+			}
+
+			// Merge the neighbouring unreachable cells of the row
+			last := column
+			for last+1 < columns {
+				if a := m.areas[row*columns+last+1]; a == impassableCell || a == area {
+					break
+				}
+				last++
+			}
+
+			rect := PlotRect{XMin: m.xs[column], XMax: m.xs[last+1] - 1, YMin: m.ys[row], YMax: m.ys[row+1] - 1}
+			rects = append(rects, rect)
+			plots += (rect.XMax - rect.XMin + 1) * (rect.YMax - rect.YMin + 1)
+			column = last
+		}
+	}
+	return rects, plots
+}
+
+// route finds the shortest way between two plots of the same area that does
+// not cross an impassable plot. It returns nil if the drone can fly straight
+// there, otherwise the plots the drone turns at ending with the destination,
+// and the horizontal distance of the way.
+//
+// Shortest ways around rectangles only ever need to turn on the rows and
+// columns next to the edges of an obstacle or of the two plots themselves, so
+// the search runs over the crossings of those lines rather than every plot.
+func (m *obstacleMap) route(fromX, fromY, toX, toY int) ([]Plot, int) {
+	if (fromX == toX || fromY == toY) && m.clear(fromX, fromY, toX, toY) {
+		return nil, 0
+	}
+
+	xs, ys := []int{fromX, toX}, []int{fromY, toY}
+	for _, obstacle := range m.blocked {
+		xs = append(xs, obstacle.XMin-1, obstacle.XMax+1)
+		ys = append(ys, obstacle.YMin-1, obstacle.YMax+1)
+	}
+	xs, ys = clipped(uniqueSorted(xs), m.width), clipped(uniqueSorted(ys), m.length)
+
+	columns := len(xs)
+	node := func(x, y int) int {
+		return sort.SearchInts(ys, y)*columns + sort.SearchInts(xs, x)
+	}
+	start, goal := node(fromX, fromY), node(toX, toY)
+
+	// A* search with the straight distance to the destination as heuristic
+	distances := make([]int, len(xs)*len(ys))
+	previous := make([]int, len(distances))
+	for i := range distances {
+		distances[i] = -1
+	}
+	distances[start] = 0
+	queue := &routeQueue{{node: start, estimate: abs(toX-fromX) + abs(toY-fromY)}}
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(routeStep)
+		if current.node == goal {
+			break
+		}
+		if current.distance > distances[current.node] {
+			continue
+		}
+
+		column, row := current.node%columns, current.node/columns
+		x, y := xs[column], ys[row]
+		for _, next := range [4][2]int{{column - 1, row}, {column + 1, row}, {column, row - 1}, {column, row + 1}} {
+			if next[0] < 0 || next[0] >= columns || next[1] < 0 || next[1] >= len(ys) {
+				continue
+			}
+			nx, ny := xs[next[0]], ys[next[1]]
+			if !m.clear(x, y, nx, ny) {
+				continue
+			}
+
+			n := next[1]*columns + next[0]
+			distance := current.distance + abs(nx-x) + abs(ny-y)
+			if distances[n] >= 0 && distances[n] <= distance {
+				continue
+			}
+			distances[n], previous[n] = distance, current.node
+			heap.Push(queue, routeStep{node: n, distance: distance, estimate: distance + abs(toX-nx) + abs(toY-ny)})
+		}
+	}
+	if distances[goal] < 0 {
+		// Plots of the same area are always connected
+		return nil, 0
+	}
+
+	// Walk back from the destination, keeping only the plots turned at
+	turns := []Plot{{X: toX, Y: toY}}
+	for n := goal; n != start; n = previous[n] {
+		p := previous[n]
+		if p == start {
+			break
+		}
+		before := previous[p]
+		if (n%columns == p%columns) != (p%columns == before%columns) {
+			turns = append(turns, Plot{X: xs[p%columns], Y: ys[p/columns]})
+		}
+	}
+	for i, j := 0, len(turns)-1; i < j; i, j = i+1, j-1 {
+		turns[i], turns[j] = turns[j], turns[i]
+	}
+	return turns, distances[goal]
+}
+
+// bandKey identifies the gaps of the sweeps along a row or column of cells
+// from one plot to another: the sweeps of the band cross the gap at the same
+// positions along them, one row or column apart
+type bandKey struct {
+	from, to int
+	band     int
+	column   bool
+}
+
+// bandRoutes holds the ways across a gap from the first or last line of its
+// band, by index 0 or 1, to the first or last line, found by route
+type bandRoutes struct {
+	turns    [2][2][]Plot
+	distance [2][2]int
+}
+
+// bandRoute finds the shortest way between two plots like route, caching the
+// search for the ways across the same gap from the other sweeps of the band.
+//
+// Obstacles cover the whole band if any of it, so the way across a gap of a
+// sweep leaves the band first. Flying along the sweep's plot column to the
+// first or last line of the band and taking the shortest way from there is as
+// short as any way leaving the band on that side, so the shortest way is one
+// of the four between the first and last lines at either end.
+func (m *obstacleMap) bandRoute(fromX, fromY, toX, toY int) ([]Plot, int) {
+	if (fromX != toX && fromY != toY) || m.clear(fromX, fromY, toX, toY) {
+		return m.route(fromX, fromY, toX, toY)
+	}
+
+	// Work along the sweep: from and to are positions along it, line is the
+	// row or column it follows and first and last bound the band across it
+	column, row := m.cell(fromX, fromY)
+	key := bandKey{from: fromX, to: toX, band: row, column: fromX == toX}
+	line, first, last := fromY, m.ys[row], m.ys[row+1]-1
+	plot := func(along, across int) Plot { return Plot{X: along, Y: across} }
+	if key.column {
+		key = bandKey{from: fromY, to: toY, band: column, column: true}
+		line, first, last = fromX, m.xs[column], m.xs[column+1]-1
+		plot = func(along, across int) Plot { return Plot{X: across, Y: along} }
+	}
+	edges := [2]int{first, last}
+
+	routes, ok := m.bands[key]
+	if !ok {
+		routes = &bandRoutes{}
+		for i, from := range edges {
+			for j, to := range edges {
+				start, end := plot(key.from, from), plot(key.to, to)
+				turns, distance := m.route(start.X, start.Y, end.X, end.Y)
+				if turns == nil {
+					turns, distance = []Plot{end}, abs(key.to-key.from)
+				}
+				routes.turns[i][j], routes.distance[i][j] = turns, distance
+			}
+		}
+		if m.bands == nil {
+			m.bands = make(map[bandKey]*bandRoutes)
+		}
+		m.bands[key] = routes
+	}
+
+	best, bestDistance := [2]int{}, -1
+	for i, from := range edges {
+		for j, to := range edges {
+			distance := abs(line-from) + routes.distance[i][j] + abs(line-to)
+			if bestDistance < 0 || distance < bestDistance {
+				best, bestDistance = [2]int{i, j}, distance
+			}
+		}
+	}
+
+	turns := []Plot{plot(key.from, line), plot(key.from, edges[best[0]])}
+	turns = append(turns, routes.turns[best[0]][best[1]]...)
+	turns = append(turns, plot(key.to, line))
+	return straightened(turns), bestDistance
+}
+
+// straightened drops the first plot of a way, given as the plots it starts
+// from and turns at, along with the plots it passes straight through
+func straightened(plots []Plot) []Plot {
+	var turns []Plot
+	for _, plot := range plots[1:] {
+		n := len(turns)
+		if n > 0 && turns[n-1] == plot || n == 0 && plots[0] == plot {
+			continue
+		}
+		if n > 0 {
+			before := plots[0]
+			if n > 1 {
+				before = turns[n-2]
+			}
+			if between(before, turns[n-1], plot) {
+				turns = turns[:n-1]
+			}
+		}
+		turns = append(turns, plot)
+	}
+	return turns
+}
+
+// between reports whether b lies on the straight line from a to c
+func between(a, b, c Plot) bool {
+	if a.X == b.X && b.X == c.X {
+		return (b.Y-a.Y)*(c.Y-b.Y) >= 0
+	}
+	if a.Y == b.Y && b.Y == c.Y {
+		return (b.X-a.X)*(c.X-b.X) >= 0
+	}
+	return false
+}
+
+// routeStep is a crossing reached by a route search, with the distance flown
+// to it and the estimated distance of the whole route through it
+type routeStep struct {
+	node     int
+	distance int
+	estimate int
+}
+
+// routeQueue orders route steps by their estimated distance, implementing
+// heap.Interface
+type routeQueue []routeStep
+
+func (q routeQueue) Len() int { return len(q) }
+
+func (q routeQueue) Less(i, j int) bool {
+	if q[i].estimate != q[j].estimate {
+		return q[i].estimate < q[j].estimate
+	}
+	// Among equally good steps, prefer the one closest to the destination
+	return q[i].distance > q[j].distance
+}
+
+func (q routeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *routeQueue) Push(x any) { *q = append(*q, x.(routeStep)) }
+
+func (q *routeQueue) Pop() any {
+	old := *q
+	step := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return step
+}
+
+// uniqueSorted sorts values and drops duplicates
+func uniqueSorted(values []int) []int {
+	sort.Ints(values)
+	unique := values[:0]
+	for _, v := range values {
+		if len(unique) == 0 || v != unique[len(unique)-1] {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// clipped drops the sorted values outside 1 to limit
+func clipped(values []int, limit int) []int {
+	from := sort.SearchInts(values, 1)
+	to := sort.SearchInts(values, limit+1)
+	return values[from:to]
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+	"drone/internal/repository/mocks"
+)
+
+func TestCreateObstacle(t *testing.T) {
+	estateID := uuid.New()
+	obstacleID := uuid.New()
+
+	testCases := []struct {
+		name             string
+		input            ObstacleInput
+		mockSetup        func(*mocks.MockRepository)
+		expectedObstacle repository.Obstacle
+		expectedErr      string
+	}{
+		{
+			name:  "Power Line",
+			input: ObstacleInput{Rect: PlotRect{XMin: 1, XMax: 10, YMin: 4, YMax: 4}, MinAltitude: 40},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().ListObstacles(gomock.Any(), estateID).Return(nil, nil)
+				mockRepo.EXPECT().CreateObstacle(gomock.Any(), repository.Obstacle{
+					EstateID:    estateID,
+					XMin:        1,
+					XMax:        10,
+					YMin:        4,
+					YMax:        4,
+					MinAltitude: 40,
+				}).Return(obstacleID, nil)
+			},
+			expectedObstacle: repository.Obstacle{
+				ID:          obstacleID,
+				EstateID:    estateID,
+				XMin:        1,
+				XMax:        10,
+				YMin:        4,
+				YMax:        4,
+				MinAltitude: 40,
+			},
+		},
+		{
+			name:  "Impassable Plot",
+			input: ObstacleInput{Rect: PlotRect{XMin: 3, XMax: 3, YMin: 5, YMax: 5}, Impassable: true},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().ListObstacles(gomock.Any(), estateID).Return(nil, nil)
+				mockRepo.EXPECT().CreateObstacle(gomock.Any(), gomock.Any()).Return(obstacleID, nil)
+			},
+			expectedObstacle: repository.Obstacle{
+				ID:         obstacleID,
+				EstateID:   estateID,
+				XMin:       3,
+				XMax:       3,
+				YMin:       5,
+				YMax:       5,
+				Impassable: true,
+			},
+		},
+		{
+			name:        "Altitude And Impassable",
+			input:       ObstacleInput{Rect: PlotRect{XMin: 1, XMax: 1, YMin: 1, YMax: 1}, MinAltitude: 5, Impassable: true},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid obstacle altitude",
+		},
+		{
+			name:        "Neither Altitude Nor Impassable",
+			input:       ObstacleInput{Rect: PlotRect{XMin: 1, XMax: 1, YMin: 1, YMax: 1}},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid obstacle altitude",
+		},
+		{
+			name:  "Outside Estate",
+			input: ObstacleInput{Rect: PlotRect{XMin: 8, XMax: 11, YMin: 1, YMax: 1}, Impassable: true},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 10, nil)
+			},
+			expectedErr: "invalid obstacle bounds",
+		},
+		{
+			name:  "Inverted Bounds",
+			input: ObstacleInput{Rect: PlotRect{XMin: 5, XMax: 4, YMin: 1, YMax: 1}, Impassable: true},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 10, nil)
+			},
+			expectedErr: "invalid obstacle bounds",
+		},
+		{
+			name:  "Too Many Obstacles",
+			input: ObstacleInput{Rect: PlotRect{XMin: 1, XMax: 1, YMin: 1, YMax: 1}, Impassable: true},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().ListObstacles(gomock.Any(), estateID).Return(make([]repository.Obstacle, maxObstacles), nil)
+			},
+			expectedErr: "too many obstacles",
+		},
+		{
+			name:  "Estate Not Found",
+			input: ObstacleInput{Rect: PlotRect{XMin: 1, XMax: 1, YMin: 1, YMax: 1}, Impassable: true},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(0, 0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			obstacle, err := NewService(mockRepo).CreateObstacle(context.Background(), estateID, tc.input)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedObstacle, obstacle)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestDeleteObstacle(t *testing.T) {
+	estateID := uuid.New()
+	obstacleID := uuid.New()
+
+	testCases := []struct {
+		name        string
+		mockSetup   func(*mocks.MockRepository)
+		expectedErr string
+	}{
+		{
+			name: "Success",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().DeleteObstacle(gomock.Any(), estateID, obstacleID).Return(nil)
+			},
+		},
+		{
+			name: "Obstacle Not Found",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().DeleteObstacle(gomock.Any(), estateID, obstacleID).Return(pgx.ErrNoRows)
+			},
+			expectedErr: "obstacle not found",
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(0, 0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			err := NewService(mockRepo).DeleteObstacle(context.Background(), estateID, obstacleID)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
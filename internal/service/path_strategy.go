@@ -0,0 +1,191 @@
+package service
+
+// PathStrategy defines the order in which the drone covers the plots of an estate
+type PathStrategy interface {
+	// Sweeps calls sweep for every straight pass of the pattern in flight order,
+	// stopping early if sweep returns false. Consecutive sweeps must end and
+	// start on neighbouring plots.
+	Sweeps(width, length int, sweep func(s Sweep) bool)
+}
+
+// Sweep is a straight pass of the drone along the x or y axis, from
+// (FromX, FromY) to (ToX, ToY) inclusive
+type Sweep struct {
+	FromX, FromY int
+	ToX, ToY     int
+}
+
+// plots calls visit for every plot of the sweep in flight order, returning
+// false if visit stopped the walk
+func (s Sweep) plots(visit func(x, y int) bool) bool {
+	dx, dy := sign(s.ToX-s.FromX), sign(s.ToY-s.FromY)
+	x, y := s.FromX, s.FromY
+	for {
+		if !visit(x, y) {
+			return false
+		}
+		if x == s.ToX && y == s.ToY {
+			return true
+		}
+		x += dx
+		y += dy
+	}
+}
+
+// pathStrategies holds the available patrol patterns by name
+var pathStrategies = map[string]PathStrategy{
+	"row":    rowZigzag{},
+	"column": columnZigzag{},
+	"spiral": inwardSpiral{},
+}
+
+// defaultPattern is the pattern used when a plan does not ask for one
+const defaultPattern = "row"
+
+// getPathStrategy returns the strategy for the named pattern, falling back to
+// the default pattern for an empty name
+func getPathStrategy(name string) (PathStrategy, error) {
+	if name == "" {
+		name = defaultPattern
+	}
+
+	strategy, ok := pathStrategies[name]
+	if !ok {
+		return nil, NewValidationError("unknown patrol pattern", "pattern")
+	}
+	return strategy, nil
+}
+
+// startCorners lists the corners a sweep pattern can start from, with the
+// mirroring that moves a pattern's southwestern start there
+var startCorners = []struct {
+	name         string
+	flipX, flipY bool
+}{
+	{name: "southwest"},
+	{name: "southeast", flipX: true},
+	{name: "northwest", flipY: true},
+	{name: "northeast", flipX: true, flipY: true},
+}
+
+// defaultStartCorner is the corner every pattern starts from unless mirrored
+const defaultStartCorner = "southwest"
+
+// firstPlot returns the plot the strategy starts its patrol on
+func firstPlot(strategy PathStrategy, width, length int) (x, y int) {
+	strategy.Sweeps(width, length, func(s Sweep) bool {
+		x, y = s.FromX, s.FromY
+		return false
+	})
+	return x, y
+}
+
+// rowZigzag flies the estate row by row from south to north, alternating
+// between west-to-east and east-to-west
+type rowZigzag struct{}
+
+// Sweeps implements the PathStrategy interface
+func (rowZigzag) Sweeps(width, length int, sweep func(s Sweep) bool) {
+	for y := 1; y <= length; y++ {
+		// Odd-numbered rows go from west to east, even-numbered rows from east to west
+		s := Sweep{FromX: 1, FromY: y, ToX: width, ToY: y}
+		if y%2 == 0 {
+			s.FromX, s.ToX = width, 1
+		}
+		if !sweep(s) {
+			return
+		}
+	}
+}
+
+// columnZigzag flies the estate column by column from west to east,
+// alternating between south-to-north and north-to-south
+type columnZigzag struct{}
+
+// Sweeps implements the PathStrategy interface
+func (columnZigzag) Sweeps(width, length int, sweep func(s Sweep) bool) {
+	for x := 1; x <= width; x++ {
+		// Odd-numbered columns go from south to north, even-numbered columns from north to south
+		s := Sweep{FromX: x, FromY: 1, ToX: x, ToY: length}
+		if x%2 == 0 {
+			s.FromY, s.ToY = length, 1
+		}
+		if !sweep(s) {
+			return
+		}
+	}
+}
+
+// inwardSpiral flies around the edge of the estate counter-clockwise, starting
+// eastwards from the southwestern corner, and spirals inwards to the centre
+type inwardSpiral struct{}
+
+// Sweeps implements the PathStrategy interface
+func (inwardSpiral) Sweeps(width, length int, sweep func(s Sweep) bool) {
+	left, right, bottom, top := 1, width, 1, length
+	for left <= right && bottom <= top {
+		// East along the southern edge
+		if !sweep(Sweep{FromX: left, FromY: bottom, ToX: right, ToY: bottom}) {
+			return
+		}
+		bottom++
+		if bottom > top {
+			return
+		}
+
+		// North along the eastern edge
+		if !sweep(Sweep{FromX: right, FromY: bottom, ToX: right, ToY: top}) {
+			return
+		}
+		right--
+		if left > right {
+			return
+		}
+
+		// West along the northern edge
+		if !sweep(Sweep{FromX: right, FromY: top, ToX: left, ToY: top}) {
+			return
+		}
+		top--
+		if bottom > top {
+			return
+		}
+
+		// South along the western edge
+		if !sweep(Sweep{FromX: left, FromY: top, ToX: left, ToY: bottom}) {
+			return
+		}
+		left++
+	}
+}
+
+// mirrored flips another strategy along the x and/or y axis so that it starts
+// from a different corner of the estate
+type mirrored struct {
+	base         PathStrategy
+	flipX, flipY bool
+}
+
+// Sweeps implements the PathStrategy interface
+func (m mirrored) Sweeps(width, length int, sweep func(s Sweep) bool) {
+	m.base.Sweeps(width, length, func(s Sweep) bool {
+		if m.flipX {
+			s.FromX, s.ToX = width+1-s.FromX, width+1-s.ToX
+		}
+		if m.flipY {
+			s.FromY, s.ToY = length+1-s.FromY, length+1-s.ToY
+		}
+		return sweep(s)
+	})
+}
+
+// sign returns -1, 0 or 1 depending on the sign of x
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
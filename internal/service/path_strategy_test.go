@@ -0,0 +1,149 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// visitOrder lists the plots a strategy covers in flight order
+func visitOrder(strategy PathStrategy, width, length int) []Plot {
+	var plots []Plot
+	strategy.Sweeps(width, length, func(s Sweep) bool {
+		return s.plots(func(x, y int) bool {
+			plots = append(plots, Plot{X: x, Y: y})
+			return true
+		})
+	})
+	return plots
+}
+
+func TestPathStrategyOrder(t *testing.T) {
+	testCases := []struct {
+		name          string
+		strategy      PathStrategy
+		width, length int
+		expectedOrder []Plot
+	}{
+		{
+			name:          "Row Zigzag",
+			strategy:      rowZigzag{},
+			width:         3,
+			length:        2,
+			expectedOrder: []Plot{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1}, {X: 3, Y: 2}, {X: 2, Y: 2}, {X: 1, Y: 2}},
+		},
+		{
+			name:          "Column Zigzag",
+			strategy:      columnZigzag{},
+			width:         3,
+			length:        2,
+			expectedOrder: []Plot{{X: 1, Y: 1}, {X: 1, Y: 2}, {X: 2, Y: 2}, {X: 2, Y: 1}, {X: 3, Y: 1}, {X: 3, Y: 2}},
+		},
+		{
+			name:     "Inward Spiral",
+			strategy: inwardSpiral{},
+			width:    3,
+			length:   3,
+			expectedOrder: []Plot{
+				{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1},
+				{X: 3, Y: 2}, {X: 3, Y: 3},
+				{X: 2, Y: 3}, {X: 1, Y: 3},
+				{X: 1, Y: 2},
+				{X: 2, Y: 2},
+			},
+		},
+		{
+			// The spiral ends on its way west once the rows run out
+			name:          "Inward Spiral Wider Than Long",
+			strategy:      inwardSpiral{},
+			width:         4,
+			length:        2,
+			expectedOrder: []Plot{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1}, {X: 4, Y: 1}, {X: 4, Y: 2}, {X: 3, Y: 2}, {X: 2, Y: 2}, {X: 1, Y: 2}},
+		},
+		{
+			name:          "Inward Spiral Single Column",
+			strategy:      inwardSpiral{},
+			width:         1,
+			length:        3,
+			expectedOrder: []Plot{{X: 1, Y: 1}, {X: 1, Y: 2}, {X: 1, Y: 3}},
+		},
+		{
+			name:          "Row Zigzag From Northeast",
+			strategy:      mirrored{base: rowZigzag{}, flipX: true, flipY: true},
+			width:         3,
+			length:        2,
+			expectedOrder: []Plot{{X: 3, Y: 2}, {X: 2, Y: 2}, {X: 1, Y: 2}, {X: 1, Y: 1}, {X: 2, Y: 1}, {X: 3, Y: 1}},
+		},
+		{
+			name:          "Column Zigzag From Southeast",
+			strategy:      mirrored{base: columnZigzag{}, flipX: true},
+			width:         3,
+			length:        2,
+			expectedOrder: []Plot{{X: 3, Y: 1}, {X: 3, Y: 2}, {X: 2, Y: 2}, {X: 2, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 2}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedOrder, visitOrder(tc.strategy, tc.width, tc.length))
+
+			x, y := firstPlot(tc.strategy, tc.width, tc.length)
+			assert.Equal(t, tc.expectedOrder[0], Plot{X: x, Y: y})
+		})
+	}
+}
+
+func TestPathStrategiesCoverEveryPlot(t *testing.T) {
+	for name, base := range pathStrategies {
+		for _, corner := range startCorners {
+			strategy := mirrored{base: base, flipX: corner.flipX, flipY: corner.flipY}
+
+			for width := 1; width <= 6; width++ {
+				for length := 1; length <= 6; length++ {
+					label := fmt.Sprintf("%s from %s %dx%d", name, corner.name, width, length)
+					plots := visitOrder(strategy, width, length)
+
+					// Every plot is visited exactly once, each next to the one before
+					assert.Len(t, plots, width*length, label)
+					seen := make(map[Plot]bool)
+					for i, plot := range plots {
+						assert.False(t, seen[plot], label)
+						seen[plot] = true
+						assert.True(t, plot.X >= 1 && plot.X <= width && plot.Y >= 1 && plot.Y <= length, label)
+						if i > 0 {
+							assert.Equal(t, 1, abs(plot.X-plots[i-1].X)+abs(plot.Y-plots[i-1].Y), label)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestGetPathStrategy(t *testing.T) {
+	testCases := []struct {
+		name             string
+		pattern          string
+		expectedStrategy PathStrategy
+		expectedErr      string
+	}{
+		{name: "Default", expectedStrategy: rowZigzag{}},
+		{name: "Row", pattern: "row", expectedStrategy: rowZigzag{}},
+		{name: "Column", pattern: "column", expectedStrategy: columnZigzag{}},
+		{name: "Spiral", pattern: "spiral", expectedStrategy: inwardSpiral{}},
+		{name: "Unknown", pattern: "zigzag", expectedErr: "unknown patrol pattern"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy, err := getPathStrategy(tc.pattern)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedStrategy, strategy)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"io"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -12,19 +15,411 @@ import (
 type EstateService interface {
 	CreateEstate(ctx context.Context, width, length int) (uuid.UUID, error)
 	GetEstate(ctx context.Context, id uuid.UUID) (width, length int, err error)
-	ListEstates(ctx context.Context) ([]repository.Estate, error)
+	ListEstates(ctx context.Context, opts EstateListOptions) (*EstatePage, error)
+	GetEstateDetail(ctx context.Context, id uuid.UUID) (repository.Estate, error)
+	ResizeEstate(ctx context.Context, id uuid.UUID, width, length int, prune bool) (estate repository.Estate, pruned int, err error)
+	DeleteEstate(ctx context.Context, id uuid.UUID) error
+	// ExportEstate writes the estate's dimensions and trees to w in the given
+	// format, one of ExportJSON, ExportCSV or ExportGeoJSON, reading the trees
+	// one at a time. The CSV export only has the optional attributes of the
+	// trees as columns with attributes.
+	ExportEstate(ctx context.Context, id uuid.UUID, format string, geo GeoReference, attributes bool, w io.Writer) error
+	// RenderHeatmap draws the plots of an estate coloured by tree height as
+	// an image in the given format, HeatmapPNG or HeatmapSVG
+	RenderHeatmap(ctx context.Context, id uuid.UUID, format string, opts HeatmapOptions, w io.Writer) error
+}
+
+// EstateListOptions selects a page of estates. Zero bounds are not applied.
+type EstateListOptions struct {
+	// Sort is "created_at" (default) or "area"
+	Sort       string
+	Descending bool
+	MinWidth   int
+	MaxWidth   int
+	MinLength  int
+	MaxLength  int
+	MinArea    int64
+	MaxArea    int64
+	// WithTreeCount fills in the tree count of every estate
+	WithTreeCount bool
+	// Cursor is the NextCursor of the previous page, if any
+	Cursor string
+	// Limit is the number of estates on a page; 0 means 100
+	Limit int
+}
+
+// EstatePage is a page of estates
+type EstatePage struct {
+	Estates []repository.Estate
+	// NextCursor fetches the next page; it is empty on the last page
+	NextCursor string
+}
+
+// GeoReference places an estate on the earth for the GeoJSON export
+type GeoReference struct {
+	// OriginLat and OriginLon are the latitude and longitude in degrees of the
+	// south-west corner of plot (1,1)
+	OriginLat float64
+	OriginLon float64
+	// PlotSize is the length in meters of the side of a plot; 0 means 10
+	PlotSize float64
+}
+
+// HeatmapOptions configures the heatmap image of an estate
+type HeatmapOptions struct {
+	// Size is the number of pixels along the longest side of the image; 0
+	// means 512. Estates with more plots than that along a side are
+	// downsampled, colouring each pixel by the tallest tree it covers.
+	Size int
+	// Route overlays the route of the drone plan calculated with Drone, and
+	// its rest point if it has one
+	Route bool
+	Drone DronePlanOptions
 }
 
 // TreeService defines the interface for tree-related operations
 type TreeService interface {
-	CreateTree(ctx context.Context, estateID uuid.UUID, x, y, height int) (uuid.UUID, error)
-	GetTreeStats(ctx context.Context, estateID uuid.UUID) (count, maxHeight, minHeight, medianHeight int, err error)
+	CreateTree(ctx context.Context, estateID uuid.UUID, input TreeInput) (uuid.UUID, error)
+	GetTreeStats(ctx context.Context, estateID uuid.UUID, opts TreeStatsOptions) (*TreeStats, error)
+	ListTrees(ctx context.Context, estateID uuid.UUID, opts TreeListOptions) (*TreePage, error)
+	GetTree(ctx context.Context, estateID, treeID uuid.UUID) (repository.Tree, error)
+	UpdateTree(ctx context.Context, estateID, treeID uuid.UUID, update TreeUpdate) (repository.Tree, error)
+	DeleteTree(ctx context.Context, estateID, treeID uuid.UUID) error
+	// ImportTrees plants the trees read from r in the given format, one of
+	// TreeImportCSV, TreeImportJSON or TreeImportGeoJSON. Unless bestEffort is
+	// set, nothing is planted if any row is rejected.
+	ImportTrees(ctx context.Context, estateID uuid.UUID, format string, r io.Reader, bestEffort bool) (*TreeImportReport, error)
+	// GetTreeHistory returns the heights a tree has been measured at, oldest
+	// first
+	GetTreeHistory(ctx context.Context, estateID, treeID uuid.UUID) ([]repository.TreeMeasurement, error)
+	GetGrowthReport(ctx context.Context, estateID uuid.UUID, opts GrowthReportOptions) (*GrowthReport, error)
+}
+
+// TreeStatsOptions configures the tree statistics of an estate. Zero bounds
+// are not applied.
+type TreeStatsOptions struct {
+	// Percentiles lists the percentiles, between 0 and 100, to report the
+	// height at; nil means 25, 75 and 90
+	Percentiles []float64
+	XMin        int
+	XMax        int
+	YMin        int
+	YMax        int
+	// Grid is the side, in plots, of the square cells to also break the
+	// statistics down by; 0 means no grid
+	Grid int
+	// Zone is the ID or name of a zone to only count the trees of, within
+	// the bounds if given; it cannot be combined with a grid
+	Zone string
+	// Filter only counts the trees with the given attributes; the density
+	// and empty ratios then count the other trees as missing
+	Filter repository.TreeFilter
+}
+
+// TreeStats summarizes the trees of an estate. All heights are 0 when the
+// estate has no trees.
+type TreeStats struct {
+	Count        int
+	MaxHeight    int
+	MinHeight    int
+	MedianHeight float64
+	MeanHeight   float64
+	// StdDevHeight is the population standard deviation of the heights
+	StdDevHeight float64
+	Percentiles  []HeightPercentile
+	// Histogram holds the number of trees of each height, from 1 to 30
+	Histogram [30]int
+	// Density is the number of trees per plot of the estate, or of the
+	// bounding box or zone when one is given
+	Density float64
+	// Grid is only filled in when asked for
+	Grid *StatsGrid
+}
+
+// StatsGrid breaks the tree statistics of an estate down by square cells
+type StatsGrid struct {
+	CellSize int
+	Columns  int
+	Rows     int
+	// Cells holds the statistics of each cell by row and then column, from
+	// the south-west corner; cells on the edges may be smaller than CellSize
+	Cells [][]CellStats
+}
+
+// CellStats summarizes the trees of a cell of a StatsGrid. All heights are 0
+// when the cell has no trees.
+type CellStats struct {
+	XMin         int
+	XMax         int
+	YMin         int
+	YMax         int
+	Count        int
+	MaxHeight    int
+	MinHeight    int
+	MedianHeight float64
+	MeanHeight   float64
+	// EmptyRatio is the fraction of the plots of the cell without a tree
+	EmptyRatio float64
+}
+
+// GrowthReportOptions configures the growth report of an estate
+type GrowthReportOptions struct {
+	// Limit is the number of fastest and of slowest growing trees listed; 0
+	// means 5
+	Limit int
+}
+
+// GrowthReport summarizes how fast the trees of an estate grow. Trees are
+// only counted once they have been measured at two different times.
+type GrowthReport struct {
+	Trees int
+	// AverageMonthlyGrowth is the mean height in meters the trees gain per
+	// month
+	AverageMonthlyGrowth float64
+	// Fastest lists the fastest growing trees, fastest first, and Slowest the
+	// slowest growing ones, slowest first
+	Fastest []repository.TreeGrowth
+	Slowest []repository.TreeGrowth
+}
+
+// HeightPercentile is the tree height at a percentile
+type HeightPercentile struct {
+	Percentile float64
+	Height     float64
+}
+
+// TreeImportReport is the outcome of a bulk tree import
+type TreeImportReport struct {
+	// Imported is the number of trees planted
+	Imported int
+	// Errors lists the rejected rows
+	Errors []TreeImportError
+}
+
+// TreeImportError describes why a row of a bulk tree import was rejected
+type TreeImportError struct {
+	// Row is the number of the row in the import, counting from 1 and
+	// not counting a CSV header
+	Row     int
+	Message string
+}
+
+// TreeListOptions selects a page of the trees of an estate. Zero bounds are
+// not applied.
+type TreeListOptions struct {
+	// Sort is "position" (default), ordering by x and then y, or "height"
+	Sort       string
+	Descending bool
+	MinHeight  int
+	MaxHeight  int
+	XMin       int
+	XMax       int
+	YMin       int
+	YMax       int
+	Filter     repository.TreeFilter
+	// Cursor is the NextCursor of the previous page, if any
+	Cursor string
+	// Limit is the number of trees on a page; 0 means 100
+	Limit int
+}
+
+// TreePage is a page of the trees of an estate
+type TreePage struct {
+	Trees []repository.Tree
+	// NextCursor fetches the next page; it is empty on the last page
+	NextCursor string
+}
+
+// TreeInput describes a new tree. Species, PlantedOn, Health and Tags are
+// optional.
+type TreeInput struct {
+	X         int
+	Y         int
+	Height    int
+	Species   string
+	PlantedOn *time.Time
+	// Health is one of repository.TreeHealthHealthy, TreeHealthDiseased or
+	// TreeHealthDead
+	Health string
+	Tags   []string
+}
+
+// TreeUpdate holds the changes to a tree; nil fields are left unchanged. An
+// empty Species or Health clears it, as does an empty non-nil Tags.
+type TreeUpdate struct {
+	X         *int
+	Y         *int
+	Height    *int
+	Species   *string
+	PlantedOn *time.Time
+	// ClearPlantedOn forgets the planting date; it cannot be combined with
+	// PlantedOn
+	ClearPlantedOn bool
+	Health         *string
+	Tags           []string
 }
 
 // DroneService defines the interface for drone-related operations
 type DroneService interface {
 	CalculateDronePath(ctx context.Context, estateID uuid.UUID) (distance int, err error)
 	CalculateDronePathWithRest(ctx context.Context, estateID uuid.UUID, maxDistance int) (distance int, restX, restY int, err error)
+	PlanDronePath(ctx context.Context, estateID uuid.UUID, opts DronePlanOptions) (*DronePlan, error)
+}
+
+// ZoneService defines the interface for the named zones of an estate
+type ZoneService interface {
+	CreateZone(ctx context.Context, estateID uuid.UUID, input ZoneInput) (repository.Zone, error)
+	GetZone(ctx context.Context, estateID, zoneID uuid.UUID) (repository.Zone, error)
+	ListZones(ctx context.Context, estateID uuid.UUID) ([]repository.Zone, error)
+	UpdateZone(ctx context.Context, estateID, zoneID uuid.UUID, update ZoneUpdate) (repository.Zone, error)
+	DeleteZone(ctx context.Context, estateID, zoneID uuid.UUID) error
+}
+
+// ZoneInput describes a new zone, shaped either as Rect or as Polygon
+type ZoneInput struct {
+	Name    string
+	Rect    *ZoneRect
+	Polygon []repository.Point
+}
+
+// ZoneUpdate holds the changes to a zone; nil fields are left unchanged. A
+// zone is reshaped by giving either Rect or Polygon.
+type ZoneUpdate struct {
+	Name    *string
+	Rect    *ZoneRect
+	Polygon []repository.Point
+}
+
+// ZoneRect is a rectangular zone, its bounds included
+type ZoneRect struct {
+	XMin int
+	XMax int
+	YMin int
+	YMax int
+}
+
+// ObstacleService defines the interface for the obstacles the drone has to
+// climb over or fly around
+type ObstacleService interface {
+	CreateObstacle(ctx context.Context, estateID uuid.UUID, input ObstacleInput) (repository.Obstacle, error)
+	ListObstacles(ctx context.Context, estateID uuid.UUID) ([]repository.Obstacle, error)
+	DeleteObstacle(ctx context.Context, estateID, obstacleID uuid.UUID) error
+}
+
+// ObstacleInput describes a new obstacle covering a rectangle of plots, a
+// single plot when the bounds coincide. Exactly one of MinAltitude and
+// Impassable must be given.
+type ObstacleInput struct {
+	Rect        PlotRect
+	MinAltitude int
+	Impassable  bool
+}
+
+// JobService defines the interface for asynchronous drone plan jobs
+type JobService interface {
+	SubmitDronePlanJob(ctx context.Context, estateID uuid.UUID, opts DronePlanOptions) (uuid.UUID, error)
+	GetJob(ctx context.Context, id uuid.UUID) (*Job, error)
+	CancelJob(ctx context.Context, id uuid.UUID) error
+	// RunJobWorkers runs the given number of workers calculating queued jobs
+	// until the context is done. It returns once every worker has stopped,
+	// having put the jobs it interrupted back into the queue.
+	RunJobWorkers(ctx context.Context, workers int)
+}
+
+// Job is a drone plan calculated in the background
+type Job struct {
+	ID       uuid.UUID
+	EstateID uuid.UUID
+	// Status is one of "queued", "running", "succeeded", "failed" or "cancelled"
+	Status string
+	// Options are the options the plan is calculated with
+	Options DronePlanOptions
+	// Progress is the percentage of the plan calculated so far
+	Progress int
+	// Error describes why a failed job failed
+	Error string
+	// Result is the plan of a succeeded job
+	Result    *DronePlan
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// DronePlanOptions configures how a drone plan is calculated
+type DronePlanOptions struct {
+	// MaxDistance is the battery range of the drone; 0 means unlimited
+	MaxDistance int
+	// Pattern names the patrol pattern: "row" (default), "column" or "spiral"
+	Pattern string
+	// Optimize evaluates the row and column patterns from every start corner and
+	// plans with the one that has the least total distance, ignoring Pattern
+	Optimize bool
+	// Clearance is the altitude the drone keeps above the ground or a tree; 0 means 1 meter
+	Clearance int
+	// SmoothAltitude holds the altitude across dips no longer than Lookahead
+	// plots instead of following the terrain plot by plot
+	SmoothAltitude bool
+	// Lookahead is the number of plots a smoothed flight looks ahead; 0 means 3
+	Lookahead int
+	// IncludePath requests the waypoints of the flight path
+	IncludePath bool
+	// Legs splits the patrol into legs of at most MaxDistance, landing to swap
+	// batteries between legs, instead of stopping at the first rest point
+	Legs bool
+	// Zone is the ID or name of a zone to only patrol. A polygonal zone is
+	// patrolled over its bounding box, so that every pattern stays intact.
+	Zone string
+	// ExcludeDead flies over dead trees as if their plots were empty
+	ExcludeDead bool
+}
+
+// DronePlan is the result of a drone plan calculation
+type DronePlan struct {
+	Pattern      string
+	StartCorner  string
+	Alternatives []DronePlanVariant
+	Distance     int
+	// VerticalSaved is the vertical distance a smoothed plan saves over the
+	// whole patrol compared to following the terrain
+	VerticalSaved int
+	Rest          *Plot
+	Legs          []DroneLeg
+	Path          []Waypoint
+	// Unreachable holds the plots the drone cannot get to without crossing an
+	// impassable obstacle, and UnreachablePlots their number
+	Unreachable      []PlotRect
+	UnreachablePlots int
+}
+
+// DronePlanVariant is a pattern and start corner evaluated by an optimized
+// drone plan, with the total distance of patrolling the whole estate with it
+type DronePlanVariant struct {
+	Pattern     string
+	StartCorner string
+	Distance    int
+}
+
+// DroneLeg is a single battery charge of a multi-leg drone plan. Its distance
+// includes the take-off from Start and the descent onto Landing.
+type DroneLeg struct {
+	Start    Plot
+	Landing  Plot
+	Distance int
+}
+
+// Plot identifies a plot of an estate
+type Plot struct {
+	X, Y int
+}
+
+// PlotRect is a rectangle of plots of an estate, its bounds included
+type PlotRect struct {
+	XMin, XMax int
+	YMin, YMax int
+}
+
+// Waypoint is a point on the drone's flight path, Z being the altitude in meters
+type Waypoint struct {
+	X, Y, Z int
 }
 
 // Service combines all service interfaces
@@ -32,16 +427,27 @@ type Service interface {
 	EstateService
 	TreeService
 	DroneService
+	ZoneService
+	ObstacleService
+	JobService
 }
 
 // service implements the Service interface
 type service struct {
 	repo repository.Repository
+
+	// wake tells an idle job worker that a job has been submitted
+	wake chan struct{}
+	// mu guards running, the cancel functions of the jobs run by this process
+	mu      sync.Mutex
+	running map[uuid.UUID]context.CancelFunc
 }
 
 // NewService creates a new service with the given repository
 func NewService(repo repository.Repository) Service {
 	return &service{
-		repo: repo,
+		repo:    repo,
+		wake:    make(chan struct{}, 1),
+		running: make(map[uuid.UUID]context.CancelFunc),
 	}
 } 
\ No newline at end of file
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"drone/internal/repository"
+)
+
+const (
+	// maxPercentiles is the largest number of percentiles reported at once
+	maxPercentiles = 20
+	// maxGridCells is the largest number of cells of a stats grid
+	maxGridCells = 10000
+)
+
+// defaultPercentiles are reported when no percentiles are asked for
+var defaultPercentiles = []float64{25, 75, 90}
+
+// GetTreeStats implements the TreeService.GetTreeStats method
+func (s *service) GetTreeStats(ctx context.Context, estateID uuid.UUID, opts TreeStatsOptions) (*TreeStats, error) {
+	percentiles := opts.Percentiles
+	if percentiles == nil {
+		percentiles = defaultPercentiles
+	}
+	if len(percentiles) > maxPercentiles {
+		return nil, NewValidationError("invalid percentile", "percentiles")
+	}
+	query := repository.StatsQuery{
+		Percentiles: make([]float64, len(percentiles)),
+		XMin:        opts.XMin,
+		XMax:        opts.XMax,
+		YMin:        opts.YMin,
+		YMax:        opts.YMax,
+		Filter:      opts.Filter,
+	}
+	for i, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, NewValidationError("invalid percentile", "percentiles")
+		}
+		query.Percentiles[i] = p / 100
+	}
+
+	if query.XMin < 0 || query.XMax < 0 || query.YMin < 0 || query.YMax < 0 ||
+		(query.XMax > 0 && query.XMin > query.XMax) || (query.YMax > 0 && query.YMin > query.YMax) {
+		return nil, NewValidationError("invalid bounding box", "x_min", "x_max", "y_min", "y_max")
+	}
+	if err := validateTreeFilter(query.Filter); err != nil {
+		return nil, err
+	}
+	if opts.Grid < 0 {
+		return nil, NewValidationError("invalid grid", "grid")
+	}
+	if opts.Zone != "" && opts.Grid > 0 {
+		return nil, NewValidationError("zone cannot be combined with a grid", "zone", "grid")
+	}
+
+	// Check if estate exists
+	width, length, err := s.repo.GetEstate(ctx, estateID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEstateNotFound
+		}
+		return nil, err
+	}
+
+	// The region the statistics cover is the bounding box clipped to the estate
+	region := repository.GridQuery{CellSize: opts.Grid, XMin: 1, XMax: width, YMin: 1, YMax: length, Filter: opts.Filter}
+	if query.XMin > 0 {
+		region.XMin = query.XMin
+	}
+	if query.XMax > 0 && query.XMax < width {
+		region.XMax = query.XMax
+	}
+	if query.YMin > 0 {
+		region.YMin = query.YMin
+	}
+	if query.YMax > 0 && query.YMax < length {
+		region.YMax = query.YMax
+	}
+	if region.XMin > region.XMax || region.YMin > region.YMax {
+		return nil, NewValidationError("invalid bounding box", "x_min", "x_max", "y_min", "y_max")
+	}
+	regionWidth := region.XMax - region.XMin + 1
+	regionLength := region.YMax - region.YMin + 1
+	plots := regionWidth * regionLength
+
+	if opts.Zone != "" {
+		zone, err := s.findZone(ctx, estateID, opts.Zone)
+		if err != nil {
+			return nil, err
+		}
+		query.ZoneID = zone.ID
+		plots = zonePlotsWithin(zone.Vertices, region.XMin, region.XMax, region.YMin, region.YMax)
+	}
+
+	var columns, rows int
+	if opts.Grid > 0 {
+		columns = (regionWidth + opts.Grid - 1) / opts.Grid
+		rows = (regionLength + opts.Grid - 1) / opts.Grid
+		if columns*rows > maxGridCells {
+			return nil, NewValidationError("invalid grid", "grid")
+		}
+	}
+
+	// The heavy lifting is done by the database rather than loading every tree
+	stats, err := s.repo.GetTreeStats(ctx, estateID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TreeStats{
+		Count:        stats.Count,
+		MaxHeight:    stats.MaxHeight,
+		MinHeight:    stats.MinHeight,
+		MedianHeight: stats.MedianHeight,
+		MeanHeight:   stats.MeanHeight,
+		StdDevHeight: stats.StdDevHeight,
+		Percentiles:  make([]HeightPercentile, len(percentiles)),
+		Histogram:    stats.Histogram,
+	}
+	// A zone may lie wholly outside the bounding box
+	if plots > 0 {
+		result.Density = float64(stats.Count) / float64(plots)
+	}
+	for i, p := range percentiles {
+		result.Percentiles[i] = HeightPercentile{Percentile: p, Height: stats.Percentiles[i]}
+	}
+
+	if opts.Grid == 0 {
+		return result, nil
+	}
+
+	cells, err := s.repo.GetGridStats(ctx, estateID, region)
+	if err != nil {
+		return nil, err
+	}
+	result.Grid = newStatsGrid(region, columns, rows, cells)
+
+	return result, nil
+}
+
+// newStatsGrid lays the statistics of the cells with trees out over every
+// cell of the grid
+func newStatsGrid(region repository.GridQuery, columns, rows int, cells []repository.CellStats) *StatsGrid {
+	grid := &StatsGrid{
+		CellSize: region.CellSize,
+		Columns:  columns,
+		Rows:     rows,
+		Cells:    make([][]CellStats, rows),
+	}
+	for row := range grid.Cells {
+		grid.Cells[row] = make([]CellStats, columns)
+		for column := range grid.Cells[row] {
+			cell := &grid.Cells[row][column]
+			cell.XMin = region.XMin + column*region.CellSize
+			cell.XMax = min(cell.XMin+region.CellSize-1, region.XMax)
+			cell.YMin = region.YMin + row*region.CellSize
+			cell.YMax = min(cell.YMin+region.CellSize-1, region.YMax)
+			cell.EmptyRatio = 1
+		}
+	}
+
+	for _, stats := range cells {
+		cell := &grid.Cells[stats.Row][stats.Column]
+		plots := (cell.XMax - cell.XMin + 1) * (cell.YMax - cell.YMin + 1)
+		cell.Count = stats.Count
+		cell.MaxHeight = stats.MaxHeight
+		cell.MinHeight = stats.MinHeight
+		cell.MedianHeight = stats.MedianHeight
+		cell.MeanHeight = stats.MeanHeight
+		cell.EmptyRatio = float64(plots-stats.Count) / float64(plots)
+	}
+
+	return grid
+}
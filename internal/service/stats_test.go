@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+	"drone/internal/repository/mocks"
+)
+
+func TestGetTreeStats(t *testing.T) {
+	estateID := uuid.New()
+	zoneID := uuid.New()
+
+	testCases := []struct {
+		name          string
+		opts          TreeStatsOptions
+		mockSetup     func(*mocks.MockRepository)
+		expectedErr   string
+		checkResponse func(t *testing.T, stats *TreeStats)
+	}{
+		{
+			name: "Default Percentiles",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 5, nil)
+				mockRepo.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, repository.StatsQuery{Percentiles: []float64{0.25, 0.75, 0.9}}).
+					Return(repository.Stats{
+						Count:        2,
+						MaxHeight:    6,
+						MinHeight:    5,
+						MedianHeight: 5.5,
+						MeanHeight:   5.5,
+						StdDevHeight: 0.5,
+						Percentiles:  []float64{5.25, 5.75, 5.9},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, stats *TreeStats) {
+				assert.Equal(t, 2, stats.Count)
+				assert.Equal(t, 5.5, stats.MedianHeight)
+				assert.Equal(t, 0.04, stats.Density)
+				assert.Equal(t, []HeightPercentile{
+					{Percentile: 25, Height: 5.25},
+					{Percentile: 75, Height: 5.75},
+					{Percentile: 90, Height: 5.9},
+				}, stats.Percentiles)
+			},
+		},
+		{
+			name: "No Percentiles",
+			opts: TreeStatsOptions{Percentiles: []float64{}},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 5, nil)
+				mockRepo.EXPECT().GetTreeStats(gomock.Any(), estateID, repository.StatsQuery{Percentiles: []float64{}}).Return(repository.Stats{}, nil)
+			},
+			checkResponse: func(t *testing.T, stats *TreeStats) {
+				assert.Empty(t, stats.Percentiles)
+				assert.Equal(t, 0.0, stats.Density)
+			},
+		},
+		{
+			name: "Bounding Box Clipped To Estate",
+			opts: TreeStatsOptions{Percentiles: []float64{}, XMin: 6, YMax: 20},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 5, nil)
+				mockRepo.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, repository.StatsQuery{Percentiles: []float64{}, XMin: 6, YMax: 20}).
+					Return(repository.Stats{Count: 5}, nil)
+			},
+			checkResponse: func(t *testing.T, stats *TreeStats) {
+				assert.Equal(t, 0.2, stats.Density)
+				assert.Nil(t, stats.Grid)
+			},
+		},
+		{
+			name: "Grid",
+			opts: TreeStatsOptions{Percentiles: []float64{}, XMin: 2, Grid: 4},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 5, nil)
+				mockRepo.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, repository.StatsQuery{Percentiles: []float64{}, XMin: 2}).
+					Return(repository.Stats{Count: 3}, nil)
+				mockRepo.EXPECT().
+					GetGridStats(gomock.Any(), estateID, repository.GridQuery{CellSize: 4, XMin: 2, XMax: 10, YMin: 1, YMax: 5}).
+					Return([]repository.CellStats{
+						{Column: 0, Row: 0, Count: 2, MaxHeight: 6, MinHeight: 5, MedianHeight: 5.5, MeanHeight: 5.5},
+						{Column: 2, Row: 1, Count: 1, MaxHeight: 7, MinHeight: 7, MedianHeight: 7, MeanHeight: 7},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, stats *TreeStats) {
+				grid := stats.Grid
+				assert.Equal(t, 4, grid.CellSize)
+				assert.Equal(t, 3, grid.Columns)
+				assert.Equal(t, 2, grid.Rows)
+				assert.Len(t, grid.Cells, 2)
+				assert.Len(t, grid.Cells[0], 3)
+				assert.Equal(t, CellStats{
+					XMin: 2, XMax: 5, YMin: 1, YMax: 4,
+					Count: 2, MaxHeight: 6, MinHeight: 5, MedianHeight: 5.5, MeanHeight: 5.5,
+					EmptyRatio: 0.875,
+				}, grid.Cells[0][0])
+				assert.Equal(t, CellStats{XMin: 6, XMax: 9, YMin: 1, YMax: 4, EmptyRatio: 1}, grid.Cells[0][1])
+				// The last column and row are cut short by the edge of the estate
+				assert.Equal(t, CellStats{
+					XMin: 10, XMax: 10, YMin: 5, YMax: 5,
+					Count: 1, MaxHeight: 7, MinHeight: 7, MedianHeight: 7, MeanHeight: 7,
+				}, grid.Cells[1][2])
+			},
+		},
+		{
+			name: "Filtered Grid",
+			opts: TreeStatsOptions{Percentiles: []float64{}, Grid: 10, Filter: repository.TreeFilter{Health: "diseased"}},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 5, nil)
+				mockRepo.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, repository.StatsQuery{Percentiles: []float64{}, Filter: repository.TreeFilter{Health: "diseased"}}).
+					Return(repository.Stats{Count: 5}, nil)
+				mockRepo.EXPECT().
+					GetGridStats(gomock.Any(), estateID, repository.GridQuery{
+						CellSize: 10, XMin: 1, XMax: 10, YMin: 1, YMax: 5,
+						Filter: repository.TreeFilter{Health: "diseased"},
+					}).
+					Return([]repository.CellStats{{Count: 5, MaxHeight: 3, MinHeight: 1, MedianHeight: 2, MeanHeight: 2}}, nil)
+			},
+			checkResponse: func(t *testing.T, stats *TreeStats) {
+				assert.Equal(t, 0.1, stats.Density)
+				assert.Equal(t, 0.9, stats.Grid.Cells[0][0].EmptyRatio)
+			},
+		},
+		{
+			name: "Zone By Name",
+			opts: TreeStatsOptions{Percentiles: []float64{}, Zone: "Block A"},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 5, nil)
+				mockRepo.EXPECT().GetZoneByName(gomock.Any(), estateID, "Block A").Return(repository.Zone{
+					ID:       zoneID,
+					EstateID: estateID,
+					Name:     "Block A",
+					Shape:    repository.ZoneShapeRect,
+					Vertices: []repository.Point{{X: 1, Y: 1}, {X: 4, Y: 1}, {X: 4, Y: 2}, {X: 1, Y: 2}},
+				}, nil)
+				mockRepo.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, repository.StatsQuery{Percentiles: []float64{}, ZoneID: zoneID}).
+					Return(repository.Stats{Count: 2}, nil)
+			},
+			checkResponse: func(t *testing.T, stats *TreeStats) {
+				assert.Equal(t, 0.25, stats.Density)
+			},
+		},
+		{
+			// Only the 9 plots of the triangle west of x=3 are counted
+			name: "Zone With Bounding Box",
+			opts: TreeStatsOptions{Percentiles: []float64{}, XMax: 2, Zone: "triangle"},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 5, nil)
+				mockRepo.EXPECT().GetZoneByName(gomock.Any(), estateID, "triangle").Return(repository.Zone{
+					ID:       zoneID,
+					EstateID: estateID,
+					Name:     "triangle",
+					Shape:    repository.ZoneShapePolygon,
+					Vertices: []repository.Point{{X: 1, Y: 1}, {X: 5, Y: 1}, {X: 1, Y: 5}},
+				}, nil)
+				mockRepo.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, repository.StatsQuery{Percentiles: []float64{}, XMax: 2, ZoneID: zoneID}).
+					Return(repository.Stats{Count: 3}, nil)
+			},
+			checkResponse: func(t *testing.T, stats *TreeStats) {
+				assert.InDelta(t, 1.0/3, stats.Density, 1e-9)
+			},
+		},
+		{
+			name: "Zone Outside Bounding Box",
+			opts: TreeStatsOptions{Percentiles: []float64{}, XMin: 7, Zone: "triangle"},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 5, nil)
+				mockRepo.EXPECT().GetZoneByName(gomock.Any(), estateID, "triangle").Return(repository.Zone{
+					ID:       zoneID,
+					EstateID: estateID,
+					Name:     "triangle",
+					Shape:    repository.ZoneShapePolygon,
+					Vertices: []repository.Point{{X: 1, Y: 1}, {X: 5, Y: 1}, {X: 1, Y: 5}},
+				}, nil)
+				mockRepo.EXPECT().
+					GetTreeStats(gomock.Any(), estateID, repository.StatsQuery{Percentiles: []float64{}, XMin: 7, ZoneID: zoneID}).
+					Return(repository.Stats{}, nil)
+			},
+			checkResponse: func(t *testing.T, stats *TreeStats) {
+				assert.Equal(t, 0.0, stats.Density)
+			},
+		},
+		{
+			name: "Zone Not Found",
+			opts: TreeStatsOptions{Zone: "Block A"},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 5, nil)
+				mockRepo.EXPECT().GetZoneByName(gomock.Any(), estateID, "Block A").Return(repository.Zone{}, pgx.ErrNoRows)
+			},
+			expectedErr: "zone not found",
+		},
+		{
+			name:        "Zone With Grid",
+			opts:        TreeStatsOptions{Zone: "Block A", Grid: 2},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "zone cannot be combined with a grid",
+		},
+		{
+			name: "Bounding Box Outside Estate",
+			opts: TreeStatsOptions{XMin: 11},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 5, nil)
+			},
+			expectedErr: "invalid bounding box",
+		},
+		{
+			name:        "Inverted Bounding Box",
+			opts:        TreeStatsOptions{YMin: 4, YMax: 3},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid bounding box",
+		},
+		{
+			name: "Too Many Grid Cells",
+			opts: TreeStatsOptions{Grid: 1},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(1000, 1000, nil)
+			},
+			expectedErr: "invalid grid",
+		},
+		{
+			name:        "Invalid Health",
+			opts:        TreeStatsOptions{Filter: repository.TreeFilter{Health: "sick"}},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid health status",
+		},
+		{
+			name:        "Percentile Out Of Range",
+			opts:        TreeStatsOptions{Percentiles: []float64{50, 100.5}},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid percentile",
+		},
+		{
+			name:        "Too Many Percentiles",
+			opts:        TreeStatsOptions{Percentiles: make([]float64, maxPercentiles+1)},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid percentile",
+		},
+		{
+			name: "Estate Not Found",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(0, 0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+		{
+			name: "Repository Error",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 5, nil)
+				mockRepo.EXPECT().GetTreeStats(gomock.Any(), estateID, gomock.Any()).Return(repository.Stats{}, errors.New("database error"))
+			},
+			expectedErr: "database error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			stats, err := NewService(mockRepo).GetTreeStats(context.Background(), estateID, tc.opts)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			tc.checkResponse(t, stats)
+		})
+	}
+}
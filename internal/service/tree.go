@@ -3,88 +3,266 @@ package service
 import (
 	"context"
 	"errors"
-	"sort"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+
+	"drone/internal/repository"
+)
+
+const (
+	// maxSpeciesLength is the longest species name of a tree
+	maxSpeciesLength = 100
+	// maxTags is the largest number of tags on a tree
+	maxTags = 20
+	// maxTagLength is the longest tag of a tree
+	maxTagLength = 50
 )
 
 // CreateTree implements the TreeService.CreateTree method
-func (s *service) CreateTree(ctx context.Context, estateID uuid.UUID, x, y, height int) (uuid.UUID, error) {
-	// Validate estate exists
-	width, length, err := s.repo.GetEstate(ctx, estateID)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return uuid.Nil, errors.New("estate not found")
+func (s *service) CreateTree(ctx context.Context, estateID uuid.UUID, input TreeInput) (uuid.UUID, error) {
+	tree := repository.Tree{
+		EstateID:  estateID,
+		X:         input.X,
+		Y:         input.Y,
+		Height:    input.Height,
+		Species:   strings.TrimSpace(input.Species),
+		PlantedOn: input.PlantedOn,
+		Health:    input.Health,
+		Tags:      normalizeTags(input.Tags),
+	}
+
+	// Validate height
+	if tree.Height < 1 || tree.Height > 30 {
+		return uuid.Nil, NewValidationError("invalid tree height", "height")
+	}
+	if err := validateTreeAttributes(tree); err != nil {
+		return uuid.Nil, err
+	}
+
+	// The estate is locked until the tree is planted, so that it cannot be
+	// shrunk or deleted once the coordinates have been checked against it
+	err := s.repo.WithTx(ctx, func(repo repository.Repository) error {
+		width, length, err := repo.LockEstate(ctx, estateID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrEstateNotFound
+			}
+			return err
+		}
+
+		// Validate coordinates are within estate bounds
+		if tree.X < 1 || tree.X > width || tree.Y < 1 || tree.Y > length {
+			return NewValidationError("tree coordinates outside estate boundaries", "x", "y")
+		}
+
+		// The database has a unique constraint on (estate_id, x, y), which
+		// also catches a tree planted on the same plot in the meantime
+		tree.ID, err = repo.CreateTree(ctx, tree)
+		if errors.Is(err, repository.ErrPlotOccupied) {
+			return ErrPlotOccupied
 		}
+		return err
+	})
+	if err != nil {
 		return uuid.Nil, err
 	}
 
-	// Validate coordinates are within estate bounds
-	if x < 1 || x > width || y < 1 || y > length {
-		return uuid.Nil, errors.New("tree coordinates outside estate boundaries")
+	return tree.ID, nil
+}
+
+// validateTreeAttributes checks the optional attributes of a tree
+func validateTreeAttributes(tree repository.Tree) error {
+	if len(tree.Species) > maxSpeciesLength {
+		return NewValidationError("invalid species", "species")
+	}
+	if tree.PlantedOn != nil && tree.PlantedOn.After(time.Now()) {
+		return NewValidationError("planting date in the future", "planted_on")
+	}
+	if tree.Health != "" && !validHealth(tree.Health) {
+		return NewValidationError("invalid health status", "health")
+	}
+	if len(tree.Tags) > maxTags {
+		return NewValidationError("too many tags", "tags")
 	}
+	for _, tag := range tree.Tags {
+		if tag == "" || len(tag) > maxTagLength {
+			return NewValidationError("invalid tag", "tags")
+		}
+	}
+	return nil
+}
 
-	// Validate height
-	if height < 1 || height > 30 {
-		return uuid.Nil, errors.New("invalid tree height")
+// validateTreeFilter checks a filter on the optional attributes of trees
+func validateTreeFilter(filter repository.TreeFilter) error {
+	if filter.Health != "" && !validHealth(filter.Health) {
+		return NewValidationError("invalid health status", "health")
 	}
+	if !filter.PlantedFrom.IsZero() && !filter.PlantedTo.IsZero() && filter.PlantedFrom.After(filter.PlantedTo) {
+		return NewValidationError("invalid planting date range", "planted_from", "planted_to")
+	}
+	return nil
+}
 
-	// The database has a unique constraint on (estate_id, x, y) so if there's already a tree
-	// at this location, the repository layer will return an error
+// validHealth reports whether health is a known tree health status
+func validHealth(health string) bool {
+	switch health {
+	case repository.TreeHealthHealthy, repository.TreeHealthDiseased, repository.TreeHealthDead:
+		return true
+	}
+	return false
+}
 
-	return s.repo.CreateTree(ctx, estateID, x, y, height)
+// normalizeTags trims the tags of a tree and drops repeated ones, keeping
+// their order
+func normalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
 }
 
-// GetTreeStats implements the TreeService.GetTreeStats method
-func (s *service) GetTreeStats(ctx context.Context, estateID uuid.UUID) (count, maxHeight, minHeight, medianHeight int, err error) {
+// GetTree implements the TreeService.GetTree method
+func (s *service) GetTree(ctx context.Context, estateID, treeID uuid.UUID) (repository.Tree, error) {
 	// Check if estate exists
-	_, _, err = s.repo.GetEstate(ctx, estateID)
-	if err != nil {
+	if _, _, err := s.repo.GetEstate(ctx, estateID); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, 0, 0, 0, errors.New("estate not found")
+			return repository.Tree{}, ErrEstateNotFound
 		}
-		return 0, 0, 0, 0, err
+		return repository.Tree{}, err
 	}
 
-	// Get all trees for the estate
-	trees, err := s.repo.GetTrees(ctx, estateID)
+	tree, err := s.repo.GetTree(ctx, estateID, treeID)
 	if err != nil {
-		return 0, 0, 0, 0, err
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.Tree{}, ErrTreeNotFound
+		}
+		return repository.Tree{}, err
 	}
 
-	// If no trees, return all zeros as per requirements
-	count = len(trees)
-	if count == 0 {
-		return 0, 0, 0, 0, nil
+	return tree, nil
+}
+
+// UpdateTree implements the TreeService.UpdateTree method
+func (s *service) UpdateTree(ctx context.Context, estateID, treeID uuid.UUID, update TreeUpdate) (repository.Tree, error) {
+	if update.PlantedOn != nil && update.ClearPlantedOn {
+		return repository.Tree{}, NewValidationError("planting date both set and cleared", "planted_on", "clear_planted_on")
 	}
 
-	// Calculate max and min height
-	maxHeight = trees[0].Height
-	minHeight = trees[0].Height
-	
-	// Collect heights for median calculation
-	heights := make([]int, count)
-	for i, tree := range trees {
-		heights[i] = tree.Height
-		
-		if tree.Height > maxHeight {
-			maxHeight = tree.Height
+	var tree repository.Tree
+
+	// The estate is locked until the tree is written, so that it cannot be
+	// shrunk or deleted once the new coordinates have been checked against it
+	err := s.repo.WithTx(ctx, func(repo repository.Repository) error {
+		width, length, err := repo.LockEstate(ctx, estateID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrEstateNotFound
+			}
+			return err
+		}
+
+		tree, err = repo.GetTree(ctx, estateID, treeID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrTreeNotFound
+			}
+			return err
+		}
+
+		moved := (update.X != nil && *update.X != tree.X) || (update.Y != nil && *update.Y != tree.Y)
+		if update.X != nil {
+			tree.X = *update.X
+		}
+		if update.Y != nil {
+			tree.Y = *update.Y
+		}
+		if update.Height != nil {
+			tree.Height = *update.Height
+		}
+		if update.Species != nil {
+			tree.Species = strings.TrimSpace(*update.Species)
+		}
+		if update.PlantedOn != nil {
+			tree.PlantedOn = update.PlantedOn
+		}
+		if update.ClearPlantedOn {
+			tree.PlantedOn = nil
+		}
+		if update.Health != nil {
+			tree.Health = *update.Health
+		}
+		if update.Tags != nil {
+			tree.Tags = normalizeTags(update.Tags)
+		}
+
+		// Apply the same rules as when the tree was planted
+		if tree.X < 1 || tree.X > width || tree.Y < 1 || tree.Y > length {
+			return NewValidationError("tree coordinates outside estate boundaries", "x", "y")
+		}
+		if tree.Height < 1 || tree.Height > 30 {
+			return NewValidationError("invalid tree height", "height")
 		}
-		if tree.Height < minHeight {
-			minHeight = tree.Height
+		if err := validateTreeAttributes(tree); err != nil {
+			return err
 		}
+
+		if err := repo.UpdateTree(ctx, tree); err != nil {
+			if errors.Is(err, repository.ErrPlotOccupied) {
+				return ErrPlotOccupied
+			}
+			// The tree was deleted in the meantime
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrTreeNotFound
+			}
+			return err
+		}
+
+		// A tree that moved may stand in other zones now
+		if moved {
+			tree, err = repo.GetTree(ctx, estateID, treeID)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return ErrTreeNotFound
+				}
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return repository.Tree{}, err
 	}
 
-	// Calculate median height
-	sort.Ints(heights)
-	if count%2 == 0 {
-		// Even number of trees, average the middle two
-		medianHeight = (heights[count/2-1] + heights[count/2]) / 2
-	} else {
-		// Odd number of trees, take the middle one
-		medianHeight = heights[count/2]
+	return tree, nil
+}
+
+// DeleteTree implements the TreeService.DeleteTree method
+func (s *service) DeleteTree(ctx context.Context, estateID, treeID uuid.UUID) error {
+	// Check if estate exists
+	if _, _, err := s.repo.GetEstate(ctx, estateID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrEstateNotFound
+		}
+		return err
+	}
+
+	if err := s.repo.DeleteTree(ctx, estateID, treeID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTreeNotFound
+		}
+		return err
 	}
 
-	return count, maxHeight, minHeight, medianHeight, nil
-} 
\ No newline at end of file
+	return nil
+}
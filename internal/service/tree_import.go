@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"drone/internal/repository"
+)
+
+// Formats accepted by ImportTrees
+const (
+	TreeImportCSV     = "csv"
+	TreeImportJSON    = "json"
+	TreeImportGeoJSON = "geojson"
+)
+
+// treeImportRow is a tree read from an import, numbered from 1 in the order
+// it appears in
+type treeImportRow struct {
+	row          int
+	x, y, height int
+}
+
+// ImportTrees implements the TreeService.ImportTrees method
+func (s *service) ImportTrees(ctx context.Context, estateID uuid.UUID, format string, r io.Reader, bestEffort bool) (*TreeImportReport, error) {
+	var (
+		rows []treeImportRow
+		err  error
+	)
+	report := &TreeImportReport{}
+	switch format {
+	case TreeImportCSV:
+		rows, err = parseTreeCSV(r, report)
+	case TreeImportJSON:
+		rows, err = parseTreeJSON(r, report)
+	case TreeImportGeoJSON:
+		rows, err = parseTreeGeoJSON(r, report)
+	default:
+		return nil, NewValidationError("unsupported import format")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The estate is locked from validating the rows until the trees are
+	// planted, so that it cannot be shrunk or deleted in between
+	err = s.repo.WithTx(ctx, func(repo repository.Repository) error {
+		width, length, err := repo.LockEstate(ctx, estateID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrEstateNotFound
+			}
+			return err
+		}
+
+		// Only the plots the rows would be planted on are looked up, rather
+		// than every tree of the estate
+		var plots []repository.Point
+		for _, row := range rows {
+			if row.x >= 1 && row.x <= width && row.y >= 1 && row.y <= length {
+				plots = append(plots, repository.Point{X: row.x, Y: row.y})
+			}
+		}
+		taken, err := repo.OccupiedPlots(ctx, estateID, plots)
+		if err != nil {
+			return err
+		}
+
+		// Plots taken by trees already on the estate or earlier in the import
+		occupied := make(map[repository.Point]bool, len(taken)+len(rows))
+		for _, plot := range taken {
+			occupied[plot] = true
+		}
+
+		// Apply the same rules as CreateTree to every row
+		var valid []treeImportRow
+		for _, row := range rows {
+			plot := repository.Point{X: row.x, Y: row.y}
+			switch {
+			case row.x < 1 || row.x > width || row.y < 1 || row.y > length:
+				report.addError(row.row, "tree coordinates outside estate boundaries")
+			case row.height < 1 || row.height > 30:
+				report.addError(row.row, "invalid tree height")
+			case occupied[plot]:
+				report.addError(row.row, "plot already has a tree")
+			default:
+				occupied[plot] = true
+				valid = append(valid, row)
+			}
+		}
+
+		for len(valid) > 0 && (len(report.Errors) == 0 || bestEffort) {
+			trees := make([]repository.Tree, len(valid))
+			for i, row := range valid {
+				trees[i] = repository.Tree{EstateID: estateID, X: row.x, Y: row.y, Height: row.height}
+			}
+
+			imported, err := repo.CreateTrees(ctx, estateID, trees)
+			if err == nil {
+				report.Imported = imported
+				break
+			}
+			if !errors.Is(err, repository.ErrPlotOccupied) {
+				return err
+			}
+
+			// A tree was planted on some of the plots since they were looked
+			// up. Those rows are rejected like any other taken plot, and in
+			// best effort mode the others are planted.
+			plots = make([]repository.Point, len(valid))
+			for i, row := range valid {
+				plots[i] = repository.Point{X: row.x, Y: row.y}
+			}
+			taken, err := repo.OccupiedPlots(ctx, estateID, plots)
+			if err != nil {
+				return err
+			}
+			if len(taken) == 0 {
+				return ErrPlotOccupied
+			}
+			conflicts := make(map[repository.Point]bool, len(taken))
+			for _, plot := range taken {
+				conflicts[plot] = true
+			}
+			remaining := valid[:0]
+			for _, row := range valid {
+				if conflicts[repository.Point{X: row.x, Y: row.y}] {
+					report.addError(row.row, "plot already has a tree")
+				} else {
+					remaining = append(remaining, row)
+				}
+			}
+			valid = remaining
+		}
+
+		// Parsing errors were found before the validation errors, so list them all by row
+		sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Row < report.Errors[j].Row })
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// addError records that a row of the import was rejected
+func (r *TreeImportReport) addError(row int, message string) {
+	r.Errors = append(r.Errors, TreeImportError{Row: row, Message: message})
+}
+
+// parseTreeCSV reads x,y,height records. A header row naming the columns may
+// come first, in which case the columns can be in any order.
+func parseTreeCSV(r io.Reader, report *TreeImportReport) ([]treeImportRow, error) {
+	reader := csv.NewReader(r)
+	// Rows with the wrong number of fields are reported rather than aborting the import
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	columns := map[string]int{"x": 0, "y": 1, "height": 2}
+	var rows []treeImportRow
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, NewValidationError("invalid CSV: " + err.Error())
+			}
+			return nil, err
+		}
+
+		if n == 1 && isTreeCSVHeader(record) {
+			for i, name := range record {
+				columns[strings.ToLower(strings.TrimSpace(name))] = i
+			}
+			n--
+			continue
+		}
+
+		if len(record) != 3 {
+			report.addError(n, "expected 3 fields: x, y, height")
+			continue
+		}
+
+		var values [3]int
+		valid := true
+		for i, name := range []string{"x", "y", "height"} {
+			value, err := strconv.Atoi(strings.TrimSpace(record[columns[name]]))
+			if err != nil {
+				report.addError(n, fmt.Sprintf("%s is not an integer", name))
+				valid = false
+				break
+			}
+			values[i] = value
+		}
+		if valid {
+			rows = append(rows, treeImportRow{row: n, x: values[0], y: values[1], height: values[2]})
+		}
+	}
+
+	return rows, nil
+}
+
+// isTreeCSVHeader reports whether a CSV record names the x, y and height columns
+func isTreeCSVHeader(record []string) bool {
+	if len(record) != 3 {
+		return false
+	}
+	seen := make(map[string]bool)
+	for _, name := range record {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "x" && name != "y" && name != "height" {
+			return false
+		}
+		seen[name] = true
+	}
+	return len(seen) == 3
+}
+
+// treeImportObject is a tree of a JSON import. Fields are pointers so that
+// missing ones can be told from zero.
+type treeImportObject struct {
+	X      *int `json:"x"`
+	Y      *int `json:"y"`
+	Height *int `json:"height"`
+}
+
+// parseTreeJSON reads an array of {"x", "y", "height"} objects
+func parseTreeJSON(r io.Reader, report *TreeImportReport) ([]treeImportRow, error) {
+	var elements []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&elements); err != nil {
+		return nil, NewValidationError("invalid JSON: expected an array of trees")
+	}
+
+	var rows []treeImportRow
+	for i, element := range elements {
+		var tree treeImportObject
+		if err := json.Unmarshal(element, &tree); err != nil {
+			report.addError(i+1, "expected an object with integer x, y and height")
+			continue
+		}
+		if tree.X == nil || tree.Y == nil || tree.Height == nil {
+			report.addError(i+1, "x, y and height are required")
+			continue
+		}
+		rows = append(rows, treeImportRow{row: i + 1, x: *tree.X, y: *tree.Y, height: *tree.Height})
+	}
+
+	return rows, nil
+}
+
+// treeImportFeature is a tree of a GeoJSON import: a point at the plot
+// coordinates with the height as a property. The plot coordinates may
+// instead be given as properties, as the export writes them next to a point
+// on the earth.
+type treeImportFeature struct {
+	Geometry *struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		X      *int `json:"x"`
+		Y      *int `json:"y"`
+		Height *int `json:"height"`
+	} `json:"properties"`
+}
+
+// parseTreeGeoJSON reads a FeatureCollection of points, one per tree. Other
+// features, such as the estate boundary of an export, are skipped but still
+// numbered.
+func parseTreeGeoJSON(r io.Reader, report *TreeImportReport) ([]treeImportRow, error) {
+	var collection struct {
+		Type     string            `json:"type"`
+		Features []json.RawMessage `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&collection); err != nil || collection.Type != "FeatureCollection" {
+		return nil, NewValidationError("invalid GeoJSON: expected a FeatureCollection")
+	}
+
+	var rows []treeImportRow
+	for i, raw := range collection.Features {
+		var feature treeImportFeature
+		if err := json.Unmarshal(raw, &feature); err != nil {
+			report.addError(i+1, "invalid feature")
+			continue
+		}
+		if feature.Geometry != nil && feature.Geometry.Type != "Point" {
+			continue
+		}
+
+		var x, y int
+		if feature.Properties.X != nil && feature.Properties.Y != nil {
+			x, y = *feature.Properties.X, *feature.Properties.Y
+		} else {
+			var coordinates []float64
+			if feature.Geometry == nil || json.Unmarshal(feature.Geometry.Coordinates, &coordinates) != nil || len(coordinates) < 2 {
+				report.addError(i+1, "expected a Point geometry")
+				continue
+			}
+			if coordinates[0] != math.Trunc(coordinates[0]) || coordinates[1] != math.Trunc(coordinates[1]) {
+				report.addError(i+1, "coordinates must be whole plot numbers")
+				continue
+			}
+			x, y = int(coordinates[0]), int(coordinates[1])
+		}
+		if feature.Properties.Height == nil {
+			report.addError(i+1, "height property is required")
+			continue
+		}
+		rows = append(rows, treeImportRow{row: i + 1, x: x, y: y, height: *feature.Properties.Height})
+	}
+
+	return rows, nil
+}
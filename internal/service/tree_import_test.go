@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+	"drone/internal/repository/mocks"
+)
+
+func TestImportTrees(t *testing.T) {
+	estateID := uuid.New()
+	plots := func(points ...[2]int) []repository.Point {
+		var plots []repository.Point
+		for _, point := range points {
+			plots = append(plots, repository.Point{X: point[0], Y: point[1]})
+		}
+		return plots
+	}
+
+	testCases := []struct {
+		name           string
+		format         string
+		input          string
+		bestEffort     bool
+		mockSetup      func(*mocks.MockRepository)
+		expectedReport *TreeImportReport
+		expectedErr    string
+	}{
+		{
+			name:   "CSV With Header",
+			format: TreeImportCSV,
+			input:  "height,x,y\n5,2,1\n7,3,2\n",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(5, 5, nil)
+				mockRepo.EXPECT().OccupiedPlots(gomock.Any(), estateID, plots([2]int{2, 1}, [2]int{3, 2})).Return(nil, nil)
+				mockRepo.EXPECT().CreateTrees(gomock.Any(), estateID, []repository.Tree{
+					{EstateID: estateID, X: 2, Y: 1, Height: 5},
+					{EstateID: estateID, X: 3, Y: 2, Height: 7},
+				}).Return(2, nil)
+			},
+			expectedReport: &TreeImportReport{Imported: 2},
+		},
+		{
+			name:   "Locks Estate Before Writing",
+			format: TreeImportCSV,
+			input:  "2,1,5\n",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				gomock.InOrder(
+					expectTx(mockRepo),
+					mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(5, 5, nil),
+					mockRepo.EXPECT().OccupiedPlots(gomock.Any(), estateID, plots([2]int{2, 1})).Return(nil, nil),
+					mockRepo.EXPECT().CreateTrees(gomock.Any(), estateID, []repository.Tree{
+						{EstateID: estateID, X: 2, Y: 1, Height: 5},
+					}).Return(1, nil),
+				)
+			},
+			expectedReport: &TreeImportReport{Imported: 1},
+		},
+		{
+			name:   "CSV Atomic Rejects All",
+			format: TreeImportCSV,
+			input:  "2,1,5\n1,1,5\n6,1,5\n2,2,31\n2,3\n2,x,5\n2,1,6\n",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(5, 5, nil)
+				mockRepo.EXPECT().
+					OccupiedPlots(gomock.Any(), estateID, plots([2]int{2, 1}, [2]int{1, 1}, [2]int{2, 2}, [2]int{2, 1})).
+					Return(plots([2]int{1, 1}), nil)
+			},
+			expectedReport: &TreeImportReport{Errors: []TreeImportError{
+				{Row: 2, Message: "plot already has a tree"},
+				{Row: 3, Message: "tree coordinates outside estate boundaries"},
+				{Row: 4, Message: "invalid tree height"},
+				{Row: 5, Message: "expected 3 fields: x, y, height"},
+				{Row: 6, Message: "y is not an integer"},
+				{Row: 7, Message: "plot already has a tree"},
+			}},
+		},
+		{
+			name:       "JSON Best Effort",
+			format:     TreeImportJSON,
+			input:      `[{"x": 2, "y": 1, "height": 5}, {"x": 2, "y": 2}, "tree", {"x": 9, "y": 1, "height": 5}]`,
+			bestEffort: true,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(5, 5, nil)
+				mockRepo.EXPECT().OccupiedPlots(gomock.Any(), estateID, plots([2]int{2, 1})).Return(nil, nil)
+				mockRepo.EXPECT().CreateTrees(gomock.Any(), estateID, []repository.Tree{
+					{EstateID: estateID, X: 2, Y: 1, Height: 5},
+				}).Return(1, nil)
+			},
+			expectedReport: &TreeImportReport{Imported: 1, Errors: []TreeImportError{
+				{Row: 2, Message: "x, y and height are required"},
+				{Row: 3, Message: "expected an object with integer x, y and height"},
+				{Row: 4, Message: "tree coordinates outside estate boundaries"},
+			}},
+		},
+		{
+			name:   "GeoJSON",
+			format: TreeImportGeoJSON,
+			input: `{"type": "FeatureCollection", "features": [
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": [3, 4]}, "properties": {"height": 12}}
+			]}`,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(5, 5, nil)
+				mockRepo.EXPECT().OccupiedPlots(gomock.Any(), estateID, plots([2]int{3, 4})).Return(nil, nil)
+				mockRepo.EXPECT().CreateTrees(gomock.Any(), estateID, []repository.Tree{
+					{EstateID: estateID, X: 3, Y: 4, Height: 12},
+				}).Return(1, nil)
+			},
+			expectedReport: &TreeImportReport{Imported: 1},
+		},
+		{
+			// Plot coordinates in the properties win over the point, and the
+			// estate boundary is skipped
+			name:   "GeoJSON With Plot Properties",
+			format: TreeImportGeoJSON,
+			input: `{"type": "FeatureCollection", "features": [
+				{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[100, 1], [100.1, 1], [100.1, 1.1], [100, 1]]]}, "properties": {"width": 5}},
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": [100.00004, 1.00004]}, "properties": {"x": 1, "y": 2, "height": 7}},
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": [100.00004, 1.00004]}, "properties": {"x": 3, "height": 7}}
+			]}`,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(5, 5, nil)
+				mockRepo.EXPECT().OccupiedPlots(gomock.Any(), estateID, plots([2]int{1, 2})).Return(nil, nil)
+				mockRepo.EXPECT().CreateTrees(gomock.Any(), estateID, []repository.Tree{
+					{EstateID: estateID, X: 1, Y: 2, Height: 7},
+				}).Return(1, nil)
+			},
+			bestEffort: true,
+			expectedReport: &TreeImportReport{Imported: 1, Errors: []TreeImportError{
+				{Row: 3, Message: "coordinates must be whole plot numbers"},
+			}},
+		},
+		{
+			name:        "Malformed JSON",
+			format:      TreeImportJSON,
+			input:       `{"x": 1}`,
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid JSON: expected an array of trees",
+		},
+		{
+			name:   "Estate Not Found",
+			format: TreeImportCSV,
+			input:  "1,1,1\n",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(0, 0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+		{
+			name:   "Plot Taken During Atomic Import",
+			format: TreeImportCSV,
+			input:  "2,1,5\n3,1,5\n",
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(5, 5, nil)
+				gomock.InOrder(
+					mockRepo.EXPECT().OccupiedPlots(gomock.Any(), estateID, plots([2]int{2, 1}, [2]int{3, 1})).Return(nil, nil),
+					mockRepo.EXPECT().CreateTrees(gomock.Any(), estateID, gomock.Any()).Return(0, repository.ErrPlotOccupied),
+					mockRepo.EXPECT().OccupiedPlots(gomock.Any(), estateID, plots([2]int{2, 1}, [2]int{3, 1})).Return(plots([2]int{3, 1}), nil),
+				)
+			},
+			expectedReport: &TreeImportReport{Errors: []TreeImportError{
+				{Row: 2, Message: "plot already has a tree"},
+			}},
+		},
+		{
+			name:       "Plot Taken During Best Effort Import",
+			format:     TreeImportCSV,
+			input:      "2,1,5\n3,1,5\n",
+			bestEffort: true,
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(5, 5, nil)
+				gomock.InOrder(
+					mockRepo.EXPECT().OccupiedPlots(gomock.Any(), estateID, plots([2]int{2, 1}, [2]int{3, 1})).Return(nil, nil),
+					mockRepo.EXPECT().CreateTrees(gomock.Any(), estateID, gomock.Any()).Return(0, repository.ErrPlotOccupied),
+					mockRepo.EXPECT().OccupiedPlots(gomock.Any(), estateID, plots([2]int{2, 1}, [2]int{3, 1})).Return(plots([2]int{3, 1}), nil),
+					mockRepo.EXPECT().CreateTrees(gomock.Any(), estateID, []repository.Tree{
+						{EstateID: estateID, X: 2, Y: 1, Height: 5},
+					}).Return(1, nil),
+				)
+			},
+			expectedReport: &TreeImportReport{Imported: 1, Errors: []TreeImportError{
+				{Row: 2, Message: "plot already has a tree"},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			report, err := NewService(mockRepo).ImportTrees(context.Background(), estateID, tc.format, strings.NewReader(tc.input), tc.bestEffort)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedReport, report)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestImportTreesGeoJSONExport(t *testing.T) {
+	sourceID, estateID := uuid.New(), uuid.New()
+	trees := []repository.Tree{
+		{ID: uuid.New(), EstateID: sourceID, X: 1, Y: 1, Height: 5},
+		{ID: uuid.New(), EstateID: sourceID, X: 4, Y: 3, Height: 12},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetEstate(gomock.Any(), sourceID).Return(4, 3, nil)
+	mockRepo.EXPECT().EachTree(gomock.Any(), sourceID, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, fn func(tree repository.Tree) error) error {
+			for _, tree := range trees {
+				if err := fn(tree); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	// The exported trees are planted on the same plots of another estate
+	expectTx(mockRepo)
+	mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(4, 3, nil)
+	mockRepo.EXPECT().OccupiedPlots(gomock.Any(), estateID, []repository.Point{{X: 1, Y: 1}, {X: 4, Y: 3}}).Return(nil, nil)
+	mockRepo.EXPECT().CreateTrees(gomock.Any(), estateID, []repository.Tree{
+		{EstateID: estateID, X: 1, Y: 1, Height: 5},
+		{EstateID: estateID, X: 4, Y: 3, Height: 12},
+	}).Return(2, nil)
+
+	svc := NewService(mockRepo)
+	var export strings.Builder
+	geo := GeoReference{OriginLat: 1, OriginLon: 100}
+	assert.NoError(t, svc.ExportEstate(context.Background(), sourceID, ExportGeoJSON, geo, false, &export))
+
+	report, err := svc.ImportTrees(context.Background(), estateID, TreeImportGeoJSON, strings.NewReader(export.String()), false)
+	assert.NoError(t, err)
+	assert.Equal(t, &TreeImportReport{Imported: 2}, report)
+}
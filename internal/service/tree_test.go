@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+	"drone/internal/repository/mocks"
+)
+
+func TestCreateTree(t *testing.T) {
+	estateID := uuid.New()
+	treeID := uuid.New()
+	plantedOn := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	future := time.Now().AddDate(0, 0, 2)
+
+	testCases := []struct {
+		name        string
+		input       TreeInput
+		mockSetup   func(*mocks.MockRepository)
+		expectedErr string
+	}{
+		{
+			name:  "Success",
+			input: TreeInput{X: 5, Y: 10, Height: 15},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().CreateTree(gomock.Any(), repository.Tree{EstateID: estateID, X: 5, Y: 10, Height: 15}).Return(treeID, nil)
+			},
+		},
+		{
+			name: "Success - Attributes",
+			input: TreeInput{
+				X: 5, Y: 10, Height: 15,
+				Species: " Oil palm ", PlantedOn: &plantedOn, Health: "healthy", Tags: []string{"north", " fenced", "north"},
+			},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().CreateTree(gomock.Any(), repository.Tree{
+					EstateID: estateID, X: 5, Y: 10, Height: 15,
+					Species: "Oil palm", PlantedOn: &plantedOn, Health: "healthy", Tags: []string{"north", "fenced"},
+				}).Return(treeID, nil)
+			},
+		},
+		{
+			name:        "Invalid Health",
+			input:       TreeInput{X: 5, Y: 10, Height: 15, Health: "sick"},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid health status",
+		},
+		{
+			name:        "Planted In The Future",
+			input:       TreeInput{X: 5, Y: 10, Height: 15, PlantedOn: &future},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "planting date in the future",
+		},
+		{
+			name:        "Species Too Long",
+			input:       TreeInput{X: 5, Y: 10, Height: 15, Species: strings.Repeat("a", 101)},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid species",
+		},
+		{
+			name:        "Empty Tag",
+			input:       TreeInput{X: 5, Y: 10, Height: 15, Tags: []string{"north", " "}},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid tag",
+		},
+		{
+			name:        "Too Many Tags",
+			input:       TreeInput{X: 5, Y: 10, Height: 15, Tags: strings.Split("a b c d e f g h i j k l m n o p q r s t u", " ")},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "too many tags",
+		},
+		{
+			name:  "Plot Occupied",
+			input: TreeInput{X: 5, Y: 10, Height: 15},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().CreateTree(gomock.Any(), gomock.Any()).Return(uuid.Nil, repository.ErrPlotOccupied)
+			},
+			expectedErr: "plot already has a tree",
+		},
+		{
+			name:  "Estate Not Found",
+			input: TreeInput{X: 5, Y: 10, Height: 15},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(0, 0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+		{
+			name:  "Outside Estate",
+			input: TreeInput{X: 11, Y: 10, Height: 15},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+			},
+			expectedErr: "tree coordinates outside estate boundaries",
+		},
+		{
+			name:        "Invalid Height",
+			input:       TreeInput{X: 5, Y: 10, Height: 31},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid tree height",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			id, err := NewService(mockRepo).CreateTree(context.Background(), estateID, tc.input)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, treeID, id)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestUpdateTree(t *testing.T) {
+	estateID := uuid.New()
+	treeID := uuid.New()
+	tree := repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 15}
+	intPtr := func(v int) *int { return &v }
+	strPtr := func(v string) *string { return &v }
+	plantedOn := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	tagged := repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 15, Species: "Oil palm", Tags: []string{"north"}}
+
+	testCases := []struct {
+		name         string
+		update       TreeUpdate
+		mockSetup    func(*mocks.MockRepository)
+		expectedTree repository.Tree
+		expectedErr  string
+	}{
+		{
+			name:   "Move Keeps Height",
+			update: TreeUpdate{X: intPtr(7), Y: intPtr(8)},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(tree, nil)
+				mockRepo.EXPECT().UpdateTree(gomock.Any(), repository.Tree{ID: treeID, EstateID: estateID, X: 7, Y: 8, Height: 15}).Return(nil)
+				mockRepo.EXPECT().
+					GetTree(gomock.Any(), estateID, treeID).
+					Return(repository.Tree{ID: treeID, EstateID: estateID, X: 7, Y: 8, Height: 15, Zones: []string{"Block A"}}, nil)
+			},
+			expectedTree: repository.Tree{ID: treeID, EstateID: estateID, X: 7, Y: 8, Height: 15, Zones: []string{"Block A"}},
+		},
+		{
+			name:   "Locks Estate Before Writing",
+			update: TreeUpdate{Y: intPtr(12)},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				gomock.InOrder(
+					expectTx(mockRepo),
+					mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil),
+					mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(tree, nil),
+					mockRepo.EXPECT().UpdateTree(gomock.Any(), repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 12, Height: 15}).Return(nil),
+					mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 12, Height: 15}, nil),
+				)
+			},
+			expectedTree: repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 12, Height: 15},
+		},
+		{
+			name:   "Height Change Keeps Zones",
+			update: TreeUpdate{X: intPtr(5), Height: intPtr(20)},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(tree, nil)
+				mockRepo.EXPECT().UpdateTree(gomock.Any(), repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 20}).Return(nil)
+			},
+			expectedTree: repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 20},
+		},
+		{
+			name:   "Attributes",
+			update: TreeUpdate{PlantedOn: &plantedOn, Health: strPtr("diseased"), Tags: []string{" fenced ", "fenced"}},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(tagged, nil)
+				mockRepo.EXPECT().UpdateTree(gomock.Any(), repository.Tree{
+					ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 15,
+					Species: "Oil palm", PlantedOn: &plantedOn, Health: "diseased", Tags: []string{"fenced"},
+				}).Return(nil)
+			},
+			expectedTree: repository.Tree{
+				ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 15,
+				Species: "Oil palm", PlantedOn: &plantedOn, Health: "diseased", Tags: []string{"fenced"},
+			},
+		},
+		{
+			name:   "Clear Attributes",
+			update: TreeUpdate{Species: strPtr(""), Tags: []string{}},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(tagged, nil)
+				mockRepo.EXPECT().UpdateTree(gomock.Any(), repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 15, Tags: []string{}}).Return(nil)
+			},
+			expectedTree: repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 15, Tags: []string{}},
+		},
+		{
+			name:   "Clear Planting Date",
+			update: TreeUpdate{ClearPlantedOn: true},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().
+					GetTree(gomock.Any(), estateID, treeID).
+					Return(repository.Tree{ID: treeID, EstateID: estateID, X: 5, Y: 10, Height: 15, PlantedOn: &plantedOn}, nil)
+				mockRepo.EXPECT().UpdateTree(gomock.Any(), tree).Return(nil)
+			},
+			expectedTree: tree,
+		},
+		{
+			name:        "Set And Clear Planting Date",
+			update:      TreeUpdate{PlantedOn: &plantedOn, ClearPlantedOn: true},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "planting date both set and cleared",
+		},
+		{
+			name:   "Invalid Health",
+			update: TreeUpdate{Health: strPtr("sick")},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(tree, nil)
+			},
+			expectedErr: "invalid health status",
+		},
+		{
+			name:   "Estate Not Found",
+			update: TreeUpdate{Height: intPtr(20)},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(0, 0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+		{
+			name:   "Tree Not Found",
+			update: TreeUpdate{Height: intPtr(20)},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(repository.Tree{}, pgx.ErrNoRows)
+			},
+			expectedErr: "tree not found",
+		},
+		{
+			name:   "Move Out of Bounds",
+			update: TreeUpdate{X: intPtr(11)},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(tree, nil)
+			},
+			expectedErr: "tree coordinates outside estate boundaries",
+		},
+		{
+			name:   "Invalid Height",
+			update: TreeUpdate{Height: intPtr(31)},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(tree, nil)
+			},
+			expectedErr: "invalid tree height",
+		},
+		{
+			name:   "Plot Occupied",
+			update: TreeUpdate{X: intPtr(7)},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 20, nil)
+				mockRepo.EXPECT().GetTree(gomock.Any(), estateID, treeID).Return(tree, nil)
+				mockRepo.EXPECT().UpdateTree(gomock.Any(), repository.Tree{ID: treeID, EstateID: estateID, X: 7, Y: 10, Height: 15}).Return(repository.ErrPlotOccupied)
+			},
+			expectedErr: "plot already has a tree",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			updated, err := NewService(mockRepo).UpdateTree(context.Background(), estateID, treeID, tc.update)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedTree, updated)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+// expectTx expects a transaction, running its function against the mock
+// repository itself
+func expectTx(mockRepo *mocks.MockRepository) *gomock.Call {
+	return mockRepo.EXPECT().
+		WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, fn func(repository.Repository) error) error {
+			return fn(mockRepo)
+		})
+}
@@ -0,0 +1,418 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"drone/internal/repository"
+)
+
+const (
+	// maxZoneNameLength is the longest zone name in characters
+	maxZoneNameLength = 100
+	// maxZoneVertices is the largest number of vertices of a polygonal zone
+	maxZoneVertices = 100
+)
+
+// CreateZone implements the ZoneService.CreateZone method
+func (s *service) CreateZone(ctx context.Context, estateID uuid.UUID, input ZoneInput) (repository.Zone, error) {
+	if !validZoneName(input.Name) {
+		return repository.Zone{}, NewValidationError("invalid zone name", "name")
+	}
+
+	// The estate is locked until the zone is stored, so that it cannot be
+	// shrunk once the vertices have been checked against it
+	zone := repository.Zone{EstateID: estateID, Name: input.Name}
+	err := s.repo.WithTx(ctx, func(repo repository.Repository) error {
+		width, length, err := repo.LockEstate(ctx, estateID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrEstateNotFound
+			}
+			return err
+		}
+
+		zone.Shape, zone.Vertices, err = zoneShape(input.Rect, input.Polygon, width, length)
+		if err != nil {
+			return err
+		}
+
+		zone.ID, err = repo.CreateZone(ctx, zone)
+		if errors.Is(err, repository.ErrZoneExists) {
+			return ErrZoneNameTaken
+		}
+		return err
+	})
+	if err != nil {
+		return repository.Zone{}, err
+	}
+
+	return zone, nil
+}
+
+// GetZone implements the ZoneService.GetZone method
+func (s *service) GetZone(ctx context.Context, estateID, zoneID uuid.UUID) (repository.Zone, error) {
+	// Check if estate exists
+	if _, _, err := s.repo.GetEstate(ctx, estateID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.Zone{}, ErrEstateNotFound
+		}
+		return repository.Zone{}, err
+	}
+
+	zone, err := s.repo.GetZone(ctx, estateID, zoneID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.Zone{}, ErrZoneNotFound
+		}
+		return repository.Zone{}, err
+	}
+
+	return zone, nil
+}
+
+// ListZones implements the ZoneService.ListZones method
+func (s *service) ListZones(ctx context.Context, estateID uuid.UUID) ([]repository.Zone, error) {
+	// Check if estate exists
+	if _, _, err := s.repo.GetEstate(ctx, estateID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrEstateNotFound
+		}
+		return nil, err
+	}
+
+	return s.repo.ListZones(ctx, estateID)
+}
+
+// UpdateZone implements the ZoneService.UpdateZone method
+func (s *service) UpdateZone(ctx context.Context, estateID, zoneID uuid.UUID, update ZoneUpdate) (repository.Zone, error) {
+	if update.Name != nil && !validZoneName(*update.Name) {
+		return repository.Zone{}, NewValidationError("invalid zone name", "name")
+	}
+
+	zone, err := s.GetZone(ctx, estateID, zoneID)
+	if err != nil {
+		return repository.Zone{}, err
+	}
+
+	if update.Name != nil {
+		zone.Name = *update.Name
+	}
+	save := func(repo repository.Repository) error {
+		err := repo.UpdateZone(ctx, zone)
+		if errors.Is(err, repository.ErrZoneExists) {
+			return ErrZoneNameTaken
+		}
+		// The zone was deleted in the meantime
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrZoneNotFound
+		}
+		return err
+	}
+
+	if update.Rect == nil && update.Polygon == nil {
+		err = save(s.repo)
+	} else {
+		// A reshaped zone is checked against the estate locked like a new one
+		err = s.repo.WithTx(ctx, func(repo repository.Repository) error {
+			width, length, err := repo.LockEstate(ctx, estateID)
+			if err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return ErrEstateNotFound
+				}
+				return err
+			}
+			zone.Shape, zone.Vertices, err = zoneShape(update.Rect, update.Polygon, width, length)
+			if err != nil {
+				return err
+			}
+			return save(repo)
+		})
+	}
+	if err != nil {
+		return repository.Zone{}, err
+	}
+
+	return zone, nil
+}
+
+// DeleteZone implements the ZoneService.DeleteZone method
+func (s *service) DeleteZone(ctx context.Context, estateID, zoneID uuid.UUID) error {
+	// Check if estate exists
+	if _, _, err := s.repo.GetEstate(ctx, estateID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrEstateNotFound
+		}
+		return err
+	}
+
+	if err := s.repo.DeleteZone(ctx, estateID, zoneID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrZoneNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// findZone looks a zone of an estate up by ID, or by name if ref is not an ID
+func (s *service) findZone(ctx context.Context, estateID uuid.UUID, ref string) (repository.Zone, error) {
+	var zone repository.Zone
+	var err error
+	if id, parseErr := uuid.Parse(ref); parseErr == nil {
+		zone, err = s.repo.GetZone(ctx, estateID, id)
+	} else {
+		zone, err = s.repo.GetZoneByName(ctx, estateID, ref)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return repository.Zone{}, ErrZoneNotFound
+		}
+		return repository.Zone{}, err
+	}
+	return zone, nil
+}
+
+// validZoneName reports whether a zone name is neither empty nor too long
+func validZoneName(name string) bool {
+	n := len([]rune(name))
+	return n >= 1 && n <= maxZoneNameLength
+}
+
+// zoneShape validates the shape of a zone, given either as a rectangle or as
+// a polygon, and returns its shape and vertices. Every vertex has to lie on
+// the estate and a polygon must not cross itself.
+func zoneShape(rect *ZoneRect, polygon []repository.Point, width, length int) (string, []repository.Point, error) {
+	onEstate := func(p repository.Point) bool {
+		return p.X >= 1 && p.X <= width && p.Y >= 1 && p.Y <= length
+	}
+
+	if rect != nil {
+		if polygon != nil || rect.XMin > rect.XMax || rect.YMin > rect.YMax {
+			return "", nil, NewValidationError("invalid zone shape", "rect", "polygon")
+		}
+		vertices := []repository.Point{
+			{X: rect.XMin, Y: rect.YMin},
+			{X: rect.XMax, Y: rect.YMin},
+			{X: rect.XMax, Y: rect.YMax},
+			{X: rect.XMin, Y: rect.YMax},
+		}
+		if !onEstate(vertices[0]) || !onEstate(vertices[2]) {
+			return "", nil, NewValidationError("invalid zone shape", "rect", "polygon")
+		}
+		return repository.ZoneShapeRect, vertices, nil
+	}
+
+	if len(polygon) < 3 || len(polygon) > maxZoneVertices {
+		return "", nil, NewValidationError("invalid zone shape", "rect", "polygon")
+	}
+	for _, p := range polygon {
+		if !onEstate(p) {
+			return "", nil, NewValidationError("invalid zone shape", "rect", "polygon")
+		}
+	}
+	if doubleArea(polygon) == 0 || selfIntersecting(polygon) {
+		return "", nil, NewValidationError("invalid zone shape", "rect", "polygon")
+	}
+	return repository.ZoneShapePolygon, polygon, nil
+}
+
+// zoneBounds returns the bounding box of the vertices of a zone
+func zoneBounds(vertices []repository.Point) (xMin, xMax, yMin, yMax int) {
+	xMin, xMax, yMin, yMax = vertices[0].X, vertices[0].X, vertices[0].Y, vertices[0].Y
+	for _, v := range vertices[1:] {
+		xMin, xMax = min(xMin, v.X), max(xMax, v.X)
+		yMin, yMax = min(yMin, v.Y), max(yMax, v.Y)
+	}
+	return xMin, xMax, yMin, yMax
+}
+
+// zonePlots counts the plots of a zone, those inside or on the edge of its
+// polygon. By Pick's theorem a polygon with integer vertices and area A has
+// A - B/2 + 1 plots inside it, B being the number of plots on its edge.
+func zonePlots(vertices []repository.Point) int {
+	boundary := 0
+	for i, a := range vertices {
+		b := vertices[(i+1)%len(vertices)]
+		boundary += gcd(abs(b.X-a.X), abs(b.Y-a.Y))
+	}
+	interior := (abs(doubleArea(vertices))-boundary)/2 + 1
+	return interior + boundary
+}
+
+// zonePlotsWithin counts the plots of a zone lying in a rectangle
+func zonePlotsWithin(vertices []repository.Point, xMin, xMax, yMin, yMax int) int {
+	zoneXMin, zoneXMax, zoneYMin, zoneYMax := zoneBounds(vertices)
+	if zoneXMin >= xMin && zoneXMax <= xMax && zoneYMin >= yMin && zoneYMax <= yMax {
+		return zonePlots(vertices)
+	}
+
+	plots := 0
+	for y := max(yMin, zoneYMin); y <= min(yMax, zoneYMax); y++ {
+		for _, stretch := range zoneLine(vertices, y, false) {
+			plots += max(0, min(stretch[1], xMax)-max(stretch[0], xMin)+1)
+		}
+	}
+	return plots
+}
+
+// zoneLine returns the stretches of a row, or of a column if column is set,
+// lying in a zone as sorted, disjoint pairs of first and last x (or y). They
+// hold the same plots as a point-in-polygon test of each plot would.
+func zoneLine(vertices []repository.Point, line int, column bool) [][2]int {
+	at := func(i int) (pos, across int) {
+		v := vertices[i%len(vertices)]
+		if column {
+			return v.Y, v.X
+		}
+		return v.X, v.Y
+	}
+
+	// Plots strictly between a pair of edge crossings lie inside the polygon.
+	// Crossings are kept as fractions num/den to place them exactly.
+	type crossing struct{ num, den int }
+	var (
+		crossings []crossing
+		stretches [][2]int
+	)
+	for i := range vertices {
+		aPos, aLine := at(i)
+		bPos, bLine := at(i + 1)
+		switch {
+		case aLine == line && bLine == line:
+			// An edge along the line lies on the zone's edge
+			stretches = append(stretches, [2]int{min(aPos, bPos), max(aPos, bPos)})
+		case aLine == line:
+			stretches = append(stretches, [2]int{aPos, aPos})
+		}
+		if (aLine > line) != (bLine > line) {
+			num := aPos*(bLine-aLine) + (line-aLine)*(bPos-aPos)
+			den := bLine - aLine
+			if den < 0 {
+				num, den = -num, -den
+			}
+			crossings = append(crossings, crossing{num, den})
+		}
+	}
+	sort.Slice(crossings, func(i, j int) bool {
+		return crossings[i].num*crossings[j].den < crossings[j].num*crossings[i].den
+	})
+	for i := 0; i+1 < len(crossings); i += 2 {
+		from, to := ceilDiv(crossings[i].num, crossings[i].den), floorDiv(crossings[i+1].num, crossings[i+1].den)
+		if from <= to {
+			stretches = append(stretches, [2]int{from, to})
+		}
+	}
+
+	// Merge the stretches that overlap or touch
+	sort.Slice(stretches, func(i, j int) bool { return stretches[i][0] < stretches[j][0] })
+	merged := stretches[:0]
+	for _, s := range stretches {
+		if n := len(merged); n > 0 && s[0] <= merged[n-1][1]+1 {
+			merged[n-1][1] = max(merged[n-1][1], s[1])
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// floorDiv divides a by a positive b, rounding down
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// ceilDiv divides a by a positive b, rounding up
+func ceilDiv(a, b int) int {
+	return -floorDiv(-a, b)
+}
+
+// doubleArea returns twice the signed area of a polygon
+func doubleArea(vertices []repository.Point) int {
+	area := 0
+	for i, a := range vertices {
+		b := vertices[(i+1)%len(vertices)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return area
+}
+
+// selfIntersecting reports whether any two edges of a polygon that are not
+// next to each other touch, or two neighbouring edges fold back onto each other
+func selfIntersecting(vertices []repository.Point) bool {
+	n := len(vertices)
+	for i := 0; i < n; i++ {
+		a, b := vertices[i], vertices[(i+1)%n]
+		if a == b {
+			return true
+		}
+		for j := i + 1; j < n; j++ {
+			c, d := vertices[j], vertices[(j+1)%n]
+			// Neighbouring edges share a vertex, and only overlap if they
+			// run back along each other
+			if j == i+1 {
+				if foldsBack(b, a, d) {
+					return true
+				}
+				continue
+			}
+			if i == 0 && j == n-1 {
+				if foldsBack(a, b, c) {
+					return true
+				}
+				continue
+			}
+			if segmentsTouch(a, b, c, d) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// foldsBack reports whether the edges from a shared vertex to p and to q
+// point in the same direction
+func foldsBack(shared, p, q repository.Point) bool {
+	return cross(shared, p, q) == 0 && dot(shared, p, q) > 0
+}
+
+// cross returns the cross product of the vectors from o to a and from o to b
+func cross(o, a, b repository.Point) int {
+	return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+}
+
+// dot returns the dot product of the vectors from o to a and from o to b
+func dot(o, a, b repository.Point) int {
+	return (a.X-o.X)*(b.X-o.X) + (a.Y-o.Y)*(b.Y-o.Y)
+}
+
+// segmentsTouch reports whether the segments ab and cd have a point in common
+func segmentsTouch(a, b, c, d repository.Point) bool {
+	d1, d2 := cross(c, d, a), cross(c, d, b)
+	d3, d4 := cross(a, b, c), cross(a, b, d)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	onSegment := func(p, q, r repository.Point) bool {
+		return min(p.X, q.X) <= r.X && r.X <= max(p.X, q.X) && min(p.Y, q.Y) <= r.Y && r.Y <= max(p.Y, q.Y)
+	}
+	return (d1 == 0 && onSegment(c, d, a)) || (d2 == 0 && onSegment(c, d, b)) ||
+		(d3 == 0 && onSegment(a, b, c)) || (d4 == 0 && onSegment(a, b, d))
+}
+
+// gcd returns the greatest common divisor of two non-negative integers
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+
+	"drone/internal/repository"
+	"drone/internal/repository/mocks"
+)
+
+func TestCreateZone(t *testing.T) {
+	estateID := uuid.New()
+	zoneID := uuid.New()
+	triangle := []repository.Point{{X: 1, Y: 1}, {X: 5, Y: 1}, {X: 1, Y: 5}}
+
+	testCases := []struct {
+		name         string
+		input        ZoneInput
+		mockSetup    func(*mocks.MockRepository)
+		expectedZone repository.Zone
+		expectedErr  string
+	}{
+		{
+			name:  "Rectangle",
+			input: ZoneInput{Name: "Block A", Rect: &ZoneRect{XMin: 2, XMax: 4, YMin: 3, YMax: 5}},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().CreateZone(gomock.Any(), repository.Zone{
+					EstateID: estateID,
+					Name:     "Block A",
+					Shape:    repository.ZoneShapeRect,
+					Vertices: []repository.Point{{X: 2, Y: 3}, {X: 4, Y: 3}, {X: 4, Y: 5}, {X: 2, Y: 5}},
+				}).Return(zoneID, nil)
+			},
+			expectedZone: repository.Zone{
+				ID:       zoneID,
+				EstateID: estateID,
+				Name:     "Block A",
+				Shape:    repository.ZoneShapeRect,
+				Vertices: []repository.Point{{X: 2, Y: 3}, {X: 4, Y: 3}, {X: 4, Y: 5}, {X: 2, Y: 5}},
+			},
+		},
+		{
+			name:  "Polygon",
+			input: ZoneInput{Name: "nursery", Polygon: triangle},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().CreateZone(gomock.Any(), gomock.Any()).Return(zoneID, nil)
+			},
+			expectedZone: repository.Zone{
+				ID:       zoneID,
+				EstateID: estateID,
+				Name:     "nursery",
+				Shape:    repository.ZoneShapePolygon,
+				Vertices: triangle,
+			},
+		},
+		{
+			name:        "Empty Name",
+			input:       ZoneInput{Rect: &ZoneRect{XMin: 1, XMax: 1, YMin: 1, YMax: 1}},
+			mockSetup:   func(mockRepo *mocks.MockRepository) {},
+			expectedErr: "invalid zone name",
+		},
+		{
+			name:  "Rectangle Outside Estate",
+			input: ZoneInput{Name: "Block A", Rect: &ZoneRect{XMin: 8, XMax: 11, YMin: 1, YMax: 2}},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 10, nil)
+			},
+			expectedErr: "invalid zone shape",
+		},
+		{
+			name:  "Self-Intersecting Polygon",
+			input: ZoneInput{Name: "bow tie", Polygon: []repository.Point{{X: 1, Y: 1}, {X: 5, Y: 5}, {X: 5, Y: 1}, {X: 1, Y: 5}}},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 10, nil)
+			},
+			expectedErr: "invalid zone shape",
+		},
+		{
+			name:  "Flat Polygon",
+			input: ZoneInput{Name: "line", Polygon: []repository.Point{{X: 1, Y: 1}, {X: 3, Y: 3}, {X: 5, Y: 5}}},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 10, nil)
+			},
+			expectedErr: "invalid zone shape",
+		},
+		{
+			name:  "Name Taken",
+			input: ZoneInput{Name: "nursery", Polygon: triangle},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().CreateZone(gomock.Any(), gomock.Any()).Return(uuid.Nil, repository.ErrZoneExists)
+			},
+			expectedErr: "zone name already taken",
+		},
+		{
+			name:  "Estate Not Found",
+			input: ZoneInput{Name: "nursery", Polygon: triangle},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(0, 0, pgx.ErrNoRows)
+			},
+			expectedErr: "estate not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			zone, err := NewService(mockRepo).CreateZone(context.Background(), estateID, tc.input)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedZone, zone)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestUpdateZone(t *testing.T) {
+	estateID := uuid.New()
+	zoneID := uuid.New()
+	zone := repository.Zone{
+		ID:       zoneID,
+		EstateID: estateID,
+		Name:     "Block A",
+		Shape:    repository.ZoneShapeRect,
+		Vertices: []repository.Point{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 2, Y: 2}, {X: 1, Y: 2}},
+	}
+	name := "Block B"
+
+	testCases := []struct {
+		name         string
+		update       ZoneUpdate
+		mockSetup    func(*mocks.MockRepository)
+		expectedZone repository.Zone
+		expectedErr  string
+	}{
+		{
+			name:   "Rename Keeps Shape",
+			update: ZoneUpdate{Name: &name},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				renamed := zone
+				renamed.Name = name
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().GetZone(gomock.Any(), estateID, zoneID).Return(zone, nil)
+				mockRepo.EXPECT().UpdateZone(gomock.Any(), renamed).Return(nil)
+			},
+			expectedZone: repository.Zone{ID: zoneID, EstateID: estateID, Name: name, Shape: zone.Shape, Vertices: zone.Vertices},
+		},
+		{
+			name:   "Reshape As Polygon",
+			update: ZoneUpdate{Polygon: []repository.Point{{X: 1, Y: 1}, {X: 4, Y: 1}, {X: 4, Y: 4}}},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().GetZone(gomock.Any(), estateID, zoneID).Return(zone, nil)
+				expectTx(mockRepo)
+				mockRepo.EXPECT().LockEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().UpdateZone(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			expectedZone: repository.Zone{
+				ID:       zoneID,
+				EstateID: estateID,
+				Name:     "Block A",
+				Shape:    repository.ZoneShapePolygon,
+				Vertices: []repository.Point{{X: 1, Y: 1}, {X: 4, Y: 1}, {X: 4, Y: 4}},
+			},
+		},
+		{
+			name:   "Zone Not Found",
+			update: ZoneUpdate{Name: &name},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().GetZone(gomock.Any(), estateID, zoneID).Return(repository.Zone{}, pgx.ErrNoRows)
+			},
+			expectedErr: "zone not found",
+		},
+		{
+			name:   "Name Taken",
+			update: ZoneUpdate{Name: &name},
+			mockSetup: func(mockRepo *mocks.MockRepository) {
+				mockRepo.EXPECT().GetEstate(gomock.Any(), estateID).Return(10, 10, nil)
+				mockRepo.EXPECT().GetZone(gomock.Any(), estateID, zoneID).Return(zone, nil)
+				mockRepo.EXPECT().UpdateZone(gomock.Any(), gomock.Any()).Return(repository.ErrZoneExists)
+			},
+			expectedErr: "zone name already taken",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			tc.mockSetup(mockRepo)
+
+			updated, err := NewService(mockRepo).UpdateZone(context.Background(), estateID, zoneID, tc.update)
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedZone, updated)
+			} else {
+				assert.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestZonePlots(t *testing.T) {
+	testCases := []struct {
+		name     string
+		vertices []repository.Point
+		expected int
+	}{
+		{
+			name:     "Rectangle",
+			vertices: []repository.Point{{X: 2, Y: 3}, {X: 4, Y: 3}, {X: 4, Y: 5}, {X: 2, Y: 5}},
+			expected: 9,
+		},
+		{
+			name:     "Single Plot",
+			vertices: []repository.Point{{X: 2, Y: 3}, {X: 2, Y: 3}, {X: 2, Y: 3}, {X: 2, Y: 3}},
+			expected: 1,
+		},
+		{
+			// Plots (1,1) to (5,1), (1,2) to (4,2) and so on up to (1,5)
+			name:     "Triangle",
+			vertices: []repository.Point{{X: 1, Y: 1}, {X: 5, Y: 1}, {X: 1, Y: 5}},
+			expected: 15,
+		},
+		{
+			name:     "Clockwise Triangle",
+			vertices: []repository.Point{{X: 1, Y: 5}, {X: 5, Y: 1}, {X: 1, Y: 1}},
+			expected: 15,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, zonePlots(tc.vertices))
+		})
+	}
+}
+
+func TestZoneLine(t *testing.T) {
+	triangle := []repository.Point{{X: 1, Y: 1}, {X: 5, Y: 1}, {X: 1, Y: 5}}
+	// A U shape, open to the north between x=3 and x=4
+	u := []repository.Point{{X: 1, Y: 1}, {X: 6, Y: 1}, {X: 6, Y: 4}, {X: 5, Y: 4}, {X: 5, Y: 2}, {X: 2, Y: 2}, {X: 2, Y: 4}, {X: 1, Y: 4}}
+
+	testCases := []struct {
+		name      string
+		vertices  []repository.Point
+		line      int
+		column    bool
+		stretches [][2]int
+	}{
+		{name: "Triangle Base", vertices: triangle, line: 1, stretches: [][2]int{{1, 5}}},
+		{name: "Triangle Middle Row", vertices: triangle, line: 3, stretches: [][2]int{{1, 3}}},
+		{name: "Triangle Apex", vertices: triangle, line: 5, stretches: [][2]int{{1, 1}}},
+		{name: "Triangle Column", vertices: triangle, line: 4, column: true, stretches: [][2]int{{1, 2}}},
+		{name: "Outside", vertices: triangle, line: 6},
+		{name: "U Base", vertices: u, line: 2, stretches: [][2]int{{1, 6}}},
+		{name: "U Arms", vertices: u, line: 3, stretches: [][2]int{{1, 2}, {5, 6}}},
+		{name: "U Column Through The Gap", vertices: u, line: 3, column: true, stretches: [][2]int{{1, 2}}},
+		{
+			// The slanted edges cross rows between plots
+			name:      "Slanted",
+			vertices:  []repository.Point{{X: 1, Y: 1}, {X: 7, Y: 1}, {X: 4, Y: 7}},
+			line:      4,
+			stretches: [][2]int{{3, 5}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stretches := zoneLine(tc.vertices, tc.line, tc.column)
+			if len(tc.stretches) == 0 {
+				assert.Empty(t, stretches)
+			} else {
+				assert.Equal(t, tc.stretches, stretches)
+			}
+		})
+	}
+}
+
+func TestZoneLineMatchesZonePlots(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 500; i++ {
+		var vertices []repository.Point
+		for j := rng.Intn(6) + 3; j > 0; j-- {
+			vertices = append(vertices, repository.Point{X: rng.Intn(12) + 1, Y: rng.Intn(12) + 1})
+		}
+		if doubleArea(vertices) == 0 || selfIntersecting(vertices) {
+			continue
+		}
+
+		// Counting the plots row by row and column by column agrees with
+		// Pick's theorem
+		rows, columns := 0, 0
+		for line := 1; line <= 12; line++ {
+			for _, s := range zoneLine(vertices, line, false) {
+				rows += s[1] - s[0] + 1
+			}
+			for _, s := range zoneLine(vertices, line, true) {
+				columns += s[1] - s[0] + 1
+			}
+		}
+		assert.Equal(t, zonePlots(vertices), rows, "%v", vertices)
+		assert.Equal(t, zonePlots(vertices), columns, "%v", vertices)
+	}
+}